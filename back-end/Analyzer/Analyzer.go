@@ -4,116 +4,216 @@ import (
 	commands "backend/commands"
 	Disks "backend/commands/Disks"
 	Users "backend/commands/Users"
+	globals "backend/globals"
+	utils "backend/utils"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // mapCommands define un mapeo entre comandos y funciones correspondientes
-var mapCommands = map[string]func([]string) (string, error){ // Cambiamos a (string, error)
-	"mkdisk": func(args []string) (string, error) {
+// Los comandos del sistema de archivos reciben la sesión del cliente; los de disco la ignoran
+var mapCommands = map[string]func([]string, *globals.Session) (string, error){ // Cambiamos a (string, error)
+	"mkdisk": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserMkdisk(args)
 		return fmt.Sprintf("%v", result), err // Aseguramos que se devuelva un string
 	},
-	"rmdisk": func(args []string) (string, error) {
+	"rmdisk": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserRmdisk(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"fdisk": func(args []string) (string, error) {
+	"fdisk": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserFdisk(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mount": func(args []string) (string, error) {
+	"mount": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserMount(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"unmount": func(args []string) (string, error) {
+	"unmount": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserUnmount(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkfs": func(args []string) (string, error) {
+	"mkfs": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserMkfs(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rep": func(args []string) (string, error) {
+	"rep": func(args []string, session *globals.Session) (string, error) {
 		result, err := commands.ParserRep(args)
 		return fmt.Sprintf("%v", result), err
 	},
-	"login": func(args []string) (string, error) {
-		result, err := Users.ParserLogin(args)
+	"login": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserLogin(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"logout": func(args []string) (string, error) {
-		result, err := Users.ParserLogout(args)
+	"logout": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserLogout(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkgrp": func(args []string) (string, error) {
-		result, err := Users.ParserMkgrp(args)
+	"mkgrp": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserMkgrp(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rmgrp": func(args []string) (string, error) {
-		result, err := Users.ParserRmgrp(args)
+	"rmgrp": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserRmgrp(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkusr": func(args []string) (string, error) {
-		result, err := Users.ParserMkusr(args)
+	"mkusr": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserMkusr(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rmusr": func(args []string) (string, error) {
-		result, err := Users.ParserRmusr(args)
+	"rmusr": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserRmusr(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"chgrp": func(args []string) (string, error) {
-		result, err := Users.ParserChgrp(args)
+	"chgrp": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserChgrp(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkfile": func(args []string) (string, error) {
-		result, err := commands.ParserMkfile(args)
+	"passwd": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserPasswd(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"mkdir": func(args []string) (string, error) {
-		result, err := commands.ParserMkdir(args)
+	"usermod": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserUsermod(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"cat": func(args []string) (string, error) {
-		result, err := commands.ParserCat(args)
+	"groupmod": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserGroupmod(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"rename": func(args []string) (string, error) {
-		result, err := commands.ParserRename(args)
+	"unlock": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserUnlock(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"edit": func(args []string) (string, error) {
-		result, err := commands.ParserEdit(args)
+	"whoami": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserWhoami(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"find": func(args []string) (string, error) {
-		result, err := commands.ParserFind(args)
+	"id": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserId(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"remove": func(args []string) (string, error) {
-		result, err := commands.ParserRemove(args)
+	"groups": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserGroups(args, session)
 		return fmt.Sprintf("%v", result), err
 	},
-	"lsblk": func(args []string) (string, error) {
+	"lsusr": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserLsusr(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"lsgrp": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserLsgrp(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"su": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserSu(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"exit-su": func(args []string, session *globals.Session) (string, error) {
+		result, err := Users.ParserExitSu(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"mkfile": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserMkfile(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"mkdir": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserMkdir(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"cat": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserCat(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"rename": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserRename(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"edit": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserEdit(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"find": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserFind(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"chattr": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserChattr(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"remove": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserRemove(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"migrate": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserMigrate(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"fsck": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserFsck(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"compactdisk": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserCompactDisk(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"snapshot": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserSnapshot(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"listsnap": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserListSnap(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"rollback": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserRollback(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"clonepart": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserClonePart(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"clonedisk": func(args []string, session *globals.Session) (string, error) {
+		result, err := Disks.ParserCloneDisk(args)
+		return fmt.Sprintf("%v", result), err
+	},
+	"audit": func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserAudit(args, session)
+		return fmt.Sprintf("%v", result), err
+	},
+	"lsblk": func(args []string, session *globals.Session) (string, error) {
 		result, err := Disks.ParserListPartitions(args)
 		return fmt.Sprintf("%v", result), err
 	},
 	"help": help,
 }
 
-func Analyzer(input string) (string, error) {
+func init() {
+	// sudo vuelve a llamar al Analyzer, por eso se registra aquí y no en la declaración del mapa
+	mapCommands["sudo"] = func(args []string, session *globals.Session) (string, error) {
+		result, err := commands.ParserSudo(args, session, Analyzer)
+		return fmt.Sprintf("%v", result), err
+	}
+}
+
+// Analyzer ejecuta una línea de comandos en nombre de la sesión indicada
+func Analyzer(input string, session *globals.Session) (string, error) {
 	// Verificar si es un comentario
 	if strings.HasPrefix(strings.TrimSpace(input), "#") {
 		// Retornamos el comentario sin procesarlo
 		return fmt.Sprintf("Comentario detectado: %s", input), nil
 	}
 
-	// Separar el input en tokens
-	tokens := strings.Fields(input)
+	// Separar el input en tokens respetando comillas, escapes y comentarios al final
+	tokens, err := utils.Tokenize(input)
+	if err != nil {
+		return "", err
+	}
 	if len(tokens) == 0 {
 		return "", errors.New("no se proporcionó ningún comando")
 	}
@@ -132,36 +232,182 @@ func Analyzer(input string) (string, error) {
 		return "", fmt.Errorf("comando desconocido: %s", tokens[0])
 	}
 
+	// Los comandos que modifican un disco quedan en el registro de auditoría
+	if writeCommands[command] || diskCommands[command] {
+		start := time.Now()
+		partition, name, disk := auditTarget(command, tokens[1:], session)
+		// unmount y rmdisk cierran las sesiones de la partición; se audita al usuario que ejecutó el comando
+		auditSession := *session
+		result, err := runWriteCommand(command, cmdFunc, tokens[1:], session)
+		if auditErr := globals.RecordAudit(&auditSession, partition, name, disk, strings.TrimSpace(input), start, err); auditErr != nil {
+			fmt.Println("Error registrando la auditoría:", auditErr) // Depuración
+		}
+		return result, err
+	}
+
 	// Ejecutar la función correspondiente
-	return cmdFunc(tokens[1:])
+	return cmdFunc(tokens[1:], session)
+}
+
+// runWriteCommand ejecuta un comando que modifica un disco; los que escriben en el sistema de archivos
+// respetan las opciones de montaje
+func runWriteCommand(command string, cmdFunc func([]string, *globals.Session) (string, error), args []string, session *globals.Session) (string, error) {
+	if !writeCommands[command] {
+		return cmdFunc(args, session)
+	}
+
+	id := targetPartitionID(command, args, session)
+	if err := globals.CheckWritable(id); err != nil {
+		return "", err
+	}
+
+	result, err := cmdFunc(args, session)
+	if err == nil {
+		if syncErr := globals.SyncPartition(id); syncErr != nil {
+			return result, fmt.Errorf("error sincronizando la partición: %v", syncErr)
+		}
+	}
+	return result, err
+}
+
+// writeCommands son los comandos que escriben en el sistema de archivos de una partición montada
+var writeCommands = map[string]bool{
+	"mkfs": true, "mkgrp": true, "rmgrp": true, "mkusr": true, "rmusr": true, "chgrp": true, "passwd": true, "usermod": true, "groupmod": true, "unlock": true,
+	"mkfile": true, "mkdir": true, "rename": true, "edit": true, "chattr": true, "remove": true,
 }
 
-func help(args []string) (string, error) {
+// diskCommands son los comandos que modifican un disco sin pasar por el sistema de archivos montado
+var diskCommands = map[string]bool{
+	"mkdisk": true, "rmdisk": true, "fdisk": true, "mount": true, "unmount": true, "migrate": true,
+	"compactdisk": true, "snapshot": true, "rollback": true, "clonepart": true, "clonedisk": true,
+}
+
+// partitionNameParams es el parámetro con el nombre de la partición que modifica un comando de disco
+var partitionNameParams = map[string]string{"fdisk": "-name", "mount": "-name", "clonepart": "-dstname"}
+
+// auditTarget devuelve el ID y el nombre de la partición y el disco que modifica un comando, para la auditoría.
+// Se calcula antes de ejecutar el comando, mientras la partición sigue montada.
+func auditTarget(command string, args []string, session *globals.Session) (string, string, string) {
+	var partition, name, disk string
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := kv[1]
+		if strings.EqualFold(kv[0], partitionNameParams[command]) {
+			name = value
+		}
+		switch strings.ToLower(kv[0]) {
+		case "-id":
+			partition = value
+		case "-path", "-dst":
+			// En los comandos del sistema de archivos -path es una ruta dentro de la partición
+			if diskCommands[command] {
+				disk = value
+			}
+		case "-src":
+			if diskCommands[command] && disk == "" {
+				disk = value
+			}
+		}
+	}
+
+	// Los comandos sobre el sistema de archivos actúan en la partición de la sesión
+	if partition == "" && writeCommands[command] {
+		partition = targetPartitionID(command, args, session)
+	}
+	if partition != "" {
+		if disk == "" {
+			disk = globals.MountedPartitions[partition]
+		}
+		if name == "" {
+			name = globals.PartitionName(partition)
+		}
+	}
+	return partition, name, disk
+}
+
+// targetPartitionID devuelve el ID de la partición sobre la que actúa un comando de escritura
+func targetPartitionID(command string, args []string, session *globals.Session) string {
+	if command == "mkfs" {
+		for _, arg := range args {
+			if strings.HasPrefix(strings.ToLower(arg), "-id=") {
+				return arg[len("-id="):]
+			}
+		}
+	}
+	return session.PartitionID()
+}
+
+func help(args []string, session *globals.Session) (string, error) {
 	helpMessage := `
+Sintaxis: los parámetros son -nombre=valor o banderas -nombre. Los valores con espacios van entre comillas
+("..." admite \" y \\; '...' es literal) y un # fuera de comillas comenta el resto de la línea.
+Un parámetro desconocido, repetido o sin valor se rechaza con el mismo mensaje en todos los comandos.
+
 Comandos disponibles:
 - mkdisk: Crea un nuevo disco. Ejemplo: mkdisk -size=100 -unit=M -fit=FF -path="/home/user/disco.mia"
+  Opcional: -scheme=GPT crea una tabla GPT (hasta 128 particiones primarias) en lugar de MBR.
+  Opcional: -zero escribe ceros en todo el archivo en lugar de crearlo disperso.
+  Las posiciones del disco son de 32 bits: un disco no llega a 2 GB, así que -unit=G solo admite -size=1.
 - rmdisk: Elimina un disco existente. Ejemplo: rmdisk -path="/home/user/disco.mia"
 - fdisk: Maneja las particiones del disco. Ejemplo: fdisk -size=50 -unit=M -path="/home/user/disco.mia" -type=P -name="Part1"
+  Mover: fdisk -move -path="/home/user/disco.mia" -name="Part1" -start=2048 (inicio en bytes, o en la unidad de -unit)
 - mount: Monta una partición. Ejemplo: mount -path="/home/user/disco.mia" -name="Part1"
+  Opcional: -options=ro,noatime,sync (solo lectura, sin fecha de acceso, sincronizar después de cada comando)
+  Opcional: -passphrase=clave abre una partición cifrada (o la prepara para mkfs -encrypt)
 - mkfs: Formatea una partición. Ejemplo: mkfs -id=vd1 -type=full
+  Opcional: -encrypt cifra la partición (AES-256-XTS por sectores de 512 bytes) con la contraseña indicada en mount -passphrase
 - login: Inicia sesión en el sistema. Ejemplo: login -user=admin -pass=1234 -id=vd1
 - logout: Cierra la sesión actual. Ejemplo: logout
 - mkgrp: Crea un nuevo grupo. Ejemplo: mkgrp -name=users
 - rmgrp: Elimina un grupo existente. Ejemplo: rmgrp -name=users
-- mkusr: Crea un nuevo usuario. Ejemplo: mkusr -user=user1 -pass=user -grp=users
-- rmusr: Elimina un usuario existente. Ejemplo: rmusr -user=user1
+- mkusr: Crea un nuevo usuario con su directorio /home/<usuario> (copia /etc/skel si existe). Ejemplo: mkusr -user=user1 -pass=user -grp=users
+  Pasados los 15 bloques directos users.txt crece con bloques indirectos; en discos anteriores a la rev 5 ejecute migrate para pasar de unos 10 usuarios.
+- rmusr: Elimina un usuario existente; con -purge borra también su directorio personal. Ejemplo: rmusr -user=user1 -purge
 - chgrp: Cambia el grupo de un usuario. Ejemplo: chgrp -user=user1 -grp=users
+- usermod: Modifica un usuario con un solo cambio por vez (solo root). Ejemplo: usermod -user=user1 -addgrp=proyecto
+  -addgrp/-delgrp agregan o quitan un grupo suplementario, -rename=nuevo cambia el nombre (conserva el id, sus archivos y /home)
+  -disable impide iniciar sesión sin perder el id; -enable la vuelve a habilitar o restaura una cuenta eliminada con rmusr
+- groupmod: Renombra un grupo conservando su id y actualizando a sus usuarios (solo root). Ejemplo: groupmod -name=users -rename=equipo
+- passwd: Cambia la contraseña del usuario actual. Ejemplo: passwd -old=user -new=nueva
+  root puede cambiar la de otro usuario: passwd -user=user1 -new=nueva
+- unlock: Desbloquea una cuenta bloqueada por intentos fallidos de login (solo root). Ejemplo: unlock -user=user1
+  El umbral se configura con la variable de entorno MIA_MAX_LOGIN_FAILURES (por defecto 3); los inicios de sesión se registran en /var/log/auth.log
+  En una partición montada con -options=ro los intentos fallidos se cuentan en memoria y se reinician al desmontarla
+- whoami: Muestra el usuario actual y, si se usó su o sudo, quién inició la sesión. Ejemplo: whoami
+- id: Muestra uid, gid, grupos y partición de un usuario (por defecto el actual). Ejemplo: id -user=user1
+- groups: Muestra el grupo principal y los suplementarios de un usuario (por defecto el actual). Ejemplo: groups -user=user1
+- lsusr: Lista los usuarios de la partición, activos y eliminados (id 0). Ejemplo: lsusr
+- lsgrp: Lista los grupos de la partición con sus miembros, activos y eliminados (id 0). Ejemplo: lsgrp
+- su: Trabaja como otro usuario sin cerrar la sesión. Ejemplo: su -user=user1 -pass=user (root no necesita -pass)
+- exit-su: Vuelve al usuario que estaba activo antes del último su. Ejemplo: exit-su
+- sudo: Ejecuta un comando como root. Ejemplo: sudo -pass=mi_clave -cmd="mkusr -user=user2 -pass=abc -grp=users"
+  Pueden usarlo root y los grupos listados en /etc/sudoers de la partición (uno por línea; por defecto el grupo sudo)
 - rep: Genera reportes. Ejemplo: rep -id=vd1 -path="/home/user/disco.mia" -name=mbr
 - clear: Limpia la terminal.
 - exit: Sale del programa.
+- migrate: Actualiza un disco antiguo a la revisión actual del formato. Ejemplo: migrate -path="/home/user/disco.mia"
+- fsck: Verifica los checksums del sistema de archivos de una partición montada. Ejemplo: fsck -id=061A
+- compactdisk: Junta las particiones al inicio del disco para dejar el espacio libre contiguo. Ejemplo: compactdisk -path="/home/user/disco.mia"
+- snapshot: Crea un snapshot copy-on-write del disco. Ejemplo: snapshot -path="/home/user/disco.mia" -name=antes
+- listsnap: Lista los snapshots de un disco. Ejemplo: listsnap -path="/home/user/disco.mia"
+- rollback: Devuelve el disco al estado de un snapshot. Ejemplo: rollback -path="/home/user/disco.mia" -name=antes
+- clonepart: Copia una partición sobre otra partición existente. Ejemplo: clonepart -src="/home/user/disco1.mia" -name=Part1 -dst="/home/user/disco2.mia" -dstname=PartX
+- clonedisk: Copia un disco completo a un disco nuevo. Ejemplo: clonedisk -src="/home/user/disco1.mia" -dst="/home/user/disco2.mia"
+- audit: Muestra quién ejecutó los comandos que modificaron una partición o su disco (solo root). Ejemplo: audit -id=061A -user=user1 -since=2024-01-01
+  -since acepta una fecha (2024-01-01, 2024-01-01T10:30) o una duración hacia atrás (2h, 30m). Los registros se guardan en audit.log del directorio de datos
+  Cada registro guarda el disco y el nombre de la partición: -id de una partición desmontada usa los de su último registro
 - lsblk: Lista las particiones de un disco. Ejemplo: lsblk -path="/home/user/disco.mia"
-- mkfile: Crea un archivo. Ejemplo: mkfile -path="/home/user/disco.mia" -p -size=10 -cont="Hola, mundo"
+- mkfile: Crea un archivo. Ejemplo: mkfile -path="/home/user/archivo.txt" -r -size=10 -cont="Hola, mundo" [-compress]
 - mkdir: Crea un directorio. Ejemplo: mkdir -path="/home/user/disco.mia" -p
-- cat: Muestra el contenido de un archivo. Ejemplo: cat -file="/home/user/disco.mia" -path="/home/user/disco.mia"
+- cat: Muestra el contenido de un archivo. Ejemplo: cat -file1="/home/user/a.txt" -file2="/home/user/b.txt"
 - rename: Renombra un archivo o directorio. Ejemplo: rename -path="/home/user/disco.mia" -name="nuevo_nombre"
-- edit: Edita el contenido de un archivo. Ejemplo: edit -path="/home/user/disco.mia" -cont="Nuevo contenido"
+- edit: Edita el contenido de un archivo. Ejemplo: edit -path="/home/user/archivo.txt" -contenido="/home/local/nuevo.txt"
 - find: Busca un archivo o directorio. Ejemplo: find -path="/home/user/disco.mia" -name="archivo"
-- remove: Elimina un archivo o directorio. Ejemplo: remove -path="/home/user/disco.mia" -name="archivo"
+- chattr: Activa (+c) o desactiva (-c) la compresión de un archivo. Ejemplo: chattr -path="/home/archivo.txt" +c
+- remove: Elimina un archivo o directorio. Ejemplo: remove -path="/home/user/archivo.txt"
 - help: Muestra este mensaje de ayuda.
 
 `
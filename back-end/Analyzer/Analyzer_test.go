@@ -0,0 +1,335 @@
+package analyzer
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runIn ejecuta una línea en la sesión del token y falla la prueba si el comando devuelve error
+func runIn(t *testing.T, token, line string) string {
+	t.Helper()
+	var out string
+	var cmdErr error
+	if err := globals.RunInSession(token, func(session *globals.Session) {
+		out, cmdErr = Analyzer(line, session)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if cmdErr != nil {
+		t.Fatalf("%s: %v", line, cmdErr)
+	}
+	return out
+}
+
+// whoamiIn devuelve el usuario que ve la sesión del token
+func whoamiIn(t *testing.T, token string) string {
+	t.Helper()
+	var user string
+	if err := globals.RunInSession(token, func(session *globals.Session) {
+		if session.IsLoggedIn() {
+			user = session.Usuario.Name
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newTestPartition crea, monta y formatea una partición en un disco temporal y abre una sesión de root en ella.
+// Devuelve el token de la sesión y el ID de la partición.
+func newTestPartition(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(globals.DataDirEnv, filepath.Join(dir, "data"))
+	disk := filepath.Join(dir, "a.mia")
+
+	admin, err := globals.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { globals.EndSession(admin) })
+
+	runIn(t, admin, "mkdisk -size=2 -unit=M -path="+disk)
+	runIn(t, admin, "fdisk -size=1 -unit=M -path="+disk+" -name=P1")
+	runIn(t, admin, "mount -path="+disk+" -name=P1")
+	var id string
+	for mountID, path := range globals.MountedPartitions {
+		if path == disk {
+			id = mountID
+		}
+	}
+	if id == "" {
+		t.Fatal("la partición no quedó montada")
+	}
+	t.Cleanup(func() { runIn(t, admin, "unmount -id="+id) })
+
+	runIn(t, admin, "mkfs -id="+id+" -type=full")
+	runIn(t, admin, "login -user=root -pass=123 -id="+id)
+	return admin, id
+}
+
+func TestSessionsAreIndependent(t *testing.T) {
+	admin, id := newTestPartition(t)
+	ana, err := globals.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer globals.EndSession(ana)
+
+	runIn(t, admin, "mkgrp -name=sudo")
+	runIn(t, admin, "mkusr -user=ana -pass=abc -grp=sudo")
+	runIn(t, ana, "login -user=ana -pass=abc -id="+id)
+
+	if got := whoamiIn(t, admin); got != "root" {
+		t.Fatalf("la sesión de root ve a %q", got)
+	}
+	if got := whoamiIn(t, ana); got != "ana" {
+		t.Fatalf("la sesión de ana ve a %q", got)
+	}
+
+	// sudo ejecuta como root sin cambiar el usuario de ninguna sesión
+	runIn(t, ana, `sudo -pass=abc -cmd="mkdir -path=/x"`)
+	if got := whoamiIn(t, ana); got != "ana" {
+		t.Fatalf("después de sudo la sesión de ana ve a %q", got)
+	}
+	records, err := globals.ReadAudit(func(r globals.AuditRecord) bool {
+		return strings.HasPrefix(r.Command, "mkdir")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].User != "root" || records[0].LoginUser != "ana" {
+		t.Fatalf("auditoría de sudo = %+v, se esperaba root iniciado por ana", records)
+	}
+
+	// Cerrar una sesión no cierra la otra
+	runIn(t, ana, "logout")
+	if got := whoamiIn(t, ana); got != "" {
+		t.Fatalf("después de logout la sesión de ana ve a %q", got)
+	}
+	if got := whoamiIn(t, admin); got != "root" {
+		t.Fatalf("el logout de ana cerró la sesión de root: %q", got)
+	}
+}
+
+func TestUnmountLogsOutPartitionSessions(t *testing.T) {
+	admin, id := newTestPartition(t)
+	ana, err := globals.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer globals.EndSession(ana)
+	runIn(t, admin, "mkusr -user=ana -pass=abc -grp=root")
+	runIn(t, ana, "login -user=ana -pass=abc -id="+id)
+
+	disk := globals.MountedPartitions[id]
+	runIn(t, admin, "unmount -id="+id)
+	if got := whoamiIn(t, ana); got != "" {
+		t.Fatalf("después de unmount la sesión de ana sigue como %q", got)
+	}
+	if got := whoamiIn(t, admin); got != "" {
+		t.Fatalf("después de unmount la sesión de root sigue como %q", got)
+	}
+
+	// La auditoría registra a quien desmontó aunque su sesión se haya cerrado
+	records, err := globals.ReadAudit(func(r globals.AuditRecord) bool {
+		return strings.HasPrefix(r.Command, "unmount")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].User != "root" {
+		t.Fatalf("auditoría de unmount = %+v, se esperaba root", records)
+	}
+
+	// Volver a montar para la limpieza de newTestPartition
+	runIn(t, admin, "mount -path="+disk+" -name=P1")
+}
+
+// inodeAt devuelve el inodo de una ruta de la partición, o nil si no existe
+func inodeAt(t *testing.T, id string, path ...string) *structs.Inode {
+	t.Helper()
+	_, sb, diskPath, err := globals.GetMountedPartitionRep(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	index, err := globals.FindPath(file, sb, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index == -1 {
+		return nil
+	}
+	inode := &structs.Inode{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(index), sb); err != nil {
+		t.Fatal(err)
+	}
+	return inode
+}
+
+func TestMkusrHomeDirectory(t *testing.T) {
+	admin, id := newTestPartition(t)
+	runIn(t, admin, "mkgrp -name=devs")
+	runIn(t, admin, "mkdir -path=/etc/skel -p")
+	runIn(t, admin, `mkfile -path=/etc/skel/.profile -cont="export PATH=/bin:/usr/bin" -compress`)
+	runIn(t, admin, "mkusr -user=ana -pass=abc -grp=devs")
+	runIn(t, admin, "mkusr -user=bob -pass=abc -grp=devs")
+
+	// Cada usuario tiene su propio UID aunque compartan grupo
+	home := inodeAt(t, id, "home", "ana")
+	other := inodeAt(t, id, "home", "bob")
+	if home == nil || other == nil {
+		t.Fatal("mkusr no creó los directorios personales")
+	}
+	if home.I_uid == other.I_uid {
+		t.Fatalf("ana y bob comparten el uid %d", home.I_uid)
+	}
+	if home.I_gid != other.I_gid {
+		t.Fatalf("ana y bob están en devs pero sus carpetas tienen gid %d y %d", home.I_gid, other.I_gid)
+	}
+	if got := runIn(t, admin, "id -user=ana"); !strings.Contains(got, fmt.Sprintf("uid=%d(ana)", home.I_uid)) {
+		t.Fatalf("id no muestra el uid de /home/ana (%d):\n%s", home.I_uid, got)
+	}
+
+	// El esqueleto se copia con sus atributos: el archivo sigue comprimido y se lee igual
+	profile := inodeAt(t, id, "home", "ana", ".profile")
+	if profile == nil || !profile.IsCompressed() || profile.I_uid != home.I_uid {
+		t.Fatalf("/home/ana/.profile = %+v, se esperaba comprimido y de ana", profile)
+	}
+	if got := runIn(t, admin, "cat -file1=/home/ana/.profile"); !strings.Contains(got, "export PATH=/bin:/usr/bin") {
+		t.Fatalf("contenido de la copia comprimida:\n%s", got)
+	}
+
+	// -purge solo borra /home/<usuario>, no otra carpeta con el mismo nombre
+	runIn(t, admin, "mkdir -path=/srv/ana -p")
+	runIn(t, admin, "rmusr -user=ana -purge")
+	if inodeAt(t, id, "home", "ana") != nil {
+		t.Fatal("rmusr -purge no borró /home/ana")
+	}
+	if inodeAt(t, id, "srv", "ana") == nil {
+		t.Fatal("rmusr -purge borró /srv/ana")
+	}
+	if inodeAt(t, id, "home", "bob") == nil {
+		t.Fatal("rmusr -purge borró /home/bob")
+	}
+}
+
+// runErrIn ejecuta una línea en la sesión del token y devuelve el error del comando
+func runErrIn(t *testing.T, token, line string) error {
+	t.Helper()
+	var cmdErr error
+	if err := globals.RunInSession(token, func(session *globals.Session) {
+		_, cmdErr = Analyzer(line, session)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return cmdErr
+}
+
+func TestSudoersGroupFromFile(t *testing.T) {
+	admin, id := newTestPartition(t)
+	runIn(t, admin, "mkgrp -name=sudo")
+	runIn(t, admin, "mkgrp -name=admins")
+	runIn(t, admin, "mkusr -user=ana -pass=abc -grp=sudo")
+	runIn(t, admin, "mkusr -user=bob -pass=abc -grp=root")
+	runIn(t, admin, "usermod -user=bob -addgrp=admins")
+
+	bob, err := globals.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer globals.EndSession(bob)
+	runIn(t, bob, "login -user=bob -pass=abc -id="+id)
+
+	// Sin /etc/sudoers solo el grupo sudo puede usar sudo
+	if err := runErrIn(t, bob, `sudo -pass=abc -cmd="mkdir -path=/x"`); err == nil || !strings.Contains(err.Error(), "sudoers") {
+		t.Fatalf("sudo de bob sin /etc/sudoers = %v", err)
+	}
+
+	// Con /etc/sudoers vale cualquier grupo listado, también uno suplementario
+	runIn(t, admin, `mkfile -path=/etc/sudoers -cont=admins -r`)
+	if err := runErrIn(t, bob, `sudo -pass=mala -cmd="mkdir -path=/x"`); err == nil {
+		t.Fatal("sudo con una contraseña incorrecta debería fallar")
+	}
+	runIn(t, bob, `sudo -pass=abc -cmd="mkdir -path=/x"`)
+	if dir := inodeAt(t, id, "x"); dir == nil || dir.I_uid != 1 {
+		t.Fatalf("/x = %+v, se esperaba creado por root", dir)
+	}
+	if got := whoamiIn(t, bob); got != "bob" {
+		t.Fatalf("después de sudo la sesión de bob ve a %q", got)
+	}
+
+	// El grupo por defecto deja de valer cuando /etc/sudoers lo omite
+	ana, err := globals.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer globals.EndSession(ana)
+	runIn(t, ana, "login -user=ana -pass=abc -id="+id)
+	if err := runErrIn(t, ana, `sudo -pass=abc -cmd="mkdir -path=/y"`); err == nil {
+		t.Fatal("ana no está en /etc/sudoers y sudo no debería permitirlo")
+	}
+
+	// Los comandos que cambian la sesión no se ejecutan con sudo
+	if err := runErrIn(t, bob, `sudo -pass=abc -cmd="su -user=root"`); err == nil {
+		t.Fatal("sudo su debería rechazarse")
+	}
+}
+
+func TestHomeDirectoryLifecycle(t *testing.T) {
+	admin, id := newTestPartition(t)
+	runIn(t, admin, "mkdir -path=/etc/skel/.config -p")
+	runIn(t, admin, `mkfile -path=/etc/skel/.config/app.conf -cont="tema=oscuro"`)
+	runIn(t, admin, "mkusr -user=ana -pass=abc -grp=root")
+
+	// El directorio personal es de ana, con permisos 700, y el esqueleto se copia completo
+	home := inodeAt(t, id, "home", "ana")
+	if home == nil || string(home.I_perm[:]) != "700" {
+		t.Fatalf("/home/ana = %+v, se esperaban permisos 700", home)
+	}
+	for _, path := range [][]string{{"home", "ana", ".config"}, {"home", "ana", ".config", "app.conf"}} {
+		inode := inodeAt(t, id, path...)
+		if inode == nil || inode.I_uid != home.I_uid || inode.I_gid != home.I_gid {
+			t.Fatalf("/%s = %+v, se esperaba copiado y de ana", strings.Join(path, "/"), inode)
+		}
+	}
+	if got := runIn(t, admin, "cat -file1=/home/ana/.config/app.conf"); !strings.Contains(got, "tema=oscuro") {
+		t.Fatalf("contenido de la copia del esqueleto:\n%s", got)
+	}
+	if skel := inodeAt(t, id, "etc", "skel", ".config", "app.conf"); skel == nil || skel.I_uid == home.I_uid {
+		t.Fatalf("el esqueleto original cambió de propietario: %+v", skel)
+	}
+
+	// Una carpeta que ya existe no se modifica ni recibe el esqueleto
+	runIn(t, admin, "mkdir -path=/home/bob")
+	runIn(t, admin, "mkusr -user=bob -pass=abc -grp=root")
+	if bob := inodeAt(t, id, "home", "bob"); bob == nil || bob.I_uid != 1 {
+		t.Fatalf("mkusr cambió la carpeta existente /home/bob: %+v", bob)
+	}
+	if inodeAt(t, id, "home", "bob", ".config") != nil {
+		t.Fatal("mkusr copió el esqueleto en una carpeta que ya existía")
+	}
+
+	// Renombrar al usuario mueve su directorio personal
+	runIn(t, admin, "usermod -user=ana -rename=anita")
+	if inodeAt(t, id, "home", "ana") != nil || inodeAt(t, id, "home", "anita", ".config", "app.conf") == nil {
+		t.Fatal("usermod -rename no movió /home/ana a /home/anita")
+	}
+
+	// Sin -purge el directorio personal se conserva
+	runIn(t, admin, "rmusr -user=anita")
+	if inodeAt(t, id, "home", "anita") == nil {
+		t.Fatal("rmusr sin -purge borró /home/anita")
+	}
+}
@@ -1,7 +1,9 @@
 package structs
 
 import (
+	"backend/utils"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
 )
@@ -30,11 +32,6 @@ func (sb *Superblock) CreateBitMaps(file *os.File) error {
 
 // Cada bloque o inodo está representado por un bit
 func (sb *Superblock) createBitmap(file *os.File, start int32, count int32, occupied bool) error {
-	_, err := file.Seek(int64(start), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando el inicio del bitmap: %w", err)
-	}
-
 	// Calcular el número de bytes necesarios (cada byte tiene 8 bits)
 	byteCount := (count + 7) / 8
 
@@ -50,14 +47,104 @@ func (sb *Superblock) createBitmap(file *os.File, start int32, count int32, occu
 	}
 
 	// Escribir el buffer en el archivo
-	err = binary.Write(file, binary.LittleEndian, buffer)
+	err := utils.WriteAt(file, buffer, int64(start))
 	if err != nil {
 		return fmt.Errorf("error escribiendo el bitmap: %w", err)
 	}
 
+	return sb.writeBitmapChecksum(file, start, count)
+}
+
+// bitmapCount devuelve cuántos elementos representa el bitmap que inicia en start
+func (sb *Superblock) bitmapCount(start int32) int32 {
+	if start == sb.S_bm_inode_start {
+		return sb.S_inodes_count + sb.S_free_inodes_count
+	}
+	return sb.S_blocks_count + sb.S_free_blocks_count
+}
+
+// bitmapChecksumOffset devuelve dónde se guarda el checksum de un bitmap.
+// La zona reservada tiene un byte por elemento pero el bitmap usa un bit, así que el
+// checksum ocupa los últimos 4 bytes libres de la zona.
+func bitmapChecksumOffset(start int32, count int32) (int64, bool) {
+	if count-(count+7)/8 < 4 {
+		return 0, false
+	}
+	return int64(start + count - 4), true
+}
+
+// readBitmapWithChecksum lee los bytes del bitmap y el checksum guardado
+func readBitmapWithChecksum(file *os.File, start int32, count int32) ([]byte, uint32, error) {
+	bitmap := make([]byte, (count+7)/8)
+	if err := utils.ReadAt(file, bitmap, int64(start)); err != nil {
+		return nil, 0, fmt.Errorf("error leyendo el bitmap: %w", err)
+	}
+
+	offset, ok := bitmapChecksumOffset(start, count)
+	if !ok {
+		return bitmap, 0, nil
+	}
+
+	stored := make([]byte, 4)
+	if err := utils.ReadAt(file, stored, offset); err != nil {
+		return nil, 0, fmt.Errorf("error leyendo el checksum del bitmap: %w", err)
+	}
+	return bitmap, binary.LittleEndian.Uint32(stored), nil
+}
+
+// writeBitmapChecksum recalcula y guarda el checksum del bitmap (solo desde la revisión 4)
+func (sb *Superblock) writeBitmapChecksum(file *os.File, start int32, count int32) error {
+	offset, ok := bitmapChecksumOffset(start, count)
+	if sb.S_revision < RevisionChecksums || !ok {
+		return nil
+	}
+
+	bitmap, _, err := readBitmapWithChecksum(file, start, count)
+	if err != nil {
+		return err
+	}
+
+	checksum := make([]byte, 4)
+	binary.LittleEndian.PutUint32(checksum, checksumBytes(bitmap))
+	if err := utils.WriteAt(file, checksum, offset); err != nil {
+		return fmt.Errorf("error escribiendo el checksum del bitmap: %w", err)
+	}
+	return nil
+}
+
+// verifyBitmap compara el checksum guardado del bitmap con su contenido
+func (sb *Superblock) verifyBitmap(file *os.File, start int32, count int32, name string) error {
+	offset, ok := bitmapChecksumOffset(start, count)
+	if sb.S_revision < RevisionChecksums || !ok {
+		return nil
+	}
+
+	bitmap, stored, err := readBitmapWithChecksum(file, start, count)
+	if err != nil {
+		return err
+	}
+	if computed := checksumBytes(bitmap); computed != stored {
+		return &ChecksumError{Structure: name, Offset: offset, Stored: stored, Computed: computed}
+	}
 	return nil
 }
 
+// UpdateBitmapChecksums recalcula los checksums de ambos bitmaps
+func (sb *Superblock) UpdateBitmapChecksums(file *os.File) error {
+	if err := sb.writeBitmapChecksum(file, sb.S_bm_inode_start, sb.bitmapCount(sb.S_bm_inode_start)); err != nil {
+		return err
+	}
+	return sb.writeBitmapChecksum(file, sb.S_bm_block_start, sb.bitmapCount(sb.S_bm_block_start))
+}
+
+// VerifyBitmaps verifica los checksums de los bitmaps de inodos y bloques
+func (sb *Superblock) VerifyBitmaps(file *os.File) error {
+	return errors.Join(
+		sb.verifyBitmap(file, sb.S_bm_inode_start, sb.bitmapCount(sb.S_bm_inode_start), "bitmap de inodos"),
+		sb.verifyBitmap(file, sb.S_bm_block_start, sb.bitmapCount(sb.S_bm_block_start), "bitmap de bloques"),
+	)
+}
+
 // UpdateBitmapInode actualiza el bitmap de inodos
 func (sb *Superblock) UpdateBitmapInode(file *os.File, position int32, occupied bool) error {
 	return sb.updateBitmap(file, sb.S_bm_inode_start, position, occupied)
@@ -74,15 +161,9 @@ func (sb *Superblock) updateBitmap(file *os.File, start int32, position int32, o
 	byteIndex := position / 8
 	bitOffset := position % 8
 
-	// Mover el puntero al byte correspondiente
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando la posición en el bitmap: %w", err)
-	}
-
 	// Leer el byte actual
 	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
+	err := utils.ReadFromFile(file, int64(start)+int64(byteIndex), &byteVal)
 	if err != nil {
 		return fmt.Errorf("error leyendo el byte del bitmap: %w", err)
 	}
@@ -94,19 +175,13 @@ func (sb *Superblock) updateBitmap(file *os.File, start int32, position int32, o
 		byteVal &= ^(1 << bitOffset) // Poner el bit a 0 (libre)
 	}
 
-	// Mover el puntero de nuevo al byte correspondiente
-	_, err = file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return fmt.Errorf("error buscando la posición en el bitmap para escribir: %w", err)
-	}
-
 	// Escribir el byte actualizado de vuelta en el archivo
-	err = binary.Write(file, binary.LittleEndian, &byteVal)
+	err = utils.WriteAt(file, []byte{byteVal}, int64(start)+int64(byteIndex))
 	if err != nil {
 		return fmt.Errorf("error escribiendo el byte actualizado del bitmap: %w", err)
 	}
 
-	return nil
+	return sb.writeBitmapChecksum(file, start, sb.bitmapCount(start))
 }
 
 // isBlockFree verifica si un bloque en el bitmap está libre
@@ -118,16 +193,9 @@ func (sb *Superblock) isBlockFree(file *os.File, start int32, position int32) (b
 	bitOffset := position % 8
 	fmt.Printf("Calculando byteIndex: %d y bitOffset: %d\n", byteIndex, bitOffset)
 
-	// Mover el puntero al byte correspondiente
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return false, fmt.Errorf("error buscando la posición en el bitmap en byteIndex %d: %w", byteIndex, err)
-	}
-	fmt.Printf("Puntero movido a la posición %d en el archivo.\n", int64(start)+int64(byteIndex))
-
 	// Leer el byte actual
 	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
+	err := utils.ReadFromFile(file, int64(start)+int64(byteIndex), &byteVal)
 	if err != nil {
 		return false, fmt.Errorf("error leyendo el byte en byteIndex %d del bitmap: %w", byteIndex, err)
 	}
@@ -152,16 +220,9 @@ func (sb *Superblock) isInodeFree(file *os.File, start int32, position int32) (b
 	bitOffset := position % 8
 	fmt.Printf("Calculando byteIndex: %d y bitOffset: %d\n", byteIndex, bitOffset)
 
-	// Mover el puntero al byte correspondiente
-	_, err := file.Seek(int64(start)+int64(byteIndex), 0)
-	if err != nil {
-		return false, fmt.Errorf("error buscando la posición en el bitmap en byteIndex %d: %w", byteIndex, err)
-	}
-	fmt.Printf("Puntero movido a la posición %d en el archivo.\n", int64(start)+int64(byteIndex))
-
 	// Leer el byte actual
 	var byteVal byte
-	err = binary.Read(file, binary.LittleEndian, &byteVal)
+	err := utils.ReadFromFile(file, int64(start)+int64(byteIndex), &byteVal)
 	if err != nil {
 		return false, fmt.Errorf("error leyendo el byte en byteIndex %d del bitmap de inodos: %w", byteIndex, err)
 	}
@@ -184,7 +245,7 @@ func (sb *Superblock) FreeBlock(file *os.File, blockIndex int32) error {
 	// Limpiar el contenido del bloque antes de liberarlo
 	blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
 	zeroes := make([]byte, sb.S_block_size)
-	_, err := file.WriteAt(zeroes, blockOffset)
+	err := utils.WriteAt(file, zeroes, blockOffset)
 	if err != nil {
 		return fmt.Errorf("error al limpiar el contenido del bloque %d: %w", blockIndex, err)
 	}
@@ -209,7 +270,7 @@ func (sb *Superblock) FreeInode(file *os.File, inodeIndex int32) error {
 	// Deserializar el inodo para limpiar sus datos
 	inode := &Inode{}
 	inodeOffset := int64(sb.S_inode_start + inodeIndex*sb.S_inode_size)
-	err := inode.Decode(file, inodeOffset)
+	err := inode.Decode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar inodo %d para su limpieza: %w", inodeIndex, err)
 	}
@@ -220,7 +281,7 @@ func (sb *Superblock) FreeInode(file *os.File, inodeIndex int32) error {
 	inode.I_block = [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
 
 	// Sobrescribir el inodo limpio en su posición original
-	err = inode.Encode(file, inodeOffset)
+	err = inode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error al sobrescribir el inodo limpio %d: %w", inodeIndex, err)
 	}
@@ -0,0 +1,48 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// Tabla CRC32C (Castagnoli) usada por todas las estructuras con checksum
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumError indica que una estructura leída del disco no coincide con su checksum
+type ChecksumError struct {
+	Structure string // Estructura dañada (superbloque, inodo, bitmap, bloque de carpeta)
+	Offset    int64  // Posición de la estructura en el disco
+	Stored    uint32 // Checksum guardado en el disco
+	Computed  uint32 // Checksum calculado al leer
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum inválido en %s (offset %d): guardado %08x, calculado %08x",
+		e.Structure, e.Offset, e.Stored, e.Computed)
+}
+
+// checksumBytes calcula el CRC32C de un arreglo de bytes
+func checksumBytes(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoliTable)
+}
+
+// structBytes devuelve la codificación binaria de una estructura, igual a la que se escribe en disco
+func structBytes(data interface{}) []byte {
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, data); err != nil {
+		return nil
+	}
+	return buffer.Bytes()
+}
+
+// isZeroBytes indica si todos los bytes son cero; una estructura nunca escrita no tiene checksum
+func isZeroBytes(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
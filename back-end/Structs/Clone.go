@@ -0,0 +1,111 @@
+package structs
+
+import (
+	"backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+)
+
+// CopyRange copia size bytes desde src en srcStart hacia dst en dstStart
+func CopyRange(src *os.File, srcStart int64, dst *os.File, dstStart int64, size int64) error {
+	buffer := make([]byte, relocateChunkSize)
+	for offset := int64(0); offset < size; {
+		chunk := buffer[:min(int64(relocateChunkSize), size-offset)]
+		if _, err := src.ReadAt(chunk, srcStart+offset); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error leyendo el byte %d del origen: %v", srcStart+offset, err)
+		}
+		if err := utils.WriteRawAt(dst, chunk, dstStart+offset); err != nil {
+			return fmt.Errorf("error escribiendo el byte %d del destino: %v", dstStart+offset, err)
+		}
+		offset += int64(len(chunk))
+	}
+	return nil
+}
+
+// Tamaño de los bloques que CopySparse revisa para saltar las zonas vacías
+const sparseChunkSize = 4096
+
+// CopySparse copia size bytes de src a un archivo dst recién creado. Las zonas en cero no se
+// escriben, así el disco copiado sigue siendo disperso como uno creado por mkdisk.
+func CopySparse(src *os.File, dst *os.File, size int64) error {
+	if err := dst.Truncate(size); err != nil {
+		return fmt.Errorf("error reservando el disco destino: %v", err)
+	}
+
+	buffer := make([]byte, sparseChunkSize)
+	zeroes := make([]byte, sparseChunkSize)
+	for offset := int64(0); offset < size; {
+		chunk := buffer[:min(int64(sparseChunkSize), size-offset)]
+		if _, err := src.ReadAt(chunk, offset); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error leyendo el byte %d del origen: %v", offset, err)
+		}
+		if !bytes.Equal(chunk, zeroes[:len(chunk)]) {
+			if err := utils.WriteRawAt(dst, chunk, offset); err != nil {
+				return fmt.Errorf("error escribiendo el byte %d del destino: %v", offset, err)
+			}
+		}
+		offset += int64(len(chunk))
+	}
+	return nil
+}
+
+// ClonePartition copia el contenido de src (una partición del disco srcFile) sobre dst
+// (una partición del disco dstFile) y corrige los punteros absolutos de su sistema de archivos
+func ClonePartition(srcFile *os.File, src *Partition, dstFile *os.File, dst *Partition) error {
+	if src.Part_size > dst.Part_size {
+		return fmt.Errorf("la partición destino (%d bytes) es menor que la de origen (%d bytes)", dst.Part_size, src.Part_size)
+	}
+
+	delta := dst.Part_start - src.Part_start
+	if !IsEncrypted(srcFile, src.Part_start) {
+		err := CopyRange(srcFile, int64(src.Part_start), dstFile, int64(dst.Part_start), int64(src.Part_size))
+		if err != nil {
+			return err
+		}
+		return RebaseFilesystem(dstFile, dst.Part_start, delta)
+	}
+
+	// Una partición cifrada se copia tal cual, con su cabecera; sus punteros se corrigen con la
+	// llave del origen, que debe estar montado
+	key, unlocked := utils.CipherKey(srcFile.Name(), int64(src.Part_start+CryptHeaderSize))
+	if delta != 0 && !unlocked {
+		return errors.New("la partición de origen está cifrada; móntela con -passphrase para clonarla")
+	}
+
+	err := CopyRange(srcFile, int64(src.Part_start), dstFile, int64(dst.Part_start), int64(src.Part_size))
+	if err != nil || delta == 0 {
+		return err
+	}
+
+	// La zona cifrada del destino abarca solo lo copiado
+	cloned := *dst
+	cloned.Part_size = src.Part_size
+	if err := cloned.RegisterPartitionKey(dstFile, key); err != nil {
+		return err
+	}
+	defer utils.UnregisterCipher(dstFile.Name(), int64(cloned.Part_start+CryptHeaderSize))
+	return RebaseFilesystem(dstFile, dst.Part_start+CryptHeaderSize, delta)
+}
+
+// ResetIdentity da al disco una identidad nueva después de clonarlo: otro número de serie,
+// otro GUID en los discos GPT y ninguna partición marcada como montada
+func (mbr *MBR) ResetIdentity(file *os.File) error {
+	mbr.MbrDiskSignature = rand.Int31()
+	if mbr.IsGPT() {
+		mbr.MbrDiskGUID = NewDiskGUID()
+	}
+
+	for i := range mbr.MbrPartitions {
+		partition := &mbr.MbrPartitions[i]
+		if partition.Part_start == -1 {
+			continue
+		}
+		partition.MountPartition(0, "")
+	}
+
+	return mbr.Encode(file)
+}
@@ -0,0 +1,46 @@
+package structs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestCopySparse(t *testing.T) {
+	const size = 256 * 1024
+	src := newTestDisk(t, size)
+	for _, offset := range []int64{0, 5000, size - 10} {
+		if _, err := src.WriteAt([]byte("datos"), offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst, err := os.Create(filepath.Join(t.TempDir(), "copia.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if err := CopySparse(src, dst, size); err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := os.ReadFile(src.Name())
+	got, err := os.ReadFile(dst.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("CopySparse() copió %d bytes distintos del origen (%d)", len(got), len(want))
+	}
+
+	// Solo se escribieron los bloques con datos: el resto del archivo sigue disperso
+	info, err := dst.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && stat.Blocks*512 >= size {
+		t.Errorf("la copia ocupa %d bytes en disco, se esperaba un archivo disperso", stat.Blocks*512)
+	}
+}
@@ -0,0 +1,73 @@
+package structs
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// Atributos de archivo guardados en I_flags
+const (
+	InodeFlagCompressed uint32 = 1 << 0 // El contenido del archivo se guarda comprimido con flate
+	InodeFlagIndirect   uint32 = 1 << 1 // I_block[12..14] son apuntadores indirectos (ver WriteFileBlocks)
+)
+
+// IsCompressed indica si el contenido del archivo se guarda comprimido
+func (inode *Inode) IsCompressed() bool {
+	return inode.I_flags&InodeFlagCompressed != 0
+}
+
+// SetCompressed activa o desactiva la compresión del archivo (no reescribe sus bloques)
+func (inode *Inode) SetCompressed(enabled bool) {
+	if enabled {
+		inode.I_flags |= InodeFlagCompressed
+	} else {
+		inode.I_flags &^= InodeFlagCompressed
+	}
+}
+
+// CompressContent comprime el contenido de un archivo antes de dividirlo en bloques
+func CompressContent(content string) (string, error) {
+	var buffer bytes.Buffer
+	writer, err := flate.NewWriter(&buffer, flate.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("error creando el compresor: %w", err)
+	}
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return "", fmt.Errorf("error comprimiendo el contenido: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("error comprimiendo el contenido: %w", err)
+	}
+	return buffer.String(), nil
+}
+
+// DecompressContent descomprime el contenido leído de los bloques de un archivo.
+// Los bytes en cero que rellenan el último bloque quedan después del fin del flujo y se ignoran.
+func DecompressContent(data string) (string, error) {
+	reader := flate.NewReader(bytes.NewReader([]byte(data)))
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("error descomprimiendo el contenido: %w", err)
+	}
+	return string(content), nil
+}
+
+// DecodeContent devuelve el contenido real del archivo a partir de lo leído en sus bloques
+func (inode *Inode) DecodeContent(data string) (string, error) {
+	if !inode.IsCompressed() {
+		return data, nil
+	}
+	return DecompressContent(data)
+}
+
+// EncodeContent prepara el contenido del archivo para dividirlo en bloques con SplitContent
+func (inode *Inode) EncodeContent(content string) (string, error) {
+	if !inode.IsCompressed() {
+		return content, nil
+	}
+	return CompressContent(content)
+}
@@ -0,0 +1,71 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompressContentRoundTrip(t *testing.T) {
+	content := strings.Repeat("1,U,users,ana,$2a$10$abcdefghijklmnopqrstuv\n", 30)
+	compressed, err := CompressContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(content) {
+		t.Fatalf("CompressContent() ocupa %d bytes, el original %d", len(compressed), len(content))
+	}
+
+	// Lo leído de los bloques trae el relleno en cero del último bloque
+	blocks, err := SplitContent(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored strings.Builder
+	for _, block := range blocks {
+		stored.Write(block.B_content[:])
+	}
+	got, err := DecompressContent(stored.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != content {
+		t.Fatalf("DecompressContent() = %q, se esperaba %q", got, content)
+	}
+
+	if _, err := DecompressContent("no es un flujo flate"); err == nil {
+		t.Fatal("DecompressContent() debería fallar con datos que no están comprimidos")
+	}
+}
+
+func TestEncodeContentFollowsFlag(t *testing.T) {
+	inode := &Inode{}
+	content := strings.Repeat("abc", 100)
+
+	stored, err := inode.EncodeContent(content)
+	if err != nil || stored != content {
+		t.Fatalf("EncodeContent() sin compresión = %q, %v", stored, err)
+	}
+
+	inode.SetCompressed(true)
+	if !inode.IsCompressed() {
+		t.Fatal("SetCompressed(true) no activó el atributo")
+	}
+	stored, err = inode.EncodeContent(content)
+	if err != nil || stored == content {
+		t.Fatalf("EncodeContent() con compresión = %q, %v", stored, err)
+	}
+	got, err := inode.DecodeContent(stored)
+	if err != nil || got != content {
+		t.Fatalf("DecodeContent() = %q, %v", got, err)
+	}
+
+	// Desactivar la compresión no toca otros atributos
+	inode.I_flags |= 1 << 7
+	inode.SetCompressed(false)
+	if inode.IsCompressed() || inode.I_flags != 1<<7 {
+		t.Fatalf("SetCompressed(false) dejó I_flags = %#x", inode.I_flags)
+	}
+	if got, _ := inode.DecodeContent(stored); got != stored {
+		t.Fatal("DecodeContent() sin compresión debe devolver los datos tal cual")
+	}
+}
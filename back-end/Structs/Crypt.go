@@ -0,0 +1,129 @@
+package structs
+
+import (
+	"backend/utils"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// CryptMagic identifica la cabecera de una partición cifrada
+var CryptMagic = [8]byte{'M', 'I', 'A', 'C', 'R', 'Y', 'P', 'T'}
+
+const (
+	cryptVersion    = 1      // AES-256-XTS por sectores
+	cryptIterations = 100000 // Iteraciones de PBKDF2
+	cryptKeySize    = 64     // AES-256-XTS usa dos llaves de 256 bits
+	checkKeySize    = 32     // Llave del HMAC que verifica la contraseña
+)
+
+// CryptHeader se guarda al inicio de una partición cifrada; todo lo que sigue está cifrado
+type CryptHeader struct {
+	Magic      [8]byte
+	Version    int32
+	Iterations int32
+	Salt       [16]byte
+	Check      [32]byte // HMAC-SHA256 del salt con la llave de verificación
+}
+
+// CryptHeaderSize es el espacio que ocupa la cabecera al inicio de la partición
+var CryptHeaderSize = int32(binary.Size(CryptHeader{}))
+
+// ErrWrongPassphrase indica que la contraseña no abre la partición
+var ErrWrongPassphrase = errors.New("contraseña incorrecta")
+
+// NewCryptHeader crea la cabecera de una partición cifrada con un salt nuevo y devuelve su llave
+func NewCryptHeader(passphrase string) (*CryptHeader, []byte, error) {
+	header := &CryptHeader{Magic: CryptMagic, Version: cryptVersion, Iterations: cryptIterations}
+	if _, err := rand.Read(header.Salt[:]); err != nil {
+		return nil, nil, fmt.Errorf("error generando el salt: %v", err)
+	}
+
+	key, checkKey := header.deriveKeys(passphrase)
+	header.Check = header.checksum(checkKey)
+	return header, key, nil
+}
+
+// Unlock deriva la llave de la partición y verifica que la contraseña sea la correcta
+func (h *CryptHeader) Unlock(passphrase string) ([]byte, error) {
+	key, checkKey := h.deriveKeys(passphrase)
+	check := h.checksum(checkKey)
+	if !hmac.Equal(check[:], h.Check[:]) {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// deriveKeys obtiene con PBKDF2 la llave de cifrado y una llave aparte para verificar la contraseña
+func (h *CryptHeader) deriveKeys(passphrase string) ([]byte, []byte) {
+	derived := deriveKey(passphrase, h.Salt[:], int(h.Iterations), cryptKeySize+checkKeySize)
+	return derived[:cryptKeySize], derived[cryptKeySize:]
+}
+
+// deriveKey aplica PBKDF2-HMAC-SHA256 (RFC 8018) a la contraseña
+func deriveKey(passphrase string, salt []byte, iterations int, size int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, size, sha256.New)
+}
+
+// checksum calcula el valor de verificación de la contraseña
+func (h *CryptHeader) checksum(checkKey []byte) [32]byte {
+	mac := hmac.New(sha256.New, checkKey)
+	mac.Write(h.Salt[:])
+	return [32]byte(mac.Sum(nil))
+}
+
+// Encode escribe la cabecera al inicio de la partición
+func (h *CryptHeader) Encode(file *os.File, start int32) error {
+	return utils.WriteToFile(file, int64(start), h)
+}
+
+// ReadCryptHeader lee la cabecera de cifrado de la partición que empieza en start.
+// Devuelve nil si la partición no está cifrada.
+func ReadCryptHeader(file *os.File, start int32) (*CryptHeader, error) {
+	header := &CryptHeader{}
+	if err := utils.ReadFromFile(file, int64(start), header); err != nil {
+		return nil, err
+	}
+	if header.Magic != CryptMagic {
+		return nil, nil
+	}
+	if header.Version != cryptVersion || header.Iterations <= 0 {
+		return nil, fmt.Errorf("cabecera de cifrado con versión %d no soportada", header.Version)
+	}
+	return header, nil
+}
+
+// IsEncrypted indica si la partición que empieza en start tiene una cabecera de cifrado
+func IsEncrypted(file *os.File, start int32) bool {
+	header, err := ReadCryptHeader(file, start)
+	return err == nil && header != nil
+}
+
+// RegisterPartitionKey registra la llave para que el contenido de la partición se descifre al leerlo
+func (p *Partition) RegisterPartitionKey(file *os.File, key []byte) error {
+	return utils.RegisterCipher(file.Name(), int64(p.Part_start+CryptHeaderSize), int64(p.Part_start+p.Part_size), key)
+}
+
+// IsUnlocked indica si la partición cifrada tiene su llave registrada
+func (p *Partition) IsUnlocked(file *os.File) bool {
+	_, ok := utils.CipherKey(file.Name(), int64(p.Part_start+CryptHeaderSize))
+	return ok
+}
+
+// FilesystemPartition devuelve la zona de la partición donde está el sistema de archivos:
+// en una partición cifrada empieza después de la cabecera
+func (p *Partition) FilesystemPartition(file *os.File) *Partition {
+	if !IsEncrypted(file, p.Part_start) {
+		return p
+	}
+	view := *p
+	view.Part_start += CryptHeaderSize
+	view.Part_size -= CryptHeaderSize
+	return &view
+}
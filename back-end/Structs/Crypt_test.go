@@ -0,0 +1,56 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	salt := make([]byte, 16)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		salt       []byte
+		iterations int
+		want       string
+	}{
+		// RFC 7914, sección 11 (PBKDF2-HMAC-SHA256)
+		{"rfc7914 c=1", "passwd", []byte("salt"), 1,
+			"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"rfc7914 c=80000", "Password", []byte("NaCl"), 80000,
+			"4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+		// Llave que derivaba la implementación anterior: las particiones ya cifradas se siguen abriendo
+		{"compatibilidad", "clave secreta", salt, 1000,
+			"5cf7cf7a5aa37b6c5dc0f7ac41820074aeacceecd5ab0baf6210e63bdef988a3816750c148b75c2aba664641c086704abbacd71c6f43a831eddb984fbebec296"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(deriveKey(tt.passphrase, tt.salt, tt.iterations, 64))
+			if got != tt.want {
+				t.Errorf("deriveKey() = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCryptHeaderUnlock(t *testing.T) {
+	header, key, err := NewCryptHeader("correcta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := header.Unlock("correcta")
+	if err != nil {
+		t.Fatalf("Unlock() con la contraseña correcta: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Unlock() devolvió otra llave")
+	}
+	if _, err := header.Unlock("incorrecta"); err != ErrWrongPassphrase {
+		t.Errorf("Unlock() con otra contraseña = %v, se esperaba ErrWrongPassphrase", err)
+	}
+}
@@ -2,8 +2,11 @@ package structs
 
 import (
 	utilidades "backend/utils" // Importa el paquete utils
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // EBR representa el Extended Boot Record
@@ -16,6 +19,51 @@ type EBR struct {
 	Ebr_name  [16]byte // Nombre de la partición
 }
 
+// Las particiones lógicas no guardan su ID de montaje en el disco: agregarlo al EBR cambiaría su tamaño
+// y con él el inicio de los datos de las lógicas ya creadas. El ID se registra al montar, por disco y
+// nombre de la partición, y la tabla de montaje lo guarda para restaurarlo al reiniciar el servidor.
+var logicalMounts = make(map[string]map[string]string) // disco -> ID -> nombre de la partición lógica
+
+// MountLogical registra el ID con que se montó la partición lógica del disco
+func MountLogical(diskPath string, id string, name string) {
+	k := utilidades.DiskKey(diskPath)
+	if logicalMounts[k] == nil {
+		logicalMounts[k] = make(map[string]string)
+	}
+	logicalMounts[k][id] = strings.Trim(name, "\x00 ")
+}
+
+// UnmountLogical olvida el ID de montaje de una partición lógica del disco
+func UnmountLogical(diskPath string, id string) {
+	k := utilidades.DiskKey(diskPath)
+	delete(logicalMounts[k], id)
+	if len(logicalMounts[k]) == 0 {
+		delete(logicalMounts, k)
+	}
+}
+
+// LogicalMountName devuelve el nombre de la partición lógica montada con el ID indicado, o "" si el ID no es de una lógica
+func LogicalMountName(diskPath string, id string) string {
+	inputID := strings.Trim(id, "\x00 ")
+	for mountedID, name := range logicalMounts[utilidades.DiskKey(diskPath)] {
+		if strings.EqualFold(mountedID, inputID) {
+			return name
+		}
+	}
+	return ""
+}
+
+// LogicalMountID devuelve el ID con que está montada la partición lógica con ese nombre, o "" si no está montada
+func LogicalMountID(diskPath string, name string) string {
+	inputName := strings.Trim(name, "\x00 ")
+	for id, mountedName := range logicalMounts[utilidades.DiskKey(diskPath)] {
+		if strings.EqualFold(mountedName, inputName) {
+			return id
+		}
+	}
+	return ""
+}
+
 // Encode serializa la estructura EBR en un archivo en la posición especificada
 func (e *EBR) Encode(file *os.File, position int64) error {
 	return utilidades.WriteToFile(file, position, e)
@@ -139,17 +187,11 @@ func (e *EBR) Overwrite(file *os.File) error {
 		return fmt.Errorf("el tamaño del EBR es inválido o cero")
 	}
 
-	// Posicionarse en el inicio del EBR (donde comienza la partición lógica)
-	_, err := file.Seek(int64(e.Ebr_start), 0)
-	if err != nil {
-		return fmt.Errorf("error al mover el puntero del archivo a la posición del EBR: %v", err)
-	}
-
 	// Crear un buffer de ceros del tamaño de la partición lógica
 	zeroes := make([]byte, e.Ebr_size)
 
-	// Escribir los ceros en el archivo
-	_, err = file.Write(zeroes)
+	// Escribir los ceros en el archivo, desde el inicio del EBR
+	err := utilidades.WriteAt(file, zeroes, int64(e.Ebr_start))
 	if err != nil {
 		return fmt.Errorf("error al sobrescribir el espacio del EBR: %v", err)
 	}
@@ -163,3 +205,160 @@ func (e *EBR) SetNextEBR(newNext int32) {
 	fmt.Printf("Estableciendo el siguiente EBR: Actual Start: %d, Nuevo Next: %d\n", e.Ebr_start, newNext)
 	e.Ebr_next = newNext
 }
+
+// DataStart devuelve el byte donde inicia el contenido de la partición lógica, después de su EBR
+func (e *EBR) DataStart() int32 {
+	return e.Ebr_start + int32(binary.Size(EBR{}))
+}
+
+// ToPartition describe la partición lógica como una partición, para montarla y formatearla igual que una primaria
+func (e *EBR) ToPartition() *Partition {
+	return &Partition{
+		Part_status: [1]byte{'1'},
+		Part_type:   [1]byte{'L'},
+		Part_fit:    e.Ebr_fit,
+		Part_start:  e.DataStart(),
+		Part_size:   e.Ebr_size - int32(binary.Size(EBR{})),
+		Part_name:   e.Ebr_name,
+	}
+}
+
+// EBRChain devuelve los EBRs de la partición extendida que inicia en start, en orden
+func EBRChain(start int32, file *os.File) ([]*EBR, error) {
+	var chain []*EBR
+	for position := start; position != -1; {
+		ebr := &EBR{}
+		if err := ebr.Decode(file, int64(position)); err != nil {
+			return nil, err
+		}
+		chain = append(chain, ebr)
+
+		// El siguiente EBR siempre está más adelante; cualquier otro valor indica una cadena dañada
+		if ebr.Ebr_next != -1 && ebr.Ebr_next <= position {
+			return nil, fmt.Errorf("cadena de EBRs dañada en la posición %d", position)
+		}
+		position = ebr.Ebr_next
+	}
+	return chain, nil
+}
+
+// FindEBRByID busca la partición lógica montada con el ID indicado dentro de las extendidas del disco
+func (mbr *MBR) FindEBRByID(file *os.File, id string) (*EBR, error) {
+	name := LogicalMountName(file.Name(), id)
+	if name == "" {
+		return nil, errors.New("partición no encontrada")
+	}
+	for _, partition := range mbr.MbrPartitions {
+		if partition.Part_type[0] != 'E' {
+			continue
+		}
+		ebr, _, err := FindEBRByName(partition.Part_start, name, file)
+		if err != nil {
+			return nil, err
+		}
+		if ebr != nil {
+			return ebr, nil
+		}
+	}
+	return nil, errors.New("partición no encontrada")
+}
+
+// FindPartitionByID busca la partición montada con el ID indicado y devuelve la zona de su
+// sistema de archivos, que en una partición cifrada empieza después de la cabecera
+func (mbr *MBR) FindPartitionByID(file *os.File, id string) (*Partition, error) {
+	partition, err := mbr.FindRawPartitionByID(file, id)
+	if err != nil {
+		return nil, err
+	}
+	return partition.FilesystemPartition(file), nil
+}
+
+// FindRawPartitionByID busca la partición montada con el ID indicado, primaria o lógica.
+// Para una lógica devuelve su descripción como partición: modificarla no cambia el EBR.
+func (mbr *MBR) FindRawPartitionByID(file *os.File, id string) (*Partition, error) {
+	if partition, err := mbr.GetPartitionByID(id); err == nil {
+		return partition, nil
+	}
+
+	ebr, err := mbr.FindEBRByID(file, id)
+	if err != nil {
+		return nil, err
+	}
+	partition := ebr.ToPartition()
+	copy(partition.Part_id[:], strings.Trim(id, "\x00 "))
+	return partition, nil
+}
+
+// FindEBRByName recorre la cadena de EBRs y devuelve el EBR de la partición lógica con ese nombre
+// junto con el EBR anterior (nil si es el primero de la cadena)
+func FindEBRByName(start int32, name string, file *os.File) (*EBR, *EBR, error) {
+	var previous *EBR
+	inputName := strings.Trim(name, "\x00 ")
+
+	for position := start; position != -1; {
+		current := &EBR{}
+		err := current.Decode(file, int64(position))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ebrName := strings.Trim(string(current.Ebr_name[:]), "\x00 ")
+		if current.Ebr_size > 0 && strings.EqualFold(ebrName, inputName) {
+			return current, previous, nil
+		}
+
+		previous = current
+		position = current.Ebr_next
+	}
+	return nil, nil, nil
+}
+
+// Delete elimina la partición lógica de la cadena de EBRs.
+// El primer EBR está fijo al inicio de la extendida, por lo que solo se vacía; los demás se desenlazan.
+func (e *EBR) Delete(deleteType string, previous *EBR, file *os.File) error {
+	// Si el tipo de eliminación es 'full', sobrescribir el espacio de la partición lógica
+	if deleteType == "full" {
+		err := e.Overwrite(file)
+		if err != nil {
+			return fmt.Errorf("error al sobrescribir la partición lógica: %v", err)
+		}
+	}
+
+	if previous == nil {
+		e.Ebr_mount[0] = '0'
+		e.Ebr_size = 0
+		e.Ebr_name = [16]byte{}
+		err := e.Encode(file, int64(e.Ebr_start))
+		if err != nil {
+			return fmt.Errorf("error al actualizar el primer EBR: %v", err)
+		}
+	} else {
+		previous.SetNextEBR(e.Ebr_next)
+		err := previous.Encode(file, int64(previous.Ebr_start))
+		if err != nil {
+			return fmt.Errorf("error al actualizar el EBR anterior: %v", err)
+		}
+	}
+
+	fmt.Printf("La partición lógica en la posición %d ha sido eliminada (%s).\n", e.Ebr_start, deleteType)
+	return nil
+}
+
+// ModifySize cambia el tamaño de la partición lógica sin pasar del límite indicado
+// (el siguiente EBR o el final de la partición extendida)
+func (e *EBR) ModifySize(addSize int32, limit int32) error {
+	newSize := e.Ebr_size + addSize
+
+	// La partición lógica debe conservar al menos el espacio de su EBR
+	if newSize <= int32(binary.Size(EBR{})) {
+		return fmt.Errorf("el tamaño de la partición lógica debe ser mayor a %d bytes", binary.Size(EBR{}))
+	}
+
+	if addSize > 0 && e.Ebr_start+newSize > limit {
+		return fmt.Errorf("no hay suficiente espacio libre después de la partición lógica (disponible: %d bytes)", limit-e.Ebr_start-e.Ebr_size)
+	}
+
+	e.Ebr_size = newSize
+	fmt.Printf("El tamaño de la partición lógica '%s' ha sido modificado. Nuevo tamaño: %d bytes.\n", strings.TrimRight(string(e.Ebr_name[:]), "\x00"), e.Ebr_size)
+	return nil
+}
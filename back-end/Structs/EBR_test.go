@@ -0,0 +1,48 @@
+package structs
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestEBRSize(t *testing.T) {
+	// Los datos de una partición lógica empiezan después de su EBR: cambiar su tamaño movería
+	// el contenido de las lógicas de los discos ya creados
+	if size := binary.Size(EBR{}); size != 30 {
+		t.Errorf("binary.Size(EBR{}) = %d, se esperaba 30", size)
+	}
+}
+
+func TestFindEBRByID(t *testing.T) {
+	file := newTestDisk(t, 4096)
+	mbr := &MBR{MbrPartitions: []Partition{{Part_type: [1]byte{'E'}, Part_start: 100, Part_size: 3000}}}
+
+	first := &EBR{}
+	first.SetEBR('F', 500, 100, 600, "L1")
+	second := &EBR{}
+	second.SetEBR('F', 500, 600, -1, "L2")
+	for _, ebr := range []*EBR{first, second} {
+		if err := ebr.Encode(file, int64(ebr.Ebr_start)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	MountLogical(file.Name(), "063A", "L2")
+	t.Cleanup(func() { UnmountLogical(file.Name(), "063A") })
+
+	partition, err := mbr.FindRawPartitionByID(file, "063a")
+	if err != nil {
+		t.Fatalf("FindRawPartitionByID() de una lógica montada: %v", err)
+	}
+	if partition.Part_start != second.DataStart() || string(partition.Part_id[:]) != "063a" {
+		t.Errorf("FindRawPartitionByID() = inicio %d, ID %q; se esperaba %d", partition.Part_start, partition.Part_id, second.DataStart())
+	}
+	if id := LogicalMountID(file.Name(), "l2"); id != "063A" {
+		t.Errorf("LogicalMountID() = %q, se esperaba 063A", id)
+	}
+
+	UnmountLogical(file.Name(), "063A")
+	if _, err := mbr.FindRawPartitionByID(file, "063A"); err == nil {
+		t.Errorf("FindRawPartitionByID() encontró una lógica ya desmontada")
+	}
+}
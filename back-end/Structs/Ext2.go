@@ -12,16 +12,14 @@ func (sb *Superblock) CreateUsersFile(file *os.File) error {
 		I_uid:   1,
 		I_gid:   1,
 		I_size:  0,
-		I_atime: float32(time.Now().Unix()),
-		I_ctime: float32(time.Now().Unix()),
-		I_mtime: float32(time.Now().Unix()),
 		I_block: [15]int32{sb.S_blocks_count, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
 		I_type:  [1]byte{'0'}, // Tipo carpeta
 		I_perm:  [3]byte{'7', '7', '7'},
 	}
+	rootInode.SetTimestamps(time.Now())
 
 	// Escribir el inodo raíz (inodo 0)
-	err := rootInode.Encode(file, int64(sb.S_inode_start+0))
+	err := rootInode.Encode(file, int64(sb.S_inode_start+0), sb)
 	if err != nil {
 		return fmt.Errorf("error al escribir el inodo raíz: %w", err)
 	}
@@ -46,7 +44,7 @@ func (sb *Superblock) CreateUsersFile(file *os.File) error {
 	}
 
 	// Escribir el bloque raíz
-	err = rootBlock.Encode(file, int64(sb.S_block_start))
+	err = rootBlock.Encode(file, int64(sb.S_block_start), sb)
 	if err != nil {
 		return fmt.Errorf("error al escribir el bloque raíz: %w", err)
 	}
@@ -62,23 +60,24 @@ func (sb *Superblock) CreateUsersFile(file *os.File) error {
 
 	// ----------- Crear Inodo para /users.txt (inodo 1) -----------
 	rootGroup := NewGroup("1", "root")
-	rootUser := NewUser("1", "root", "root", "123")
+	rootUser := NewUser("1", "root", "root", "")
+	if err := rootUser.SetPassword("123"); err != nil {
+		return err
+	}
 	usersText := fmt.Sprintf("%s\n%s\n", rootGroup.ToString(), rootUser.ToString())
 
 	usersInode := &Inode{
 		I_uid:   1,
 		I_gid:   1,
 		I_size:  int32(len(usersText)),
-		I_atime: float32(time.Now().Unix()),
-		I_ctime: float32(time.Now().Unix()),
-		I_mtime: float32(time.Now().Unix()),
 		I_block: [15]int32{1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1}, // Apunta al bloque 1 (users.txt)
 		I_type:  [1]byte{'1'},                                                         // Tipo archivo
 		I_perm:  [3]byte{'7', '7', '7'},
 	}
+	usersInode.SetTimestamps(time.Now())
 
 	// Escribir el inodo de users.txt (inodo 1)
-	err = usersInode.Encode(file, int64(sb.S_first_ino))
+	err = usersInode.Encode(file, int64(sb.S_first_ino), sb)
 	if err != nil {
 		return fmt.Errorf("error al escribir el inodo de users.txt: %w", err)
 	}
@@ -110,6 +109,12 @@ func (sb *Superblock) CreateUsersFile(file *os.File) error {
 	// Actualizar el contador de bloques y el puntero al primer bloque libre
 	sb.UpdateSuperblockAfterBlockAllocation()
 
+	// El resto del contenido (los hashes no caben en un solo bloque) va en bloques nuevos
+	err = sb.writeRemainingUsersBlocks(file, usersInode, usersText)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Archivo users.txt creado correctamente.")
 	fmt.Println("Superbloque después de la creación de users.txt:")
 	sb.Print()
@@ -119,3 +124,28 @@ func (sb *Superblock) CreateUsersFile(file *os.File) error {
 	sb.PrintInodes(file.Name())
 	return nil
 }
+
+// writeRemainingUsersBlocks escribe en bloques nuevos la parte de users.txt que no cupo en el primer bloque
+func (sb *Superblock) writeRemainingUsersBlocks(file *os.File, usersInode *Inode, usersText string) error {
+	if len(usersText) <= BlockSize {
+		return nil
+	}
+
+	blocks, err := SplitContent(usersText[BlockSize:])
+	if err != nil {
+		return fmt.Errorf("error al dividir el contenido de users.txt: %w", err)
+	}
+	for i, block := range blocks {
+		blockIndex, err := sb.AssignNewBlock(file, usersInode, i+1)
+		if err != nil {
+			return fmt.Errorf("error asignando bloque para users.txt: %w", err)
+		}
+		err = block.Encode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size))
+		if err != nil {
+			return fmt.Errorf("error al escribir el bloque de users.txt: %w", err)
+		}
+	}
+
+	// Guardar el inodo con los bloques nuevos
+	return usersInode.Encode(file, sb.CalculateInodeOffset(1), sb)
+}
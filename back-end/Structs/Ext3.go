@@ -63,7 +63,7 @@ func (sb *Superblock) CreateUsersFileExt3(file *os.File, journaling_start int64)
 	}
 
 	// Serializar el bloque de carpeta raíz
-	err = rootBlock.Encode(file, int64(sb.S_first_blo))
+	err = rootBlock.Encode(file, int64(sb.S_first_blo), sb)
 	if err != nil {
 		return fmt.Errorf("error serializando el bloque raíz: %w", err)
 	}
@@ -73,7 +73,10 @@ func (sb *Superblock) CreateUsersFileExt3(file *os.File, journaling_start int64)
 
 	// ----------- Creamos /users.txt -----------
 	rootGroup := NewGroup("1", "root")
-	rootUser := NewUser("1", "root", "root", "123")
+	rootUser := NewUser("1", "root", "root", "")
+	if err := rootUser.SetPassword("123"); err != nil {
+		return err
+	}
 	usersText := fmt.Sprintf("%s\n%s\n", rootGroup.ToString(), rootUser.ToString())
 
 	//Se crea el journal de users.txt
@@ -120,8 +123,8 @@ func (sb *Superblock) CreateUsersFileExt3(file *os.File, journaling_start int64)
 	usersBlock := &FileBlock{
 		B_content: [64]byte{},
 	}
-	// Copiamos el texto de usuarios en el bloque
-	usersBlock.AppendContent(usersText)
+	// Copiamos el texto de usuarios en el bloque (lo que no cabe va en bloques nuevos)
+	copy(usersBlock.B_content[:], usersText)
 
 	// Serializar el bloque de users.txt
 	//s_first_blo es el primer bloque de datos + el tamaño del inodo de users.txt = el inicio del bloque de users.txt
@@ -138,6 +141,13 @@ func (sb *Superblock) CreateUsersFileExt3(file *os.File, journaling_start int64)
 
 	// Actualizamos el superbloque
 	sb.UpdateSuperblockAfterBlockAllocation()
+
+	// El resto del contenido (los hashes no caben en un solo bloque) va en bloques nuevos
+	err = sb.writeRemainingUsersBlocks(file, usersInode, usersText)
+	if err != nil {
+		return err
+	}
+
 	//mostar las estructuras
 	fmt.Println("Bloques")
 	sb.PrintBlocks(file.Name())
@@ -13,7 +13,7 @@ func (sb *Superblock) createFileInInode(file *os.File, inodeIndex int32, parents
 	fmt.Printf("Intentando crear archivo '%s' en inodo index %d\n", destFile, inodeIndex) // Depuración
 	//Deserializar el inodo
 	inode := &Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
 	}
@@ -24,7 +24,7 @@ func (sb *Superblock) createFileInInode(file *os.File, inodeIndex int32, parents
 	}
 
 	// Iterar sobre cada bloque del inodo (apuntadores)
-	for _, blockIndex := range inode.I_block {
+	for blockPos, blockIndex := range inode.I_block {
 		// Si el bloque no existe, salir
 		if blockIndex == -1 {
 			fmt.Printf("El inodo %d no tiene más bloques, saliendo.\n", inodeIndex) // Depuración
@@ -35,13 +35,13 @@ func (sb *Superblock) createFileInInode(file *os.File, inodeIndex int32, parents
 		block := &FolderBlock{}
 
 		// Deserializar el bloque
-		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // posición del bloque
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb) // posición del bloque
 		if err != nil {
 			return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
 
-		// Iterar sobre cada contenido del bloque, desde el index 2 porque los primeros dos son . y ..
-		for indexContent := 2; indexContent < len(block.B_content); indexContent++ {
+		// Iterar sobre cada contenido del bloque, desde el index 2 en el primero porque los primeros dos son . y ..
+		for indexContent := firstFolderEntry(blockPos); indexContent < len(block.B_content); indexContent++ {
 			content := block.B_content[indexContent]
 
 			// Si hay carpetas padres, buscar la carpeta más cercana
@@ -74,134 +74,148 @@ func (sb *Superblock) createFileInInode(file *os.File, inodeIndex int32, parents
 					continue
 				}
 
-				// Actualizar el contenido del bloque
-				copy(content.B_name[:], []byte(destFile))
-				content.B_inodo = sb.S_inodes_count
-				block.B_content[indexContent] = content
+				return sb.addFileEntry(file, blockIndex, block, indexContent, destFile, fileSize, fileContent)
+			}
+		}
+	}
 
-				// Serializar el bloque
-				err = block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
-				if err != nil {
-					return fmt.Errorf("error al serializar bloque %d: %v", blockIndex, err)
-				}
+	// Todos los bloques de la carpeta destino están llenos: agregar uno nuevo
+	if len(parentsDir) == 0 {
+		blockIndex, block, err := sb.appendFolderBlock(file, inodeIndex, inode)
+		if err != nil {
+			return fmt.Errorf("no se pudo crear el archivo '%s': %w", destFile, err)
+		}
+		return sb.addFileEntry(file, blockIndex, block, 0, destFile, fileSize, fileContent)
+	}
+	return nil
+}
 
-				fmt.Printf("Bloque actualizado para el archivo '%s' en el inodo %d\n", destFile, sb.S_inodes_count) // Depuración
+// addFileEntry registra el archivo destFile en la posición indexContent del bloque y crea su inodo con el contenido
+func (sb *Superblock) addFileEntry(file *os.File, folderBlockIndex int32, block *FolderBlock, indexContent int, destFile string, fileSize int, fileContent []string) error {
+	content := block.B_content[indexContent]
 
-				// Verificación adicional para realizar el journaling si el sistema de archivos lo requiere
-				if sb.S_filesystem_type == 3 { // Aquí usamos el tipo de sistema de archivos (por ejemplo, ext3)
-					FileJournal := &Journal{
-						J_count: sb.S_inodes_count,
-					}
-					var superblockSize int64 = int64(binary.Size(sb))
-					journaling_start := superblockSize
-					err := FileJournal.SaveJournalEntry(
-						file,
-						journaling_start,              // Iniciar el journaling después del superbloque
-						"mkfile",                      // Tipo de operación: crear archivo
-						"/"+destFile,                  // Ruta del archivo
-						strings.Join(fileContent, ""), // El contenido del archivo
-					)
-					if err != nil {
-						return fmt.Errorf("error al guardar la entrada en el journal: %w", err)
-					}
+	// Actualizar el contenido del bloque
+	copy(content.B_name[:], []byte(destFile))
+	content.B_inodo = sb.S_inodes_count
+	block.B_content[indexContent] = content
 
-					//Codificar el journal
-					err = FileJournal.Encode(file, journaling_start)
-					if err != nil {
-						return fmt.Errorf("error al codificar el journal: %w", err)
-					}
-					fmt.Println("Journal creado para el archivo:", destFile)
-					FileJournal.Print()
-				}
+	// Serializar el bloque
+	err := block.Encode(file, int64(sb.S_block_start+(folderBlockIndex*sb.S_block_size)), sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar bloque %d: %v", folderBlockIndex, err)
+	}
 
-				// Crear el inodo del archivo
-				fileInode := &Inode{
-					I_uid:   1,
-					I_gid:   1,
-					I_size:  int32(fileSize),
-					I_atime: float32(time.Now().Unix()),
-					I_ctime: float32(time.Now().Unix()),
-					I_mtime: float32(time.Now().Unix()),
-					I_block: [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
-					I_type:  [1]byte{'1'},
-					I_perm:  [3]byte{'6', '6', '4'},
-				}
+	fmt.Printf("Bloque actualizado para el archivo '%s' en el inodo %d\n", destFile, sb.S_inodes_count) // Depuración
 
-				// Combinar todo el contenido en un string
-				contentStr := strings.Join(fileContent, "")
+	// Verificación adicional para realizar el journaling si el sistema de archivos lo requiere
+	if sb.S_filesystem_type == 3 { // Aquí usamos el tipo de sistema de archivos (por ejemplo, ext3)
+		FileJournal := &Journal{
+			J_count: sb.S_inodes_count,
+		}
+		var superblockSize int64 = int64(binary.Size(sb))
+		journaling_start := superblockSize
+		err := FileJournal.SaveJournalEntry(
+			file,
+			journaling_start,              // Iniciar el journaling después del superbloque
+			"mkfile",                      // Tipo de operación: crear archivo
+			"/"+destFile,                  // Ruta del archivo
+			strings.Join(fileContent, ""), // El contenido del archivo
+		)
+		if err != nil {
+			return fmt.Errorf("error al guardar la entrada en el journal: %w", err)
+		}
 
-				// Dividir el contenido en bloques de tamaño BlockSize
-				blocks, err := SplitContent(contentStr)
-				if err != nil {
-					return fmt.Errorf("error al dividir el contenido en bloques: %v", err)
-				}
+		//Codificar el journal
+		err = FileJournal.Encode(file, journaling_start)
+		if err != nil {
+			return fmt.Errorf("error al codificar el journal: %w", err)
+		}
+		fmt.Println("Journal creado para el archivo:", destFile)
+		FileJournal.Print()
+	}
 
-				blockIndex := 0
-				for _, fileBlock := range blocks {
-					if blockIndex >= len(fileInode.I_block) {
-						return fmt.Errorf("se alcanzó el límite máximo de bloques del inodo")
-					}
+	// Crear el inodo del archivo
+	fileInode := &Inode{
+		I_uid:   1,
+		I_gid:   1,
+		I_size:  int32(fileSize),
+		I_block: [15]int32{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+		I_type:  [1]byte{'1'},
+		I_perm:  [3]byte{'6', '6', '4'},
+	}
+	fileInode.SetTimestamps(time.Now())
 
-					if fileInode.I_block[blockIndex] == -1 {
-						newBlockIndex, err := sb.AssignNewBlock(file, fileInode, blockIndex)
-						if err != nil {
-							return fmt.Errorf("error asignando nuevo bloque: %v", err)
-						}
-						fileInode.I_block[blockIndex] = newBlockIndex
-					}
+	// Combinar todo el contenido en un string
+	contentStr := strings.Join(fileContent, "")
 
-					// Calcular el offset del bloque en el archivo
-					blockOffset := int64(sb.S_block_start + fileInode.I_block[blockIndex]*int32(sb.S_block_size))
+	// Dividir el contenido en bloques de tamaño BlockSize
+	blocks, err := SplitContent(contentStr)
+	if err != nil {
+		return fmt.Errorf("error al dividir el contenido en bloques: %v", err)
+	}
 
-					// Escribir el contenido del bloque en el archivo
-					err = fileBlock.Encode(file, blockOffset)
-					if err != nil {
-						return fmt.Errorf("error escribiendo bloque %d: %v", fileInode.I_block[blockIndex], err)
-					}
+	blockIndex := 0
+	for _, fileBlock := range blocks {
+		if blockIndex >= len(fileInode.I_block) {
+			return fmt.Errorf("se alcanzó el límite máximo de bloques del inodo")
+		}
 
-					fmt.Printf("Bloque de archivo '%s' serializado correctamente en el bloque %d.\n", destFile, fileInode.I_block[blockIndex]) // Depuración
+		if fileInode.I_block[blockIndex] == -1 {
+			newBlockIndex, err := sb.AssignNewBlock(file, fileInode, blockIndex)
+			if err != nil {
+				return fmt.Errorf("error asignando nuevo bloque: %v", err)
+			}
+			fileInode.I_block[blockIndex] = newBlockIndex
+		}
 
-					// Actualizar el bitmap de bloques
-					err = sb.UpdateBitmapBlock(file, fileInode.I_block[blockIndex], true)
-					if err != nil {
-						return fmt.Errorf("error al actualizar bitmap de bloque: %v", err)
-					}
+		// Calcular el offset del bloque en el archivo
+		blockOffset := int64(sb.S_block_start + fileInode.I_block[blockIndex]*int32(sb.S_block_size))
 
-					sb.UpdateSuperblockAfterBlockAllocation()
+		// Escribir el contenido del bloque en el archivo
+		err = fileBlock.Encode(file, blockOffset)
+		if err != nil {
+			return fmt.Errorf("error escribiendo bloque %d: %v", fileInode.I_block[blockIndex], err)
+		}
 
-					blockIndex++
-				}
+		fmt.Printf("Bloque de archivo '%s' serializado correctamente en el bloque %d.\n", destFile, fileInode.I_block[blockIndex]) // Depuración
 
-				// Actualizar el tamaño del archivo en el inodo
-				fileInode.I_size = int32(fileSize)
+		// Actualizar el bitmap de bloques
+		err = sb.UpdateBitmapBlock(file, fileInode.I_block[blockIndex], true)
+		if err != nil {
+			return fmt.Errorf("error al actualizar bitmap de bloque: %v", err)
+		}
 
-				// Actualizar los tiempos de modificación y creación
-				fileInode.UpdateMtime()
-				fileInode.UpdateCtime()
+		sb.UpdateSuperblockAfterBlockAllocation()
 
-				// Serializar el inodo
-				err = fileInode.Encode(file, int64(sb.S_inode_start+(sb.S_inodes_count*sb.S_inode_size)))
-				if err != nil {
-					return fmt.Errorf("error al serializar inodo del archivo: %v", err)
-				}
+		blockIndex++
+	}
 
-				fmt.Printf("Inodo del archivo '%s' serializado correctamente.\n", destFile) // Depuración
+	// Actualizar el tamaño del archivo en el inodo
+	fileInode.I_size = int32(fileSize)
 
-				// Actualizar el bitmap de inodos
-				err = sb.UpdateBitmapInode(file, sb.S_inodes_count, true)
-				if err != nil {
-					return fmt.Errorf("error al actualizar bitmap de inodo: %v", err)
-				}
+	// Actualizar los tiempos de modificación y creación
+	fileInode.UpdateMtime()
+	fileInode.UpdateCtime()
 
-				// Actualizar el superbloque
-				sb.UpdateSuperblockAfterInodeAllocation()
+	// Serializar el inodo
+	err = fileInode.Encode(file, int64(sb.S_inode_start+(sb.S_inodes_count*sb.S_inode_size)), sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar inodo del archivo: %v", err)
+	}
 
-				fmt.Printf("Archivo '%s' creado correctamente en el inodo %d.\n", destFile, sb.S_inodes_count) // Depuración
+	fmt.Printf("Inodo del archivo '%s' serializado correctamente.\n", destFile) // Depuración
 
-				return nil
-			}
-		}
+	// Actualizar el bitmap de inodos
+	err = sb.UpdateBitmapInode(file, sb.S_inodes_count, true)
+	if err != nil {
+		return fmt.Errorf("error al actualizar bitmap de inodo: %v", err)
 	}
+
+	// Actualizar el superbloque
+	sb.UpdateSuperblockAfterInodeAllocation()
+
+	fmt.Printf("Archivo '%s' creado correctamente en el inodo %d.\n", destFile, sb.S_inodes_count) // Depuración
+
 	return nil
 }
 
@@ -229,7 +243,7 @@ func (sb *Superblock) CreateFile(file *os.File, parentsDir []string, destFile st
 func (sb *Superblock) deleteFileInInode(file *os.File, inodeIndex int32, fileName string) error {
 	// Deserializar el inodo
 	inode := &Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
 	}
@@ -247,7 +261,7 @@ func (sb *Superblock) deleteFileInInode(file *os.File, inodeIndex int32, fileNam
 
 		// Deserializar el bloque de la carpeta
 		block := &FolderBlock{}
-		err = block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+		err = block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 		if err != nil {
 			return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
@@ -259,25 +273,27 @@ func (sb *Superblock) deleteFileInInode(file *os.File, inodeIndex int32, fileNam
 				fmt.Printf("Archivo '%s' encontrado en inodo %d, eliminando.\n", fileName, content.B_inodo)
 
 				// Eliminar la referencia al archivo en el bloque de la carpeta usando RemoveEntry
-				err := block.RemoveEntry(file, fileName, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+				err := block.RemoveEntry(file, fileName, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 				if err != nil {
 					return fmt.Errorf("error al eliminar la entrada '%s' del bloque %d: %v", fileName, blockIndex, err)
 				}
 
 				// Deserializar el inodo del archivo
 				fileInode := &Inode{}
-				err = fileInode.Decode(file, int64(sb.S_inode_start+(content.B_inodo*sb.S_inode_size)))
+				err = fileInode.Decode(file, int64(sb.S_inode_start+(content.B_inodo*sb.S_inode_size)), sb)
 				if err != nil {
 					return fmt.Errorf("error al deserializar inodo del archivo %d: %v", content.B_inodo, err)
 				}
 
-				// Liberar los bloques asignados al archivo
-				for _, fileBlockIndex := range fileInode.I_block {
-					if fileBlockIndex != -1 {
-						err = sb.FreeBlock(file, fileBlockIndex)
-						if err != nil {
-							return fmt.Errorf("error al liberar bloque %d: %v", fileBlockIndex, err)
-						}
+				// Liberar los bloques asignados al archivo, incluidos los de apuntadores
+				fileBlocks, err := fileInode.AllBlocks(file, sb)
+				if err != nil {
+					return fmt.Errorf("error al leer los bloques del inodo %d: %v", content.B_inodo, err)
+				}
+				for _, fileBlockIndex := range fileBlocks {
+					err = sb.FreeBlock(file, fileBlockIndex)
+					if err != nil {
+						return fmt.Errorf("error al liberar bloque %d: %v", fileBlockIndex, err)
 					}
 				}
 
@@ -313,7 +329,7 @@ func (sb *Superblock) DeleteFile(file *os.File, parentsDir []string, fileName st
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		// Deserializar el inodo
 		inode := &Inode{}
-		err := inode.Decode(file, int64(sb.S_inode_start+(i*sb.S_inode_size)))
+		err := inode.Decode(file, int64(sb.S_inode_start+(i*sb.S_inode_size)), sb)
 		if err != nil {
 			return fmt.Errorf("error al deserializar inodo %d: %v", i, err)
 		}
@@ -327,7 +343,7 @@ func (sb *Superblock) DeleteFile(file *os.File, parentsDir []string, fileName st
 				}
 
 				block := &FolderBlock{}
-				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 				if err != nil {
 					return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 				}
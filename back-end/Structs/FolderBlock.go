@@ -2,6 +2,7 @@ package structs
 
 import (
 	"backend/utils" // Asegúrate de ajustar el path del package "utils"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"strings"
@@ -20,25 +21,64 @@ type FolderContent struct {
 	// Total: 16 bytes
 }
 
-// Encode serializa la estructura FolderBlock en un archivo binario en la posición especificada
-func (fb *FolderBlock) Encode(file *os.File, offset int64) error {
+// Encode serializa la estructura FolderBlock en un archivo binario en la posición especificada;
+// sb es el superbloque del sistema de archivos al que pertenece el bloque
+func (fb *FolderBlock) Encode(file *os.File, offset int64, sb *Superblock) error {
 	// Utilizamos la función WriteToFile del paquete utils
 	err := utils.WriteToFile(file, offset, fb)
 	if err != nil {
 		return fmt.Errorf("error writing FolderBlock to file: %w", err)
 	}
+
+	// Desde la revisión 4 el checksum del bloque se guarda en la tabla de checksums
+	if csumOffset, ok := sb.folderChecksumOffset(offset); ok {
+		err = utils.WriteToFile(file, csumOffset, checksumBytes(structBytes(fb)))
+		if err != nil {
+			return fmt.Errorf("error writing FolderBlock checksum to file: %w", err)
+		}
+	}
 	return nil
 }
 
-// Decode deserializa la estructura FolderBlock desde un archivo binario en la posición especificada
-func (fb *FolderBlock) Decode(file *os.File, offset int64) error {
+// Decode deserializa la estructura FolderBlock desde un archivo binario en la posición especificada;
+// sb es el superbloque del sistema de archivos al que pertenece el bloque
+func (fb *FolderBlock) Decode(file *os.File, offset int64, sb *Superblock) error {
 	// Utilizamos la función ReadFromFile del paquete utils
 	err := utils.ReadFromFile(file, offset, fb)
 	if err != nil {
 		return fmt.Errorf("error reading FolderBlock from file: %w", err)
 	}
+
+	csumOffset, ok := sb.folderChecksumOffset(offset)
+	if !ok {
+		return nil
+	}
+
+	data := structBytes(fb)
+	if isZeroBytes(data) {
+		return nil
+	}
+
+	var stored uint32
+	if err := utils.ReadFromFile(file, csumOffset, &stored); err != nil {
+		return fmt.Errorf("error reading FolderBlock checksum from file: %w", err)
+	}
+	if computed := checksumBytes(data); computed != stored {
+		return &ChecksumError{Structure: "bloque de carpeta", Offset: offset, Stored: stored, Computed: computed}
+	}
 	return nil
 }
+
+// folderChecksumOffset devuelve la posición del checksum de un bloque de carpeta en la tabla de checksums
+func (sb *Superblock) folderChecksumOffset(offset int64) (int64, bool) {
+	if sb.S_revision < RevisionChecksums || sb.S_csum_start == 0 {
+		return 0, false
+	}
+
+	index := (offset - int64(sb.S_block_start)) / int64(sb.S_block_size)
+	return int64(sb.S_csum_start) + index*int64(binary.Size(uint32(0))), true
+}
+
 func NewFolderBlock(selfInodo, parentInodo int32, additionalContents map[string]int32) *FolderBlock {
 	fb := &FolderBlock{}
 
@@ -122,7 +162,7 @@ func (fb *FolderBlock) RenameInFolderBlock(oldName string, newName string) error
 }
 
 // RemoveEntry elimina una entrada de archivo o carpeta del FolderBlock y actualiza el archivo
-func (fb *FolderBlock) RemoveEntry(file *os.File, name string, blockOffset int64) error {
+func (fb *FolderBlock) RemoveEntry(file *os.File, name string, blockOffset int64, sb *Superblock) error {
 	// Iterar sobre los contenidos del bloque, comenzando desde el índice 2 para evitar modificar . y ..
 	for i := 2; i < len(fb.B_content); i++ {
 		content := &fb.B_content[i]
@@ -135,7 +175,7 @@ func (fb *FolderBlock) RemoveEntry(file *os.File, name string, blockOffset int64
 			copy(content.B_name[:], strings.Repeat("\x00", len(content.B_name)))
 
 			// Serializar el bloque actualizado de vuelta al archivo
-			err := fb.Encode(file, blockOffset)
+			err := fb.Encode(file, blockOffset, sb)
 			if err != nil {
 				return fmt.Errorf("error al serializar el FolderBlock después de eliminar la entrada '%s': %w", name, err)
 			}
@@ -16,7 +16,7 @@ func (sb *Superblock) createFolderInInode(file *os.File, inodeIndex int32, paren
 	fmt.Printf("Deserializando inodo %d\n", inodeIndex) // Depuración
 
 	// Deserializar el inodo
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
 	}
@@ -29,7 +29,7 @@ func (sb *Superblock) createFolderInInode(file *os.File, inodeIndex int32, paren
 	}
 
 	// Iterar sobre cada bloque del inodo (apuntadores)
-	for _, blockIndex := range inode.I_block {
+	for blockPos, blockIndex := range inode.I_block {
 		// Si el bloque no existe, salir
 		if blockIndex == -1 {
 			fmt.Printf("Inodo %d no tiene más bloques asignados, terminando la búsqueda.\n", inodeIndex) // Depuración
@@ -41,14 +41,14 @@ func (sb *Superblock) createFolderInInode(file *os.File, inodeIndex int32, paren
 		block := &FolderBlock{}
 
 		// Deserializar el bloque
-		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size))) // Calcular la posición del bloque
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb) // Calcular la posición del bloque
 		if err != nil {
 			return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
 		fmt.Printf("Bloque %d del inodo %d deserializado correctamente\n", blockIndex, inodeIndex) // Depuración
 
-		// Iterar sobre cada contenido del bloque, desde el índice 2 (evitamos . y ..)
-		for indexContent := 2; indexContent < len(block.B_content); indexContent++ {
+		// Iterar sobre cada contenido del bloque, desde el índice 2 en el primero (evitamos . y ..)
+		for indexContent := firstFolderEntry(blockPos); indexContent < len(block.B_content); indexContent++ {
 			content := block.B_content[indexContent]
 			fmt.Printf("Verificando contenido en índice %d del bloque %d\n", indexContent, blockIndex) // Depuración
 
@@ -86,114 +86,178 @@ func (sb *Superblock) createFolderInInode(file *os.File, inodeIndex int32, paren
 					continue
 				}
 
-				fmt.Printf("Asignando el nombre del directorio '%s' al bloque en la posición %d\n", destDir, indexContent) // Depuración
-				// Actualizar el contenido del bloque con el nuevo directorio
-				copy(content.B_name[:], destDir)
-				content.B_inodo = sb.S_inodes_count
+				return sb.addFolderEntry(file, inodeIndex, blockIndex, block, indexContent, destDir)
+			}
+		}
+	}
 
-				// Actualizar el bloque con el nuevo contenido
-				block.B_content[indexContent] = content
+	// Todos los bloques de la carpeta destino están llenos: agregar uno nuevo
+	if len(parentsDir) == 0 {
+		blockIndex, block, err := sb.appendFolderBlock(file, inodeIndex, inode)
+		if err != nil {
+			return fmt.Errorf("no se pudo crear la carpeta '%s': %w", destDir, err)
+		}
+		return sb.addFolderEntry(file, inodeIndex, blockIndex, block, 0, destDir)
+	}
 
-				// Serializar el bloque
-				err = block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
-				if err != nil {
-					return fmt.Errorf("error al serializar el bloque %d: %v", blockIndex, err)
-				}
-				fmt.Printf("Bloque %d actualizado con éxito.\n", blockIndex) // Depuración
+	fmt.Printf("No se encontraron bloques disponibles para crear la carpeta '%s' en inodo %d\n", destDir, inodeIndex) // Depuración
+	return nil
+}
 
-				// Verificación adicional para realizar el journaling si el sistema de archivos lo requiere
-				if sb.S_filesystem_type == 3 { // Aquí usamos el tipo de sistema de archivos (ext3)
-					FolderJournal := &Journal{
-						J_count: sb.S_inodes_count,
-					}
-					var superblockSize int64 = int64(binary.Size(sb))
-					journaling_start := superblockSize
-					err = FolderJournal.SaveJournalEntry(
-						file,
-						journaling_start, // Iniciar el journaling después del superbloque
-						"mkdir",          // Tipo de operación: crear carpeta
-						"/"+destDir,      // Ruta del directorio
-						"",               // No hay contenido asociado en este caso
-					)
-					if err != nil {
-						return fmt.Errorf("error al guardar la entrada en el journal: %w", err)
-					}
+// addFolderEntry registra la carpeta destDir en la posición indexContent del bloque y crea su inodo y su bloque
+func (sb *Superblock) addFolderEntry(file *os.File, inodeIndex int32, blockIndex int32, block *FolderBlock, indexContent int, destDir string) error {
+	content := block.B_content[indexContent]
 
-					//Codificar el journal
-					err = FolderJournal.Encode(file, journaling_start)
-					if err != nil {
-						return fmt.Errorf("error al serializar el journal: %w", err)
-					}
+	fmt.Printf("Asignando el nombre del directorio '%s' al bloque en la posición %d\n", destDir, indexContent) // Depuración
+	// Actualizar el contenido del bloque con el nuevo directorio
+	copy(content.B_name[:], destDir)
+	content.B_inodo = sb.S_inodes_count
 
-					fmt.Printf("Entrada de journal creada para la carpeta '%s'\n", destDir) // Depuración
-					FolderJournal.Print()                                                   // Depuración
-				}
+	// Actualizar el bloque con el nuevo contenido
+	block.B_content[indexContent] = content
 
-				// Crear el inodo de la nueva carpeta
-				folderInode := &Inode{
-					I_uid:   1,
-					I_gid:   1,
-					I_size:  0,
-					I_atime: float32(time.Now().Unix()),
-					I_ctime: float32(time.Now().Unix()),
-					I_mtime: float32(time.Now().Unix()),
-					I_block: [15]int32{sb.S_blocks_count, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
-					I_type:  [1]byte{'0'}, // Tipo carpeta
-					I_perm:  [3]byte{'6', '6', '4'},
-				}
+	// Serializar el bloque
+	err := block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar el bloque %d: %v", blockIndex, err)
+	}
+	fmt.Printf("Bloque %d actualizado con éxito.\n", blockIndex) // Depuración
 
-				fmt.Printf("Serializando el inodo de la carpeta '%s' (inodo %d)\n", destDir, sb.S_inodes_count) // Depuración
-				// Serializar el inodo de la nueva carpeta
-				err = folderInode.Encode(file, int64(sb.S_first_ino))
-				if err != nil {
-					return fmt.Errorf("error al serializar el inodo del directorio '%s': %v", destDir, err)
-				}
+	// Verificación adicional para realizar el journaling si el sistema de archivos lo requiere
+	if sb.S_filesystem_type == 3 { // Aquí usamos el tipo de sistema de archivos (ext3)
+		FolderJournal := &Journal{
+			J_count: sb.S_inodes_count,
+		}
+		var superblockSize int64 = int64(binary.Size(sb))
+		journaling_start := superblockSize
+		err = FolderJournal.SaveJournalEntry(
+			file,
+			journaling_start, // Iniciar el journaling después del superbloque
+			"mkdir",          // Tipo de operación: crear carpeta
+			"/"+destDir,      // Ruta del directorio
+			"",               // No hay contenido asociado en este caso
+		)
+		if err != nil {
+			return fmt.Errorf("error al guardar la entrada en el journal: %w", err)
+		}
 
-				// Actualizar el bitmap de inodos
-				err = sb.UpdateBitmapInode(file, sb.S_inodes_count, true)
-				if err != nil {
-					return fmt.Errorf("error al actualizar el bitmap de inodos para el directorio '%s': %v", destDir, err)
-				}
+		//Codificar el journal
+		err = FolderJournal.Encode(file, journaling_start)
+		if err != nil {
+			return fmt.Errorf("error al serializar el journal: %w", err)
+		}
 
-				// Actualizar el superbloque con los nuevos valores de inodos
-				sb.UpdateSuperblockAfterInodeAllocation()
-
-				// Crear el bloque para la nueva carpeta
-				folderBlock := &FolderBlock{
-					B_content: [4]FolderContent{
-						{B_name: [12]byte{'.'}, B_inodo: content.B_inodo},
-						{B_name: [12]byte{'.', '.'}, B_inodo: inodeIndex},
-						{B_name: [12]byte{'-'}, B_inodo: -1},
-						{B_name: [12]byte{'-'}, B_inodo: -1},
-					},
-				}
+		fmt.Printf("Entrada de journal creada para la carpeta '%s'\n", destDir) // Depuración
+		FolderJournal.Print()                                                   // Depuración
+	}
 
-				fmt.Printf("Serializando el bloque de la carpeta '%s'\n", destDir) // Depuración
-				// Serializar el bloque de la carpeta
-				err = folderBlock.Encode(file, int64(sb.S_first_blo))
-				if err != nil {
-					return fmt.Errorf("error al serializar el bloque del directorio '%s': %v", destDir, err)
-				}
+	// Crear el inodo de la nueva carpeta
+	folderInode := &Inode{
+		I_uid:   1,
+		I_gid:   1,
+		I_size:  0,
+		I_block: [15]int32{sb.S_blocks_count, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+		I_type:  [1]byte{'0'}, // Tipo carpeta
+		I_perm:  [3]byte{'6', '6', '4'},
+	}
+	folderInode.SetTimestamps(time.Now())
 
-				// Actualizar el bitmap de bloques
-				err = sb.UpdateBitmapBlock(file, sb.S_blocks_count, true)
-				if err != nil {
-					return fmt.Errorf("error al actualizar el bitmap de bloques para el directorio '%s': %v", destDir, err)
-				}
+	fmt.Printf("Serializando el inodo de la carpeta '%s' (inodo %d)\n", destDir, sb.S_inodes_count) // Depuración
+	// Serializar el inodo de la nueva carpeta
+	err = folderInode.Encode(file, int64(sb.S_first_ino), sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar el inodo del directorio '%s': %v", destDir, err)
+	}
 
-				// Actualizar el superbloque con los nuevos valores de bloques
-				sb.UpdateSuperblockAfterBlockAllocation()
+	// Actualizar el bitmap de inodos
+	err = sb.UpdateBitmapInode(file, sb.S_inodes_count, true)
+	if err != nil {
+		return fmt.Errorf("error al actualizar el bitmap de inodos para el directorio '%s': %v", destDir, err)
+	}
 
-				fmt.Printf("Directorio '%s' creado correctamente en inodo %d.\n", destDir, sb.S_inodes_count) // Depuración
-				return nil
-			}
-		}
+	// Actualizar el superbloque con los nuevos valores de inodos
+	sb.UpdateSuperblockAfterInodeAllocation()
+
+	// Crear el bloque para la nueva carpeta
+	folderBlock := &FolderBlock{
+		B_content: [4]FolderContent{
+			{B_name: [12]byte{'.'}, B_inodo: content.B_inodo},
+			{B_name: [12]byte{'.', '.'}, B_inodo: inodeIndex},
+			{B_name: [12]byte{'-'}, B_inodo: -1},
+			{B_name: [12]byte{'-'}, B_inodo: -1},
+		},
 	}
 
-	fmt.Printf("No se encontraron bloques disponibles para crear la carpeta '%s' en inodo %d\n", destDir, inodeIndex) // Depuración
+	fmt.Printf("Serializando el bloque de la carpeta '%s'\n", destDir) // Depuración
+	// Serializar el bloque de la carpeta
+	err = folderBlock.Encode(file, int64(sb.S_first_blo), sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar el bloque del directorio '%s': %v", destDir, err)
+	}
+
+	// Actualizar el bitmap de bloques
+	err = sb.UpdateBitmapBlock(file, sb.S_blocks_count, true)
+	if err != nil {
+		return fmt.Errorf("error al actualizar el bitmap de bloques para el directorio '%s': %v", destDir, err)
+	}
+
+	// Actualizar el superbloque con los nuevos valores de bloques
+	sb.UpdateSuperblockAfterBlockAllocation()
+
+	fmt.Printf("Directorio '%s' creado correctamente en inodo %d.\n", destDir, sb.S_inodes_count) // Depuración
 	return nil
 }
 
+// firstFolderEntry devuelve la primera entrada libre para usar de un bloque de carpeta: el primer bloque guarda . y ..
+func firstFolderEntry(blockPos int) int {
+	if blockPos == 0 {
+		return 2
+	}
+	return 0
+}
+
+// appendFolderBlock agrega un bloque de carpeta vacío al inodo cuando sus bloques directos ya están llenos
+func (sb *Superblock) appendFolderBlock(file *os.File, inodeIndex int32, inode *Inode) (int32, *FolderBlock, error) {
+	for i := 0; i < 12; i++ {
+		if inode.I_block[i] != -1 {
+			continue
+		}
+
+		// El bloque nuevo se toma igual que el de una carpeta nueva: el siguiente según el superbloque
+		blockIndex := sb.S_blocks_count
+		block := &FolderBlock{
+			B_content: [4]FolderContent{
+				{B_name: [12]byte{'-'}, B_inodo: -1},
+				{B_name: [12]byte{'-'}, B_inodo: -1},
+				{B_name: [12]byte{'-'}, B_inodo: -1},
+				{B_name: [12]byte{'-'}, B_inodo: -1},
+			},
+		}
+		err := block.Encode(file, int64(sb.S_first_blo), sb)
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al serializar el bloque %d: %v", blockIndex, err)
+		}
+		err = sb.UpdateBitmapBlock(file, blockIndex, true)
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al actualizar el bitmap de bloques: %v", err)
+		}
+		sb.UpdateSuperblockAfterBlockAllocation()
+
+		// Enlazar el bloque en la carpeta
+		inode.I_block[i] = blockIndex
+		inode.UpdateMtime()
+		err = inode.Encode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
+		if err != nil {
+			return -1, nil, fmt.Errorf("error al serializar inodo %d: %v", inodeIndex, err)
+		}
+
+		fmt.Printf("Bloque %d agregado a la carpeta del inodo %d\n", blockIndex, inodeIndex) // Depuración
+		return blockIndex, block, nil
+	}
+
+	return -1, nil, fmt.Errorf("la carpeta del inodo %d no admite más entradas", inodeIndex)
+}
+
 // CreateFolder crea una carpeta en el sistema de archivos
 func (sb *Superblock) CreateFolder(file *os.File, parentsDir []string, destDir string) error {
 	// Si parentsDir está vacío, solo trabajar con el primer inodo que sería el raíz "/"
@@ -248,7 +312,7 @@ func (sb *Superblock) createFolderRecursivelyInInode(file *os.File, inodeIndex i
 func (sb *Superblock) deleteFolderInInode(file *os.File, inodeIndex int32) error {
 	// Deserializar el inodo
 	inode := &Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
 	}
@@ -266,19 +330,20 @@ func (sb *Superblock) deleteFolderInInode(file *os.File, inodeIndex int32) error
 
 		// Deserializar el bloque de la carpeta
 		block := &FolderBlock{}
-		err = block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+		err = block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 		if err != nil {
 			return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
 
 		// Eliminar los contenidos del bloque (recursivamente si son directorios)
 		for _, content := range block.B_content {
-			if content.B_inodo != -1 && string(content.B_name[:]) != "." && string(content.B_name[:]) != ".." {
+			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			if content.B_inodo != -1 && contentName != "." && contentName != ".." {
 				fmt.Printf("Eliminando contenido '%s' en inodo %d\n", content.B_name, content.B_inodo)
 
 				// Deserializar el inodo para verificar si es archivo o carpeta
 				childInode := &Inode{}
-				err = childInode.Decode(file, int64(sb.S_inode_start+(content.B_inodo*sb.S_inode_size)))
+				err = childInode.Decode(file, int64(sb.S_inode_start+(content.B_inodo*sb.S_inode_size)), sb)
 				if err != nil {
 					return fmt.Errorf("error al deserializar inodo hijo %d: %v", content.B_inodo, err)
 				}
@@ -290,13 +355,15 @@ func (sb *Superblock) deleteFolderInInode(file *os.File, inodeIndex int32) error
 						return err
 					}
 				} else { // Si es archivo
-					// Liberar los bloques del archivo
-					for _, fileBlockIndex := range childInode.I_block {
-						if fileBlockIndex != -1 {
-							err = sb.FreeBlock(file, fileBlockIndex)
-							if err != nil {
-								return err
-							}
+					// Liberar los bloques del archivo, incluidos los de apuntadores
+					fileBlocks, err := childInode.AllBlocks(file, sb)
+					if err != nil {
+						return err
+					}
+					for _, fileBlockIndex := range fileBlocks {
+						err = sb.FreeBlock(file, fileBlockIndex)
+						if err != nil {
+							return err
 						}
 					}
 
@@ -332,7 +399,7 @@ func (sb *Superblock) DeleteFolder(file *os.File, parentsDir []string, folderNam
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		// Deserializar el inodo
 		inode := &Inode{}
-		err := inode.Decode(file, int64(sb.S_inode_start+(i*sb.S_inode_size)))
+		err := inode.Decode(file, int64(sb.S_inode_start+(i*sb.S_inode_size)), sb)
 		if err != nil {
 			return fmt.Errorf("error al deserializar inodo %d: %v", i, err)
 		}
@@ -346,7 +413,7 @@ func (sb *Superblock) DeleteFolder(file *os.File, parentsDir []string, folderNam
 				}
 
 				block := &FolderBlock{}
-				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 				if err != nil {
 					return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 				}
@@ -367,7 +434,7 @@ func (sb *Superblock) DeleteFolder(file *os.File, parentsDir []string, folderNam
 						copy(content.B_name[:], "")
 
 						// Actualizar el bloque después de eliminar la referencia
-						err = block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+						err = block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 						if err != nil {
 							return fmt.Errorf("error al serializar el bloque %d después de eliminar la carpeta: %v", blockIndex, err)
 						}
@@ -381,3 +448,81 @@ func (sb *Superblock) DeleteFolder(file *os.File, parentsDir []string, folderNam
 
 	return fmt.Errorf("carpeta '%s' no encontrada", folderName)
 }
+
+// DeleteFolderEntry elimina recursivamente la carpeta folderName que está dentro de la carpeta del inodo parentInode
+func (sb *Superblock) DeleteFolderEntry(file *os.File, parentInode int32, folderName string) error {
+	inode := &Inode{}
+	err := inode.Decode(file, int64(sb.S_inode_start+(parentInode*sb.S_inode_size)), sb)
+	if err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", parentInode, err)
+	}
+
+	// Iterar sobre los bloques de la carpeta padre
+	for _, blockIndex := range inode.I_block {
+		if blockIndex == -1 {
+			break
+		}
+
+		block := &FolderBlock{}
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
+		if err != nil {
+			return fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
+		}
+
+		// Buscar la carpeta a eliminar
+		for i, content := range block.B_content {
+			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			if content.B_inodo == -1 || !strings.EqualFold(contentName, folderName) || contentName == "." || contentName == ".." {
+				continue
+			}
+
+			// Eliminar el contenido de la carpeta y luego su entrada en la carpeta padre
+			err = sb.deleteFolderInInode(file, content.B_inodo)
+			if err != nil {
+				return err
+			}
+			block.B_content[i] = FolderContent{B_name: [12]byte{'-'}, B_inodo: -1}
+
+			err = block.Encode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
+			if err != nil {
+				return fmt.Errorf("error al serializar el bloque %d después de eliminar la carpeta: %v", blockIndex, err)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("carpeta '%s' no encontrada", folderName)
+}
+
+// FindFolderEntry busca una entrada por nombre en la carpeta del inodo dado y devuelve su inodo, o -1 si no existe
+func (sb *Superblock) FindFolderEntry(file *os.File, inodeIndex int32, name string) (int32, error) {
+	inode := &Inode{}
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
+	if err != nil {
+		return -1, fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
+	}
+	if inode.I_type[0] != '0' {
+		return -1, fmt.Errorf("el inodo %d no es una carpeta", inodeIndex)
+	}
+
+	for _, blockIndex := range inode.I_block {
+		if blockIndex == -1 {
+			break
+		}
+
+		block := &FolderBlock{}
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
+		if err != nil {
+			return -1, fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
+		}
+
+		for _, content := range block.B_content {
+			contentName := strings.Trim(string(content.B_name[:]), "\x00 ")
+			if content.B_inodo != -1 && strings.EqualFold(contentName, name) {
+				return content.B_inodo, nil
+			}
+		}
+	}
+
+	return -1, nil
+}
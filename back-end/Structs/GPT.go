@@ -0,0 +1,306 @@
+package structs
+
+import (
+	"backend/utils"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// Esquemas de particionado de un disco
+const (
+	PartitionSchemeMBR = "mbr"
+	PartitionSchemeGPT = "gpt"
+)
+
+// Disposición de la tabla GPT
+const (
+	LBASize           = 512 // Tamaño de un bloque lógico
+	GPTEntryCount     = 128 // Entradas de la tabla de particiones
+	GPTEntrySize      = 128 // Tamaño de cada entrada
+	GPTFirstUsableLBA = 34  // MBR protector + cabecera + 32 LBAs de entradas
+
+	gptEntryArrayLBAs = GPTEntryCount * GPTEntrySize / LBASize
+	gptBackupSize     = (gptEntryArrayLBAs + 1) * LBASize // Entradas y cabecera de respaldo al final del disco
+
+	// GPTMinDiskSize es el espacio ocupado por las tablas primaria y de respaldo
+	GPTMinDiskSize = GPTFirstUsableLBA*LBASize + gptBackupSize
+)
+
+// PartitionTypeProtective es el tipo de la única partición del MBR protector de un disco GPT
+const PartitionTypeProtective = 'G'
+
+// GPTSignature es la firma de la cabecera GPT
+var GPTSignature = [8]byte{'E', 'F', 'I', ' ', 'P', 'A', 'R', 'T'}
+
+// GPTRevision es la revisión de la cabecera GPT (1.0)
+const GPTRevision uint32 = 0x00010000
+
+// LinuxFilesystemGUID es el tipo de las particiones de datos (0FC63DAF-8483-4772-8E79-3D69D8477DE4)
+var LinuxFilesystemGUID = [16]byte{0xAF, 0x3D, 0xC6, 0x0F, 0x83, 0x84, 0x72, 0x47, 0x8E, 0x79, 0x3D, 0x69, 0xD8, 0x47, 0x7D, 0xE4}
+
+// GPTHeader es la cabecera de la tabla GPT (92 bytes)
+type GPTHeader struct {
+	Signature                [8]byte  // "EFI PART"
+	Revision                 uint32   // Revisión de la cabecera
+	HeaderSize               uint32   // Tamaño de la cabecera en bytes
+	HeaderCRC32              uint32   // CRC32 de la cabecera (calculado con este campo en 0)
+	Reserved                 uint32   // Debe ser 0
+	MyLBA                    uint64   // LBA de esta cabecera
+	AlternateLBA             uint64   // LBA de la otra cabecera
+	FirstUsableLBA           uint64   // Primera LBA asignable a particiones
+	LastUsableLBA            uint64   // Última LBA asignable a particiones
+	DiskGUID                 [16]byte // GUID del disco
+	PartitionEntryLBA        uint64   // LBA donde inicia la tabla de entradas
+	NumberOfPartitionEntries uint32   // Cantidad de entradas
+	SizeOfPartitionEntry     uint32   // Tamaño de cada entrada
+	PartitionEntryArrayCRC32 uint32   // CRC32 de la tabla de entradas
+}
+
+// GPTEntry es una entrada de la tabla de particiones GPT (128 bytes).
+// Los bits 48-55 de Attributes guardan el ajuste y los últimos 4 caracteres del nombre el ID de montaje.
+type GPTEntry struct {
+	PartitionTypeGUID   [16]byte   // Tipo de la partición (todo en cero si la entrada está libre)
+	UniquePartitionGUID [16]byte   // GUID de la partición
+	StartingLBA         uint64     // Primera LBA de la partición
+	EndingLBA           uint64     // Última LBA de la partición (incluida)
+	Attributes          uint64     // Atributos de la partición
+	PartitionName       [36]uint16 // Nombre en UTF-16LE
+}
+
+// Posición de los datos propios dentro de la entrada GPT
+const (
+	gptFitShift = 48
+	gptIDStart  = 32
+)
+
+// NewGPTPartitions devuelve la tabla de particiones vacía de un disco GPT nuevo
+func NewGPTPartitions() []Partition {
+	partitions := make([]Partition, GPTEntryCount)
+	for i := range partitions {
+		partitions[i] = emptyPartition()
+	}
+	return partitions
+}
+
+// NewDiskGUID genera un GUID aleatorio (versión 4) para un disco GPT
+func NewDiskGUID() [16]byte {
+	var guid [16]byte
+	rand.Read(guid[:])
+	guid[7] = guid[7]&0x0F | 0x40
+	guid[8] = guid[8]&0x3F | 0x80
+	return guid
+}
+
+// FormatGUID muestra un GUID en su forma textual (los tres primeros campos se guardan en little-endian)
+func FormatGUID(guid [16]byte) string {
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X",
+		binary.LittleEndian.Uint32(guid[0:4]),
+		binary.LittleEndian.Uint16(guid[4:6]),
+		binary.LittleEndian.Uint16(guid[6:8]),
+		guid[8:10], guid[10:16])
+}
+
+// emptyPartition devuelve una entrada libre de la tabla de particiones
+func emptyPartition() Partition {
+	return Partition{
+		Part_status:      [1]byte{'9'},
+		Part_type:        [1]byte{'0'},
+		Part_fit:         [1]byte{'0'},
+		Part_start:       -1,
+		Part_size:        -1,
+		Part_correlative: -1,
+	}
+}
+
+// protectivePartitions devuelve la tabla del MBR protector: una partición que cubre todo el disco
+func protectivePartitions(diskSize int32) [4]Partition {
+	partitions := [4]Partition{emptyPartition(), emptyPartition(), emptyPartition(), emptyPartition()}
+	partitions[0] = Partition{
+		Part_status: [1]byte{'1'},
+		Part_type:   [1]byte{PartitionTypeProtective},
+		Part_fit:    [1]byte{'0'},
+		Part_start:  LBASize,
+		Part_size:   diskSize - LBASize,
+	}
+	copy(partitions[0].Part_name[:], "GPT")
+	return partitions
+}
+
+// alignToLBA redondea un tamaño hacia arriba a LBAs completas
+func alignToLBA(size int32) int32 {
+	return (size + LBASize - 1) / LBASize * LBASize
+}
+
+// partitionGUID deriva el GUID de una partición a partir del GUID del disco y su índice
+func partitionGUID(diskGUID [16]byte, index int) [16]byte {
+	guid := diskGUID
+	guid[14] ^= byte((index + 1) >> 8)
+	guid[15] ^= byte(index + 1)
+	return guid
+}
+
+// toGPTEntry convierte una partición a su entrada en la tabla GPT
+func (mbr *MBR) toGPTEntry(index int) GPTEntry {
+	partition := mbr.MbrPartitions[index]
+	if partition.Part_start == -1 || partition.Part_size <= 0 {
+		return GPTEntry{}
+	}
+
+	entry := GPTEntry{
+		PartitionTypeGUID:   LinuxFilesystemGUID,
+		UniquePartitionGUID: partitionGUID(mbr.MbrDiskGUID, index),
+		StartingLBA:         uint64(partition.Part_start / LBASize),
+		EndingLBA:           uint64((partition.Part_start+alignToLBA(partition.Part_size))/LBASize - 1),
+		Attributes:          uint64(partition.Part_fit[0]) << gptFitShift,
+	}
+
+	name := utf16.Encode([]rune(strings.Trim(string(partition.Part_name[:]), "\x00 ")))
+	copy(entry.PartitionName[:len(partition.Part_name)], name)
+	for i, c := range partition.Part_id {
+		entry.PartitionName[gptIDStart+i] = uint16(c)
+	}
+	return entry
+}
+
+// toPartition convierte una entrada de la tabla GPT a partición
+func (entry *GPTEntry) toPartition(index int) Partition {
+	if entry.PartitionTypeGUID == ([16]byte{}) {
+		return emptyPartition()
+	}
+
+	partition := Partition{
+		Part_status: [1]byte{'1'},
+		Part_type:   [1]byte{'P'},
+		Part_fit:    [1]byte{byte(entry.Attributes >> gptFitShift)},
+		Part_start:  int32(entry.StartingLBA * LBASize),
+		Part_size:   int32((entry.EndingLBA - entry.StartingLBA + 1) * LBASize),
+	}
+
+	var nameLength int
+	for nameLength < len(partition.Part_name) && entry.PartitionName[nameLength] != 0 {
+		nameLength++
+	}
+	copy(partition.Part_name[:], string(utf16.Decode(entry.PartitionName[:nameLength])))
+
+	for i := range partition.Part_id {
+		partition.Part_id[i] = byte(entry.PartitionName[gptIDStart+i])
+	}
+	if partition.Part_id[0] != 0 {
+		partition.Part_correlative = int32(index)
+	}
+	return partition
+}
+
+// encodeGPT escribe la cabecera y la tabla de entradas GPT, junto con su copia de respaldo al final del disco
+func (mbr *MBR) encodeGPT(file *os.File) error {
+	var entries bytes.Buffer
+	for i := 0; i < GPTEntryCount; i++ {
+		var entry GPTEntry
+		if i < len(mbr.MbrPartitions) {
+			entry = mbr.toGPTEntry(i)
+		}
+		if err := binary.Write(&entries, binary.LittleEndian, &entry); err != nil {
+			return fmt.Errorf("error serializando la entrada GPT %d: %w", i, err)
+		}
+	}
+
+	lastLBA := uint64(mbr.MbrSize/LBASize - 1)
+	backupEntriesLBA := lastLBA - gptEntryArrayLBAs
+
+	primary := GPTHeader{
+		Signature:                GPTSignature,
+		Revision:                 GPTRevision,
+		HeaderSize:               uint32(binary.Size(GPTHeader{})),
+		MyLBA:                    1,
+		AlternateLBA:             lastLBA,
+		FirstUsableLBA:           GPTFirstUsableLBA,
+		LastUsableLBA:            backupEntriesLBA - 1,
+		DiskGUID:                 mbr.MbrDiskGUID,
+		PartitionEntryLBA:        2,
+		NumberOfPartitionEntries: GPTEntryCount,
+		SizeOfPartitionEntry:     GPTEntrySize,
+		PartitionEntryArrayCRC32: crc32.ChecksumIEEE(entries.Bytes()),
+	}
+	primary.HeaderCRC32 = primary.computeCRC()
+
+	backup := primary
+	backup.MyLBA, backup.AlternateLBA = lastLBA, 1
+	backup.PartitionEntryLBA = backupEntriesLBA
+	backup.HeaderCRC32 = backup.computeCRC()
+
+	for _, header := range []GPTHeader{primary, backup} {
+		if err := utils.WriteAt(file, entries.Bytes(), int64(header.PartitionEntryLBA)*LBASize); err != nil {
+			return fmt.Errorf("error escribiendo las entradas GPT: %w", err)
+		}
+		if err := utils.WriteToFile(file, int64(header.MyLBA)*LBASize, &header); err != nil {
+			return fmt.Errorf("error escribiendo la cabecera GPT: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeGPT lee la tabla GPT; si la cabecera primaria está dañada usa la copia de respaldo
+func (mbr *MBR) decodeGPT(file *os.File) error {
+	header, entries, err := readGPT(file, 1)
+	if err != nil {
+		fmt.Printf("Tabla GPT primaria inválida (%v), leyendo la copia de respaldo...\n", err)
+		var backupErr error
+		header, entries, backupErr = readGPT(file, int64(mbr.MbrSize/LBASize-1))
+		if backupErr != nil {
+			return fmt.Errorf("tabla GPT dañada: %v; respaldo: %v", err, backupErr)
+		}
+	}
+
+	mbr.MbrScheme = PartitionSchemeGPT
+	mbr.MbrDiskGUID = header.DiskGUID
+	mbr.MbrPartitions = make([]Partition, len(entries))
+	for i := range entries {
+		mbr.MbrPartitions[i] = entries[i].toPartition(i)
+	}
+	return nil
+}
+
+// readGPT lee y valida una cabecera GPT y su tabla de entradas
+func readGPT(file *os.File, lba int64) (*GPTHeader, []GPTEntry, error) {
+	header := &GPTHeader{}
+	if err := utils.ReadFromFile(file, lba*LBASize, header); err != nil {
+		return nil, nil, err
+	}
+	if header.Signature != GPTSignature {
+		return nil, nil, errors.New("firma GPT inválida")
+	}
+	if computed := header.computeCRC(); computed != header.HeaderCRC32 {
+		return nil, nil, fmt.Errorf("CRC de la cabecera inválido: guardado %08x, calculado %08x", header.HeaderCRC32, computed)
+	}
+	if header.SizeOfPartitionEntry != GPTEntrySize || header.NumberOfPartitionEntries > GPTEntryCount {
+		return nil, nil, errors.New("tamaño de la tabla de entradas no soportado")
+	}
+
+	raw := make([]byte, header.NumberOfPartitionEntries*header.SizeOfPartitionEntry)
+	if _, err := file.ReadAt(raw, int64(header.PartitionEntryLBA)*LBASize); err != nil {
+		return nil, nil, fmt.Errorf("error leyendo las entradas GPT: %w", err)
+	}
+	if computed := crc32.ChecksumIEEE(raw); computed != header.PartitionEntryArrayCRC32 {
+		return nil, nil, fmt.Errorf("CRC de las entradas inválido: guardado %08x, calculado %08x", header.PartitionEntryArrayCRC32, computed)
+	}
+
+	entries := make([]GPTEntry, header.NumberOfPartitionEntries)
+	if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, entries); err != nil {
+		return nil, nil, fmt.Errorf("error deserializando las entradas GPT: %w", err)
+	}
+	return header, entries, nil
+}
+
+// computeCRC calcula el CRC32 de la cabecera con el campo HeaderCRC32 en 0
+func (header *GPTHeader) computeCRC() uint32 {
+	copyHeader := *header
+	copyHeader.HeaderCRC32 = 0
+	return crc32.ChecksumIEEE(structBytes(&copyHeader))
+}
@@ -0,0 +1,144 @@
+package structs
+
+import (
+	"backend/utils"
+	"os"
+	"testing"
+)
+
+// newTestGPT crea un disco GPT de size bytes con las particiones indicadas (nombre y tamaño)
+func newTestGPT(t *testing.T, size int32, sizes map[string]int32) (*os.File, *MBR) {
+	t.Helper()
+	file := newTestDisk(t, int64(size))
+	mbr := &MBR{
+		MbrSize:       size,
+		MbrDiskFit:    [1]byte{'F'},
+		MbrRevision:   FormatRevision,
+		MbrScheme:     PartitionSchemeGPT,
+		MbrDiskGUID:   NewDiskGUID(),
+		MbrPartitions: NewGPTPartitions(),
+	}
+	for _, name := range []string{"datos", "respaldo", "extra"} {
+		if partSize, ok := sizes[name]; ok {
+			if err := mbr.CreatePartitionWithFit(partSize, "P", name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := mbr.Encode(file); err != nil {
+		t.Fatal(err)
+	}
+	return file, mbr
+}
+
+func TestGPTProtectiveMBRRoundTrip(t *testing.T) {
+	const size = 64 * 1024
+	file, mbr := newTestGPT(t, size, map[string]int32{"datos": 4096, "respaldo": 2048})
+	datos, _ := mbr.GetPartitionByName("datos")
+	copy(datos.Part_id[:], "761A")
+	if err := mbr.Encode(file); err != nil {
+		t.Fatal(err)
+	}
+
+	// El MBR protector tiene una sola partición que cubre todo el disco después de la LBA 0
+	record := &MBRRecord{}
+	if err := utils.ReadFromFile(file, 0, record); err != nil {
+		t.Fatal(err)
+	}
+	protective := record.MbrPartitions[0]
+	if protective.Part_type[0] != PartitionTypeProtective || protective.Part_start != LBASize || protective.Part_size != size-LBASize {
+		t.Fatalf("partición protectora = tipo %c, inicio %d, tamaño %d", protective.Part_type[0], protective.Part_start, protective.Part_size)
+	}
+	for _, partition := range record.MbrPartitions[1:] {
+		if partition.Part_start != -1 {
+			t.Fatalf("el MBR protector tiene otra partición en %d", partition.Part_start)
+		}
+	}
+
+	var got MBR
+	if err := got.Decode(file); err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsGPT() || got.MbrDiskGUID != mbr.MbrDiskGUID || len(got.MbrPartitions) != GPTEntryCount {
+		t.Fatalf("Decode() = GPT %v, GUID %s, %d entradas", got.IsGPT(), FormatGUID(got.MbrDiskGUID), len(got.MbrPartitions))
+	}
+	for _, name := range []string{"datos", "respaldo"} {
+		want, _ := mbr.GetPartitionByName(name)
+		partition, _ := got.GetPartitionByName(name)
+		if partition == nil || partition.Part_start != want.Part_start || partition.Part_size != want.Part_size ||
+			partition.Part_fit != want.Part_fit || partition.Part_id != want.Part_id {
+			t.Errorf("partición %s = %+v, se esperaba %+v", name, partition, want)
+		}
+	}
+	if partition, err := got.GetPartitionByID("761A"); err != nil || partition == nil {
+		t.Error("el ID de montaje no se conservó en la entrada GPT")
+	}
+}
+
+func TestGPTBackupTableAtEndOfDisk(t *testing.T) {
+	const size = 64 * 1024
+	file, mbr := newTestGPT(t, size, map[string]int32{"datos": 8192})
+
+	lastLBA := int64(size/LBASize - 1)
+	backup, entries, err := readGPT(file, lastLBA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup.MyLBA != uint64(lastLBA) || backup.AlternateLBA != 1 || backup.PartitionEntryLBA != uint64(lastLBA-gptEntryArrayLBAs) {
+		t.Fatalf("cabecera de respaldo = MyLBA %d, AlternateLBA %d, entradas en %d", backup.MyLBA, backup.AlternateLBA, backup.PartitionEntryLBA)
+	}
+	if backup.LastUsableLBA*LBASize+LBASize != uint64(mbr.UsableEnd()) {
+		t.Errorf("LastUsableLBA = %d, el espacio asignable termina en %d", backup.LastUsableLBA, mbr.UsableEnd())
+	}
+	if entries[0].PartitionTypeGUID != LinuxFilesystemGUID {
+		t.Fatal("la tabla de respaldo no tiene la partición")
+	}
+
+	// Con la cabecera primaria dañada las particiones se leen del respaldo
+	if _, err := file.WriteAt(make([]byte, LBASize), LBASize); err != nil {
+		t.Fatal(err)
+	}
+	var got MBR
+	if err := got.Decode(file); err != nil {
+		t.Fatalf("Decode() con la cabecera primaria dañada: %v", err)
+	}
+	if partition, _ := got.GetPartitionByName("datos"); partition == nil || partition.Part_size != 8192 {
+		t.Fatalf("partición leída del respaldo = %+v", partition)
+	}
+
+	// Sin ninguna de las dos cabeceras la tabla no se puede leer
+	if _, err := file.WriteAt(make([]byte, LBASize), lastLBA*LBASize); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Decode(file); err == nil {
+		t.Fatal("Decode() debería fallar sin cabeceras GPT válidas")
+	}
+}
+
+func TestGPTPartitionsAlignedToLBA(t *testing.T) {
+	const size = 64 * 1024
+	file, mbr := newTestGPT(t, size, map[string]int32{"datos": 1000, "respaldo": 513, "extra": 1})
+
+	var got MBR
+	if err := got.Decode(file); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int32{"datos": 1024, "respaldo": 1024, "extra": 512}
+	for name, wantSize := range want {
+		partition, _ := got.GetPartitionByName(name)
+		if partition == nil || partition.Part_start%LBASize != 0 || partition.Part_size != wantSize {
+			t.Errorf("partición %s = %+v, se esperaba inicio alineado y tamaño %d", name, partition, wantSize)
+		}
+		if partition != nil && partition.Part_start < GPTFirstUsableLBA*LBASize {
+			t.Errorf("partición %s inicia en %d, dentro de la tabla primaria", name, partition.Part_start)
+		}
+	}
+
+	if got := mbr.AlignPartitionSize(1); got != LBASize {
+		t.Errorf("AlignPartitionSize(1) en GPT = %d, se esperaba %d", got, LBASize)
+	}
+	mbr.MbrScheme = PartitionSchemeMBR
+	if got := mbr.AlignPartitionSize(1); got != 1 {
+		t.Errorf("AlignPartitionSize(1) en MBR = %d, se esperaba 1", got)
+	}
+}
@@ -8,36 +8,99 @@ import (
 )
 
 type Inode struct {
-	I_uid   int32     //UID del usuario propietario del archivo
-	I_gid   int32     //GID del grupo propietario del archivo
-	I_size  int32     //Tamaño del archivo en bytes
-	I_atime float32   //Último acceso al archivo
-	I_ctime float32   //Último cambio de permisos
-	I_mtime float32   //Última modificación del archivo
-	I_block [15]int32 // 12 bloques directos, 1 indirecto simple, 1 indirecto doble, 1 indirecto triple
-	I_type  [1]byte   //Indica si es archivo o carpeta 1=archivo, 0=carpeta
-	I_perm  [3]byte   //Guarda los permisos del archivo
-	// Total: 88 bytes
+	I_uid        int32     //UID del usuario propietario del archivo
+	I_gid        int32     //GID del grupo propietario del archivo
+	I_size       int32     //Tamaño del archivo en bytes
+	I_atime      int64     //Último acceso al archivo (segundos Unix)
+	I_atime_nsec int32     //Nanosegundos del último acceso
+	I_ctime      int64     //Último cambio de permisos (segundos Unix)
+	I_ctime_nsec int32     //Nanosegundos del último cambio de permisos
+	I_mtime      int64     //Última modificación del archivo (segundos Unix)
+	I_mtime_nsec int32     //Nanosegundos de la última modificación
+	I_block      [15]int32 // 12 bloques directos, 1 indirecto simple, 1 indirecto doble, 1 indirecto triple
+	I_type       [1]byte   //Indica si es archivo o carpeta 1=archivo, 0=carpeta
+	I_perm       [3]byte   //Guarda los permisos del archivo
+	I_flags      uint32    //Atributos del archivo (InodeFlagCompressed, InodeFlagIndirect)
+	I_checksum   uint32    //CRC32C del inodo (calculado con este campo en 0)
+	// Total: 120 bytes
 }
 
-func (inode *Inode) Encode(file *os.File, offset int64) error {
+// Encode escribe el inodo en la posición indicada con la disposición de la revisión del superbloque sb
+func (inode *Inode) Encode(file *os.File, offset int64, sb *Superblock) error {
+	// Las tablas de inodos de revisiones anteriores se escriben con su propia disposición
+	var data interface{}
+	switch revision := sb.S_revision; {
+	case revision == RevisionLegacy:
+		data = inode.toRev1()
+	case revision < RevisionChecksums:
+		data = inode.toRev3()
+	case revision == RevisionChecksums:
+		legacy := inode.toRev4()
+		legacy.I_checksum = legacy.computeChecksum()
+		data = legacy
+	default:
+		inode.I_checksum = inode.computeChecksum()
+		data = inode
+	}
+
 	// Utilizamos la función WriteToFile del paquete utils
-	err := utils.WriteToFile(file, offset, inode)
+	err := utils.WriteToFile(file, offset, data)
 	if err != nil {
 		return fmt.Errorf("error writing Inode to file: %w", err)
 	}
 	return nil
 }
 
-func (inode *Inode) Decode(file *os.File, offset int64) error {
+// Decode lee el inodo de la posición indicada con la disposición de la revisión del superbloque sb
+func (inode *Inode) Decode(file *os.File, offset int64, sb *Superblock) error {
+	// Lectores de compatibilidad para inodos de revisiones anteriores
+	switch revision := sb.S_revision; {
+	case revision == RevisionLegacy:
+		legacy := &InodeRev1{}
+		if err := utils.ReadFromFile(file, offset, legacy); err != nil {
+			return fmt.Errorf("error reading legacy Inode from file: %w", err)
+		}
+		*inode = legacy.toInode()
+		return nil
+	case revision < RevisionChecksums:
+		legacy := &InodeRev3{}
+		if err := utils.ReadFromFile(file, offset, legacy); err != nil {
+			return fmt.Errorf("error reading legacy Inode from file: %w", err)
+		}
+		*inode = legacy.toInode()
+		return nil
+	case revision == RevisionChecksums:
+		legacy := &InodeRev4{}
+		if err := utils.ReadFromFile(file, offset, legacy); err != nil {
+			return fmt.Errorf("error reading legacy Inode from file: %w", err)
+		}
+		*inode = legacy.toInode()
+		if computed := legacy.computeChecksum(); computed != legacy.I_checksum && !isZeroBytes(structBytes(legacy)) {
+			return &ChecksumError{Structure: "inodo", Offset: offset, Stored: legacy.I_checksum, Computed: computed}
+		}
+		return nil
+	}
+
 	// Utilizamos la función ReadFromFile del paquete utils
 	err := utils.ReadFromFile(file, offset, inode)
 	if err != nil {
 		return fmt.Errorf("error reading Inode from file: %w", err)
 	}
+
+	// Un inodo nunca escrito está en ceros y no tiene checksum
+	if computed := inode.computeChecksum(); computed != inode.I_checksum && !isZeroBytes(structBytes(inode)) {
+		return &ChecksumError{Structure: "inodo", Offset: offset, Stored: inode.I_checksum, Computed: computed}
+	}
 	return nil
 }
 
+// computeChecksum calcula el CRC32C del inodo sin tomar en cuenta el campo I_checksum
+func (inode *Inode) computeChecksum() uint32 {
+	copyInode := *inode
+	copyInode.I_checksum = 0
+	return checksumBytes(structBytes(&copyInode))
+}
+
 // Crear y serializar un inodo, actualizando el bitmap de inodos
 func (inode *Inode) CreateInode(
 	file *os.File, // Archivo del sistema de archivos
@@ -57,9 +120,7 @@ func (inode *Inode) CreateInode(
 	inode.I_uid = 1
 	inode.I_gid = 1
 	inode.I_size = size
-	inode.I_atime = float32(time.Now().Unix())
-	inode.I_ctime = float32(time.Now().Unix())
-	inode.I_mtime = float32(time.Now().Unix())
+	inode.SetTimestamps(time.Now())
 	inode.I_block = blocks
 	inode.I_type = [1]byte{inodeType}
 	inode.I_perm = permissions
@@ -72,7 +133,7 @@ func (inode *Inode) CreateInode(
 
 	// Serializar el inodo en la ubicación correcta
 	inodeOffset := int64(sb.S_inode_start + (inodeIndex * sb.S_inode_size))
-	err = inode.Encode(file, inodeOffset)
+	err = inode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error serializando el inodo en la ubicación %d: %w", inodeOffset, err)
 	}
@@ -81,29 +142,54 @@ func (inode *Inode) CreateInode(
 }
 
 func (inode *Inode) UpdateAtime() {
-	inode.I_atime = float32(time.Now().Unix())
+	now := time.Now()
+	inode.I_atime, inode.I_atime_nsec = now.Unix(), int32(now.Nanosecond())
 }
 
 func (inode *Inode) UpdateMtime() {
-	inode.I_mtime = float32(time.Now().Unix())
+	now := time.Now()
+	inode.I_mtime, inode.I_mtime_nsec = now.Unix(), int32(now.Nanosecond())
 }
 
 func (inode *Inode) UpdateCtime() {
-	inode.I_ctime = float32(time.Now().Unix())
+	now := time.Now()
+	inode.I_ctime, inode.I_ctime_nsec = now.Unix(), int32(now.Nanosecond())
+}
+
+// SetTimestamps asigna la misma fecha a atime, ctime y mtime (creación del inodo)
+func (inode *Inode) SetTimestamps(t time.Time) {
+	inode.I_atime, inode.I_atime_nsec = t.Unix(), int32(t.Nanosecond())
+	inode.I_ctime, inode.I_ctime_nsec = t.Unix(), int32(t.Nanosecond())
+	inode.I_mtime, inode.I_mtime_nsec = t.Unix(), int32(t.Nanosecond())
+}
+
+// Atime devuelve la fecha del último acceso con precisión de nanosegundos
+func (inode *Inode) Atime() time.Time {
+	return time.Unix(inode.I_atime, int64(inode.I_atime_nsec))
+}
+
+// Ctime devuelve la fecha del último cambio de permisos
+func (inode *Inode) Ctime() time.Time {
+	return time.Unix(inode.I_ctime, int64(inode.I_ctime_nsec))
+}
+
+// Mtime devuelve la fecha de la última modificación
+func (inode *Inode) Mtime() time.Time {
+	return time.Unix(inode.I_mtime, int64(inode.I_mtime_nsec))
 }
 
 // Print imprime los atributos del inodo
 func (inode *Inode) Print() {
-	atime := time.Unix(int64(inode.I_atime), 0)
-	ctime := time.Unix(int64(inode.I_ctime), 0)
-	mtime := time.Unix(int64(inode.I_mtime), 0)
+	atime := inode.Atime()
+	ctime := inode.Ctime()
+	mtime := inode.Mtime()
 
 	fmt.Printf("I_uid: %d\n", inode.I_uid)
 	fmt.Printf("I_gid: %d\n", inode.I_gid)
 	fmt.Printf("I_size: %d\n", inode.I_size)
-	fmt.Printf("I_atime: %s\n", atime.Format(time.RFC3339))
-	fmt.Printf("I_ctime: %s\n", ctime.Format(time.RFC3339))
-	fmt.Printf("I_mtime: %s\n", mtime.Format(time.RFC3339))
+	fmt.Printf("I_atime: %s\n", atime.Format(time.RFC3339Nano))
+	fmt.Printf("I_ctime: %s\n", ctime.Format(time.RFC3339Nano))
+	fmt.Printf("I_mtime: %s\n", mtime.Format(time.RFC3339Nano))
 	fmt.Printf("I_block: %v\n", inode.I_block)
 	fmt.Printf("I_type: %s\n", string(inode.I_type[:]))
 	fmt.Printf("I_perm: %s\n", string(inode.I_perm[:]))
@@ -0,0 +1,118 @@
+package structs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestDisk crea un archivo de prueba en ceros
+func newTestDisk(t *testing.T, size int64) *os.File {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "disco.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	if err := file.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestInodeRoundTripByRevision(t *testing.T) {
+	modified := time.Unix(1700000000, 0)
+	revisions := []int32{RevisionLegacy, RevisionTimestamps64, RevisionVersioned, RevisionChecksums, RevisionInodeFlags}
+	for _, revision := range revisions {
+		t.Run(RevisionName(revision), func(t *testing.T) {
+			file := newTestDisk(t, 4096)
+			sb := &Superblock{S_revision: revision}
+
+			inode := Inode{I_uid: 3, I_gid: 2, I_size: 150, I_type: [1]byte{'1'}, I_perm: [3]byte{'6', '6', '4'}, I_flags: InodeFlagCompressed}
+			for i := range inode.I_block {
+				inode.I_block[i] = int32(i) - 1
+			}
+			inode.SetTimestamps(modified)
+			if err := inode.Encode(file, 256, sb); err != nil {
+				t.Fatal(err)
+			}
+
+			var got Inode
+			if err := got.Decode(file, 256, sb); err != nil {
+				t.Fatal(err)
+			}
+			if got.I_uid != inode.I_uid || got.I_gid != inode.I_gid || got.I_size != inode.I_size ||
+				got.I_block != inode.I_block || got.I_type != inode.I_type || got.I_perm != inode.I_perm {
+				t.Errorf("Decode() = %+v, se esperaba %+v", got, inode)
+			}
+			if !got.Mtime().Equal(modified) {
+				t.Errorf("Mtime() = %v, se esperaba %v", got.Mtime(), modified)
+			}
+
+			// Los atributos por archivo solo existen desde la revisión 5
+			wantFlags := uint32(0)
+			if revision >= RevisionInodeFlags {
+				wantFlags = InodeFlagCompressed
+			}
+			if got.I_flags != wantFlags {
+				t.Errorf("I_flags = %d, se esperaba %d", got.I_flags, wantFlags)
+			}
+		})
+	}
+}
+
+func TestInodeChecksumDetectsCorruption(t *testing.T) {
+	for _, revision := range []int32{RevisionChecksums, RevisionInodeFlags} {
+		t.Run(RevisionName(revision), func(t *testing.T) {
+			file := newTestDisk(t, 4096)
+			sb := &Superblock{S_revision: revision}
+			inode := Inode{I_uid: 1, I_size: 10, I_type: [1]byte{'1'}}
+			if err := inode.Encode(file, 0, sb); err != nil {
+				t.Fatal(err)
+			}
+
+			// Alterar el tamaño guardado en el disco
+			if _, err := file.WriteAt([]byte{99}, 8); err != nil {
+				t.Fatal(err)
+			}
+			var checksumErr *ChecksumError
+			if err := inode.Decode(file, 0, sb); !errors.As(err, &checksumErr) {
+				t.Errorf("Decode() = %v, se esperaba un ChecksumError", err)
+			}
+		})
+	}
+}
+
+func TestFolderBlockChecksum(t *testing.T) {
+	file := newTestDisk(t, 4096)
+	sb := &Superblock{S_revision: FormatRevision, S_block_start: 1024, S_block_size: 64, S_csum_start: 512}
+	block := NewFolderBlock(0, 0, map[string]int32{"users.txt": 1})
+	offset := int64(sb.S_block_start + 2*sb.S_block_size)
+	if err := block.Encode(file, offset, sb); err != nil {
+		t.Fatal(err)
+	}
+
+	var got FolderBlock
+	if err := got.Decode(file, offset, sb); err != nil {
+		t.Fatalf("Decode() de un bloque intacto: %v", err)
+	}
+	if got != *block {
+		t.Errorf("Decode() = %+v, se esperaba %+v", got, *block)
+	}
+
+	// El checksum del tercer bloque está en la tercera posición de la tabla
+	if _, err := file.WriteAt([]byte{'X'}, offset+20); err != nil {
+		t.Fatal(err)
+	}
+	var checksumErr *ChecksumError
+	if err := got.Decode(file, offset, sb); !errors.As(err, &checksumErr) {
+		t.Errorf("Decode() de un bloque alterado = %v, se esperaba un ChecksumError", err)
+	}
+
+	// Un superbloque sin tabla de checksums (revisión anterior) no verifica el bloque
+	if err := got.Decode(file, offset, &Superblock{S_revision: RevisionVersioned}); err != nil {
+		t.Errorf("Decode() con un superbloque de la revisión 3: %v", err)
+	}
+}
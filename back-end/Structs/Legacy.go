@@ -0,0 +1,262 @@
+package structs
+
+import (
+	"encoding/binary"
+)
+
+// Disposiciones anteriores de las estructuras en disco.
+// Se mantienen solo para poder leer, escribir y migrar las imágenes .mia ya existentes.
+
+// InodeRev1 es el inodo de la revisión 1, con fechas float32 (88 bytes)
+type InodeRev1 struct {
+	I_uid   int32
+	I_gid   int32
+	I_size  int32
+	I_atime float32
+	I_ctime float32
+	I_mtime float32
+	I_block [15]int32
+	I_type  [1]byte
+	I_perm  [3]byte
+}
+
+// InodeRev3 es el inodo de las revisiones 2 y 3, con fechas de 64 bits pero sin checksum (112 bytes)
+type InodeRev3 struct {
+	I_uid        int32
+	I_gid        int32
+	I_size       int32
+	I_atime      int64
+	I_atime_nsec int32
+	I_ctime      int64
+	I_ctime_nsec int32
+	I_mtime      int64
+	I_mtime_nsec int32
+	I_block      [15]int32
+	I_type       [1]byte
+	I_perm       [3]byte
+}
+
+// InodeRev4 es el inodo de la revisión 4, con checksum pero sin atributos (116 bytes)
+type InodeRev4 struct {
+	InodeRev3
+	I_checksum uint32
+}
+
+// MBRRev1 es el MBR de la revisión 1, con la fecha de creación en float32 (153 bytes)
+type MBRRev1 struct {
+	MbrSize          int32
+	MbrCreacionDate  float32
+	MbrDiskSignature int32
+	MbrDiskFit       [1]byte
+	MbrPartitions    [4]Partition
+}
+
+// MBRRev2 es el MBR de la revisión 2, con fechas de 64 bits pero sin firma ni revisión (161 bytes)
+type MBRRev2 struct {
+	MbrSize          int32
+	MbrCreacionDate  int64
+	MbrCreacionNsec  int32
+	MbrDiskSignature int32
+	MbrDiskFit       [1]byte
+	MbrPartitions    [4]Partition
+}
+
+// SuperblockRev2 es el superbloque de las revisiones 1 y 2, sin firma ni revisión (68 bytes)
+type SuperblockRev2 struct {
+	S_filesystem_type   int32
+	S_inodes_count      int32
+	S_blocks_count      int32
+	S_free_blocks_count int32
+	S_free_inodes_count int32
+	S_mtime             float64
+	S_umtime            float64
+	S_mnt_count         int32
+	S_magic             int32
+	S_inode_size        int32
+	S_block_size        int32
+	S_first_ino         int32
+	S_first_blo         int32
+	S_bm_inode_start    int32
+	S_bm_block_start    int32
+	S_inode_start       int32
+	S_block_start       int32
+}
+
+// SuperblockRev3 es el superbloque de la revisión 3, con firma y revisión pero sin checksums (76 bytes)
+type SuperblockRev3 struct {
+	SuperblockRev2
+	S_rev_magic int32
+	S_revision  int32
+}
+
+// InodeRev1Size es el valor de S_inode_size en los sistemas de archivos de la revisión 1
+var InodeRev1Size = int32(binary.Size(InodeRev1{}))
+
+// toInode convierte un inodo de la revisión 1 a la disposición actual
+func (legacy *InodeRev1) toInode() Inode {
+	return Inode{
+		I_uid:   legacy.I_uid,
+		I_gid:   legacy.I_gid,
+		I_size:  legacy.I_size,
+		I_atime: int64(legacy.I_atime),
+		I_ctime: int64(legacy.I_ctime),
+		I_mtime: int64(legacy.I_mtime),
+		I_block: legacy.I_block,
+		I_type:  legacy.I_type,
+		I_perm:  legacy.I_perm,
+	}
+}
+
+// toRev1 convierte un inodo actual a la revisión 1 (se pierden los nanosegundos)
+func (inode *Inode) toRev1() *InodeRev1 {
+	return &InodeRev1{
+		I_uid:   inode.I_uid,
+		I_gid:   inode.I_gid,
+		I_size:  inode.I_size,
+		I_atime: float32(inode.I_atime),
+		I_ctime: float32(inode.I_ctime),
+		I_mtime: float32(inode.I_mtime),
+		I_block: inode.I_block,
+		I_type:  inode.I_type,
+		I_perm:  inode.I_perm,
+	}
+}
+
+// toInode convierte un inodo de las revisiones 2 y 3 a la disposición actual
+func (legacy *InodeRev3) toInode() Inode {
+	return Inode{
+		I_uid:        legacy.I_uid,
+		I_gid:        legacy.I_gid,
+		I_size:       legacy.I_size,
+		I_atime:      legacy.I_atime,
+		I_atime_nsec: legacy.I_atime_nsec,
+		I_ctime:      legacy.I_ctime,
+		I_ctime_nsec: legacy.I_ctime_nsec,
+		I_mtime:      legacy.I_mtime,
+		I_mtime_nsec: legacy.I_mtime_nsec,
+		I_block:      legacy.I_block,
+		I_type:       legacy.I_type,
+		I_perm:       legacy.I_perm,
+	}
+}
+
+// toRev3 convierte un inodo actual a la disposición sin checksum
+func (inode *Inode) toRev3() *InodeRev3 {
+	return &InodeRev3{
+		I_uid:        inode.I_uid,
+		I_gid:        inode.I_gid,
+		I_size:       inode.I_size,
+		I_atime:      inode.I_atime,
+		I_atime_nsec: inode.I_atime_nsec,
+		I_ctime:      inode.I_ctime,
+		I_ctime_nsec: inode.I_ctime_nsec,
+		I_mtime:      inode.I_mtime,
+		I_mtime_nsec: inode.I_mtime_nsec,
+		I_block:      inode.I_block,
+		I_type:       inode.I_type,
+		I_perm:       inode.I_perm,
+	}
+}
+
+// toInode convierte un inodo de la revisión 4 a la disposición actual
+func (legacy *InodeRev4) toInode() Inode {
+	inode := legacy.InodeRev3.toInode()
+	inode.I_checksum = legacy.I_checksum
+	return inode
+}
+
+// toRev4 convierte un inodo actual a la disposición sin atributos (se pierden los flags)
+func (inode *Inode) toRev4() *InodeRev4 {
+	return &InodeRev4{InodeRev3: *inode.toRev3()}
+}
+
+// computeChecksum calcula el CRC32C de un inodo de la revisión 4 sin tomar en cuenta I_checksum
+func (legacy *InodeRev4) computeChecksum() uint32 {
+	copyInode := *legacy
+	copyInode.I_checksum = 0
+	return checksumBytes(structBytes(&copyInode))
+}
+
+// toMBR convierte un MBR de la revisión 1 a la disposición actual
+func (legacy *MBRRev1) toMBR() MBR {
+	partitions := legacy.MbrPartitions
+	return MBR{
+		MbrSize:          legacy.MbrSize,
+		MbrCreacionDate:  int64(legacy.MbrCreacionDate),
+		MbrDiskSignature: legacy.MbrDiskSignature,
+		MbrDiskFit:       legacy.MbrDiskFit,
+		MbrPartitions:    partitions[:],
+		MbrRevision:      RevisionLegacy,
+		MbrScheme:        PartitionSchemeMBR,
+	}
+}
+
+// toMBR convierte un MBR de la revisión 2 a la disposición actual
+func (legacy *MBRRev2) toMBR() MBR {
+	partitions := legacy.MbrPartitions
+	return MBR{
+		MbrSize:          legacy.MbrSize,
+		MbrCreacionDate:  legacy.MbrCreacionDate,
+		MbrCreacionNsec:  legacy.MbrCreacionNsec,
+		MbrDiskSignature: legacy.MbrDiskSignature,
+		MbrDiskFit:       legacy.MbrDiskFit,
+		MbrPartitions:    partitions[:],
+		MbrRevision:      RevisionTimestamps64,
+		MbrScheme:        PartitionSchemeMBR,
+	}
+}
+
+// toRev1 convierte un MBR actual a la revisión 1
+func (mbr *MBR) toRev1() *MBRRev1 {
+	return &MBRRev1{
+		MbrSize:          mbr.MbrSize,
+		MbrCreacionDate:  float32(mbr.MbrCreacionDate),
+		MbrDiskSignature: mbr.MbrDiskSignature,
+		MbrDiskFit:       mbr.MbrDiskFit,
+		MbrPartitions:    mbr.primaryPartitions(),
+	}
+}
+
+// toRev2 convierte un MBR actual a la revisión 2
+func (mbr *MBR) toRev2() *MBRRev2 {
+	return &MBRRev2{
+		MbrSize:          mbr.MbrSize,
+		MbrCreacionDate:  mbr.MbrCreacionDate,
+		MbrCreacionNsec:  mbr.MbrCreacionNsec,
+		MbrDiskSignature: mbr.MbrDiskSignature,
+		MbrDiskFit:       mbr.MbrDiskFit,
+		MbrPartitions:    mbr.primaryPartitions(),
+	}
+}
+
+// toRev2 convierte un superbloque actual a la disposición sin revisión
+func (sb *Superblock) toRev2() *SuperblockRev2 {
+	return &SuperblockRev2{
+		S_filesystem_type:   sb.S_filesystem_type,
+		S_inodes_count:      sb.S_inodes_count,
+		S_blocks_count:      sb.S_blocks_count,
+		S_free_blocks_count: sb.S_free_blocks_count,
+		S_free_inodes_count: sb.S_free_inodes_count,
+		S_mtime:             sb.S_mtime,
+		S_umtime:            sb.S_umtime,
+		S_mnt_count:         sb.S_mnt_count,
+		S_magic:             sb.S_magic,
+		S_inode_size:        sb.S_inode_size,
+		S_block_size:        sb.S_block_size,
+		S_first_ino:         sb.S_first_ino,
+		S_first_blo:         sb.S_first_blo,
+		S_bm_inode_start:    sb.S_bm_inode_start,
+		S_bm_block_start:    sb.S_bm_block_start,
+		S_inode_start:       sb.S_inode_start,
+		S_block_start:       sb.S_block_start,
+	}
+}
+
+// toRev3 convierte un superbloque actual a la disposición sin checksums
+func (sb *Superblock) toRev3() *SuperblockRev3 {
+	return &SuperblockRev3{
+		SuperblockRev2: *sb.toRev2(),
+		S_rev_magic:    sb.S_rev_magic,
+		S_revision:     sb.S_revision,
+	}
+}
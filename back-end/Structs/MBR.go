@@ -11,29 +11,176 @@ import (
 	utilidades "backend/utils" // Importa el paquete utils
 )
 
-// Estructura que representa un MBR
+// Estructura que representa la tabla de particiones del disco.
+// En los discos GPT el MBR es protector y las particiones se leen de la tabla GPT.
 type MBR struct {
-	MbrSize          int32        // Tamaño del MBR
-	MbrCreacionDate  float32      // Fecha de creación del MBR
-	MbrDiskSignature int32        // Número de serie del disco (random)
-	MbrDiskFit       [1]byte      // BF = Best Fit, FF = First Fit, WF = Worst Fit
-	MbrPartitions    [4]Partition // Particiones del MBR (4 particiones)
+	MbrSize          int32       // Tamaño del MBR
+	MbrCreacionDate  int64       // Fecha de creación del MBR (segundos Unix)
+	MbrCreacionNsec  int32       // Nanosegundos de la fecha de creación
+	MbrDiskSignature int32       // Número de serie del disco (random)
+	MbrDiskFit       [1]byte     // BF = Best Fit, FF = First Fit, WF = Worst Fit
+	MbrPartitions    []Partition // Particiones del disco (4 en MBR, GPTEntryCount en GPT)
+	MbrSignature     [4]byte     // Firma del formato ("MIAD")
+	MbrRevision      int32       // Revisión del formato en disco
+	MbrScheme        string      // Esquema de particionado: PartitionSchemeMBR o PartitionSchemeGPT
+	MbrDiskGUID      [16]byte    // GUID del disco (solo GPT)
+}
+
+// MBRRecord es la disposición en disco del MBR de la revisión actual (169 bytes)
+type MBRRecord struct {
+	MbrSize          int32
+	MbrCreacionDate  int64
+	MbrCreacionNsec  int32
+	MbrDiskSignature int32
+	MbrDiskFit       [1]byte
+	MbrPartitions    [4]Partition
+	MbrSignature     [4]byte
+	MbrRevision      int32
 }
 
 // Encode serializa la estructura MBR en un archivo
 func (mbr *MBR) Encode(file *os.File) error {
-	return utilidades.WriteToFile(file, 0, mbr) // Escribe el MBR en el inicio del archivo
+	// Un disco antiguo conserva su disposición para no pisar el inicio de la primera partición
+	switch mbr.MbrRevision {
+	case RevisionLegacy:
+		return utilidades.WriteToFile(file, 0, mbr.toRev1())
+	case RevisionTimestamps64:
+		return utilidades.WriteToFile(file, 0, mbr.toRev2())
+	}
+
+	mbr.MbrSignature = MBRSignature
+	if mbr.MbrRevision == 0 {
+		mbr.MbrRevision = FormatRevision
+	}
+
+	record := mbr.toRecord()
+	if mbr.IsGPT() {
+		// Un MBR protector con una sola partición que cubre todo el disco
+		record.MbrPartitions = protectivePartitions(mbr.MbrSize)
+		if err := mbr.encodeGPT(file); err != nil {
+			return err
+		}
+	}
+	return utilidades.WriteToFile(file, 0, record) // Escribe el MBR en el inicio del archivo
 }
 
 // Decode deserializa la estructura MBR desde un archivo
 func (mbr *MBR) Decode(file *os.File) error {
-	return utilidades.ReadFromFile(file, 0, mbr) // Lee el MBR desde el inicio del archivo
+	// Lectores de compatibilidad para discos de revisiones anteriores
+	switch DetectMBRRevision(file) {
+	case RevisionLegacy:
+		legacy := &MBRRev1{}
+		if err := utilidades.ReadFromFile(file, 0, legacy); err != nil {
+			return err
+		}
+		*mbr = legacy.toMBR()
+		return nil
+	case RevisionTimestamps64:
+		legacy := &MBRRev2{}
+		if err := utilidades.ReadFromFile(file, 0, legacy); err != nil {
+			return err
+		}
+		*mbr = legacy.toMBR()
+		return nil
+	}
+
+	record := &MBRRecord{}
+	if err := utilidades.ReadFromFile(file, 0, record); err != nil { // Lee el MBR desde el inicio del archivo
+		return err
+	}
+	*mbr = record.toMBR()
+
+	// Un MBR protector indica que las particiones están en la tabla GPT
+	if record.MbrPartitions[0].Part_type[0] == PartitionTypeProtective {
+		return mbr.decodeGPT(file)
+	}
+	return nil
+}
+
+// HeaderSize devuelve el tamaño que ocupa el MBR (y la tabla GPT primaria) en disco según su revisión
+func (mbr *MBR) HeaderSize() int {
+	if mbr.IsGPT() {
+		return GPTFirstUsableLBA * LBASize
+	}
+
+	switch mbr.MbrRevision {
+	case RevisionLegacy:
+		return binary.Size(MBRRev1{})
+	case RevisionTimestamps64:
+		return binary.Size(MBRRev2{})
+	}
+	return binary.Size(MBRRecord{})
+}
+
+// UsableEnd devuelve el byte donde termina el espacio asignable del disco
+func (mbr *MBR) UsableEnd() int32 {
+	if mbr.IsGPT() {
+		// La tabla GPT de respaldo ocupa las últimas 33 LBAs del disco
+		return mbr.MbrSize - gptBackupSize
+	}
+	return mbr.MbrSize
+}
+
+// AlignPartitionSize redondea el tamaño de una partición a LBAs completas en los discos GPT,
+// donde la tabla guarda las particiones por LBA
+func (mbr *MBR) AlignPartitionSize(size int32) int32 {
+	if mbr.IsGPT() {
+		return alignToLBA(size)
+	}
+	return size
+}
+
+// IsGPT indica si el disco usa una tabla de particiones GPT
+func (mbr *MBR) IsGPT() bool {
+	return mbr.MbrScheme == PartitionSchemeGPT
+}
+
+// toRecord convierte el MBR a su disposición en disco (solo las primeras 4 particiones)
+func (mbr *MBR) toRecord() *MBRRecord {
+	return &MBRRecord{
+		MbrSize:          mbr.MbrSize,
+		MbrCreacionDate:  mbr.MbrCreacionDate,
+		MbrCreacionNsec:  mbr.MbrCreacionNsec,
+		MbrDiskSignature: mbr.MbrDiskSignature,
+		MbrDiskFit:       mbr.MbrDiskFit,
+		MbrPartitions:    mbr.primaryPartitions(),
+		MbrSignature:     mbr.MbrSignature,
+		MbrRevision:      mbr.MbrRevision,
+	}
+}
+
+// toMBR convierte el MBR leído del disco a la estructura en memoria
+func (record *MBRRecord) toMBR() MBR {
+	partitions := record.MbrPartitions
+	return MBR{
+		MbrSize:          record.MbrSize,
+		MbrCreacionDate:  record.MbrCreacionDate,
+		MbrCreacionNsec:  record.MbrCreacionNsec,
+		MbrDiskSignature: record.MbrDiskSignature,
+		MbrDiskFit:       record.MbrDiskFit,
+		MbrPartitions:    partitions[:],
+		MbrSignature:     record.MbrSignature,
+		MbrRevision:      record.MbrRevision,
+		MbrScheme:        PartitionSchemeMBR,
+	}
+}
+
+// primaryPartitions devuelve las 4 entradas de la tabla de particiones del MBR
+func (mbr *MBR) primaryPartitions() [4]Partition {
+	var partitions [4]Partition
+	copy(partitions[:], mbr.MbrPartitions)
+	return partitions
+}
+
+// CreationTime devuelve la fecha de creación del disco con precisión de nanosegundos
+func (mbr *MBR) CreationTime() time.Time {
+	return time.Unix(mbr.MbrCreacionDate, int64(mbr.MbrCreacionNsec))
 }
 
 // Método para obtener la primera partición disponible
 func (mbr *MBR) GetFirstAvailablePartition() (*Partition, int, int) {
 	// Calcular el offset para el start de la partición
-	offset := binary.Size(mbr) // Tamaño del MBR en bytes
+	offset := mbr.HeaderSize() // Tamaño del MBR en bytes
 
 	// Recorrer las particiones del MBR
 	for i := 0; i < len(mbr.MbrPartitions); i++ {
@@ -49,12 +196,12 @@ func (mbr *MBR) GetFirstAvailablePartition() (*Partition, int, int) {
 
 // Método para obtener una partición por nombre
 func (mbr *MBR) GetPartitionByName(name string) (*Partition, int) {
-	for i, partition := range mbr.MbrPartitions {
-		partitionName := strings.Trim(string(partition.Part_name[:]), "\x00 ")
+	for i := range mbr.MbrPartitions {
+		partitionName := strings.Trim(string(mbr.MbrPartitions[i].Part_name[:]), "\x00 ")
 		inputName := strings.Trim(name, "\x00 ")
 		// Si el nombre de la partición coincide, devolver la partición y el índice
 		if strings.EqualFold(partitionName, inputName) {
-			return &partition, i
+			return &mbr.MbrPartitions[i], i
 		}
 	}
 	return nil, -1
@@ -87,11 +234,12 @@ func (mbr *MBR) HasExtendedPartition() bool {
 // CalculateAvailableSpace calcula el espacio disponible en el disco.
 func (mbr *MBR) CalculateAvailableSpace() (int32, error) {
 	totalSize := mbr.MbrSize
-	usedSpace := int32(binary.Size(MBR{})) // Tamaño del MBR
+	usedSpace := int32(mbr.HeaderSize())       // Tamaño del MBR
+	usedSpace += mbr.MbrSize - mbr.UsableEnd() // Tabla GPT de respaldo
 
 	partitions := mbr.MbrPartitions[:] // Obtener todas las particiones
 	for _, part := range partitions {
-		if part.Part_size != 0 { // Si la partición está ocupada
+		if part.Part_size > 0 { // Si la partición está ocupada
 			usedSpace += part.Part_size
 		}
 	}
@@ -122,10 +270,10 @@ func (mbr *MBR) ListPartitions() []map[string]interface{} {
 
 // Método para imprimir los valores del MBR
 func (mbr *MBR) Print() {
-	creationTime := time.Unix(int64(mbr.MbrCreacionDate), 0)
+	creationTime := mbr.CreationTime()
 	diskFit := rune(mbr.MbrDiskFit[0])
 	fmt.Printf("MBR Size: %d | Creation Date: %s | Disk Signature: %d | Disk Fit: %c\n",
-		mbr.MbrSize, creationTime.Format(time.RFC3339), mbr.MbrDiskSignature, diskFit)
+		mbr.MbrSize, creationTime.Format(time.RFC3339Nano), mbr.MbrDiskSignature, diskFit)
 }
 
 // Método para imprimir las particiones del MBR
@@ -154,16 +302,23 @@ func (mbr *MBR) ApplyFit(partitionSize int32) (*Partition, error) {
 		return nil, fmt.Errorf("no hay suficiente espacio en el disco")
 	}
 
+	var partition *Partition
 	switch rune(mbr.MbrDiskFit[0]) {
 	case 'F': // First Fit
-		return mbr.ApplyFirstFit(partitionSize)
+		partition, err = mbr.ApplyFirstFit(partitionSize)
 	case 'B': // Best Fit
-		return mbr.ApplyBestFit(partitionSize)
+		partition, err = mbr.ApplyBestFit(partitionSize)
 	case 'W': // Worst Fit
-		return mbr.ApplyWorstFit(partitionSize)
+		partition, err = mbr.ApplyWorstFit(partitionSize)
 	default:
 		return nil, fmt.Errorf("tipo de ajuste inválido")
 	}
+
+	// Hay espacio suficiente, pero repartido en varios huecos
+	if partition == nil && err != nil {
+		return nil, fmt.Errorf("%v: el espacio libre está fragmentado, ejecute compactdisk para juntarlo", err)
+	}
+	return partition, err
 }
 
 // CalculateAvailableSpaceForPartition calcula el espacio disponible a partir del final de la partición actual
@@ -181,7 +336,7 @@ func (mbr *MBR) CalculateAvailableSpaceForPartition(partition *Partition) (int32
 
 	// Si no hay una partición siguiente, considerar el final del disco
 	if nextPartitionStart == -1 {
-		nextPartitionStart = mbr.MbrSize
+		nextPartitionStart = mbr.UsableEnd()
 	}
 
 	availableSpace := nextPartitionStart - endOfPartition
@@ -192,27 +347,52 @@ func (mbr *MBR) CalculateAvailableSpaceForPartition(partition *Partition) (int32
 	return availableSpace, nil
 }
 
-// First Fit: Encuentra el primer espacio disponible que sea mayor o igual al tamaño de la partición
-func (mbr *MBR) ApplyFirstFit(partitionSize int32) (*Partition, error) {
-	fmt.Println("Iniciando First Fit...")
-
-	offset := binary.Size(mbr)
-	for i := 0; i < len(mbr.MbrPartitions); i++ {
-		partition := &mbr.MbrPartitions[i]
-
-		fmt.Printf("Evaluando partición %d: Inicio %d, Tamaño %d, Estado %c\n", i, partition.Part_start, partition.Part_size, partition.Part_status[0])
+// FreeGap representa un hueco libre entre las particiones del disco
+type FreeGap struct {
+	Start int32 // Byte donde inicia el hueco
+	Size  int32 // Tamaño del hueco en bytes
+}
 
-		// Verificar si la partición está disponible y si el tamaño es suficiente
-		if partition.Part_start == -1 && (partition.Part_size == -1 || partition.Part_size >= partitionSize) {
-			fmt.Printf("Partición %d es adecuada para First Fit: Inicio en %d, Tamaño %d\n", i, offset, partitionSize)
+// FreeGaps devuelve los huecos libres del área asignable del disco, ordenados por posición
+func (mbr *MBR) FreeGaps() []FreeGap {
+	var gaps []FreeGap
+	offset := int32(mbr.HeaderSize())
+	for _, index := range mbr.UsedPartitions() {
+		partition := mbr.MbrPartitions[index]
+		if partition.Part_start > offset {
+			gaps = append(gaps, FreeGap{Start: offset, Size: partition.Part_start - offset})
+		}
+		offset = max(offset, partition.Part_start+partition.Part_size)
+	}
+	if end := mbr.UsableEnd(); end > offset {
+		gaps = append(gaps, FreeGap{Start: offset, Size: end - offset})
+	}
+	return gaps
+}
 
-			// Asignar el inicio y el tamaño de la partición
-			partition.Part_start = int32(offset)
+// assignGap ocupa la primera entrada libre de la tabla con una partición ubicada en el hueco
+func (mbr *MBR) assignGap(gap FreeGap, partitionSize int32) (*Partition, error) {
+	for i := range mbr.MbrPartitions {
+		partition := &mbr.MbrPartitions[i]
+		if partition.Part_start == -1 {
+			fmt.Printf("Entrada %d asignada: Inicio en %d, Tamaño %d\n", i+1, gap.Start, partitionSize)
+			partition.Part_start = gap.Start
 			partition.Part_size = partitionSize
 			return partition, nil
 		}
-		// Actualizar el offset con el tamaño de la partición actual
-		offset += int(partition.Part_size)
+	}
+	return nil, fmt.Errorf("no hay entradas libres en la tabla de particiones")
+}
+
+// First Fit: Encuentra el primer espacio disponible que sea mayor o igual al tamaño de la partición
+func (mbr *MBR) ApplyFirstFit(partitionSize int32) (*Partition, error) {
+	fmt.Println("Iniciando First Fit...")
+
+	for _, gap := range mbr.FreeGaps() {
+		fmt.Printf("Evaluando hueco: Inicio %d, Tamaño %d\n", gap.Start, gap.Size)
+		if gap.Size >= partitionSize {
+			return mbr.assignGap(gap, partitionSize)
+		}
 	}
 
 	fmt.Println("No se encontró espacio suficiente con First Fit.")
@@ -223,74 +403,38 @@ func (mbr *MBR) ApplyFirstFit(partitionSize int32) (*Partition, error) {
 func (mbr *MBR) ApplyBestFit(partitionSize int32) (*Partition, error) {
 	fmt.Println("Iniciando Best Fit...")
 
-	bestFit := int32(-1)
-	bestPartition := -1
-	offset := binary.Size(mbr)
-
-	for i := 0; i < len(mbr.MbrPartitions); i++ {
-		partition := &mbr.MbrPartitions[i]
-
-		fmt.Printf("Evaluando partición %d: Inicio %d, Tamaño %d, Estado %c\n", i, partition.Part_start, partition.Part_size, partition.Part_status[0])
-
-		if partition.Part_start == -1 && (partition.Part_size == -1 || partition.Part_size >= partitionSize) {
-			fmt.Printf("Partición %d es candidata para Best Fit: Tamaño actual %d\n", i, partition.Part_size)
-
-			if bestFit == -1 || partition.Part_size < bestFit {
-				bestFit = partition.Part_size
-				bestPartition = i
-				fmt.Printf("Partición %d es la mejor opción actual para Best Fit con tamaño %d\n", i, bestFit)
-			}
+	best := FreeGap{Size: -1}
+	for _, gap := range mbr.FreeGaps() {
+		fmt.Printf("Evaluando hueco: Inicio %d, Tamaño %d\n", gap.Start, gap.Size)
+		if gap.Size >= partitionSize && (best.Size == -1 || gap.Size < best.Size) {
+			best = gap
 		}
-		offset += int(partition.Part_size)
 	}
 
-	if bestPartition == -1 {
+	if best.Size == -1 {
 		fmt.Println("No se encontró espacio suficiente con Best Fit.")
 		return nil, fmt.Errorf("no se encontró espacio suficiente con Best Fit")
 	}
-
-	partition := &mbr.MbrPartitions[bestPartition]
-	partition.Part_start = int32(offset)
-	partition.Part_size = partitionSize
-	fmt.Printf("Partición %d seleccionada para Best Fit: Inicio en %d, Tamaño %d\n", bestPartition, offset, partitionSize)
-	return partition, nil
+	return mbr.assignGap(best, partitionSize)
 }
 
 // Worst Fit: Encuentra el espacio disponible más grande que sea mayor o igual al tamaño de la partición
 func (mbr *MBR) ApplyWorstFit(partitionSize int32) (*Partition, error) {
 	fmt.Println("Iniciando Worst Fit...")
 
-	worstFit := int32(-1)
-	worstPartition := -1
-	offset := binary.Size(mbr)
-
-	for i := 0; i < len(mbr.MbrPartitions); i++ {
-		partition := &mbr.MbrPartitions[i]
-
-		fmt.Printf("Evaluando partición %d: Inicio %d, Tamaño %d, Estado %c\n", i, partition.Part_start, partition.Part_size, partition.Part_status[0])
-
-		if partition.Part_start == -1 && (partition.Part_size == -1 || partition.Part_size >= partitionSize) {
-			fmt.Printf("Partición %d es candidata para Worst Fit: Tamaño actual %d\n", i, partition.Part_size)
-
-			if worstFit == -1 || partition.Part_size > worstFit {
-				worstFit = partition.Part_size
-				worstPartition = i
-				fmt.Printf("Partición %d es la peor opción actual para Worst Fit con tamaño %d\n", i, worstFit)
-			}
+	worst := FreeGap{Size: -1}
+	for _, gap := range mbr.FreeGaps() {
+		fmt.Printf("Evaluando hueco: Inicio %d, Tamaño %d\n", gap.Start, gap.Size)
+		if gap.Size >= partitionSize && gap.Size > worst.Size {
+			worst = gap
 		}
-		offset += int(partition.Part_size)
 	}
 
-	if worstPartition == -1 {
+	if worst.Size == -1 {
 		fmt.Println("No se encontró espacio suficiente con Worst Fit.")
 		return nil, fmt.Errorf("no se encontró espacio suficiente con Worst Fit")
 	}
-
-	partition := &mbr.MbrPartitions[worstPartition]
-	partition.Part_start = int32(offset)
-	partition.Part_size = partitionSize
-	fmt.Printf("Partición %d seleccionada para Worst Fit: Inicio en %d, Tamaño %d\n", worstPartition, offset, partitionSize)
-	return partition, nil
+	return mbr.assignGap(worst, partitionSize)
 }
 
 // Unificar la creación de la partición y el ajuste (fit)
@@ -304,6 +448,8 @@ func (mbr *MBR) CreatePartitionWithFit(partSize int32, partType, partName string
 		return fmt.Errorf("no hay suficiente espacio en el disco para la nueva partición")
 	}
 
+	partSize = mbr.AlignPartitionSize(partSize)
+
 	// Aplicar el ajuste (fit) almacenado en el MBR
 	partition, err := mbr.ApplyFit(partSize)
 	if err != nil {
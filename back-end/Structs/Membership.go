@@ -0,0 +1,22 @@
+package structs
+
+import "fmt"
+
+// Membership define la pertenencia de un usuario a un grupo suplementario.
+// En users.txt se guarda como "gid,M,grupo,usuario".
+type Membership struct {
+	GID   string // Identificador del grupo
+	Tipo  string // Tipo de entidad, en este caso "M" para membresías
+	Group string // Nombre del grupo suplementario
+	User  string // Nombre del usuario
+}
+
+// NewMembership crea una nueva membresía
+func NewMembership(gid, group, user string) *Membership {
+	return &Membership{gid, "M", group, user}
+}
+
+// ToString devuelve una representación en cadena de la membresía
+func (m *Membership) ToString() string {
+	return fmt.Sprintf("%s,%s,%s,%s", m.GID, m.Tipo, m.Group, m.User)
+}
@@ -1,6 +1,7 @@
 package structs
 
 import (
+	"backend/utils"
 	"fmt"
 	"os"
 	"strings"
@@ -56,12 +57,8 @@ func (p *Partition) Delete(deleteType string, file *os.File, isExtended bool) er
 		}
 	}
 
-	// Marcar la partición como vacía (eliminarla de la tabla de particiones)
-	p.Part_start = -1
-	p.Part_size = -1
-	p.Part_name = [16]byte{}
-
-	// Si el tipo de eliminación es 'full', sobrescribir el espacio
+	// Si el tipo de eliminación es 'full', sobrescribir el espacio antes de perder su posición
+	name := strings.TrimRight(string(p.Part_name[:]), "\x00")
 	if deleteType == "full" {
 		err := p.Overwrite(file)
 		if err != nil {
@@ -69,23 +66,27 @@ func (p *Partition) Delete(deleteType string, file *os.File, isExtended bool) er
 		}
 	}
 
-	fmt.Printf("La partición '%s' ha sido eliminada (%s).\n", strings.TrimSpace(string(p.Part_name[:])), deleteType)
+	// Marcar la partición como vacía (eliminarla de la tabla de particiones)
+	p.Part_status = [1]byte{'9'}
+	p.Part_type = [1]byte{'0'}
+	p.Part_fit = [1]byte{'0'}
+	p.Part_start = -1
+	p.Part_size = -1
+	p.Part_name = [16]byte{}
+	p.Part_correlative = -1
+	p.Part_id = [4]byte{}
+
+	fmt.Printf("La partición '%s' ha sido eliminada (%s).\n", name, deleteType)
 	return nil
 }
 
 // Método que sobrescribe el espacio de la partición con \0 (para eliminación Full)
 func (p *Partition) Overwrite(file *os.File) error {
-	// Mover el puntero del archivo al inicio de la partición
-	_, err := file.Seek(int64(p.Part_start), 0)
-	if err != nil {
-		return err
-	}
-
 	// Crear un buffer de ceros del tamaño de la partición
 	zeroes := make([]byte, p.Part_size)
 
 	// Escribir los ceros en el archivo
-	_, err = file.Write(zeroes)
+	err := utils.WriteAt(file, zeroes, int64(p.Part_start))
 	if err != nil {
 		return fmt.Errorf("error al sobrescribir el espacio de la partición: %v", err)
 	}
@@ -94,36 +95,21 @@ func (p *Partition) Overwrite(file *os.File) error {
 	return nil
 }
 
-// Método para eliminar todas las particiones lógicas dentro de una partición extendida
+// Método para eliminar todas las particiones lógicas dentro de una partición extendida.
+// Los EBRs quedan dentro del espacio de la extendida, que se sobrescribe completo en una eliminación 'full'.
 func (p *Partition) deleteLogicalPartitions(file *os.File) error {
 	fmt.Println("Eliminando particiones lógicas dentro de la partición extendida...")
 
-	// Variables para recorrer los EBRs (Extended Boot Records)
-	var currentEBR EBR
-	start := p.Part_start
-
 	// Recorrer las particiones lógicas (EBRs)
-	for {
-		// Leer el EBR actual
+	for start := p.Part_start; start != -1; {
+		var currentEBR EBR
 		err := currentEBR.Decode(file, int64(start))
 		if err != nil {
 			return fmt.Errorf("error al leer el EBR: %v", err)
 		}
 
-		// Si no hay más EBRs (end of chain)
-		if currentEBR.Ebr_start == -1 {
-			break
-		}
-
-		// Eliminar la partición lógica actual marcando el EBR como vacío
-		currentEBR.Ebr_start = -1
-		currentEBR.Ebr_size = -1
-		copy(currentEBR.Ebr_name[:], "")
-
-		// Sobrescribir el espacio si es eliminación Full
-		err = currentEBR.Overwrite(file)
-		if err != nil {
-			return fmt.Errorf("error al sobrescribir el EBR: %v", err)
+		if currentEBR.Ebr_size > 0 {
+			fmt.Printf("Partición lógica '%s' eliminada.\n", strings.TrimRight(string(currentEBR.Ebr_name[:]), "\x00"))
 		}
 
 		// Mover al siguiente EBR en la cadena
@@ -137,6 +123,7 @@ func (p *Partition) deleteLogicalPartitions(file *os.File) error {
 // Método que monta una partición
 func (p *Partition) MountPartition(correlative int, id string) error {
 	p.Part_correlative = int32(correlative)
+	p.Part_id = [4]byte{}
 	copy(p.Part_id[:], id)
 	return nil
 }
@@ -1,6 +1,8 @@
 package structs
 
 import (
+	"backend/utils"
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"os"
@@ -8,7 +10,8 @@ import (
 
 // PointerBlock : Estructura para guardar los bloques de apuntadores
 type PointerBlock struct {
-	B_pointers [16]int64 // Apuntadores a bloques de carpetas o datos
+	B_pointers [16]int32 // Apuntadores a bloques de carpetas o datos
+	// Total: 64 bytes, lo mismo que un bloque
 }
 
 // FindFreePointer busca el primer apuntador libre en un bloque de apuntadores y devuelve su índice
@@ -22,7 +25,7 @@ func (pb *PointerBlock) FindFreePointer() (int, error) {
 }
 
 // SetPointer establece un valor específico en un índice dado
-func (pb *PointerBlock) SetPointer(index int, value int64) error {
+func (pb *PointerBlock) SetPointer(index int, value int32) error {
 	if index < 0 || index >= len(pb.B_pointers) {
 		return fmt.Errorf("índice fuera de rango")
 	}
@@ -31,7 +34,7 @@ func (pb *PointerBlock) SetPointer(index int, value int64) error {
 }
 
 // GetPointer obtiene el valor de un apuntador en un índice dado
-func (pb *PointerBlock) GetPointer(index int) (int64, error) {
+func (pb *PointerBlock) GetPointer(index int) (int32, error) {
 	if index < 0 || index >= len(pb.B_pointers) {
 		return -1, fmt.Errorf("índice fuera de rango")
 	}
@@ -61,14 +64,15 @@ func (pb *PointerBlock) CountFreePointers() int {
 
 // Encode serializa el PointerBlock en el archivo en la posición dada
 func (pb *PointerBlock) Encode(file *os.File, offset int64) error {
-	// Mover el cursor del archivo a la posición deseada
-	_, err := file.Seek(offset, 0)
+	// Serializar la estructura PointerBlock
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, pb)
 	if err != nil {
-		return fmt.Errorf("error buscando la posición en el archivo: %w", err)
+		return fmt.Errorf("error escribiendo el PointerBlock: %w", err)
 	}
 
-	// Escribir la estructura PointerBlock en el archivo
-	err = binary.Write(file, binary.BigEndian, pb)
+	// Escribir el PointerBlock en el archivo
+	err = utils.WriteAt(file, buffer.Bytes(), offset)
 	if err != nil {
 		return fmt.Errorf("error escribiendo el PointerBlock: %w", err)
 	}
@@ -77,16 +81,269 @@ func (pb *PointerBlock) Encode(file *os.File, offset int64) error {
 
 // Decode deserializa el PointerBlock desde el archivo en la posición dada
 func (pb *PointerBlock) Decode(file *os.File, offset int64) error {
-	// Mover el cursor del archivo a la posición deseada
-	_, err := file.Seek(offset, 0)
+	// Leer los bytes del PointerBlock desde el archivo
+	buffer := make([]byte, binary.Size(pb))
+	err := utils.ReadAt(file, buffer, offset)
 	if err != nil {
-		return fmt.Errorf("error buscando la posición en el archivo: %w", err)
+		return fmt.Errorf("error leyendo el PointerBlock: %w", err)
 	}
 
-	// Leer la estructura PointerBlock desde el archivo
-	err = binary.Read(file, binary.BigEndian, pb)
+	// Deserializar la estructura PointerBlock
+	err = binary.Read(bytes.NewReader(buffer), binary.BigEndian, pb)
 	if err != nil {
 		return fmt.Errorf("error leyendo el PointerBlock: %w", err)
 	}
 	return nil
 }
+
+// Con InodeFlagIndirect los primeros 12 apuntadores del inodo son directos e I_block[12], I_block[13]
+// e I_block[14] son los indirectos simple, doble y triple. Sin el atributo los 15 son directos.
+const directPointers = 12
+
+// pointersPerBlock es la cantidad de apuntadores de un PointerBlock
+const pointersPerBlock = len(PointerBlock{}.B_pointers)
+
+// HasIndirectBlocks indica si el inodo usa bloques de apuntadores
+func (inode *Inode) HasIndirectBlocks() bool {
+	return inode.I_flags&InodeFlagIndirect != 0
+}
+
+// DataBlocks devuelve en orden los bloques de contenido del archivo, siguiendo los bloques de apuntadores
+func (inode *Inode) DataBlocks(file *os.File, sb *Superblock) ([]int32, error) {
+	data, _, err := inode.fileBlocks(file, sb)
+	return data, err
+}
+
+// AllBlocks devuelve todos los bloques que ocupa el archivo: los de contenido y los de apuntadores
+func (inode *Inode) AllBlocks(file *os.File, sb *Superblock) ([]int32, error) {
+	data, pointers, err := inode.fileBlocks(file, sb)
+	return append(data, pointers...), err
+}
+
+// fileBlocks recorre los apuntadores del inodo y separa los bloques de contenido de los de apuntadores
+func (inode *Inode) fileBlocks(file *os.File, sb *Superblock) ([]int32, []int32, error) {
+	var data, pointers []int32
+	direct := inode.I_block[:]
+	if inode.HasIndirectBlocks() {
+		direct = inode.I_block[:directPointers]
+	}
+	for _, blockIndex := range direct {
+		if blockIndex == -1 {
+			return data, pointers, nil
+		}
+		data = append(data, blockIndex)
+	}
+	if !inode.HasIndirectBlocks() {
+		return data, pointers, nil
+	}
+
+	for level, blockIndex := range inode.I_block[directPointers:] {
+		if blockIndex == -1 {
+			break
+		}
+		if err := sb.readPointerBlock(file, blockIndex, level, &data, &pointers); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, pointers, nil
+}
+
+// readPointerBlock agrega los bloques que cuelgan de un bloque de apuntadores. En el nivel 0 apunta a
+// bloques de contenido; en los demás, a bloques de apuntadores del nivel anterior.
+func (sb *Superblock) readPointerBlock(file *os.File, blockIndex int32, level int, data, pointers *[]int32) error {
+	if blockIndex < 0 || blockIndex >= sb.S_blocks_count+sb.S_free_blocks_count {
+		return fmt.Errorf("apuntador a un bloque fuera del sistema de archivos: %d", blockIndex)
+	}
+	*pointers = append(*pointers, blockIndex)
+
+	pb := &PointerBlock{}
+	err := pb.Decode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size))
+	if err != nil {
+		return fmt.Errorf("error leyendo el bloque de apuntadores %d: %w", blockIndex, err)
+	}
+	for _, pointer := range pb.B_pointers {
+		if pointer == -1 {
+			break
+		}
+		if level == 0 {
+			*data = append(*data, pointer)
+		} else if err := sb.readPointerBlock(file, pointer, level-1, data, pointers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pointerBlocksFor devuelve cuántos bloques de apuntadores necesita un archivo de count bloques de
+// contenido, o -1 si ni con el indirecto triple alcanzan los apuntadores
+func pointerBlocksFor(count int) int {
+	if count <= len(Inode{}.I_block) {
+		return 0
+	}
+
+	total := 0
+	rest := count - directPointers
+	capacity := 1
+	for level := 0; level < 3 && rest > 0; level++ {
+		capacity *= pointersPerBlock
+		used := min(rest, capacity)
+		// Un bloque por cada pointersPerBlock bloques del nivel de abajo, hasta llegar a la raíz
+		for span := pointersPerBlock; span <= capacity; span *= pointersPerBlock {
+			total += (used + span - 1) / span
+		}
+		rest -= used
+	}
+	if rest > 0 {
+		return -1
+	}
+	return total
+}
+
+// fileLayout devuelve cuántos bloques de contenido tendrá un archivo de count bloques que ya ocupa
+// current bloques, y cuántos bloques de apuntadores necesita. Los bloques que sobran se conservan
+// como bloques de contenido vacíos: los bloques se asignan en orden según S_blocks_count, así que
+// liberar uno dejaría un hueco que la siguiente carpeta pisaría.
+func fileLayout(count, current int) (int, int) {
+	for {
+		pointers := pointerBlocksFor(count)
+		if pointers < 0 || count+pointers >= current {
+			return count, pointers
+		}
+		count++
+	}
+}
+
+// CheckFileBlocks verifica que count bloques de contenido quepan en el inodo y en los bloques libres
+func (sb *Superblock) CheckFileBlocks(file *os.File, inode *Inode, count int) error {
+	current, err := inode.AllBlocks(file, sb)
+	if err != nil {
+		return err
+	}
+	count, pointers := fileLayout(count, len(current))
+	if pointers < 0 {
+		return fmt.Errorf("el archivo ocuparía %d bloques, más de los que direcciona un inodo", count)
+	}
+	if pointers > 0 && sb.S_revision < RevisionInodeFlags {
+		return fmt.Errorf("el archivo ocuparía %d bloques y %s solo admite %d bloques directos; ejecute migrate para usar bloques indirectos",
+			count, RevisionName(sb.S_revision), len(inode.I_block))
+	}
+	if needed := count + pointers - len(current); needed > int(sb.S_free_blocks_count) {
+		return fmt.Errorf("el archivo necesita %d bloques más y solo quedan %d libres", needed, sb.S_free_blocks_count)
+	}
+	return nil
+}
+
+// WriteFileBlocks reemplaza el contenido del archivo por blocks. Reutiliza los bloques que ya tiene el
+// inodo y asigna los que falten; si el contenido no cabe en los 15 apuntadores directos usa bloques de
+// apuntadores y activa InodeFlagIndirect. Si el contenido no cabe no modifica nada.
+func (sb *Superblock) WriteFileBlocks(file *os.File, inode *Inode, blocks []*FileBlock) error {
+	if err := sb.CheckFileBlocks(file, inode, len(blocks)); err != nil {
+		return err
+	}
+	pool, err := inode.AllBlocks(file, sb)
+	if err != nil {
+		return err
+	}
+	count, pointers := fileLayout(len(blocks), len(pool))
+	for len(blocks) < count {
+		blocks = append(blocks, &FileBlock{})
+	}
+
+	// Toma primero los bloques que ya eran del archivo
+	next := func() (int32, error) {
+		if len(pool) > 0 {
+			blockIndex := pool[0]
+			pool = pool[1:]
+			return blockIndex, nil
+		}
+		blockIndex, err := sb.FindNextFreeBlock(file)
+		if err != nil {
+			return -1, err
+		}
+		sb.UpdateSuperblockAfterBlockAllocation()
+		return blockIndex, nil
+	}
+
+	direct := len(inode.I_block)
+	if pointers > 0 {
+		direct = directPointers
+	}
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+
+	written := 0
+	for i := 0; i < direct && written < len(blocks); i++ {
+		blockIndex, err := next()
+		if err != nil {
+			return fmt.Errorf("error asignando un bloque: %w", err)
+		}
+		err = blocks[written].Encode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size))
+		if err != nil {
+			return fmt.Errorf("error escribiendo el bloque %d: %w", blockIndex, err)
+		}
+		inode.I_block[i] = blockIndex
+		written++
+	}
+	for level := 0; level < 3 && written < len(blocks); level++ {
+		blockIndex, n, err := sb.writePointerBlock(file, blocks[written:], level, next)
+		if err != nil {
+			return err
+		}
+		inode.I_block[directPointers+level] = blockIndex
+		written += n
+	}
+
+	if pointers > 0 {
+		inode.I_flags |= InodeFlagIndirect
+	} else {
+		inode.I_flags &^= InodeFlagIndirect
+	}
+	return nil
+}
+
+// writePointerBlock arma un bloque de apuntadores del nivel indicado con los primeros bloques de
+// contenido que alcance a direccionar. Devuelve el bloque de apuntadores y cuántos bloques escribió.
+func (sb *Superblock) writePointerBlock(file *os.File, blocks []*FileBlock, level int, next func() (int32, error)) (int32, int, error) {
+	pointerIndex, err := next()
+	if err != nil {
+		return -1, 0, fmt.Errorf("error asignando un bloque de apuntadores: %w", err)
+	}
+
+	pb := &PointerBlock{}
+	for i := range pb.B_pointers {
+		pb.B_pointers[i] = -1
+	}
+	written := 0
+	for i := range pb.B_pointers {
+		if written == len(blocks) {
+			break
+		}
+		if level > 0 {
+			blockIndex, n, err := sb.writePointerBlock(file, blocks[written:], level-1, next)
+			if err != nil {
+				return -1, 0, err
+			}
+			pb.B_pointers[i] = blockIndex
+			written += n
+			continue
+		}
+
+		blockIndex, err := next()
+		if err != nil {
+			return -1, 0, fmt.Errorf("error asignando un bloque: %w", err)
+		}
+		err = blocks[written].Encode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size))
+		if err != nil {
+			return -1, 0, fmt.Errorf("error escribiendo el bloque %d: %w", blockIndex, err)
+		}
+		pb.B_pointers[i] = blockIndex
+		written++
+	}
+
+	err = pb.Encode(file, int64(sb.S_block_start+pointerIndex*sb.S_block_size))
+	if err != nil {
+		return -1, 0, err
+	}
+	return pointerIndex, written, nil
+}
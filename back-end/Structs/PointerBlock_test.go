@@ -0,0 +1,98 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPointerBlocksFor(t *testing.T) {
+	tests := []struct {
+		count, want int
+	}{
+		{15, 0},   // Sin el atributo los 15 apuntadores son directos
+		{16, 1},   // 12 directos y 4 en el indirecto simple
+		{28, 1},   // El indirecto simple lleno
+		{29, 3},   // El indirecto doble necesita su raíz y una hoja
+		{284, 18}, // El indirecto doble lleno: 1 + 16 + 1
+		{285, 21}, // El indirecto triple necesita raíz, un nivel intermedio y una hoja
+		{4380, 291},
+		{4381, -1},
+	}
+	for _, tt := range tests {
+		if got := pointerBlocksFor(tt.count); got != tt.want {
+			t.Errorf("pointerBlocksFor(%d) = %d, se esperaba %d", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFileBlocksThroughTripleIndirect(t *testing.T) {
+	sb := newTestSuperblock(FormatRevision, 128)
+	file := newTestDisk(t, int64(sb.S_block_start+3*128*sb.S_block_size))
+	if err := sb.CreateBitMaps(file); err != nil {
+		t.Fatal(err)
+	}
+
+	inode := &Inode{}
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+	blocks := make([]*FileBlock, 300)
+	for i := range blocks {
+		blocks[i] = &FileBlock{}
+		copy(blocks[i].B_content[:], fmt.Sprintf("bloque %03d", i))
+	}
+	if err := sb.WriteFileBlocks(file, inode, blocks); err != nil {
+		t.Fatal(err)
+	}
+	if !inode.HasIndirectBlocks() || inode.I_block[14] == -1 {
+		t.Fatalf("WriteFileBlocks() no usó el indirecto triple: I_flags = %#x, I_block = %v", inode.I_flags, inode.I_block)
+	}
+
+	readBlocks := func() []string {
+		t.Helper()
+		data, err := inode.DataBlocks(file, sb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var contents []string
+		for _, blockIndex := range data {
+			block := &FileBlock{}
+			if err := block.Decode(file, int64(sb.S_block_start+blockIndex*sb.S_block_size)); err != nil {
+				t.Fatal(err)
+			}
+			contents = append(contents, strings.TrimRight(string(block.B_content[:]), "\x00"))
+		}
+		return contents
+	}
+	contents := readBlocks()
+	if len(contents) != len(blocks) {
+		t.Fatalf("DataBlocks() devolvió %d bloques, se esperaban %d", len(contents), len(blocks))
+	}
+	for i, content := range contents {
+		if want := fmt.Sprintf("bloque %03d", i); content != want {
+			t.Fatalf("el bloque %d contiene %q, se esperaba %q", i, content, want)
+		}
+	}
+	all, err := inode.AllBlocks(file, sb)
+	if err != nil || len(all) != 300+21 || sb.S_free_blocks_count != 3*128-321 {
+		t.Fatalf("AllBlocks() = %d bloques, %v; quedan %d libres", len(all), err, sb.S_free_blocks_count)
+	}
+
+	// Al achicarse reutiliza sus bloques y deja vacíos los que sobran
+	free := sb.S_free_blocks_count
+	if err := sb.WriteFileBlocks(file, inode, blocks[:3]); err != nil {
+		t.Fatal(err)
+	}
+	contents = readBlocks()
+	if sb.S_free_blocks_count != free || contents[2] != "bloque 002" || contents[3] != "" {
+		t.Fatalf("después de achicar: %d libres (antes %d), bloques %q", sb.S_free_blocks_count, free, contents[:4])
+	}
+
+	// Sin bloques libres suficientes no modifica el inodo
+	before := inode.I_block
+	many := make([]*FileBlock, 400)
+	if err := sb.WriteFileBlocks(file, inode, many); err == nil || inode.I_block != before {
+		t.Fatalf("WriteFileBlocks() sin bloques libres = %v, I_block = %v", err, inode.I_block)
+	}
+}
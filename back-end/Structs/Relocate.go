@@ -0,0 +1,248 @@
+package structs
+
+import (
+	"backend/utils"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Tamaño de los bloques usados para copiar el contenido de una partición
+const relocateChunkSize = 1024 * 1024
+
+// MovePartitionData copia size bytes desde oldStart hacia newStart; las zonas pueden solaparse
+func MovePartitionData(file *os.File, oldStart int64, newStart int64, size int64) error {
+	if oldStart == newStart || size <= 0 {
+		return nil
+	}
+
+	buffer := make([]byte, relocateChunkSize)
+	copyChunk := func(offset int64, length int64) error {
+		chunk := buffer[:length]
+		if _, err := file.ReadAt(chunk, oldStart+offset); err != nil {
+			return fmt.Errorf("error leyendo el byte %d de la partición: %v", oldStart+offset, err)
+		}
+		if err := utils.WriteRawAt(file, chunk, newStart+offset); err != nil {
+			return fmt.Errorf("error escribiendo el byte %d de la partición: %v", newStart+offset, err)
+		}
+		return nil
+	}
+
+	if newStart > oldStart {
+		// Hacia adelante: copiar desde el final para no pisar datos que aún no se han leído
+		for remaining := size; remaining > 0; {
+			length := min(int64(relocateChunkSize), remaining)
+			remaining -= length
+			if err := copyChunk(remaining, length); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Hacia atrás: copiar desde el inicio
+	for offset := int64(0); offset < size; {
+		length := min(int64(relocateChunkSize), size-offset)
+		if err := copyChunk(offset, length); err != nil {
+			return err
+		}
+		offset += length
+	}
+	return nil
+}
+
+// Relocate desplaza los punteros absolutos del superbloque cuando la partición cambia de posición
+func (sb *Superblock) Relocate(delta int32) {
+	sb.S_first_ino += delta
+	sb.S_first_blo += delta
+	sb.S_bm_inode_start += delta
+	sb.S_bm_block_start += delta
+	sb.S_inode_start += delta
+	sb.S_block_start += delta
+	if sb.S_csum_start != 0 {
+		sb.S_csum_start += delta
+	}
+}
+
+// RelocateEBRChain corrige los punteros de la cadena de EBRs que ya fue movida a newStart
+func RelocateEBRChain(file *os.File, newStart int32, delta int32) error {
+	position := newStart
+	for {
+		ebr := &EBR{}
+		if err := ebr.Decode(file, int64(position)); err != nil {
+			return fmt.Errorf("error leyendo el EBR en %d: %v", position, err)
+		}
+
+		if ebr.Ebr_start != -1 {
+			ebr.Ebr_start += delta
+		}
+		if ebr.Ebr_next != -1 {
+			ebr.Ebr_next += delta
+		}
+
+		if err := ebr.Encode(file, int64(position)); err != nil {
+			return fmt.Errorf("error escribiendo el EBR en %d: %v", position, err)
+		}
+
+		// Las particiones lógicas formateadas también guardan punteros absolutos
+		if ebr.Ebr_size > 0 {
+			if err := relocateFilesystem(file, ebr.DataStart()-delta, ebr.DataStart()); err != nil {
+				return err
+			}
+		}
+
+		// El siguiente EBR siempre está más adelante; cualquier otro valor indica una cadena dañada
+		if ebr.Ebr_next == -1 || ebr.Ebr_next <= position {
+			return nil
+		}
+		position = ebr.Ebr_next
+	}
+}
+
+// relocateFilesystem corrige el superbloque de un sistema de archivos que ya fue movido de oldStart a newStart.
+// Si en newStart no hay un sistema de archivos no hace nada.
+func relocateFilesystem(file *os.File, oldStart int32, newStart int32) error {
+	// En una partición cifrada el sistema de archivos empieza después de la cabecera y su llave se mueve con los datos
+	if IsEncrypted(file, newStart) {
+		utils.MoveCipher(file.Name(), int64(oldStart+CryptHeaderSize), int64(newStart+CryptHeaderSize))
+		oldStart += CryptHeaderSize
+		newStart += CryptHeaderSize
+	}
+
+	if err := RebaseFilesystem(file, newStart, newStart-oldStart); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RebaseFilesystem corrige el superbloque de un sistema de archivos copiado a start desde una
+// posición delta bytes antes. Si en start no hay un sistema de archivos no hace nada.
+func RebaseFilesystem(file *os.File, start int32, delta int32) error {
+	// Un sistema de archivos guarda el inicio de sus bitmaps, inodos y bloques en posiciones absolutas
+	// Sus punteros aún apuntan a la posición anterior, así que la verificación de los bitmaps no aplica
+	sb := &Superblock{}
+	err := sb.Decode(file, int64(start))
+	var checksumErr *ChecksumError
+	if err != nil && !errors.As(err, &checksumErr) {
+		return fmt.Errorf("error leyendo el superbloque movido: %v", err)
+	}
+	if sb.S_magic == FilesystemMagic {
+		sb.Relocate(delta)
+		err = sb.Encode(file, int64(start))
+		if err != nil {
+			return fmt.Errorf("error actualizando el superbloque movido: %v", err)
+		}
+	}
+	return nil
+}
+
+// Relocate mueve la partición completa a newStart y actualiza los punteros absolutos que guarda dentro
+func (p *Partition) Relocate(file *os.File, newStart int32) error {
+	delta := newStart - p.Part_start
+	if delta == 0 {
+		return nil
+	}
+
+	// Validar los punteros antes de mover: un error después de copiar dejaría la partición a medias,
+	// y los de un sistema de archivos cifrado solo se pueden corregir con su llave
+	if err := p.CheckMovable(file); err != nil {
+		return err
+	}
+
+	err := MovePartitionData(file, int64(p.Part_start), int64(newStart), int64(p.Part_size))
+	if err != nil {
+		return err
+	}
+
+	if p.Part_type[0] == 'E' {
+		// Los EBRs guardan su inicio y el del siguiente EBR en posiciones absolutas
+		err = RelocateEBRChain(file, newStart, delta)
+		if err != nil {
+			return fmt.Errorf("error actualizando la cadena de EBRs: %v", err)
+		}
+	} else {
+		err = relocateFilesystem(file, p.Part_start, newStart)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Partición '%s' movida de %d a %d\n", string(p.Part_name[:]), p.Part_start, newStart)
+	p.Part_start = newStart
+	return nil
+}
+
+// CheckMovable verifica que la partición se pueda mover: si es extendida, toda su cadena de EBRs
+// debe poder leerse y quedar dentro de la partición para que RelocateEBRChain la corrija completa;
+// además, ni la partición ni sus lógicas pueden estar cifradas sin su llave
+func (p *Partition) CheckMovable(file *os.File) error {
+	partitions := []*Partition{p}
+	if p.Part_type[0] == 'E' {
+		chain, err := EBRChain(p.Part_start, file)
+		if err != nil {
+			return fmt.Errorf("error leyendo las particiones lógicas: %v", err)
+		}
+		partitions = nil
+		for _, ebr := range chain {
+			if ebr.Ebr_next != -1 && ebr.Ebr_next >= p.Part_start+p.Part_size {
+				return fmt.Errorf("la cadena de EBRs de la partición '%s' sale de la partición",
+					strings.Trim(string(p.Part_name[:]), "\x00 "))
+			}
+			if ebr.Ebr_size > 0 {
+				partitions = append(partitions, ebr.ToPartition())
+			}
+		}
+	}
+
+	for _, partition := range partitions {
+		if IsEncrypted(file, partition.Part_start) && !partition.IsUnlocked(file) {
+			return fmt.Errorf("la partición '%s' está cifrada; móntela con -passphrase antes de moverla",
+				strings.Trim(string(partition.Part_name[:]), "\x00 "))
+		}
+	}
+	return nil
+}
+
+// UsedPartitions devuelve los índices de las particiones en uso ordenados por su posición en el disco
+func (mbr *MBR) UsedPartitions() []int {
+	var used []int
+	for i, partition := range mbr.MbrPartitions {
+		if partition.Part_start != -1 {
+			used = append(used, i)
+		}
+	}
+	sort.Slice(used, func(a, b int) bool {
+		return mbr.MbrPartitions[used[a]].Part_start < mbr.MbrPartitions[used[b]].Part_start
+	})
+	return used
+}
+
+// MovePartition mueve la partición con el índice dado a newStart, validando que el destino esté libre
+func (mbr *MBR) MovePartition(file *os.File, index int, newStart int32) error {
+	partition := &mbr.MbrPartitions[index]
+	newEnd := newStart + partition.Part_size
+
+	if newStart < int32(mbr.HeaderSize()) || newEnd > mbr.UsableEnd() {
+		return fmt.Errorf("el destino [%d, %d) está fuera del área asignable del disco [%d, %d)",
+			newStart, newEnd, mbr.HeaderSize(), mbr.UsableEnd())
+	}
+	if mbr.IsGPT() && newStart%LBASize != 0 {
+		return fmt.Errorf("en un disco GPT el inicio debe ser múltiplo de %d bytes", LBASize)
+	}
+
+	// El destino puede solaparse con la propia partición, pero no con las demás
+	for i, other := range mbr.MbrPartitions {
+		if i == index || other.Part_start == -1 {
+			continue
+		}
+		if newStart < other.Part_start+other.Part_size && other.Part_start < newEnd {
+			return fmt.Errorf("el destino se solapa con la partición '%s' [%d, %d)",
+				strings.Trim(string(other.Part_name[:]), "\x00 "), other.Part_start, other.Part_start+other.Part_size)
+		}
+	}
+
+	return partition.Relocate(file, newStart)
+}
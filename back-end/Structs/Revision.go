@@ -0,0 +1,92 @@
+package structs
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"time"
+)
+
+// Revisiones del formato en disco de las imágenes .mia
+const (
+	RevisionLegacy       int32 = 1 // Fechas float32 en inodos y MBR
+	RevisionTimestamps64 int32 = 2 // Fechas de 64 bits (segundos + nanosegundos), sin revisión en disco
+	RevisionVersioned    int32 = 3 // MBR y superbloque guardan firma y revisión
+	RevisionChecksums    int32 = 4 // CRC32C en superbloque, inodos, bitmaps y bloques de carpeta
+	RevisionInodeFlags   int32 = 5 // Atributos por archivo en el inodo (compresión)
+
+	// FormatRevision es la revisión con la que se crean los discos y sistemas de archivos nuevos
+	FormatRevision = RevisionInodeFlags
+)
+
+// FilesystemMagic es el valor de S_magic de un superbloque válido
+const FilesystemMagic int32 = 0xEF53
+
+// SuperblockRevisionMagic indica que el superbloque trae los campos de revisión ("MREV")
+const SuperblockRevisionMagic int32 = 0x5645524D
+
+// MBRSignature es la firma que identifica un MBR con revisión
+var MBRSignature = [4]byte{'M', 'I', 'A', 'D'}
+
+// RevisionName devuelve una descripción corta de la revisión del formato
+func RevisionName(revision int32) string {
+	switch revision {
+	case RevisionLegacy:
+		return "rev 1 (fechas float32)"
+	case RevisionTimestamps64:
+		return "rev 2 (fechas de 64 bits, sin versión)"
+	case RevisionVersioned:
+		return "rev 3 (MBR y superbloque versionados)"
+	case RevisionChecksums:
+		return "rev 4 (checksums CRC32C)"
+	case RevisionInodeFlags:
+		return "rev 5 (atributos de archivo)"
+	default:
+		return "revisión desconocida"
+	}
+}
+
+// DetectMBRRevision revisa la cabecera del disco para saber con qué revisión fue escrito el MBR
+func DetectMBRRevision(file *os.File) int32 {
+	header := make([]byte, binary.Size(MBRRecord{}))
+	if _, err := file.ReadAt(header, 0); err != nil {
+		// Disco vacío o recién creado: se escribe con la revisión actual
+		return FormatRevision
+	}
+
+	if isRev1MBRHeader(header) {
+		return RevisionLegacy
+	}
+
+	// A partir de la revisión 3 la firma va justo después de la tabla de particiones de la revisión 2
+	signatureStart := binary.Size(MBRRev2{})
+	if [4]byte(header[signatureStart:signatureStart+4]) == MBRSignature {
+		return int32(binary.LittleEndian.Uint32(header[signatureStart+4 : signatureStart+8]))
+	}
+
+	if binary.LittleEndian.Uint32(header[0:4]) == 0 {
+		return FormatRevision
+	}
+	return RevisionTimestamps64
+}
+
+// isRev1MBRHeader indica si el MBR usa la fecha en float32.
+// En las revisiones nuevas los bytes 8..12 son la parte alta de los segundos (cero hasta el año 2106),
+// mientras que en la revisión 1 contienen la firma del disco y los bytes 4..8 una fecha float32 válida.
+func isRev1MBRHeader(header []byte) bool {
+	if binary.LittleEndian.Uint32(header[8:12]) == 0 {
+		return false
+	}
+
+	date := math.Float32frombits(binary.LittleEndian.Uint32(header[4:8]))
+	maxDate := float32(time.Date(2106, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	return date > 0 && date < maxDate
+}
+
+// detectSuperblockRevision deduce la revisión de un superbloque que no trae los campos de revisión
+func detectSuperblockRevision(sb *Superblock) int32 {
+	if sb.S_inode_size == InodeRev1Size {
+		return RevisionLegacy
+	}
+	return RevisionTimestamps64
+}
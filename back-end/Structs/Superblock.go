@@ -26,16 +26,72 @@ type Superblock struct {
 	S_bm_block_start    int32   // Inicio del bitmap de bloques
 	S_inode_start       int32   // Inicio de la tabla de inodos
 	S_block_start       int32   // Inicio de la tabla de bloques
+	S_rev_magic         int32   // Firma que indica que el superbloque guarda su revisión
+	S_revision          int32   // Revisión del formato en disco
+	S_csum_start        int32   // Inicio de la tabla de checksums de los bloques
+	S_checksum          uint32  // CRC32C del superbloque (calculado con este campo en 0)
 }
 
 // Encode codifica la estructura Superblock en un archivo
 func (sb *Superblock) Encode(file *os.File, offset int64) error {
-	return utilidades.WriteToFile(file, offset, sb)
+	// Los superbloques de revisiones anteriores no tienen espacio para los campos nuevos:
+	// lo que sigue es el bitmap o el journal
+	var data interface{} = sb
+	switch {
+	case sb.S_rev_magic != SuperblockRevisionMagic:
+		data = sb.toRev2()
+	case sb.S_revision < RevisionChecksums:
+		data = sb.toRev3()
+	default:
+		sb.S_checksum = sb.computeChecksum()
+	}
+
+	return utilidades.WriteToFile(file, offset, data)
 }
 
 // Decode decodifica la estructura Superblock desde un archivo
 func (sb *Superblock) Decode(file *os.File, offset int64) error {
-	return utilidades.ReadFromFile(file, offset, sb)
+	if err := utilidades.ReadFromFile(file, offset, sb); err != nil {
+		return err
+	}
+
+	// Los últimos campos leídos no pertenecen a los superbloques de revisiones anteriores
+	if sb.S_rev_magic != SuperblockRevisionMagic {
+		sb.S_rev_magic = 0
+		sb.S_revision = detectSuperblockRevision(sb)
+	}
+	if sb.S_revision < RevisionChecksums {
+		sb.S_csum_start = 0
+		sb.S_checksum = 0
+	}
+
+	if sb.S_magic != FilesystemMagic || sb.S_revision < RevisionChecksums {
+		return nil
+	}
+
+	// Verificar el superbloque y los bitmaps al momento de leerlos
+	if computed := sb.computeChecksum(); computed != sb.S_checksum {
+		return &ChecksumError{Structure: "superbloque", Offset: offset, Stored: sb.S_checksum, Computed: computed}
+	}
+	return sb.VerifyBitmaps(file)
+}
+
+// computeChecksum calcula el CRC32C del superbloque sin tomar en cuenta el campo S_checksum
+func (sb *Superblock) computeChecksum() uint32 {
+	copySb := *sb
+	copySb.S_checksum = 0
+	return checksumBytes(structBytes(&copySb))
+}
+
+// EncodedSize devuelve el tamaño que ocupa el superbloque en disco según su revisión
+func (sb *Superblock) EncodedSize() int64 {
+	switch {
+	case sb.S_rev_magic != SuperblockRevisionMagic:
+		return int64(binary.Size(SuperblockRev2{}))
+	case sb.S_revision < RevisionChecksums:
+		return int64(binary.Size(SuperblockRev3{}))
+	}
+	return int64(binary.Size(Superblock{}))
 }
 
 // Print imprime los valores de la estructura SuperBlock
@@ -59,6 +115,9 @@ func (sb *Superblock) Print() {
 	fmt.Printf("%-25s %-10d\n", "S_bm_block_start:", sb.S_bm_block_start)
 	fmt.Printf("%-25s %-10d\n", "S_inode_start:", sb.S_inode_start)
 	fmt.Printf("%-25s %-10d\n", "S_block_start:", sb.S_block_start)
+	fmt.Printf("%-25s %-10s\n", "S_revision:", RevisionName(sb.S_revision))
+	fmt.Printf("%-25s %-10d\n", "S_csum_start:", sb.S_csum_start)
+	fmt.Printf("%-25s %-10x\n", "S_checksum:", sb.S_checksum)
 }
 
 // PrintInodes imprime los inodos desde el archivo
@@ -75,7 +134,7 @@ func (sb *Superblock) PrintInodes(path string) error {
 	// Deserializar todos los inodos en memoria
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		inode := &inodes[i]
-		err := utilidades.ReadFromFile(file, int64(sb.S_inode_start+(i*int32(binary.Size(Inode{})))), inode)
+		err := inode.Decode(file, sb.CalculateInodeOffset(i), sb)
 		if err != nil {
 			return fmt.Errorf("failed to decode inode %d: %w", i, err)
 		}
@@ -104,7 +163,7 @@ func (sb *Superblock) PrintBlocks(path string) error {
 	// Deserializar todos los inodos en memoria
 	for i := int32(0); i < sb.S_inodes_count; i++ {
 		inode := &inodes[i]
-		err := utilidades.ReadFromFile(file, int64(sb.S_inode_start+(i*int32(binary.Size(Inode{})))), inode)
+		err := inode.Decode(file, sb.CalculateInodeOffset(i), sb)
 		if err != nil {
 			return fmt.Errorf("failed to decode inode %d: %w", i, err)
 		}
@@ -112,13 +171,20 @@ func (sb *Superblock) PrintBlocks(path string) error {
 
 	// Imprimir los bloques
 	for _, inode := range inodes {
-		for _, blockIndex := range inode.I_block {
+		blocks := inode.I_block[:]
+		if inode.I_type[0] == '1' {
+			// Los archivos pueden tener bloques de contenido detrás de bloques de apuntadores
+			if blocks, err = inode.DataBlocks(file, sb); err != nil {
+				return fmt.Errorf("failed to read blocks of inode: %w", err)
+			}
+		}
+		for _, blockIndex := range blocks {
 			if blockIndex == -1 {
 				break
 			}
 			if inode.I_type[0] == '0' {
 				block := &FolderBlock{}
-				err := utilidades.ReadFromFile(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), block)
+				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 				if err != nil {
 					return fmt.Errorf("failed to decode folder block %d: %w", blockIndex, err)
 				}
@@ -126,7 +192,7 @@ func (sb *Superblock) PrintBlocks(path string) error {
 				block.Print()
 			} else if inode.I_type[0] == '1' {
 				block := &FileBlock{}
-				err := utilidades.ReadFromFile(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), block)
+				err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
 				if err != nil {
 					return fmt.Errorf("failed to decode file block %d: %w", blockIndex, err)
 				}
@@ -140,7 +206,7 @@ func (sb *Superblock) PrintBlocks(path string) error {
 }
 
 func (sb *Superblock) FindNextFreeBlock(file *os.File) (int32, error) {
-	totalBlocks := sb.S_free_blocks_count // Usar S_blocks_count para iterar sobre el rango completo de bloques
+	totalBlocks := sb.S_blocks_count + sb.S_free_blocks_count // Iterar sobre el rango completo de bloques
 	fmt.Printf("Total de bloques disponibles: %d\n", totalBlocks)
 	fmt.Printf("Bloques libres reportados por el superbloque: %d\n", sb.S_free_blocks_count)
 
@@ -0,0 +1,204 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+// newTestSuperblock devuelve un superbloque EXT2 de n inodos con la disposición de la revisión indicada
+func newTestSuperblock(revision int32, n int32) *Superblock {
+	sb := &Superblock{
+		S_filesystem_type:   2,
+		S_free_inodes_count: n,
+		S_free_blocks_count: 3 * n,
+		S_mtime:             1700000000,
+		S_mnt_count:         1,
+		S_magic:             FilesystemMagic,
+		S_block_size:        int32(binary.Size(FileBlock{})),
+		S_revision:          revision,
+	}
+	switch {
+	case revision == RevisionLegacy:
+		sb.S_inode_size = InodeRev1Size
+	case revision < RevisionChecksums:
+		sb.S_inode_size = int32(binary.Size(InodeRev3{}))
+	case revision == RevisionChecksums:
+		sb.S_inode_size = int32(binary.Size(InodeRev4{}))
+	default:
+		sb.S_inode_size = int32(binary.Size(Inode{}))
+	}
+	if revision >= RevisionVersioned {
+		sb.S_rev_magic = SuperblockRevisionMagic
+	}
+
+	sb.S_bm_inode_start = int32(sb.EncodedSize())
+	sb.S_bm_block_start = sb.S_bm_inode_start + n
+	sb.S_inode_start = sb.S_bm_block_start + 3*n
+	if revision >= RevisionChecksums {
+		sb.S_csum_start = sb.S_inode_start
+		sb.S_inode_start += 3 * n * 4
+	}
+	sb.S_block_start = sb.S_inode_start + n*sb.S_inode_size
+	sb.S_first_ino = sb.S_inode_start
+	sb.S_first_blo = sb.S_block_start
+	return sb
+}
+
+// fillTestDisk llena el archivo con un valor distinto de cero para detectar escrituras de más
+func fillTestDisk(t *testing.T, file *os.File, size int64) {
+	t.Helper()
+	if _, err := file.WriteAt(bytes.Repeat([]byte{0xAA}, int(size)), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSuperblockRoundTripByRevision(t *testing.T) {
+	for revision := RevisionLegacy; revision <= FormatRevision; revision++ {
+		t.Run(RevisionName(revision), func(t *testing.T) {
+			file := newTestDisk(t, 4096)
+			fillTestDisk(t, file, 256)
+			sb := newTestSuperblock(revision, 16)
+			size := binary.Size(Superblock{})
+			switch {
+			case revision < RevisionVersioned:
+				size = binary.Size(SuperblockRev2{})
+			case revision < RevisionChecksums:
+				size = binary.Size(SuperblockRev3{})
+			}
+			if got := sb.EncodedSize(); got != int64(size) {
+				t.Fatalf("EncodedSize() = %d, se esperaba %d", got, size)
+			}
+			if err := sb.Encode(file, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			// Lo que sigue al superbloque (bitmap o journal) no se toca
+			after := make([]byte, 16)
+			if _, err := file.ReadAt(after, sb.EncodedSize()); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(after, bytes.Repeat([]byte{0xAA}, 16)) {
+				t.Fatalf("Encode() escribió después de los %d bytes de la revisión: %x", sb.EncodedSize(), after)
+			}
+			if err := sb.CreateBitMaps(file); err != nil {
+				t.Fatal(err)
+			}
+
+			var got Superblock
+			if err := got.Decode(file, 0); err != nil {
+				t.Fatal(err)
+			}
+			if got.S_revision != revision {
+				t.Errorf("S_revision = %d, se esperaba %d", got.S_revision, revision)
+			}
+			want := *sb
+			want.S_rev_magic, got.S_rev_magic = 0, 0
+			want.S_checksum, got.S_checksum = 0, 0
+			if got != want {
+				t.Errorf("Decode() = %+v, se esperaba %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSuperblockChecksumDetectsCorruption(t *testing.T) {
+	for revision := RevisionVersioned; revision <= FormatRevision; revision++ {
+		t.Run(RevisionName(revision), func(t *testing.T) {
+			file := newTestDisk(t, 4096)
+			sb := newTestSuperblock(revision, 16)
+			if err := sb.Encode(file, 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := sb.CreateBitMaps(file); err != nil {
+				t.Fatal(err)
+			}
+
+			// Alterar S_mnt_count
+			if _, err := file.WriteAt([]byte{7}, 36); err != nil {
+				t.Fatal(err)
+			}
+			var got Superblock
+			err := got.Decode(file, 0)
+			var checksumErr *ChecksumError
+			if revision < RevisionChecksums {
+				// Sin checksum el cambio pasa desapercibido
+				if err != nil || got.S_mnt_count != 7 {
+					t.Errorf("Decode() = %v con S_mnt_count %d", err, got.S_mnt_count)
+				}
+			} else if !errors.As(err, &checksumErr) || checksumErr.Structure != "superbloque" {
+				t.Errorf("Decode() = %v, se esperaba un ChecksumError del superbloque", err)
+			}
+		})
+	}
+}
+
+func TestBitmapChecksums(t *testing.T) {
+	file := newTestDisk(t, 8192)
+	sb := newTestSuperblock(FormatRevision, 64)
+	if err := sb.Encode(file, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.UpdateBitmapInode(file, 3, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.UpdateBitmapBlock(file, 130, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.VerifyBitmaps(file); err != nil {
+		t.Fatalf("VerifyBitmaps() después de actualizar los bitmaps: %v", err)
+	}
+
+	var got Superblock
+	if err := got.Decode(file, 0); err != nil {
+		t.Fatalf("Decode() de un sistema de archivos intacto: %v", err)
+	}
+
+	// Marcar un bloque como ocupado sin pasar por UpdateBitmapBlock
+	if _, err := file.WriteAt([]byte{0x01}, int64(sb.S_bm_block_start)+2); err != nil {
+		t.Fatal(err)
+	}
+	var checksumErr *ChecksumError
+	if err := sb.VerifyBitmaps(file); !errors.As(err, &checksumErr) || checksumErr.Structure != "bitmap de bloques" {
+		t.Fatalf("VerifyBitmaps() = %v, se esperaba un ChecksumError del bitmap de bloques", err)
+	}
+	if err := got.Decode(file, 0); !errors.As(err, &checksumErr) {
+		t.Fatalf("Decode() = %v, se esperaba que reportara el bitmap dañado", err)
+	}
+
+	if err := sb.UpdateBitmapChecksums(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.VerifyBitmaps(file); err != nil {
+		t.Fatalf("VerifyBitmaps() después de UpdateBitmapChecksums: %v", err)
+	}
+}
+
+func TestBitmapWithoutChecksumsBeforeRevision4(t *testing.T) {
+	file := newTestDisk(t, 8192)
+	sb := newTestSuperblock(RevisionVersioned, 64)
+	if err := sb.CreateBitMaps(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.UpdateBitmapInode(file, 3, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// La zona del checksum queda libre: en la revisión 3 no se usa
+	offset, _ := bitmapChecksumOffset(sb.S_bm_inode_start, 64)
+	stored := make([]byte, 4)
+	if _, err := file.ReadAt(stored, offset); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, make([]byte, 4)) {
+		t.Fatalf("la revisión 3 escribió un checksum de bitmap: %x", stored)
+	}
+	if err := sb.VerifyBitmaps(file); err != nil {
+		t.Fatalf("VerifyBitmaps() en la revisión 3: %v", err)
+	}
+}
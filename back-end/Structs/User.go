@@ -1,6 +1,12 @@
 package structs
 
-import "fmt"
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 // User define la estructura para los usuarios del sistema
 type User struct {
@@ -8,18 +14,83 @@ type User struct {
 	Tipo     string // Tipo de entidad, en este caso "U" para usuarios
 	Group    string // Grupo al que pertenece el usuario
 	Name     string // Nombre del usuario
-	Password string // Contraseña del usuario
+	Password string // Hash bcrypt de la contraseña (texto plano en archivos antiguos)
 	Status   bool   // Indica si el usuario está activo o eliminado
+	Locked   bool   // La cuenta está deshabilitada (usermod -disable)
+	Anterior *User  // Usuario que ejecutó su para cambiar a este (exit-su vuelve a él)
 }
 
+// disabledField es el sexto campo de la línea de users.txt de una cuenta deshabilitada.
+// Va en un campo aparte porque una contraseña antigua en texto plano puede empezar con cualquier carácter.
+const disabledField = "D"
+
 // NewUser crea un nuevo usuario
 func NewUser(id, group, name, password string) *User {
-	return &User{id, "U", group, name, password, true} // El usuario se crea como activo
+	return &User{Id: id, Tipo: "U", Group: group, Name: name, Password: password, Status: true} // El usuario se crea como activo
+}
+
+// ParseUser crea un usuario a partir de su línea de users.txt: id,U,grupo,usuario,contraseña[,D].
+// Devuelve nil si la línea no es de un usuario.
+func ParseUser(linea string) *User {
+	partes := strings.Split(strings.TrimSpace(linea), ",")
+	if len(partes) < 5 || len(partes) > 6 || partes[1] != "U" {
+		return nil
+	}
+	usuario := NewUser(partes[0], partes[2], partes[3], partes[4])
+	usuario.Locked = len(partes) == 6 && partes[5] == disabledField
+	return usuario
 }
 
 // ToString devuelve una representación en cadena del usuario
 func (u *User) ToString() string {
-	return fmt.Sprintf("%s,%s,%s,%s,%s", u.Id, u.Tipo, u.Group, u.Name, u.Password)
+	linea := fmt.Sprintf("%s,%s,%s,%s,%s", u.Id, u.Tipo, u.Group, u.Name, u.Password)
+	if u.Locked {
+		linea += "," + disabledField
+	}
+	return linea
+}
+
+// SetPassword guarda el hash bcrypt (con salt) de la contraseña
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error generando el hash de la contraseña: %w", err)
+	}
+	u.Password = string(hash)
+	return nil
+}
+
+// CheckPassword verifica la contraseña contra el hash guardado.
+// Las entradas antiguas en texto plano se comparan directamente.
+func (u *User) CheckPassword(password string) bool {
+	if u.Disabled() {
+		return false
+	}
+	if u.HasPlainPassword() {
+		return subtle.ConstantTimeCompare([]byte(u.Password), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
+// HasPlainPassword indica si la contraseña todavía está guardada en texto plano
+func (u *User) HasPlainPassword() bool {
+	_, err := bcrypt.Cost([]byte(u.Password))
+	return err != nil
+}
+
+// Disabled indica si la cuenta está deshabilitada
+func (u *User) Disabled() bool {
+	return u.Locked
+}
+
+// Disable deshabilita la cuenta conservando su id y el hash de su contraseña
+func (u *User) Disable() {
+	u.Locked = true
+}
+
+// Enable vuelve a habilitar una cuenta deshabilitada
+func (u *User) Enable() {
+	u.Locked = false
 }
 
 // Elimina el usuario (cambia el ID a "0" y desactiva el estado)
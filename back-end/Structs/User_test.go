@@ -0,0 +1,54 @@
+package structs
+
+import "testing"
+
+func TestPlainPasswordStartingWithBangIsNotDisabled(t *testing.T) {
+	usuario := ParseUser("2,U,users,ana,!secreto")
+	if usuario == nil {
+		t.Fatal("ParseUser no reconoció la línea")
+	}
+	if usuario.Disabled() {
+		t.Fatal("una contraseña en texto plano que empieza con ! no deshabilita la cuenta")
+	}
+	if !usuario.CheckPassword("!secreto") {
+		t.Fatal("la contraseña en texto plano no coincide")
+	}
+	if got := usuario.ToString(); got != "2,U,users,ana,!secreto" {
+		t.Fatalf("ToString() = %q", got)
+	}
+}
+
+func TestDisableRoundTrip(t *testing.T) {
+	usuario := NewUser("2", "users", "ana", "")
+	if err := usuario.SetPassword("abc"); err != nil {
+		t.Fatal(err)
+	}
+	hash := usuario.Password
+
+	usuario.Disable()
+	linea := usuario.ToString()
+	if linea != "2,U,users,ana,"+hash+","+disabledField {
+		t.Fatalf("ToString() de una cuenta deshabilitada = %q", linea)
+	}
+
+	leido := ParseUser(linea)
+	if leido == nil || !leido.Disabled() || leido.Password != hash {
+		t.Fatalf("ParseUser(%q) = %+v, se esperaba deshabilitada con el mismo hash", linea, leido)
+	}
+	if leido.CheckPassword("abc") {
+		t.Fatal("una cuenta deshabilitada no debe aceptar su contraseña")
+	}
+
+	leido.Enable()
+	if !leido.CheckPassword("abc") || leido.ToString() != "2,U,users,ana,"+hash {
+		t.Fatalf("después de Enable: %q", leido.ToString())
+	}
+}
+
+func TestParseUserRejectsOtherLines(t *testing.T) {
+	for _, linea := range []string{"1,G,root", "1,M,devs,ana", "2,U,users,ana", "2,U,users,ana,x,D,extra", ""} {
+		if usuario := ParseUser(linea); usuario != nil {
+			t.Errorf("ParseUser(%q) = %+v, se esperaba nil", linea, usuario)
+		}
+	}
+}
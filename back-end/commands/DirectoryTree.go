@@ -23,14 +23,14 @@ type DirectoryTreeService struct {
 	file                *os.File
 }
 
-// NewDirectoryTreeService inicializa un nuevo servicio de árbol de directorios
-func NewDirectoryTreeService() (*DirectoryTreeService, error) {
-	if !globals.IsLoggedIn() {
+// NewDirectoryTreeService inicializa un nuevo servicio de árbol de directorios para la partición de la sesión
+func NewDirectoryTreeService(session *globals.Session) (*DirectoryTreeService, error) {
+	if !session.IsLoggedIn() {
 		return nil, fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener la partición montada para el usuario logueado
-	idPartition := globals.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 	partitionSuperblock, _, partitionPath, err := globals.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener la partición montada: %w", err)
@@ -83,7 +83,7 @@ func (dts *DirectoryTreeService) GetDirectoryTree(path string) (*DirectoryTree,
 func (dts *DirectoryTreeService) buildDirectoryTree(inodeIndex int32, currentPath string) (*DirectoryTree, error) {
 	// Decodificar el inodo correspondiente al índice actual
 	inode := &structs.Inode{}
-	err := inode.Decode(dts.file, int64(dts.partitionSuperblock.S_inode_start+(inodeIndex*dts.partitionSuperblock.S_inode_size)))
+	err := inode.Decode(dts.file, int64(dts.partitionSuperblock.S_inode_start+(inodeIndex*dts.partitionSuperblock.S_inode_size)), dts.partitionSuperblock)
 	if err != nil {
 		return nil, fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
 	}
@@ -117,7 +117,7 @@ func (dts *DirectoryTreeService) buildDirectoryTree(inodeIndex int32, currentPat
 
 		// Decodificar el bloque de la carpeta
 		block := &structs.FolderBlock{}
-		err := block.Decode(dts.file, int64(dts.partitionSuperblock.S_block_start+(blockIndex*dts.partitionSuperblock.S_block_size)))
+		err := block.Decode(dts.file, int64(dts.partitionSuperblock.S_block_start+(blockIndex*dts.partitionSuperblock.S_block_size)), dts.partitionSuperblock)
 		if err != nil {
 			return nil, fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
 		}
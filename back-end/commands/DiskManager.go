@@ -88,6 +88,9 @@ func (dm *DiskManager) MountPartition(diskPath string, partitionName string) (*s
 	if err := mbr.Encode(file); err != nil {
 		return nil, fmt.Errorf("error serializando el MBR de vuelta al disco: %v", err)
 	}
+	if err := globals.SaveMountTable(); err != nil {
+		fmt.Println("Advertencia:", err)
+	}
 
 	fmt.Printf("Partición '%s' montada correctamente con ID: %s\n", partitionName, idPartition)
 	return partition, nil
@@ -114,8 +117,8 @@ func (dm *DiskManager) GetMountedPartition(id string) (*structs.Partition, strin
 }
 
 // PrintPartitionTree imprime el árbol de directorios de una partición montada
-func (dm *DiskManager) PrintPartitionTree(diskPath string, partitionName string, outputBuffer *bytes.Buffer) error {
-	tree, err := dm.GetPartitionTree(diskPath, partitionName)
+func (dm *DiskManager) PrintPartitionTree(session *globals.Session, diskPath string, partitionName string, outputBuffer *bytes.Buffer) error {
+	tree, err := dm.GetPartitionTree(session, diskPath, partitionName)
 	if err != nil {
 		return fmt.Errorf("error obteniendo el árbol de directorios: %v", err)
 	}
@@ -131,7 +134,7 @@ func (dm *DiskManager) PrintPartitionTree(diskPath string, partitionName string,
 }
 
 // GetPartitionTree genera el árbol de ficheros de una partición
-func (dm *DiskManager) GetPartitionTree(diskPath string, partitionName string) (*DirectoryTree, error) {
+func (dm *DiskManager) GetPartitionTree(session *globals.Session, diskPath string, partitionName string) (*DirectoryTree, error) {
 	_, exists := dm.disks[diskPath]
 	if !exists {
 		return nil, fmt.Errorf("disco '%s' no está cargado", diskPath)
@@ -144,7 +147,7 @@ func (dm *DiskManager) GetPartitionTree(diskPath string, partitionName string) (
 	}
 
 	// Usar el `DirectoryTreeService` para construir el árbol del sistema de archivos
-	treeService, err := NewDirectoryTreeService()
+	treeService, err := NewDirectoryTreeService(session)
 	if err != nil {
 		return nil, fmt.Errorf("error inicializando el servicio de árbol de directorios: %v", err)
 	}
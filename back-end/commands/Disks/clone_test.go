@@ -0,0 +1,99 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFormattedDisk crea un disco con las particiones indicadas (nombre y tamaño en K) y formatea la última
+func newFormattedDisk(t *testing.T, disk string, partitions ...[2]string) {
+	t.Helper()
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	for _, partition := range partitions {
+		if _, err := ParserFdisk([]string{"-size=" + partition[1], "-unit=K", "-path=" + disk, "-name=" + partition[0]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	formatPartition(t, disk, partitions[len(partitions)-1][0])
+}
+
+func TestClonePartRebasesFilesystem(t *testing.T) {
+	t.Setenv(globals.DataDirEnv, t.TempDir())
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "a.mia"), filepath.Join(dir, "b.mia")
+	newFormattedDisk(t, src, [2]string{"P1", "512"})
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + dst}); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"-size=128", "-name=Pad"}, {"-size=256", "-name=Chica"}, {"-size=512", "-name=PX"}} {
+		if _, err := ParserFdisk(append(args, "-unit=K", "-path="+dst)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ParserClonePart([]string{"-src=" + src, "-name=P1", "-dst=" + dst, "-dstname=Chica"}); err == nil {
+		t.Fatal("clonepart debería rechazar un destino menor que el origen")
+	}
+	if _, err := ParserClonePart([]string{"-src=" + src, "-name=P1", "-dst=" + dst, "-dstname=PX"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// PX inicia en otra posición: su superbloque debe apuntar a sus propios bitmaps e inodos
+	px := readPartition(t, dst, "PX")
+	if px.Part_start == readPartition(t, src, "P1").Part_start {
+		t.Fatal("la prueba necesita que el destino inicie en otra posición")
+	}
+	checkFilesystemAt(t, dst, px.Part_start)
+
+	// Una partición montada no se sobrescribe
+	mountPartition(t, dst, "PX")
+	if _, err := ParserClonePart([]string{"-src=" + src, "-name=P1", "-dst=" + dst, "-dstname=PX"}); err == nil || !strings.Contains(err.Error(), "montada") {
+		t.Fatalf("clonepart sobre una partición montada = %v", err)
+	}
+}
+
+func TestCloneDiskResetsIdentity(t *testing.T) {
+	t.Setenv(globals.DataDirEnv, t.TempDir())
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "a.mia"), filepath.Join(dir, "copia.mia")
+	newFormattedDisk(t, src, [2]string{"P1", "512"})
+
+	if _, err := ParserCloneDisk([]string{"-src=" + src, "-dst=" + dst}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserCloneDisk([]string{"-src=" + src, "-dst=" + dst}); err == nil {
+		t.Fatal("clonedisk no debería sobrescribir un disco existente")
+	}
+
+	var original, copied structures.MBR
+	for _, disk := range []struct {
+		path string
+		mbr  *structures.MBR
+	}{{src, &original}, {dst, &copied}} {
+		file, err := os.Open(disk.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := disk.mbr.Decode(file); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+	if copied.MbrDiskSignature == original.MbrDiskSignature {
+		t.Error("el disco clonado conserva la firma del original")
+	}
+	p1, _ := copied.GetPartitionByName("P1")
+	if id := strings.Trim(string(p1.Part_id[:]), "\x00 "); id != "" {
+		t.Errorf("el disco clonado hereda el ID de montaje %q", id)
+	}
+	checkFilesystemAt(t, dst, p1.Part_start)
+
+	// La copia se puede montar junto al original
+	mountPartition(t, dst, "P1")
+}
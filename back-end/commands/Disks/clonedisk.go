@@ -0,0 +1,96 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CloneDisk estructura que representa el comando clonedisk
+type CloneDisk struct {
+	src string // Ruta del disco de origen
+	dst string // Ruta del disco nuevo
+}
+
+// ParserCloneDisk parsea el comando clonedisk y devuelve los mensajes de la copia
+func ParserCloneDisk(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &CloneDisk{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"src", "dst"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("src", "dst"); err != nil {
+		return "", err
+	}
+	cmd.src = params.Get("src")
+	cmd.dst = params.Get("dst")
+
+	err = commandCloneDisk(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandCloneDisk copia un disco completo a un archivo nuevo; los offsets no cambian
+func commandCloneDisk(clone *CloneDisk, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================== CLONEDISK ========================")
+
+	srcFile, err := os.Open(clone.src)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco de origen %s: %v", clone.src, err)
+	}
+	defer srcFile.Close()
+
+	info, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("error leyendo el disco de origen: %v", err)
+	}
+
+	// Validar el origen antes de crear el destino
+	var mbr structures.MBR
+	if err := mbr.Decode(srcFile); err != nil {
+		return fmt.Errorf("error deserializando el MBR de origen: %v", err)
+	}
+
+	// No sobrescribir discos existentes: rmdisk es la forma explícita de descartarlos
+	if _, err := os.Stat(clone.dst); err == nil {
+		return fmt.Errorf("el disco destino %s ya existe", clone.dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(clone.dst), os.ModePerm); err != nil {
+		return fmt.Errorf("error creando directorios: %v", err)
+	}
+	if err := utils.RemoveSnapshots(clone.dst); err != nil {
+		return err
+	}
+
+	dstFile, err := os.OpenFile(clone.dst, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("error creando el disco destino %s: %v", clone.dst, err)
+	}
+	defer dstFile.Close()
+
+	err = structures.CopySparse(srcFile, dstFile, info.Size())
+	if err != nil {
+		return fmt.Errorf("error copiando el disco: %v", err)
+	}
+
+	// El disco nuevo no hereda la identidad ni las particiones montadas del original
+	if err := mbr.Decode(dstFile); err != nil {
+		return fmt.Errorf("error deserializando el MBR copiado: %v", err)
+	}
+	if err := mbr.ResetIdentity(dstFile); err != nil {
+		return fmt.Errorf("error actualizando el disco clonado: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Disco %s clonado en %s (%d bytes)\n", clone.src, clone.dst, info.Size())
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
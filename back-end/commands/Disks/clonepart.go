@@ -0,0 +1,124 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ClonePart estructura que representa el comando clonepart
+type ClonePart struct {
+	src     string // Ruta del disco de origen
+	name    string // Nombre de la partición de origen
+	dst     string // Ruta del disco destino
+	dstName string // Nombre de la partición destino
+}
+
+// ParserClonePart parsea el comando clonepart y devuelve los mensajes de la copia
+func ParserClonePart(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &ClonePart{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"src", "name", "dst", "dstname"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("src", "name", "dst", "dstname"); err != nil {
+		return "", err
+	}
+	cmd.src = params.Get("src")
+	cmd.name = params.Get("name")
+	cmd.dst = params.Get("dst")
+	cmd.dstName = params.Get("dstname")
+
+	err = commandClonePart(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandClonePart copia una partición sobre otra, del mismo disco o de otro
+func commandClonePart(clone *ClonePart, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================== CLONEPART ========================")
+
+	srcFile, err := os.Open(clone.src)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco de origen %s: %v", clone.src, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(clone.dst, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco destino %s: %v", clone.dst, err)
+	}
+	defer dstFile.Close()
+
+	src, err := findClonePartition(srcFile, clone.name)
+	if err != nil {
+		return fmt.Errorf("origen: %v", err)
+	}
+	dst, err := findClonePartition(dstFile, clone.dstName)
+	if err != nil {
+		return fmt.Errorf("destino: %v", err)
+	}
+
+	if sameDisk(clone.src, clone.dst) && src.Part_start == dst.Part_start {
+		return errors.New("la partición de origen y la de destino son la misma")
+	}
+
+	// El contenido de una partición montada no puede cambiar por debajo de sus usuarios
+	dstID := strings.Trim(string(dst.Part_id[:]), "\x00 ")
+	if _, mounted := globals.MountedPartitions[dstID]; mounted {
+		return fmt.Errorf("la partición destino '%s' está montada con ID %s; desmóntela primero", clone.dstName, dstID)
+	}
+
+	err = structures.ClonePartition(srcFile, src, dstFile, dst)
+	if err != nil {
+		return fmt.Errorf("error clonando la partición: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Partición '%s' de %s clonada en '%s' de %s\n", clone.name, clone.src, clone.dstName, clone.dst)
+	fmt.Fprintf(outputBuffer, "Bytes copiados: %d\n", src.Part_size)
+	if delta := dst.Part_start - src.Part_start; delta != 0 {
+		fmt.Fprintf(outputBuffer, "Punteros del sistema de archivos desplazados %d bytes\n", delta)
+	}
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
+
+// findClonePartition busca una partición primaria o lógica por nombre; las extendidas no se clonan
+func findClonePartition(file *os.File, name string) (*structures.Partition, error) {
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		return nil, fmt.Errorf("error deserializando el MBR: %v", err)
+	}
+
+	partition, _ := mbr.GetPartitionByName(name)
+	if partition == nil {
+		ebr, _, _, err := findLogicalPartition(file, &mbr, name)
+		if err != nil {
+			return nil, fmt.Errorf("la partición '%s' no existe en el disco", name)
+		}
+		return ebr.ToPartition(), nil
+	}
+
+	if partition.Part_type[0] == 'E' {
+		return nil, fmt.Errorf("la partición '%s' es extendida; clone sus particiones lógicas", name)
+	}
+	return partition, nil
+}
+
+// sameDisk indica si dos paths corresponden al mismo archivo de disco
+func sameDisk(a string, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
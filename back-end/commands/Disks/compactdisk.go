@@ -0,0 +1,101 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CompactDisk estructura que representa el comando compactdisk
+type CompactDisk struct {
+	path string // Ruta del disco a compactar
+}
+
+// ParserCompactDisk parsea el comando compactdisk y devuelve los mensajes de la compactación
+func ParserCompactDisk(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &CompactDisk{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path"); err != nil {
+		return "", err
+	}
+	cmd.path = params.Get("path")
+
+	err = commandCompactDisk(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandCompactDisk mueve las particiones hacia el inicio del disco para juntar el espacio libre al final
+func commandCompactDisk(compact *CompactDisk, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================== COMPACTDISK ========================")
+
+	file, err := os.OpenFile(compact.path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo el archivo del disco en el path: %s: %v", compact.path, err)
+	}
+	defer file.Close()
+
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		return fmt.Errorf("error al deserializar el MBR: %v", err)
+	}
+
+	freeBefore, _ := mbr.CalculateAvailableSpace()
+
+	// Verificar antes de mover nada que todas las particiones que se desplazan se pueden mover;
+	// si una fallara a medio camino, las anteriores ya estarían movidas sin actualizar el MBR
+	nextFree := int32(mbr.HeaderSize())
+	for _, index := range mbr.UsedPartitions() {
+		partition := &mbr.MbrPartitions[index]
+		if partition.Part_start > nextFree {
+			if err := partition.CheckMovable(file); err != nil {
+				return fmt.Errorf("no se puede compactar el disco: %v", err)
+			}
+		}
+		nextFree += partition.Part_size
+	}
+
+	// Recorrer las particiones en orden: cada una se mueve hacia atrás, así que nunca pisa a la siguiente
+	moved := 0
+	nextFree = int32(mbr.HeaderSize())
+	for _, index := range mbr.UsedPartitions() {
+		partition := &mbr.MbrPartitions[index]
+		oldStart := partition.Part_start
+		if oldStart > nextFree {
+			if err := mbr.MovePartition(file, index, nextFree); err != nil {
+				// Guardar las particiones que sí se movieron para que el MBR coincida con el disco
+				if moved > 0 {
+					mbr.Encode(file)
+				}
+				return fmt.Errorf("error moviendo la partición '%s': %v", strings.Trim(string(partition.Part_name[:]), "\x00 "), err)
+			}
+			fmt.Fprintf(outputBuffer, "Partición '%s' desplazada de %d a %d\n",
+				strings.Trim(string(partition.Part_name[:]), "\x00 "), oldStart, partition.Part_start)
+			moved++
+		}
+		nextFree = partition.Part_start + partition.Part_size
+	}
+
+	if moved == 0 {
+		fmt.Fprintln(outputBuffer, "Las particiones ya están contiguas; no hay nada que compactar.")
+	} else if err := mbr.Encode(file); err != nil {
+		return fmt.Errorf("error al actualizar el MBR en el disco: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Espacio libre total: %d bytes\n", freeBefore)
+	fmt.Fprintf(outputBuffer, "Espacio libre contiguo al final del disco: %d bytes\n", mbr.UsableEnd()-nextFree)
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompactDiskClosesGaps(t *testing.T) {
+	t.Setenv(globals.DataDirEnv, t.TempDir())
+	disk := filepath.Join(t.TempDir(), "a.mia")
+	if _, err := ParserMkdisk([]string{"-size=3", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-size=256", "-unit=K", "-name=P1"},
+		{"-size=512", "-unit=K", "-name=P2"},
+		{"-size=256", "-unit=K", "-name=P3"},
+		{"-size=768", "-unit=K", "-name=E1", "-type=E"},
+		{"-size=256", "-unit=K", "-name=L1", "-type=L"},
+		{"-size=256", "-unit=K", "-name=L2", "-type=L"},
+	} {
+		if _, err := ParserFdisk(append(args, "-path="+disk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	formatPartition(t, disk, "P2")
+	formatPartition(t, disk, "L2")
+	for _, name := range []string{"P1", "P3"} {
+		if _, err := ParserFdisk([]string{"-delete=full", "-path=" + disk, "-name=" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ParserCompactDisk([]string{"-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		t.Fatal(err)
+	}
+
+	// Las particiones quedan contiguas desde el inicio y el espacio libre queda al final
+	p2, _ := mbr.GetPartitionByName("P2")
+	e1, _ := mbr.GetPartitionByName("E1")
+	if p2.Part_start != int32(mbr.HeaderSize()) || e1.Part_start != p2.Part_start+p2.Part_size {
+		t.Fatalf("después de compactar P2 inicia en %d y E1 en %d", p2.Part_start, e1.Part_start)
+	}
+	if gaps := mbr.FreeGaps(); len(gaps) != 1 || gaps[0].Start != e1.Part_start+e1.Part_size {
+		t.Fatalf("huecos libres después de compactar: %+v", gaps)
+	}
+	checkFilesystemAt(t, disk, p2.Part_start)
+
+	// La cadena de EBRs se movió con la extendida y la lógica conserva su sistema de archivos
+	l2, _, err := structures.FindEBRByName(e1.Part_start, "L2", file)
+	if err != nil || l2 == nil {
+		t.Fatalf("no se encontró L2 en la extendida movida: %v", err)
+	}
+	if l2.Ebr_start < e1.Part_start || l2.Ebr_start+l2.Ebr_size > e1.Part_start+e1.Part_size {
+		t.Fatalf("L2 [%d, %d) quedó fuera de E1 [%d, %d)", l2.Ebr_start, l2.Ebr_start+l2.Ebr_size, e1.Part_start, e1.Part_start+e1.Part_size)
+	}
+	checkFilesystemAt(t, disk, l2.DataStart())
+
+	// Un disco ya compacto no cambia
+	out, err := ParserCompactDisk([]string{"-path=" + disk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readPartition(t, disk, "P2").Part_start; got != p2.Part_start {
+		t.Fatalf("compactar un disco contiguo movió P2 a %d:\n%s", got, out)
+	}
+}
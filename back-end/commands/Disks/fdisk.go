@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -16,13 +15,15 @@ import (
 // Fdisk estructura que representa el comando fdisk con sus parámetros
 type Fdisk struct {
 	size   int    // Tamaño de la partición (solo para crear particiones)
-	unit   string // Unidad de medida del tamaño (K o M)
+	unit   string // Unidad de medida del tamaño (B, K, M o G)
 	fit    string // Tipo de ajuste (BF, FF, WF)
 	path   string // Ruta del archivo del disco
 	typ    string // Tipo de partición (P, E, L)
 	name   string // Nombre de la partición
 	add    int    // Espacio a agregar o quitar (solo para agregar o quitar espacio)
 	delete string // Método de eliminación (fast o full)
+	move   bool   // Indica si se debe mover la partición
+	start  int    // Nueva posición de inicio (solo para mover particiones)
 }
 
 // ParserFdisk parsea el comando fdisk y devuelve los mensajes generados
@@ -30,104 +31,104 @@ func ParserFdisk(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &Fdisk{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-size=\d+|-unit=[bBkKmM]|-fit=[bBfFwfW]{2}|-path="[^"]+"|-path=[^\s]+|-type=[pPeElL]|-name="[^"]+"|-name=[^\s]+|-add=[+-]?\d+|-delete=(fast|full)`)
-	matches := re.FindAllString(args, -1)
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"size", "unit", "fit", "path", "type", "name", "add", "delete", "start"},
+		Flags:  []string{"move"},
+	})
+	if err != nil {
+		return "", err
+	}
 
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Names() {
+		value := params.Get(key)
 
 		switch key {
-		case "-size":
+		case "size":
 			size, err := strconv.Atoi(value)
 			if err != nil || size <= 0 {
 				return "", errors.New("el tamaño debe ser un número entero positivo")
 			}
 			cmd.size = size
-		case "-unit":
+		case "unit":
 			value = strings.ToUpper(value)
-			if value != "B" && value != "K" && value != "M" {
-				return "", errors.New("la unidad debe ser B, K o M")
+			if value != "B" && value != "K" && value != "M" && value != "G" {
+				return "", errors.New("la unidad debe ser B, K, M o G")
 			}
 			cmd.unit = value
-		case "-fit":
+		case "fit":
 			value = strings.ToUpper(value)
 			if value != "BF" && value != "FF" && value != "WF" {
 				return "", errors.New("el ajuste debe ser BF, FF o WF")
 			}
 			cmd.fit = value
-		case "-path":
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
-			}
+		case "path":
 			cmd.path = value
-		case "-type":
+		case "type":
 			value = strings.ToUpper(value)
 			if value != "P" && value != "E" && value != "L" {
 				return "", errors.New("el tipo debe ser P, E o L")
 			}
 			cmd.typ = value
-		case "-name":
-			if value == "" {
-				return "", errors.New("el nombre no puede estar vacío")
-			}
+		case "name":
 			cmd.name = value
-		case "-add":
+		case "add":
 			add, err := strconv.Atoi(value)
 			if err != nil {
 				return "", errors.New("el valor de -add debe ser un número entero")
 			}
 			cmd.add = add
-		case "-delete":
+		case "delete":
 			value = strings.ToLower(value)
 			if value != "fast" && value != "full" {
 				return "", errors.New("el valor de -delete debe ser 'fast' o 'full'")
 			}
 			cmd.delete = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		case "start":
+			start, err := strconv.Atoi(value)
+			if err != nil || start < 0 {
+				return "", errors.New("el valor de -start debe ser un número entero no negativo")
+			}
+			cmd.start = start
+		case "move":
+			// -move es un parámetro sin valor
+			cmd.move = true
 		}
 	}
 
 	// Identificar el tipo de operación: add, delete o crear partición
 	if cmd.delete != "" {
 		// Operación de eliminación de partición
-		if cmd.path == "" {
-			return "", errors.New("falta el parámetro requerido: -path")
-		}
-		if cmd.name == "" {
-			return "", errors.New("falta el parámetro requerido: -name")
+		if err := params.Require("path", "name"); err != nil {
+			return "", err
 		}
 		return processDeletePartition(cmd, &outputBuffer)
 	}
 
+	if cmd.move {
+		// Operación de mover la partición
+		if err := params.Require("path", "name", "start"); err != nil {
+			return "", err
+		}
+		if cmd.unit == "" {
+			cmd.unit = "B" // La posición se indica en bytes por defecto
+		}
+		return processMovePartition(cmd, &outputBuffer)
+	}
+
 	if cmd.add != 0 {
 		// Operación de agregar/quitar espacio
-		if cmd.path == "" {
-			return "", errors.New("falta el parámetro requerido: -path")
+		if err := params.Require("path", "name"); err != nil {
+			return "", err
 		}
-		if cmd.name == "" {
-			return "", errors.New("falta el parámetro requerido: -name")
+		if cmd.unit == "" {
+			cmd.unit = "K" // Valor por defecto
 		}
 		return processAddPartition(cmd, &outputBuffer)
 	}
 
 	// Operación de crear partición (requiere -size, -path, -name)
-	if cmd.size == 0 {
-		return "", errors.New("faltan parámetros requeridos: -size")
-	}
-	if cmd.path == "" {
-		return "", errors.New("falta el parámetro requerido: -path")
-	}
-	if cmd.name == "" {
-		return "", errors.New("falta el parámetro requerido: -name")
+	if err := params.Require("size", "path", "name"); err != nil {
+		return "", err
 	}
 
 	if cmd.unit == "" {
@@ -143,7 +144,7 @@ func ParserFdisk(tokens []string) (string, error) {
 	}
 
 	// Ejecutar el comando fdisk para crear la partición
-	err := commandFdisk(cmd, &outputBuffer)
+	err = commandFdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al crear la partición: %v", err)
 	}
@@ -173,7 +174,8 @@ func processDeletePartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, err
 	// Buscar la partición por nombre y eliminarla
 	partition, _ := mbr.GetPartitionByName(cmd.name)
 	if partition == nil {
-		return "", fmt.Errorf("la partición '%s' no existe", cmd.name)
+		// Si no está en la tabla, buscarla entre las particiones lógicas
+		return deleteLogicalPartition(file, &mbr, cmd, outputBuffer)
 	}
 
 	// Verificar si es extendida para eliminar particiones lógicas
@@ -220,16 +222,33 @@ func processAddPartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, error)
 		return "", fmt.Errorf("error al deserializar el MBR: %v", err)
 	}
 
+	// Convertir cmd.add a bytes según la unidad especificada
+	addBytes, err := utils.ConvertToBytes(cmd.add, cmd.unit)
+	if err != nil {
+		return "", fmt.Errorf("error al convertir las unidades de -add: %v", err)
+	}
+
 	// Buscar la partición por nombre
 	partition, _ := mbr.GetPartitionByName(cmd.name)
 	if partition == nil {
-		return "", fmt.Errorf("la partición '%s' no existe", cmd.name)
+		// Si no está en la tabla, buscarla entre las particiones lógicas
+		return resizeLogicalPartition(file, &mbr, cmd, int32(addBytes), outputBuffer)
 	}
 
-	// Convertir cmd.add a bytes según la unidad especificada
-	addBytes, err := utils.ConvertToBytes(cmd.add, cmd.unit)
-	if err != nil {
-		return "", fmt.Errorf("error al convertir las unidades de -add: %v", err)
+	// En GPT el tamaño nuevo se redondea a LBAs completas, igual que al crear la partición
+	if newSize := partition.Part_size + int32(addBytes); newSize > 0 {
+		addBytes = int(mbr.AlignPartitionSize(newSize) - partition.Part_size)
+	}
+
+	// Al reducir una extendida, sus particiones lógicas deben seguir cabiendo
+	if partition.Part_type[0] == 'E' && addBytes < 0 {
+		lastEBR, err := structures.FindLastEBR(partition.Part_start, file)
+		if err != nil {
+			return "", fmt.Errorf("error al buscar el último EBR: %v", err)
+		}
+		if lastEBR.Ebr_start+lastEBR.Ebr_size > partition.Part_start+partition.Part_size+int32(addBytes) {
+			return "", errors.New("no se puede reducir la partición extendida por debajo del final de sus particiones lógicas")
+		}
 	}
 
 	// Calcular espacio disponible si se está agregando espacio
@@ -265,6 +284,136 @@ func processAddPartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, error)
 	return outputBuffer.String(), nil
 }
 
+// processMovePartition mueve una partición, con su sistema de archivos o sus particiones lógicas, a otra posición del disco
+func processMovePartition(cmd *Fdisk, outputBuffer *bytes.Buffer) (string, error) {
+	fmt.Fprintf(outputBuffer, "========================== MOVE ==========================\n")
+
+	// Abrir el archivo del disco
+	file, err := os.OpenFile(cmd.path, os.O_RDWR, 0644)
+	if err != nil {
+		return "", fmt.Errorf("error abriendo el archivo del disco: %v", err)
+	}
+	defer file.Close()
+
+	// Leer el MBR del archivo
+	var mbr structures.MBR
+	err = mbr.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("error al deserializar el MBR: %v", err)
+	}
+
+	// Convertir la posición a bytes según la unidad especificada
+	startBytes, err := utils.ConvertToBytes(cmd.start, cmd.unit)
+	if err != nil {
+		return "", fmt.Errorf("error al convertir las unidades de -start: %v", err)
+	}
+
+	// Buscar la partición por nombre
+	partition, index := mbr.GetPartitionByName(cmd.name)
+	if partition == nil {
+		if _, _, _, err := findLogicalPartition(file, &mbr, cmd.name); err == nil {
+			return "", errors.New("las particiones lógicas se mueven junto con su partición extendida")
+		}
+		return "", fmt.Errorf("la partición '%s' no existe", cmd.name)
+	}
+
+	oldStart := partition.Part_start
+	fmt.Fprintf(outputBuffer, "Moviendo partición '%s' de %d a %d...\n", cmd.name, oldStart, startBytes)
+
+	err = mbr.MovePartition(file, index, int32(startBytes))
+	if err != nil {
+		return "", fmt.Errorf("error al mover la partición: %v", err)
+	}
+
+	// Actualizar el MBR en el archivo después del movimiento
+	err = mbr.Encode(file)
+	if err != nil {
+		return "", fmt.Errorf("error al actualizar el MBR en el disco: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Partición '%s' movida exitosamente.\n", cmd.name)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	fmt.Fprintf(outputBuffer, "========================== PARTICIONES ==========================\n")
+	printPartitions(&mbr, outputBuffer)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	return outputBuffer.String(), nil
+}
+
+// findLogicalPartition busca una partición lógica por nombre dentro de la partición extendida del disco
+func findLogicalPartition(file *os.File, mbr *structures.MBR, name string) (*structures.EBR, *structures.EBR, *structures.Partition, error) {
+	for i := range mbr.MbrPartitions {
+		if mbr.MbrPartitions[i].Part_type[0] != 'E' {
+			continue
+		}
+		extendedPartition := &mbr.MbrPartitions[i]
+		ebr, previous, err := structures.FindEBRByName(extendedPartition.Part_start, name, file)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error al recorrer los EBRs: %v", err)
+		}
+		if ebr != nil {
+			return ebr, previous, extendedPartition, nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("la partición '%s' no existe", name)
+}
+
+// deleteLogicalPartition elimina una partición lógica desenlazando su EBR de la cadena
+func deleteLogicalPartition(file *os.File, mbr *structures.MBR, cmd *Fdisk, outputBuffer *bytes.Buffer) (string, error) {
+	ebr, previous, _, err := findLogicalPartition(file, mbr, cmd.name)
+	if err != nil {
+		return "", err
+	}
+
+	err = ebr.Delete(cmd.delete, previous, file)
+	if err != nil {
+		return "", fmt.Errorf("error al eliminar la partición lógica: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Partición lógica '%s' eliminada exitosamente.\n", cmd.name)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	fmt.Fprintf(outputBuffer, "========================== PARTICIONES ==========================\n")
+	printPartitions(mbr, outputBuffer)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	return outputBuffer.String(), nil
+}
+
+// resizeLogicalPartition cambia el tamaño de una partición lógica sin invadir el siguiente EBR
+func resizeLogicalPartition(file *os.File, mbr *structures.MBR, cmd *Fdisk, addBytes int32, outputBuffer *bytes.Buffer) (string, error) {
+	ebr, _, extendedPartition, err := findLogicalPartition(file, mbr, cmd.name)
+	if err != nil {
+		return "", err
+	}
+
+	// El límite es el siguiente EBR o, si es la última, el final de la extendida
+	limit := extendedPartition.Part_start + extendedPartition.Part_size
+	if ebr.Ebr_next != -1 {
+		limit = ebr.Ebr_next
+	}
+
+	err = ebr.ModifySize(addBytes, limit)
+	if err != nil {
+		return "", fmt.Errorf("error al modificar el tamaño de la partición lógica: %v", err)
+	}
+
+	err = ebr.Encode(file, int64(ebr.Ebr_start))
+	if err != nil {
+		return "", fmt.Errorf("error al actualizar el EBR en el disco: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Espacio en la partición lógica '%s' modificado exitosamente. Nuevo tamaño: %d bytes.\n", cmd.name, ebr.Ebr_size)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	fmt.Fprintf(outputBuffer, "========================== PARTICIONES ==========================\n")
+	printPartitions(mbr, outputBuffer)
+	fmt.Fprintf(outputBuffer, "===========================================================\n")
+
+	return outputBuffer.String(), nil
+}
+
 // printPartitions imprime las particiones actuales del MBR
 func printPartitions(mbr *structures.MBR, outputBuffer *bytes.Buffer) {
 	for i, partition := range mbr.MbrPartitions {
@@ -277,7 +426,7 @@ func printPartitions(mbr *structures.MBR, outputBuffer *bytes.Buffer) {
 				partition.Part_type[0],
 				partition.Part_status[0],
 			)
-		} else {
+		} else if !mbr.IsGPT() { // Las 128 entradas libres de GPT no se listan
 			fmt.Fprintf(outputBuffer, "Partición %d: (Vacía)\n", i+1)
 		}
 	}
@@ -370,6 +519,11 @@ func createExtendedPartition(file *os.File, fdisk *Fdisk, sizeBytes int, outputB
 		return fmt.Errorf("error al deserializar el MBR: %v", err)
 	}
 
+	// Los discos GPT solo tienen entradas primarias
+	if mbr.IsGPT() {
+		return errors.New("los discos GPT no usan particiones extendidas ni lógicas")
+	}
+
 	// Verificar si ya existe una partición extendida
 	if mbr.HasExtendedPartition() {
 		return errors.New("ya existe una partición extendida en este disco")
@@ -408,6 +562,11 @@ func createLogicalPartition(file *os.File, fdisk *Fdisk, sizeBytes int, outputBu
 		return fmt.Errorf("error al deserializar el MBR: %v", err)
 	}
 
+	// Los discos GPT solo tienen entradas primarias
+	if mbr.IsGPT() {
+		return errors.New("los discos GPT no usan particiones extendidas ni lógicas")
+	}
+
 	// Verificar si existe una partición extendida utilizando HasExtendedPartition
 	if !mbr.HasExtendedPartition() {
 		return errors.New("no se encontró una partición extendida en el disco")
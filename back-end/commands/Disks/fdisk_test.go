@@ -0,0 +1,288 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readPartition decodifica la tabla del disco y devuelve la partición indicada
+func readPartition(t *testing.T, disk, name string) *structures.Partition {
+	t.Helper()
+	file, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		t.Fatal(err)
+	}
+	partition, _ := mbr.GetPartitionByName(name)
+	if partition == nil {
+		t.Fatalf("no existe la partición %s", name)
+	}
+	return partition
+}
+
+func TestFdiskAddAlignsGPTPartitions(t *testing.T) {
+	disk := filepath.Join(t.TempDir(), "gpt.mia")
+	if _, err := ParserMkdisk([]string{"-size=1", "-unit=M", "-scheme=GPT", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserFdisk([]string{"-size=1000", "-unit=B", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPartition(t, disk, "P1").Part_size; got != 1024 {
+		t.Fatalf("fdisk creó P1 con %d bytes, se esperaban 1024", got)
+	}
+
+	// Crecer y reducir redondean el tamaño nuevo a LBAs completas, igual que al crear
+	steps := []struct {
+		add  string
+		want int32
+	}{
+		{"100", 1536},
+		{"-600", 1024},
+		{"1", 1536},
+	}
+	for _, step := range steps {
+		out, err := ParserFdisk([]string{"-add=" + step.add, "-unit=B", "-path=" + disk, "-name=P1"})
+		if err != nil {
+			t.Fatalf("fdisk -add=%s: %v", step.add, err)
+		}
+		if got := readPartition(t, disk, "P1").Part_size; got != step.want {
+			t.Fatalf("fdisk -add=%s dejó P1 con %d bytes, se esperaban %d", step.add, got, step.want)
+		}
+		// La salida muestra el mismo tamaño que quedó en la tabla GPT
+		if !strings.Contains(out, fmt.Sprintf("Tamaño: %d bytes", step.want)) {
+			t.Fatalf("la salida de fdisk -add=%s no coincide con la tabla:\n%s", step.add, out)
+		}
+	}
+}
+
+// mountPartition monta una partición del disco, primaria o lógica, y la desmonta al terminar la prueba.
+// Devuelve el ID de montaje.
+func mountPartition(t *testing.T, disk, name string, options ...string) string {
+	t.Helper()
+	mounted := make(map[string]bool)
+	for id := range globals.MountedPartitions {
+		mounted[id] = true
+	}
+	if _, err := ParserMount(append([]string{"-path=" + disk, "-name=" + name}, options...)); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	for mountID := range globals.MountedPartitions {
+		if !mounted[mountID] {
+			id = mountID
+		}
+	}
+	if id == "" {
+		t.Fatalf("la partición %s no quedó montada", name)
+	}
+	t.Cleanup(func() { ParserUnmount([]string{"-id=" + id}) })
+	return id
+}
+
+// formatPartition monta y formatea una partición del disco. Devuelve el ID de montaje.
+func formatPartition(t *testing.T, disk, name string) string {
+	t.Helper()
+	id := mountPartition(t, disk, name)
+	if _, err := ParserMkfs([]string{"-id=" + id, "-type=full"}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// checkFilesystemAt verifica que el sistema de archivos que inicia en start apunte a sí mismo y
+// que su users.txt se pueda leer
+func checkFilesystemAt(t *testing.T, disk string, start int32) {
+	t.Helper()
+	file, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	sb := &structures.Superblock{}
+	if err := sb.Decode(file, int64(start)); err != nil {
+		t.Fatalf("no hay un superbloque válido en %d: %v", start, err)
+	}
+	if sb.S_bm_inode_start <= start || sb.S_inode_start <= start || sb.S_block_start <= start {
+		t.Fatalf("el superbloque en %d apunta fuera de la partición: inodos en %d, bloques en %d", start, sb.S_inode_start, sb.S_block_start)
+	}
+	if err := sb.VerifyBitmaps(file); err != nil {
+		t.Fatalf("bitmaps del sistema de archivos en %d: %v", start, err)
+	}
+	if text := readUsersText(t, file, start); !strings.Contains(text, "1,U,root,root,") {
+		t.Fatalf("users.txt del sistema de archivos en %d = %q", start, text)
+	}
+}
+
+func TestFdiskMoveFormattedPartitionOverOwnRange(t *testing.T) {
+	t.Setenv(globals.DataDirEnv, t.TempDir())
+	disk := filepath.Join(t.TempDir(), "a.mia")
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserFdisk([]string{"-size=256", "-unit=K", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserFdisk([]string{"-size=512", "-unit=K", "-path=" + disk, "-name=P2"}); err != nil {
+		t.Fatal(err)
+	}
+	id := formatPartition(t, disk, "P2")
+	if _, err := ParserFdisk([]string{"-delete=full", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// El destino cae dentro del rango actual de la partición
+	oldStart := readPartition(t, disk, "P2").Part_start
+	newStart := oldStart - 128*1024
+	if _, err := ParserFdisk([]string{"-move", "-start=" + fmt.Sprint(newStart), "-unit=B", "-path=" + disk, "-name=P2"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readPartition(t, disk, "P2").Part_start; got != newStart {
+		t.Fatalf("P2 inicia en %d, se esperaba %d", got, newStart)
+	}
+	checkFilesystemAt(t, disk, newStart)
+
+	// La partición montada sigue funcionando en su nueva posición
+	sb, _, _, err := globals.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.S_inode_start < newStart || sb.S_inode_start >= oldStart {
+		t.Fatalf("el superbloque montado apunta a los inodos en %d", sb.S_inode_start)
+	}
+
+	// Un destino que pisa a otra partición se rechaza
+	if _, err := ParserFdisk([]string{"-size=256", "-unit=K", "-path=" + disk, "-name=P3"}); err != nil {
+		t.Fatal(err)
+	}
+	p3 := readPartition(t, disk, "P3")
+	if _, err := ParserFdisk([]string{"-move", "-start=" + fmt.Sprint(p3.Part_start+1024), "-unit=B", "-path=" + disk, "-name=P2"}); err == nil {
+		t.Fatal("fdisk -move debería rechazar un destino que se solapa con otra partición")
+	}
+	checkFilesystemAt(t, disk, newStart)
+}
+
+// readLogical devuelve el EBR de la partición lógica, o nil si ya no está en la cadena
+func readLogical(t *testing.T, disk, name string) *structures.EBR {
+	t.Helper()
+	file, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		t.Fatal(err)
+	}
+	ebr, _, _, err := findLogicalPartition(file, &mbr, name)
+	if err != nil {
+		return nil
+	}
+	return ebr
+}
+
+// byteAt lee un byte del disco
+func byteAt(t *testing.T, disk string, offset int32) byte {
+	t.Helper()
+	file, err := os.Open(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	data := make([]byte, 1)
+	if _, err := file.ReadAt(data, int64(offset)); err != nil {
+		t.Fatal(err)
+	}
+	return data[0]
+}
+
+func TestFdiskLogicalDeleteAndResize(t *testing.T) {
+	disk := filepath.Join(t.TempDir(), "a.mia")
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-size=1", "-unit=M", "-name=E1", "-type=E"},
+		{"-size=256", "-unit=K", "-name=L1", "-type=L"},
+		{"-size=256", "-unit=K", "-name=L2", "-type=L"},
+		{"-size=128", "-unit=K", "-name=L3", "-type=L"},
+	} {
+		if _, err := ParserFdisk(append(args, "-path="+disk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// L1 está pegada a L2: puede reducirse, pero no crecer
+	if _, err := ParserFdisk([]string{"-add=1", "-unit=K", "-path=" + disk, "-name=L1"}); err == nil {
+		t.Fatal("fdisk -add no debería invadir el EBR de la siguiente lógica")
+	}
+	if _, err := ParserFdisk([]string{"-add=-64", "-unit=K", "-path=" + disk, "-name=L1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLogical(t, disk, "L1").Ebr_size; got != 192*1024 {
+		t.Fatalf("L1 mide %d bytes después de reducirla, se esperaban %d", got, 192*1024)
+	}
+	// La última lógica crece hasta el final de la extendida
+	if _, err := ParserFdisk([]string{"-add=64", "-unit=K", "-path=" + disk, "-name=L3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserFdisk([]string{"-add=1", "-unit=M", "-path=" + disk, "-name=L3"}); err == nil {
+		t.Fatal("fdisk -add no debería pasar del final de la extendida")
+	}
+
+	// Marcar el contenido de L2 y L3 para ver qué borra cada tipo de eliminación
+	l2, l3 := readLogical(t, disk, "L2"), readLogical(t, disk, "L3")
+	file, err := os.OpenFile(disk, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []int32{l2.DataStart() + 100, l3.DataStart() + 100} {
+		if _, err := file.WriteAt([]byte{0xAA}, int64(offset)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	if _, err := ParserFdisk([]string{"-delete=full", "-path=" + disk, "-name=L2"}); err != nil {
+		t.Fatal(err)
+	}
+	if readLogical(t, disk, "L2") != nil || byteAt(t, disk, l2.DataStart()+100) != 0 {
+		t.Fatal("fdisk -delete=full debería desenlazar L2 y borrar su contenido")
+	}
+	if l1 := readLogical(t, disk, "L1"); l1 == nil || l1.Ebr_next != l3.Ebr_start {
+		t.Fatalf("L1 debería apuntar a L3 (%d) después de borrar L2: %+v", l3.Ebr_start, l1)
+	}
+
+	if _, err := ParserFdisk([]string{"-delete=fast", "-path=" + disk, "-name=L3"}); err != nil {
+		t.Fatal(err)
+	}
+	if readLogical(t, disk, "L3") != nil || byteAt(t, disk, l3.DataStart()+100) != 0xAA {
+		t.Fatal("fdisk -delete=fast debería desenlazar L3 sin borrar su contenido")
+	}
+
+	// Con L2 y L3 eliminadas, L1 puede crecer sobre el espacio liberado
+	if _, err := ParserFdisk([]string{"-add=512", "-unit=K", "-path=" + disk, "-name=L1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserFdisk([]string{"-delete=full", "-path=" + disk, "-name=L1"}); err != nil {
+		t.Fatal(err)
+	}
+	if readLogical(t, disk, "L1") != nil {
+		t.Fatal("fdisk -delete debería eliminar la primera lógica")
+	}
+	if _, err := ParserFdisk([]string{"-delete=full", "-path=" + disk, "-name=L1"}); err == nil {
+		t.Fatal("fdisk -delete de una lógica inexistente debería fallar")
+	}
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Fsck estructura para representar el comando fsck
+type Fsck struct {
+	id string // ID de la partición a verificar
+}
+
+// ParserFsck parsea el comando fsck y devuelve el reporte de la verificación
+func ParserFsck(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &Fsck{}
+
+	// Parsear el argumento -id
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"id"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("id"); err != nil {
+		return "", err
+	}
+	cmd.id = params.Get("id")
+
+	err = commandFsck(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandFsck recorre el sistema de archivos de la partición y verifica los checksums de sus estructuras
+func commandFsck(fsck *Fsck, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================== FSCK ==========================")
+
+	partition, path, err := globals.GetMountedPartition(fsck.id)
+	if err != nil {
+		return fmt.Errorf("error obteniendo la partición montada: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error abriendo el archivo del disco: %v", err)
+	}
+	defer file.Close()
+
+	errorsFound := 0
+	report := func(err error) bool {
+		var checksumErr *structures.ChecksumError
+		if !errors.As(err, &checksumErr) {
+			return false
+		}
+		// errors.Join agrupa los errores de ambos bitmaps, uno por línea
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(outputBuffer, "  [ERROR] %s\n", line)
+			errorsFound++
+		}
+		return true
+	}
+
+	fmt.Fprintf(outputBuffer, "Partición: %s\n", fsck.id)
+
+	// Superbloque: Decode verifica su checksum y el de los bitmaps
+	sb := &structures.Superblock{}
+	err = sb.Decode(file, int64(partition.Part_start))
+	if err != nil && !report(err) {
+		return fmt.Errorf("error leyendo el superbloque: %v", err)
+	}
+	if sb.S_magic != structures.FilesystemMagic {
+		return errors.New("la partición no tiene un sistema de archivos")
+	}
+
+	fmt.Fprintf(outputBuffer, "Revisión: %s\n", structures.RevisionName(sb.S_revision))
+	if sb.S_revision < structures.RevisionChecksums {
+		fmt.Fprintln(outputBuffer, "El sistema de archivos no guarda checksums; no hay nada que verificar.")
+		fmt.Fprintf(outputBuffer, "Desmonte el disco y ejecute 'migrate -path=%s' para agregarlos.\n", path)
+		return nil
+	}
+
+	// Inodos y bloques de carpeta de los directorios
+	totalInodes := sb.S_inodes_count + sb.S_free_inodes_count
+	inodesChecked, foldersChecked := 0, 0
+	for i := int32(0); i < totalInodes; i++ {
+		inode := &structures.Inode{}
+		err := inode.Decode(file, sb.CalculateInodeOffset(i), sb)
+		if err != nil && !report(err) {
+			return fmt.Errorf("error leyendo el inodo %d: %v", i, err)
+		}
+		inodesChecked++
+
+		if err != nil || inode.I_type[0] != '0' {
+			continue
+		}
+		for _, blockIndex := range inode.I_block[:12] {
+			if blockIndex < 0 || blockIndex >= sb.S_blocks_count+sb.S_free_blocks_count {
+				continue
+			}
+			block := &structures.FolderBlock{}
+			err := block.Decode(file, int64(sb.S_block_start)+int64(blockIndex)*int64(sb.S_block_size), sb)
+			if err != nil && !report(err) {
+				return fmt.Errorf("error leyendo el bloque %d: %v", blockIndex, err)
+			}
+			foldersChecked++
+		}
+	}
+
+	fmt.Fprintf(outputBuffer, "Inodos verificados: %d\n", inodesChecked)
+	fmt.Fprintf(outputBuffer, "Bloques de carpeta verificados: %d\n", foldersChecked)
+	if errorsFound == 0 {
+		fmt.Fprintln(outputBuffer, "Sistema de archivos sin errores de checksum.")
+	} else {
+		fmt.Fprintf(outputBuffer, "Se encontraron %d estructuras dañadas.\n", errorsFound)
+	}
+
+	return nil
+}
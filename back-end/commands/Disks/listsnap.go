@@ -0,0 +1,58 @@
+package commands
+
+import (
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+)
+
+// ListSnap estructura que representa el comando listsnap
+type ListSnap struct {
+	path string // Ruta del disco
+}
+
+// ParserListSnap parsea el comando listsnap y devuelve los snapshots del disco
+func ParserListSnap(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &ListSnap{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path"); err != nil {
+		return "", err
+	}
+	cmd.path = params.Get("path")
+
+	err = commandListSnap(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandListSnap muestra los snapshots del disco, del más antiguo al más reciente
+func commandListSnap(listSnap *ListSnap, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================= LISTSNAP =========================")
+
+	snapshots, err := utils.ListSnapshots(listSnap.path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Disco: %s\n", listSnap.path)
+	if len(snapshots) == 0 {
+		fmt.Fprintln(outputBuffer, "El disco no tiene snapshots.")
+	} else {
+		fmt.Fprintf(outputBuffer, "%-20s %-20s %-15s %-8s\n", "Nombre", "Creado", "Modificado", "Rangos")
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(outputBuffer, "%-20s %-20s %-15d %-8d\n",
+				snapshot.Name, snapshot.Created.Format("2006-01-02 15:04:05"), snapshot.Preserved, snapshot.Ranges)
+		}
+	}
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
@@ -2,8 +2,8 @@ package commands
 
 import (
 	structs "backend/Structs"
+	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -17,20 +17,18 @@ func ParserListPartitions(tokens []string) (string, error) {
 	cmd := &ListPartitions{}
 	var outputBuffer bytes.Buffer
 
-	// Validar que el token contiene el path y extraerlo
-	for _, token := range tokens {
-		if strings.HasPrefix(strings.ToLower(token), "-path=") {
-			cmd.path = strings.Trim(strings.SplitN(token, "=", 2)[1], "\"")
-		}
+	// Extraer el path del disco
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Verificar que el path no esté vacío
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	if err := params.Require("path"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
 
 	// Ejecutar el comando para listar las particiones
-	err := commandListPartitions(cmd, &outputBuffer)
+	err = commandListPartitions(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al listar las particiones: %v", err)
 	}
@@ -55,6 +53,7 @@ func commandListPartitions(listCmd *ListPartitions, outputBuffer *bytes.Buffer)
 
 	fmt.Fprintln(outputBuffer, "===================== LISTA DE PARTICIONES =====================")
 	fmt.Fprintf(outputBuffer, "	Disco: %s 	(Tamaño: %d 	bytes)\n", listCmd.path, mbr.MbrSize)
+	fmt.Fprintf(outputBuffer, "	Esquema: %s\n", strings.ToUpper(mbr.MbrScheme))
 	fmt.Fprintln(outputBuffer, "-----------------------------------------------------------------")
 	fmt.Fprintln(outputBuffer, "Tipo     	Nombre      	Inicio       	Tamaño       	Estado")
 
@@ -99,12 +98,18 @@ func listLogicalPartitions(file *os.File, start int32, outputBuffer *bytes.Buffe
 			return
 		}
 
+		// El primer EBR queda vacío si se eliminó su partición lógica
+		if ebr.Ebr_size <= 0 {
+			ebrStart = ebr.Ebr_next
+			continue
+		}
+
 		// Extraer nombre y ajustar el formato
 		ebrName := strings.TrimRight(string(ebr.Ebr_name[:]), "\x00")
 		ebrFit := string(ebr.Ebr_fit[:])
 		ebrMount := "No Montada"
-		if ebr.Ebr_mount[0] == '1' {
-			ebrMount = "Montada"
+		if id := structs.LogicalMountID(file.Name(), ebrName); id != "" {
+			ebrMount = "Montada (" + id + ")"
 		}
 
 		// Mostrar los datos del EBR
@@ -0,0 +1,299 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Migrate estructura que representa el comando migrate
+type Migrate struct {
+	path string // Ruta del disco a migrar
+}
+
+// ParserMigrate parsea el comando migrate y devuelve los mensajes de la migración
+func ParserMigrate(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &Migrate{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path"); err != nil {
+		return "", err
+	}
+	cmd.path = params.Get("path")
+
+	err = commandMigrate(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandMigrate reescribe el disco y sus sistemas de archivos a la revisión actual del formato
+func commandMigrate(migrate *Migrate, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================== MIGRATE ==========================")
+
+	// No se migra un disco en uso: las particiones montadas leen sus estructuras directamente del archivo
+	for id, path := range globals.MountedPartitions {
+		if path == migrate.path {
+			return fmt.Errorf("la partición con ID '%s' de este disco está montada; desmóntela antes de migrar", id)
+		}
+	}
+
+	file, err := os.OpenFile(migrate.path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo el archivo del disco en el path: %s: %v", migrate.path, err)
+	}
+	defer file.Close()
+
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		return fmt.Errorf("error deserializando el MBR: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Disco: %s\n", migrate.path)
+	fmt.Fprintf(outputBuffer, "MBR: %s\n", structures.RevisionName(mbr.MbrRevision))
+
+	// Primero los sistemas de archivos, cada uno dentro del espacio de su partición
+	for i := range mbr.MbrPartitions {
+		partition := &mbr.MbrPartitions[i]
+		if partition.Part_start == -1 || partition.Part_type[0] != 'P' {
+			continue
+		}
+		if err := migrateFilesystem(file, partition, outputBuffer); err != nil {
+			return fmt.Errorf("error migrando la partición '%s': %v", strings.Trim(string(partition.Part_name[:]), "\x00 "), err)
+		}
+	}
+
+	// Después el MBR, que puede necesitar desplazar las particiones para crecer
+	if mbr.MbrRevision >= structures.FormatRevision {
+		fmt.Fprintln(outputBuffer, "El MBR ya está en la revisión actual.")
+	} else if err := migrateMBR(file, &mbr, outputBuffer); err != nil {
+		return fmt.Errorf("error migrando el MBR: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Disco migrado a %s\n", structures.RevisionName(structures.FormatRevision))
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
+
+// migrateMBR reescribe el MBR con la disposición actual, moviendo las particiones si el MBR nuevo no cabe
+func migrateMBR(file *os.File, mbr *structures.MBR, outputBuffer *bytes.Buffer) error {
+	fromRevision := mbr.MbrRevision
+
+	// Particiones en uso ordenadas por su posición en el disco
+	used := mbr.UsedPartitions()
+
+	// Calcular la nueva posición de cada partición: solo se mueve lo necesario para no solaparse
+	newStarts := make(map[int]int32)
+	nextFree := int32(binary.Size(structures.MBRRecord{}))
+	for _, index := range used {
+		partition := mbr.MbrPartitions[index]
+		newStart := max(partition.Part_start, nextFree)
+		newStarts[index] = newStart
+		nextFree = newStart + partition.Part_size
+	}
+	if nextFree > mbr.MbrSize {
+		return fmt.Errorf("no hay espacio libre al final del disco para ampliar el MBR (faltan %d bytes)", nextFree-mbr.MbrSize)
+	}
+
+	// Mover desde la última partición hacia la primera para no pisar datos
+	for i := len(used) - 1; i >= 0; i-- {
+		partition := &mbr.MbrPartitions[used[i]]
+		oldStart := partition.Part_start
+		if newStarts[used[i]] == oldStart {
+			continue
+		}
+		if err := partition.Relocate(file, newStarts[used[i]]); err != nil {
+			return err
+		}
+		fmt.Fprintf(outputBuffer, "Partición '%s' desplazada de %d a %d\n",
+			strings.Trim(string(partition.Part_name[:]), "\x00 "), oldStart, partition.Part_start)
+	}
+
+	mbr.MbrSignature = structures.MBRSignature
+	mbr.MbrRevision = structures.FormatRevision
+	if err := mbr.Encode(file); err != nil {
+		return fmt.Errorf("error serializando el MBR: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "MBR migrado de %s a %s\n", structures.RevisionName(fromRevision), structures.RevisionName(mbr.MbrRevision))
+	return nil
+}
+
+// migrateFilesystem reconstruye el sistema de archivos de la partición con la disposición actual.
+// Los índices de inodos y bloques se conservan, así que las carpetas y apuntadores siguen siendo válidos.
+func migrateFilesystem(file *os.File, partition *structures.Partition, outputBuffer *bytes.Buffer) error {
+	name := strings.Trim(string(partition.Part_name[:]), "\x00 ")
+
+	// Las particiones cifradas se crearon con la revisión actual
+	if structures.IsEncrypted(file, partition.Part_start) {
+		fmt.Fprintf(outputBuffer, "Partición '%s': cifrada, ya está en la revisión actual.\n", name)
+		return nil
+	}
+
+	sb := &structures.Superblock{}
+	if err := sb.Decode(file, int64(partition.Part_start)); err != nil {
+		return fmt.Errorf("error leyendo el superbloque: %v", err)
+	}
+	if sb.S_magic != structures.FilesystemMagic {
+		fmt.Fprintf(outputBuffer, "Partición '%s': sin sistema de archivos, se omite.\n", name)
+		return nil
+	}
+	if sb.S_revision >= structures.FormatRevision {
+		fmt.Fprintf(outputBuffer, "Partición '%s': el sistema de archivos ya está en la revisión actual.\n", name)
+		return nil
+	}
+
+	fs := "2fs"
+	if sb.S_filesystem_type == 3 {
+		fs = "3fs"
+	}
+
+	// Leer todo el contenido con la disposición anterior antes de reescribir la partición
+	oldInodes := sb.S_inodes_count + sb.S_free_inodes_count
+	oldBlocks := sb.S_blocks_count + sb.S_free_blocks_count
+
+	inodeBitmap := make([]byte, (oldInodes+7)/8)
+	if err := utils.ReadAt(file, inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		return fmt.Errorf("error leyendo el bitmap de inodos: %v", err)
+	}
+	blockBitmap := make([]byte, (oldBlocks+7)/8)
+	if err := utils.ReadAt(file, blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		return fmt.Errorf("error leyendo el bitmap de bloques: %v", err)
+	}
+
+	inodes := make([]structures.Inode, oldInodes)
+	for i := int32(0); i < oldInodes; i++ {
+		if err := inodes[i].Decode(file, sb.CalculateInodeOffset(i), sb); err != nil {
+			return fmt.Errorf("error leyendo el inodo %d: %v", i, err)
+		}
+	}
+
+	blocks := make([]byte, int64(oldBlocks)*int64(sb.S_block_size))
+	if err := utils.ReadAt(file, blocks, int64(sb.S_block_start)); err != nil {
+		return fmt.Errorf("error leyendo los bloques: %v", err)
+	}
+
+	journalSize := int64(binary.Size(structures.Journal{}))
+	var journal []byte
+	if fs == "3fs" {
+		journal = make([]byte, int64(oldInodes)*journalSize)
+		if err := utils.ReadAt(file, journal, int64(partition.Part_start)+sb.EncodedSize()); err != nil {
+			return fmt.Errorf("error leyendo el journal: %v", err)
+		}
+	}
+
+	// La nueva disposición puede tener menos inodos y bloques; lo que está en uso debe seguir cabiendo
+	n := calculateN(partition, fs)
+	if last := lastUsedBit(inodeBitmap); last >= n || sb.S_inodes_count > n {
+		return fmt.Errorf("la nueva disposición admite %d inodos y la partición usa hasta el inodo %d", n, last)
+	}
+	if last := lastUsedBit(blockBitmap); last >= 3*n || sb.S_blocks_count > 3*n {
+		return fmt.Errorf("la nueva disposición admite %d bloques y la partición usa hasta el bloque %d", 3*n, last)
+	}
+
+	journalStart, bmInodeStart, bmBlockStart, csumStart, inodeStart, blockStart := calculateStartPositions(partition, fs, n)
+	newSb := *sb
+	newSb.S_free_inodes_count = n - sb.S_inodes_count
+	newSb.S_free_blocks_count = 3*n - sb.S_blocks_count
+	newSb.S_inode_size = int32(binary.Size(structures.Inode{}))
+	newSb.S_block_size = int32(binary.Size(structures.FileBlock{}))
+	newSb.S_first_ino = inodeStart + (sb.S_first_ino-sb.S_inode_start)/sb.S_inode_size*newSb.S_inode_size
+	newSb.S_first_blo = blockStart + (sb.S_first_blo - sb.S_block_start)
+	newSb.S_bm_inode_start = bmInodeStart
+	newSb.S_bm_block_start = bmBlockStart
+	newSb.S_inode_start = inodeStart
+	newSb.S_block_start = blockStart
+	newSb.S_rev_magic = structures.SuperblockRevisionMagic
+	newSb.S_revision = structures.FormatRevision
+	newSb.S_csum_start = csumStart
+
+	// Limpiar la partición y escribir la nueva disposición
+	if err := partition.Overwrite(file); err != nil {
+		return err
+	}
+	if err := newSb.Encode(file, int64(partition.Part_start)); err != nil {
+		return fmt.Errorf("error escribiendo el superbloque: %v", err)
+	}
+
+	if fs == "3fs" {
+		entries := int64(min(oldInodes, n)) * journalSize
+		if err := utils.WriteAt(file, journal[:entries], int64(journalStart)); err != nil {
+			return fmt.Errorf("error escribiendo el journal: %v", err)
+		}
+	}
+
+	if err := utils.WriteAt(file, inodeBitmap[:min(int32(len(inodeBitmap)), (n+7)/8)], int64(bmInodeStart)); err != nil {
+		return fmt.Errorf("error escribiendo el bitmap de inodos: %v", err)
+	}
+	if err := utils.WriteAt(file, blockBitmap[:min(int32(len(blockBitmap)), (3*n+7)/8)], int64(bmBlockStart)); err != nil {
+		return fmt.Errorf("error escribiendo el bitmap de bloques: %v", err)
+	}
+
+	for i := int32(0); i < min(oldInodes, n); i++ {
+		if err := inodes[i].Encode(file, newSb.CalculateInodeOffset(i), &newSb); err != nil {
+			return fmt.Errorf("error escribiendo el inodo %d: %v", i, err)
+		}
+	}
+
+	blockBytes := int64(min(oldBlocks, 3*n)) * int64(newSb.S_block_size)
+	if err := utils.WriteAt(file, blocks[:blockBytes], int64(blockStart)); err != nil {
+		return fmt.Errorf("error escribiendo los bloques: %v", err)
+	}
+
+	// Reescribir los bloques de carpeta para generar sus checksums
+	for i := int32(0); i < min(oldInodes, n); i++ {
+		if inodes[i].I_type[0] != '0' || !isInodeUsed(inodeBitmap, i) {
+			continue
+		}
+		for _, blockIndex := range inodes[i].I_block[:12] {
+			if blockIndex < 0 || blockIndex >= min(oldBlocks, 3*n) {
+				continue
+			}
+			start := int64(blockIndex) * int64(newSb.S_block_size)
+			folderBlock := &structures.FolderBlock{}
+			if err := binary.Read(bytes.NewReader(blocks[start:start+int64(newSb.S_block_size)]), binary.LittleEndian, folderBlock); err != nil {
+				return fmt.Errorf("error leyendo el bloque de carpeta %d: %v", blockIndex, err)
+			}
+			if err := folderBlock.Encode(file, int64(blockStart)+start, &newSb); err != nil {
+				return fmt.Errorf("error escribiendo el bloque de carpeta %d: %v", blockIndex, err)
+			}
+		}
+	}
+
+	if err := newSb.UpdateBitmapChecksums(file); err != nil {
+		return fmt.Errorf("error escribiendo los checksums de los bitmaps: %v", err)
+	}
+
+	fmt.Fprintf(outputBuffer, "Partición '%s': sistema de archivos migrado de %s a %s (%d inodos, %d bloques)\n",
+		name, structures.RevisionName(sb.S_revision), structures.RevisionName(newSb.S_revision), n, 3*n)
+	return nil
+}
+
+// isInodeUsed indica si el bit del inodo está ocupado en el bitmap
+func isInodeUsed(bitmap []byte, index int32) bool {
+	return bitmap[index/8]&(1<<(index%8)) != 0
+}
+
+// lastUsedBit devuelve el índice del último bit ocupado de un bitmap, o -1 si está vacío
+func lastUsedBit(bitmap []byte) int32 {
+	for i := len(bitmap) - 1; i >= 0; i-- {
+		for bit := 7; bit >= 0; bit-- {
+			if bitmap[i]&(1<<bit) != 0 {
+				return int32(i*8 + bit)
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyInodeSize es el tamaño del inodo en disco de una revisión anterior
+func legacyInodeSize(revision int32) int32 {
+	switch {
+	case revision == structures.RevisionLegacy:
+		return structures.InodeRev1Size
+	case revision < structures.RevisionChecksums:
+		return int32(binary.Size(structures.InodeRev3{}))
+	case revision == structures.RevisionChecksums:
+		return int32(binary.Size(structures.InodeRev4{}))
+	}
+	return int32(binary.Size(structures.Inode{}))
+}
+
+// newLegacyDisk crea un disco con una partición EXT2 escrita con la disposición de la revisión indicada.
+// Devuelve la ruta del disco, su superbloque y el contenido de users.txt.
+func newLegacyDisk(t *testing.T, revision int32) (string, *structures.Superblock, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "legacy.mia")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	const diskSize, partitionSize, n = 256 * 1024, 128 * 1024, 32
+	if err := file.Truncate(diskSize); err != nil {
+		t.Fatal(err)
+	}
+
+	empty := structures.Partition{Part_status: [1]byte{'9'}, Part_type: [1]byte{'0'}, Part_fit: [1]byte{'0'}, Part_start: -1, Part_size: -1, Part_name: [16]byte{'0'}, Part_correlative: -1, Part_id: [4]byte{'0'}}
+	mbr := &structures.MBR{
+		MbrSize:          diskSize,
+		MbrCreacionDate:  1700000000,
+		MbrDiskSignature: 1234,
+		MbrDiskFit:       [1]byte{'F'},
+		MbrRevision:      revision,
+		MbrPartitions:    []structures.Partition{empty, empty, empty, empty},
+	}
+	// La partición empieza justo después del MBR de su revisión
+	mbr.MbrPartitions[0] = structures.Partition{
+		Part_status:      [1]byte{'0'},
+		Part_type:        [1]byte{'P'},
+		Part_fit:         [1]byte{'F'},
+		Part_start:       int32(mbr.HeaderSize()),
+		Part_size:        partitionSize,
+		Part_name:        [16]byte{'P', '1'},
+		Part_correlative: -1,
+	}
+	if err := mbr.Encode(file); err != nil {
+		t.Fatal(err)
+	}
+
+	start := mbr.MbrPartitions[0].Part_start
+	sb := &structures.Superblock{
+		S_filesystem_type:   2,
+		S_free_inodes_count: n,
+		S_free_blocks_count: 3 * n,
+		S_magic:             structures.FilesystemMagic,
+		S_inode_size:        legacyInodeSize(revision),
+		S_block_size:        int32(binary.Size(structures.FileBlock{})),
+		S_revision:          revision,
+	}
+	if revision >= structures.RevisionVersioned {
+		sb.S_rev_magic = structures.SuperblockRevisionMagic
+	}
+	sb.S_bm_inode_start = start + int32(sb.EncodedSize())
+	sb.S_bm_block_start = sb.S_bm_inode_start + n
+	sb.S_inode_start = sb.S_bm_block_start + 3*n
+	if revision >= structures.RevisionChecksums {
+		sb.S_csum_start = sb.S_inode_start
+		sb.S_inode_start += 3 * n * 4
+	}
+	sb.S_block_start = sb.S_inode_start + n*sb.S_inode_size
+	sb.S_first_ino = sb.S_inode_start
+	sb.S_first_blo = sb.S_block_start
+
+	if err := sb.Encode(file, int64(start)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateUsersFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.Encode(file, int64(start)); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, sb, readUsersText(t, file, start)
+}
+
+// readUsersText lee /users.txt del sistema de archivos que inicia en start
+func readUsersText(t *testing.T, file *os.File, start int32) string {
+	t.Helper()
+	sb := &structures.Superblock{}
+	if err := sb.Decode(file, int64(start)); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &structures.Inode{}
+	if err := root.Decode(file, sb.CalculateInodeOffset(0), sb); err != nil {
+		t.Fatal(err)
+	}
+	folder := &structures.FolderBlock{}
+	if err := folder.Decode(file, int64(sb.S_block_start+root.I_block[0]*sb.S_block_size), sb); err != nil {
+		t.Fatal(err)
+	}
+	usersIndex := int32(-1)
+	for _, content := range folder.B_content {
+		if strings.Trim(string(content.B_name[:]), "\x00 ") == "users.txt" {
+			usersIndex = content.B_inodo
+		}
+	}
+	if usersIndex == -1 {
+		t.Fatal("la carpeta raíz no tiene users.txt")
+	}
+
+	users := &structures.Inode{}
+	if err := users.Decode(file, sb.CalculateInodeOffset(usersIndex), sb); err != nil {
+		t.Fatal(err)
+	}
+	text, err := globals.ReadFileBlocks(file, sb, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestMigrateFromEachRevision(t *testing.T) {
+	for revision := structures.RevisionLegacy; revision < structures.FormatRevision; revision++ {
+		t.Run(structures.RevisionName(revision), func(t *testing.T) {
+			t.Setenv(globals.DataDirEnv, t.TempDir())
+			path, legacy, usersText := newLegacyDisk(t, revision)
+
+			var output bytes.Buffer
+			if err := commandMigrate(&Migrate{path: path}, &output); err != nil {
+				t.Fatalf("migrate: %v\n%s", err, output.String())
+			}
+			// Los MBR sin revisión son más pequeños: la partición se desplaza para hacerle espacio al nuevo
+			if moved := strings.Contains(output.String(), "desplazada"); moved != (revision < structures.RevisionVersioned) {
+				t.Errorf("migrate desplazó la partición: %v\n%s", moved, output.String())
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			var mbr structures.MBR
+			if err := mbr.Decode(file); err != nil {
+				t.Fatal(err)
+			}
+			if mbr.MbrRevision != structures.FormatRevision || mbr.MbrDiskSignature != 1234 {
+				t.Fatalf("MBR migrado: revisión %d, firma %d", mbr.MbrRevision, mbr.MbrDiskSignature)
+			}
+			partition, _ := mbr.GetPartitionByName("P1")
+			if partition == nil {
+				t.Fatal("la partición P1 no está en el MBR migrado")
+			}
+			if partition.Part_start < int32(mbr.HeaderSize()) {
+				t.Fatalf("la partición inicia en %d, dentro del MBR de %d bytes", partition.Part_start, mbr.HeaderSize())
+			}
+
+			// Decode verifica los checksums del superbloque y de los bitmaps
+			sb := &structures.Superblock{}
+			if err := sb.Decode(file, int64(partition.Part_start)); err != nil {
+				t.Fatal(err)
+			}
+			if sb.S_revision != structures.FormatRevision || sb.S_inodes_count != legacy.S_inodes_count || sb.S_blocks_count != legacy.S_blocks_count {
+				t.Fatalf("superbloque migrado: revisión %d, %d inodos y %d bloques en uso, se esperaban %d y %d",
+					sb.S_revision, sb.S_inodes_count, sb.S_blocks_count, legacy.S_inodes_count, legacy.S_blocks_count)
+			}
+			if got := readUsersText(t, file, partition.Part_start); got != usersText {
+				t.Fatalf("users.txt después de migrar = %q, se esperaba %q", got, usersText)
+			}
+
+			// fsck sobre la partición montada no encuentra estructuras dañadas
+			if _, err := ParserMount([]string{"-path=" + path, "-name=P1"}); err != nil {
+				t.Fatal(err)
+			}
+			var id string
+			for mountID, mountPath := range globals.MountedPartitions {
+				if mountPath == path {
+					id = mountID
+				}
+			}
+			defer ParserUnmount([]string{"-id=" + id})
+			report, err := ParserFsck([]string{"-id=" + id})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(report, "sin errores de checksum") {
+				t.Fatalf("fsck después de migrar:\n%s", report)
+			}
+
+			// Un disco montado no se puede migrar
+			if err := commandMigrate(&Migrate{path: path}, &output); err == nil {
+				t.Fatal("migrate debería rechazar un disco montado")
+			}
+		})
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -19,74 +18,79 @@ import (
 const (
 	UnitK = "K"
 	UnitM = "M"
+	UnitG = "G"
 	FitBF = "BF"
 	FitFF = "FF"
 	FitWF = "WF"
 )
 
+// Constantes para los esquemas de particionado
+const (
+	SchemeMBR = "MBR"
+	SchemeGPT = "GPT"
+)
+
 type MkDisk struct {
-	size int    // Tamaño del disco
-	unit string // Unidad de medida del tamaño (K o M)
-	fit  string // Tipo de ajuste (BF, FF, WF)
-	path string // Ruta del archivo del disco
+	size   int    // Tamaño del disco
+	unit   string // Unidad de medida del tamaño (K, M o G)
+	fit    string // Tipo de ajuste (BF, FF, WF)
+	path   string // Ruta del archivo del disco
+	scheme string // Esquema de particionado (MBR o GPT)
+	zero   bool   // Escribir ceros en todo el disco en lugar de crearlo disperso
 }
 
 func ParserMkdisk(tokens []string) (string, error) {
 	cmd := &MkDisk{}
 	var outputBuffer bytes.Buffer // Buffer para capturar los prints
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-size=\d+|-unit=[kKmM]|-fit=[bBfFwW]{2}|-path="[^"]+"|-path=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"size", "unit", "fit", "scheme", "path"},
+		Flags:  []string{"zero"},
+	})
+	if err != nil {
+		return "", err
+	}
 
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
+	for _, key := range params.Names() {
+		value := params.Get(key)
 
 		switch key {
-		case "-size":
+		case "size":
 			size, err := strconv.Atoi(value)
 			if err != nil || size <= 0 {
 				return "", errors.New("el tamaño debe ser un número entero positivo")
 			}
 			cmd.size = size
-		case "-unit":
+		case "unit":
 			value = strings.ToUpper(value)
-			if value != UnitK && value != UnitM {
-				return "", errors.New("la unidad debe ser K o M")
+			if value != UnitK && value != UnitM && value != UnitG {
+				return "", errors.New("la unidad debe ser K, M o G")
 			}
 			cmd.unit = value
-		case "-fit":
+		case "fit":
 			value = strings.ToUpper(value)
 			if value != FitBF && value != FitFF && value != FitWF {
 				return "", errors.New("el ajuste debe ser BF, FF o WF")
 			}
 			cmd.fit = value
-		case "-path":
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
+		case "scheme":
+			value = strings.ToUpper(value)
+			if value != SchemeMBR && value != SchemeGPT {
+				return "", errors.New("el esquema debe ser MBR o GPT")
 			}
+			cmd.scheme = value
+		case "path":
 			if !strings.HasSuffix(value, ".mia") {
 				return "", errors.New("el archivo debe tener la extensión .mia")
 			}
 			cmd.path = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
+		case "zero":
+			cmd.zero = true
 		}
 	}
 
-	if cmd.size == 0 {
-		return "", errors.New("faltan parámetros requeridos: -size")
-	}
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	if err := params.Require("size", "path"); err != nil {
+		return "", err
 	}
 	if cmd.unit == "" {
 		cmd.unit = UnitM
@@ -94,9 +98,12 @@ func ParserMkdisk(tokens []string) (string, error) {
 	if cmd.fit == "" {
 		cmd.fit = FitFF
 	}
+	if cmd.scheme == "" {
+		cmd.scheme = SchemeMBR
+	}
 
 	// Crear el disco con los parámetros proporcionados y capturar la salida en el buffer
-	err := commandMkdisk(cmd, &outputBuffer)
+	err = commandMkdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al crear el disco: %v", err)
 	}
@@ -117,6 +124,11 @@ func commandMkdisk(mkdisk *MkDisk, outputBuffer *bytes.Buffer) error {
 		return err
 	}
 
+	// Un disco GPT necesita espacio para la tabla primaria y la de respaldo
+	if mkdisk.scheme == SchemeGPT && sizeBytes <= structures.GPTMinDiskSize {
+		return fmt.Errorf("un disco GPT debe ser mayor a %d bytes", structures.GPTMinDiskSize)
+	}
+
 	// Crear el disco con el tamaño proporcionado
 	err = createDisk(mkdisk, sizeBytes, outputBuffer)
 	if err != nil {
@@ -143,6 +155,12 @@ func createDisk(mkdisk *MkDisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 		return err
 	}
 
+	// Los snapshots de un disco anterior en el mismo path ya no corresponden al disco nuevo
+	if err := utils.RemoveSnapshots(mkdisk.path); err != nil {
+		fmt.Fprintln(outputBuffer, "Error eliminando snapshots anteriores:", err)
+		return err
+	}
+
 	// Crear el archivo binario
 	file, err := os.Create(mkdisk.path)
 	if err != nil {
@@ -151,7 +169,16 @@ func createDisk(mkdisk *MkDisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 	}
 	defer file.Close()
 
-	// Escribir en el archivo usando un buffer de 1 MB
+	// Por defecto el disco se crea disperso: las zonas que nunca se escriben no ocupan espacio
+	if !mkdisk.zero {
+		if err := file.Truncate(int64(sizeBytes)); err != nil {
+			return err
+		}
+		fmt.Fprintln(outputBuffer, "Disco creado exitosamente:", mkdisk.path)
+		return nil
+	}
+
+	// Con -zero se escribe todo el disco usando un buffer de 1 MB
 	buffer := make([]byte, 1024*1024) // Crea un buffer de 1 MB
 	for sizeBytes > 0 {
 		writeSize := len(buffer)
@@ -177,12 +204,17 @@ func createMBR(mkdisk *MkDisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 	defer file.Close()
 
 	// Crear el MBR con los valores proporcionados
+	now := time.Now()
 	mbr := &structures.MBR{
 		MbrSize:          int32(sizeBytes),
-		MbrCreacionDate:  float32(time.Now().Unix()),
+		MbrCreacionDate:  now.Unix(),
+		MbrCreacionNsec:  int32(now.Nanosecond()),
 		MbrDiskSignature: rand.Int31(),
 		MbrDiskFit:       [1]byte{mkdisk.fit[0]}, // Asignamos el tipo de ajuste
-		MbrPartitions: [4]structures.Partition{
+		MbrSignature:     structures.MBRSignature,
+		MbrRevision:      structures.FormatRevision,
+		MbrScheme:        structures.PartitionSchemeMBR,
+		MbrPartitions: []structures.Partition{
 			{Part_status: [1]byte{'9'}, Part_type: [1]byte{'0'}, Part_fit: [1]byte{'0'}, Part_start: -1, Part_size: -1, Part_name: [16]byte{'0'}, Part_correlative: -1, Part_id: [4]byte{'0'}},
 			{Part_status: [1]byte{'9'}, Part_type: [1]byte{'0'}, Part_fit: [1]byte{'0'}, Part_start: -1, Part_size: -1, Part_name: [16]byte{'0'}, Part_correlative: -1, Part_id: [4]byte{'0'}},
 			{Part_status: [1]byte{'9'}, Part_type: [1]byte{'0'}, Part_fit: [1]byte{'0'}, Part_start: -1, Part_size: -1, Part_name: [16]byte{'0'}, Part_correlative: -1, Part_id: [4]byte{'0'}},
@@ -190,6 +222,13 @@ func createMBR(mkdisk *MkDisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 		},
 	}
 
+	// Los discos GPT guardan 128 entradas y un GUID propio
+	if mkdisk.scheme == SchemeGPT {
+		mbr.MbrScheme = structures.PartitionSchemeGPT
+		mbr.MbrDiskGUID = structures.NewDiskGUID()
+		mbr.MbrPartitions = structures.NewGPTPartitions()
+	}
+
 	// Serializar el MBR en el archivo usando el puntero de archivo `file`
 	err = mbr.Encode(file)
 	if err != nil {
@@ -198,7 +237,11 @@ func createMBR(mkdisk *MkDisk, sizeBytes int, outputBuffer *bytes.Buffer) error
 	}
 
 	// Agregar mensajes al buffer
-	fmt.Fprintf(outputBuffer, "MBR creado exitosamente en el disco (Ajuste: %c).\n", mkdisk.fit[0])
+	if mbr.IsGPT() {
+		fmt.Fprintf(outputBuffer, "Tabla GPT creada exitosamente en el disco (Ajuste: %c, Entradas: %d).\n", mkdisk.fit[0], len(mbr.MbrPartitions))
+	} else {
+		fmt.Fprintf(outputBuffer, "MBR creado exitosamente en el disco (Ajuste: %c).\n", mkdisk.fit[0])
+	}
 	mbr.Print()
 	fmt.Println("===========================================================")
 
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	utils "backend/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMkdiskGigabyteUnit(t *testing.T) {
+	disk := filepath.Join(t.TempDir(), "g.mia")
+	if _, err := ParserMkdisk([]string{"-size=1", "-unit=G", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 1<<30 {
+		t.Fatalf("mkdisk -size=1 -unit=G creó %d bytes, se esperaban %d", info.Size(), 1<<30)
+	}
+
+	// Las particiones también aceptan G, siempre que quepan junto al MBR
+	if _, err := ParserFdisk([]string{"-size=1", "-unit=G", "-path=" + disk, "-name=P1"}); err == nil {
+		t.Fatal("fdisk no debería crear una partición de 1 G en un disco de 1 G")
+	}
+	if _, err := ParserFdisk([]string{"-size=512", "-unit=M", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=G", "-path=" + filepath.Join(t.TempDir(), "h.mia")}); err == nil {
+		t.Fatal("mkdisk -size=2 -unit=G debería exceder los offsets de 32 bits")
+	}
+}
+
+func TestMkdiskDropsSnapshotsOfPreviousDisk(t *testing.T) {
+	disk := filepath.Join(t.TempDir(), "a.mia")
+	if _, err := ParserMkdisk([]string{"-size=64", "-unit=K", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.CreateSnapshot(disk, "base"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParserMkdisk([]string{"-size=128", "-unit=K", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if infos, err := utils.ListSnapshots(disk); err != nil || len(infos) != 0 {
+		t.Fatalf("el disco nuevo conserva los snapshots del anterior: %+v, %v", infos, err)
+	}
+	if _, err := os.Stat(disk + ".snap"); !os.IsNotExist(err) {
+		t.Fatalf("la carpeta de snapshots sigue existiendo: %v", err)
+	}
+}
@@ -3,79 +3,55 @@ package commands
 import (
 	structures "backend/Structs"
 	global "backend/globals"
-	"backend/utils"
+	utils "backend/utils"
 	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
 	"os"
-	"regexp"
-	"strings"
 	"time"
 )
 
 // MKFS estructura que representa el comando mkfs con sus parámetros
 type MKFS struct {
-	id  string // ID del disco
-	typ string // Tipo de formato (full)
-	fs  string // Tipo de sistema de archivos (2fs o 3fs)
+	id      string // ID del disco
+	typ     string // Tipo de formato (full)
+	fs      string // Tipo de sistema de archivos (2fs o 3fs)
+	encrypt bool   // Cifrar la partición con la contraseña indicada al montarla
 }
 
 func ParserMkfs(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &MKFS{}
 
-	args := strings.Join(tokens, " ")
-	// Modificado para que acepte también -fs
-	re := regexp.MustCompile(`-id=[^\s]+|-type=[^\s]+|-fs=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
-
-		switch key {
-		case "-id":
-			if value == "" {
-				return "", errors.New("el id no puede estar vacío")
-			}
-			cmd.id = value
-		case "-type":
-			if value != "full" {
-				return "", errors.New("el tipo debe ser full")
-			}
-			cmd.typ = value
-		case "-fs":
-			if value != "2fs" && value != "3fs" {
-				return "", errors.New("el sistema de archivos debe ser 2fs o 3fs")
-			}
-			cmd.fs = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"id", "type", "fs"},
+		Flags:  []string{"encrypt"},
+	})
+	if err != nil {
+		return "", err
 	}
-
-	if cmd.id == "" {
-		return "", errors.New("faltan parámetros requeridos: -id")
+	if err := params.Require("id"); err != nil {
+		return "", err
 	}
-
-	// Definir fs por defecto si no se especifica
-	if cmd.fs == "" {
-		cmd.fs = "2fs" // Por defecto EXT2
+	cmd.id = params.Get("id")
+	cmd.encrypt = params.Has("encrypt")
+
+	// Definir fs y tipo por defecto si no se especifican
+	cmd.fs = "2fs" // Por defecto EXT2
+	if params.Has("fs") {
+		cmd.fs = params.Get("fs")
+		if cmd.fs != "2fs" && cmd.fs != "3fs" {
+			return "", errors.New("el sistema de archivos debe ser 2fs o 3fs")
+		}
 	}
-
-	if cmd.typ == "" {
-		cmd.typ = "full"
+	cmd.typ = "full"
+	if params.Has("type") && params.Get("type") != "full" {
+		return "", errors.New("el tipo debe ser full")
 	}
 
-	err := commandMkfs(cmd, &outputBuffer)
+	err = commandMkfs(cmd, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return "", err
@@ -87,8 +63,8 @@ func ParserMkfs(tokens []string) (string, error) {
 func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintf(outputBuffer, "========================== MKFS ==========================\n")
 
-	// Obtener la partición montada
-	mountedPartition, partitionPath, err := global.GetMountedPartition(mkfs.id)
+	// Obtener la partición montada, incluida su cabecera de cifrado si la tiene
+	mountedPartition, partitionPath, err := global.GetMountedRawPartition(mkfs.id)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada con ID %s: %v", mkfs.id, err)
 	}
@@ -100,6 +76,15 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	}
 	defer file.Close()
 
+	// Preparar el cifrado; el sistema de archivos se crea en la zona que queda después de la cabecera
+	mountedPartition, err = prepareEncryption(file, mountedPartition, mkfs)
+	if err != nil {
+		return err
+	}
+	if mkfs.encrypt {
+		fmt.Fprintln(outputBuffer, "Partición cifrada con AES-256; la contraseña se pedirá en cada mount.")
+	}
+
 	fmt.Fprintf(outputBuffer, "Partición montada correctamente en %s.\n", partitionPath)
 	fmt.Println("\nPartición montada:")
 	mountedPartition.Print()
@@ -113,6 +98,12 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	fmt.Println("\nSuperBlock:")
 	superBlock.Print()
 
+	// Escribir el superbloque desde el inicio para que los inodos usen la disposición de la revisión actual
+	err = superBlock.Encode(file, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error escribiendo el superbloque en el disco: %v", err)
+	}
+
 	// Crear bitmaps
 	err = superBlock.CreateBitMaps(file)
 	if err != nil {
@@ -132,7 +123,7 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "Archivo users.txt creado correctamente.")
 
 	// Serializar el superbloque
-	err = utils.WriteToFile(file, int64(mountedPartition.Part_start), superBlock)
+	err = superBlock.Encode(file, int64(mountedPartition.Part_start))
 	if err != nil {
 		return fmt.Errorf("error escribiendo el superbloque en el disco: %v", err)
 	}
@@ -142,12 +133,56 @@ func commandMkfs(mkfs *MKFS, outputBuffer *bytes.Buffer) error {
 	return nil
 }
 
+// prepareEncryption escribe o elimina la cabecera de cifrado antes de formatear y devuelve la zona
+// donde se debe crear el sistema de archivos
+func prepareEncryption(file *os.File, partition *structures.Partition, mkfs *MKFS) (*structures.Partition, error) {
+	// El formato anterior deja de existir: su llave ya no aplica
+	utils.UnregisterCipher(file.Name(), int64(partition.Part_start+structures.CryptHeaderSize))
+
+	if !mkfs.encrypt {
+		// Quitar la cabecera de un cifrado anterior para que la partición vuelva a leerse en claro
+		if structures.IsEncrypted(file, partition.Part_start) {
+			if err := utils.WriteAt(file, make([]byte, structures.CryptHeaderSize), int64(partition.Part_start)); err != nil {
+				return nil, fmt.Errorf("error eliminando la cabecera de cifrado: %v", err)
+			}
+		}
+		return partition, nil
+	}
+
+	passphrase := global.PartitionPassphrases[mkfs.id]
+	if passphrase == "" {
+		return nil, errors.New("para cifrar la partición móntela con -passphrase")
+	}
+
+	header, key, err := structures.NewCryptHeader(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if err := header.Encode(file, partition.Part_start); err != nil {
+		return nil, fmt.Errorf("error escribiendo la cabecera de cifrado: %v", err)
+	}
+	if err := partition.RegisterPartitionKey(file, key); err != nil {
+		return nil, err
+	}
+
+	// Cifrar toda la zona: el espacio libre queda indistinguible del que tiene datos
+	view := partition.FilesystemPartition(file)
+	zeroes := make([]byte, 1024*1024)
+	for offset := int64(0); offset < int64(view.Part_size); offset += int64(len(zeroes)) {
+		chunk := zeroes[:min(int64(len(zeroes)), int64(view.Part_size)-offset)]
+		if err := utils.WriteAt(file, chunk, int64(view.Part_start)+offset); err != nil {
+			return nil, fmt.Errorf("error cifrando la partición: %v", err)
+		}
+	}
+	return view, nil
+}
+
 func calculateN(partition *structures.Partition, fs string) int32 {
 	// Numerador: tamaño de la partición menos el tamaño del superblock
 	numerator := int(partition.Part_size) - binary.Size(structures.Superblock{})
 
-	// Denominador base: 4 + tamaño de inodos + 3 * tamaño de bloques de archivo
-	baseDenominator := 4 + binary.Size(structures.Inode{}) + 3*binary.Size(structures.FileBlock{})
+	// Denominador base: 4 + tamaño de inodos + 3 * (tamaño de bloques de archivo + su checksum)
+	baseDenominator := 4 + binary.Size(structures.Inode{}) + 3*(binary.Size(structures.FileBlock{})+4)
 
 	// Si el sistema de archivos es "3fs", se añade el tamaño del journaling al denominador
 	temp := 0
@@ -166,7 +201,7 @@ func calculateN(partition *structures.Partition, fs string) int32 {
 
 func createSuperBlock(partition *structures.Partition, n int32, fs string) *structures.Superblock {
 	// Calcular punteros de las estructuras
-	journal_start, bm_inode_start, bm_block_start, inode_start, block_start := calculateStartPositions(partition, fs, n)
+	journal_start, bm_inode_start, bm_block_start, csum_start, inode_start, block_start := calculateStartPositions(partition, fs, n)
 
 	fmt.Println("\nInicio del SuperBlock:", partition.Part_start)
 	fmt.Println("\nFin del SuperBlock:", partition.Part_start+int32(binary.Size(structures.Superblock{})))
@@ -176,6 +211,7 @@ func createSuperBlock(partition *structures.Partition, n int32, fs string) *stru
 	fmt.Println("\nFin del Bitmap de Inodos:", bm_inode_start+n)
 	fmt.Println("\nInicio del Bitmap de Bloques:", bm_block_start)
 	fmt.Println("\nFin del Bitmap de Bloques:", bm_block_start+(3*n))
+	fmt.Println("\nInicio de la Tabla de Checksums:", csum_start)
 	fmt.Println("\nInicio de Inodos:", inode_start)
 
 	// Tipo de sistema de archivos
@@ -197,7 +233,7 @@ func createSuperBlock(partition *structures.Partition, n int32, fs string) *stru
 		S_mtime:             float64(time.Now().Unix()),
 		S_umtime:            float64(time.Now().Unix()),
 		S_mnt_count:         1,
-		S_magic:             0xEF53,
+		S_magic:             structures.FilesystemMagic,
 		S_inode_size:        int32(binary.Size(structures.Inode{})),
 		S_block_size:        int32(binary.Size(structures.FileBlock{})),
 		S_first_ino:         inode_start,
@@ -206,11 +242,14 @@ func createSuperBlock(partition *structures.Partition, n int32, fs string) *stru
 		S_bm_block_start:    bm_block_start,
 		S_inode_start:       inode_start,
 		S_block_start:       block_start,
+		S_rev_magic:         structures.SuperblockRevisionMagic,
+		S_revision:          structures.FormatRevision,
+		S_csum_start:        csum_start,
 	}
 	return superBlock
 }
 
-func calculateStartPositions(partition *structures.Partition, fs string, n int32) (int32, int32, int32, int32, int32) {
+func calculateStartPositions(partition *structures.Partition, fs string, n int32) (int32, int32, int32, int32, int32, int32) {
 	superblockSize := int32(binary.Size(structures.Superblock{}))
 	journalSize := int32(binary.Size(structures.Journal{}))
 	inodeSize := int32(binary.Size(structures.Inode{}))
@@ -220,7 +259,8 @@ func calculateStartPositions(partition *structures.Partition, fs string, n int32
 	journalStart := int32(0)
 	bmInodeStart := partition.Part_start + superblockSize
 	bmBlockStart := bmInodeStart + n
-	inodeStart := bmBlockStart + (3 * n)
+	csumStart := bmBlockStart + (3 * n)
+	inodeStart := csumStart + (3 * n * 4)
 	blockStart := inodeStart + (inodeSize * n)
 
 	// Ajustar para EXT3
@@ -228,9 +268,10 @@ func calculateStartPositions(partition *structures.Partition, fs string, n int32
 		journalStart = partition.Part_start + superblockSize
 		bmInodeStart = journalStart + (journalSize * n)
 		bmBlockStart = bmInodeStart + n
-		inodeStart = bmBlockStart + (3 * n)
+		csumStart = bmBlockStart + (3 * n)
+		inodeStart = csumStart + (3 * n * 4)
 		blockStart = inodeStart + (inodeSize * n)
 	}
 
-	return journalStart, bmInodeStart, bmBlockStart, inodeStart, blockStart
+	return journalStart, bmInodeStart, bmBlockStart, csumStart, inodeStart, blockStart
 }
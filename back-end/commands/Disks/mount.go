@@ -5,16 +5,18 @@ import (
 	globals "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Mount struct {
-	path string
-	name string
+	path       string
+	name       string
+	options    globals.MountOptions // Opciones de montaje (ro, noatime, sync)
+	passphrase string               // Contraseña de una partición cifrada
 }
 
 // ParserMount parsea el comando mount y devuelve una instancia de MOUNT junto con un buffer de salida
@@ -22,46 +24,25 @@ func ParserMount(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &Mount{}
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
-
-		// Switch para manejar diferentes parámetros
-		switch key {
-		case "-path":
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
-			}
-			cmd.path = value
-		case "-name":
-			if value == "" {
-				return "", errors.New("el nombre no puede estar vacío")
-			}
-			cmd.name = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "name", "options", "passphrase"}})
+	if err != nil {
+		return "", err
 	}
-
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	if err := params.Require("path", "name"); err != nil {
+		return "", err
 	}
-	if cmd.name == "" {
-		return "", errors.New("faltan parámetros requeridos: -name")
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
+	cmd.passphrase = params.Get("passphrase")
+	if params.Has("options") {
+		cmd.options, err = globals.ParseMountOptions(params.Get("options"))
+		if err != nil {
+			return "", err
+		}
 	}
 
 	// Ejecutar el comando mount y capturar los mensajes importantes en el buffer
-	err := commandMount(cmd, &outputBuffer)
+	err = commandMount(cmd, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err) // Mensaje de depuración en consola
 		return "", err
@@ -87,19 +68,37 @@ func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error deserializando el MBR: %v", err)
 	}
 
-	// Buscar la partición con el nombre especificado
+	// Buscar la partición con el nombre especificado, primero en la tabla y luego entre las lógicas
 	partition, indexPartition := mbr.GetPartitionByName(mount.name)
+	var logical *structures.EBR
 	if partition == nil {
-		return fmt.Errorf("error: la partición '%s' no existe en el disco", mount.name)
+		ebr, _, _, err := findLogicalPartition(file, &mbr, mount.name)
+		if err != nil {
+			return fmt.Errorf("error: la partición '%s' no existe en el disco", mount.name)
+		}
+		logical = ebr
+		partition = ebr.ToPartition()
+		copy(partition.Part_id[:], structures.LogicalMountID(mount.path, mount.name))
 	}
 
 	// Verificar si la partición ya está montada
-	if err := checkPartitionAlreadyMounted(mount); err != nil {
+	if err := checkPartitionAlreadyMounted(mount, partition); err != nil {
 		return err
 	}
 
-	// Generar ID único para la partición
-	idPartition, err := GenerateIdPartition(mount, indexPartition)
+	// Una partición cifrada solo se monta con la contraseña correcta
+	key, err := unlockPartition(file, partition, mount)
+	if err != nil {
+		return err
+	}
+
+	// Generar ID único para la partición; las lógicas usan correlativos después de las entradas de la tabla
+	var idPartition string
+	if logical == nil {
+		idPartition, err = GenerateIdPartition(mount, indexPartition)
+	} else {
+		idPartition, indexPartition, err = generateLogicalIdPartition(mount, len(mbr.MbrPartitions))
+	}
 	if err != nil {
 		return fmt.Errorf("error generando el ID de la partición: %v", err)
 	}
@@ -107,23 +106,114 @@ func commandMount(mount *Mount, outputBuffer *bytes.Buffer) error {
 	// Guardar la partición montada en la lista de particiones montadas globales
 	globals.MountedPartitions[idPartition] = mount.path
 
-	// Actualizar la partición como montada en el MBR
+	// Actualizar la partición como montada en el MBR; las lógicas solo registran su ID en memoria
 	partition.MountPartition(indexPartition, idPartition)
-	mbr.MbrPartitions[indexPartition] = *partition
+	if logical == nil {
+		mbr.MbrPartitions[indexPartition] = *partition
+		if err := mbr.Encode(file); err != nil {
+			return fmt.Errorf("error serializando el MBR de vuelta al disco: %v", err)
+		}
+	} else {
+		structures.MountLogical(mount.path, idPartition, mount.name)
+	}
+
+	globals.PartitionOptions[idPartition] = mount.options
+	if mount.passphrase != "" {
+		globals.PartitionPassphrases[idPartition] = mount.passphrase
+	}
+	if key != nil {
+		if err := partition.RegisterPartitionKey(file, key); err != nil {
+			return err
+		}
+	}
+	fsPartition := partition.FilesystemPartition(file)
 
-	// Guardar los cambios en el MBR de vuelta en el disco
-	if err := mbr.Encode(file); err != nil {
-		return fmt.Errorf("error serializando el MBR de vuelta al disco: %v", err)
+	// Registrar el montaje en el superbloque (un montaje de solo lectura no escribe en el disco)
+	if !mount.options.ReadOnly {
+		updateMountTimes(file, fsPartition, true)
 	}
 
+	// Guardar la tabla de montaje para restaurarla si el servidor se reinicia
+	if err := globals.SaveMountTable(); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+	}
+
+	// Avisar si el disco o su sistema de archivos usan una revisión anterior del formato
+	checkFormatRevision(file, &mbr, fsPartition, mount.path, outputBuffer)
+
 	// Imprimir el estado de las particiones montadas
 	printMountedPartitions(outputBuffer, mount.name, idPartition)
 	return nil
 }
 
+// unlockPartition verifica la contraseña de una partición cifrada y devuelve su llave.
+// Devuelve nil si la partición no está cifrada.
+func unlockPartition(file *os.File, partition *structures.Partition, mount *Mount) ([]byte, error) {
+	header, err := structures.ReadCryptHeader(file, partition.Part_start)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo la cabecera de cifrado: %v", err)
+	}
+	if header == nil {
+		return nil, nil
+	}
+
+	if mount.passphrase == "" {
+		return nil, fmt.Errorf("la partición '%s' está cifrada; indique -passphrase", mount.name)
+	}
+	key, err := header.Unlock(mount.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo montar la partición '%s': %v", mount.name, err)
+	}
+	return key, nil
+}
+
+// updateMountTimes actualiza los campos de montaje del superbloque, si la partición tiene un sistema de archivos
+func updateMountTimes(file *os.File, partition *structures.Partition, mounting bool) {
+	sb := &structures.Superblock{}
+	err := sb.Decode(file, int64(partition.Part_start))
+	if err != nil || sb.S_magic != structures.FilesystemMagic {
+		return
+	}
+
+	now := float64(time.Now().Unix())
+	if mounting {
+		sb.S_mtime = now
+		sb.S_mnt_count++
+	} else {
+		sb.S_umtime = now
+	}
+
+	if err := sb.Encode(file, int64(partition.Part_start)); err != nil {
+		fmt.Println("Error actualizando los campos de montaje del superbloque:", err)
+	}
+}
+
+// checkFormatRevision detecta imágenes antiguas; se pueden seguir usando, pero conviene migrarlas
+func checkFormatRevision(file *os.File, mbr *structures.MBR, partition *structures.Partition, path string, outputBuffer *bytes.Buffer) {
+	oldImage := false
+	if mbr.MbrRevision < structures.FormatRevision {
+		fmt.Fprintf(outputBuffer, "Advertencia: el MBR del disco usa %s\n", structures.RevisionName(mbr.MbrRevision))
+		oldImage = true
+	}
+
+	if partition.Part_type[0] == 'P' || partition.Part_type[0] == 'L' {
+		sb := &structures.Superblock{}
+		err := sb.Decode(file, int64(partition.Part_start))
+		if err == nil && sb.S_magic == structures.FilesystemMagic && sb.S_revision < structures.FormatRevision {
+			fmt.Fprintf(outputBuffer, "Advertencia: el sistema de archivos de la partición usa %s\n", structures.RevisionName(sb.S_revision))
+			oldImage = true
+		}
+	}
+
+	if oldImage {
+		fmt.Fprintf(outputBuffer, "Desmonte el disco y ejecute 'migrate -path=%s' para actualizarlo a %s\n",
+			path, structures.RevisionName(structures.FormatRevision))
+	}
+}
+
 // Imprimir las particiones montadas
 func printMountedPartitions(outputBuffer *bytes.Buffer, partitionName string, idPartition string) {
-	fmt.Fprintf(outputBuffer, "Partición '%s' montada correctamente con ID: %s\n", partitionName, idPartition)
+	fmt.Fprintf(outputBuffer, "Partición '%s' montada correctamente con ID: %s (%s)\n", partitionName, idPartition, globals.PartitionOptions[idPartition])
 	fmt.Fprintln(outputBuffer, "\n=== Particiones Montadas ===")
 	for id, path := range globals.MountedPartitions {
 		fmt.Fprintf(outputBuffer, "ID: %s | Path: %s\n", id, path)
@@ -132,15 +222,27 @@ func printMountedPartitions(outputBuffer *bytes.Buffer, partitionName string, id
 }
 
 // Verificar si la partición ya está montada
-func checkPartitionAlreadyMounted(mount *Mount) error {
-	for id, mountedPath := range globals.MountedPartitions {
-		if mountedPath == mount.path && strings.Contains(id, mount.name) {
-			return fmt.Errorf("error: la partición '%s' ya está montada con ID: %s", mount.name, id)
-		}
+func checkPartitionAlreadyMounted(mount *Mount, partition *structures.Partition) error {
+	id := strings.Trim(string(partition.Part_id[:]), "\x00 ")
+	if id != "" && globals.MountedPartitions[id] == mount.path {
+		return fmt.Errorf("error: la partición '%s' ya está montada con ID: %s", mount.name, id)
 	}
 	return nil
 }
 
+// generateLogicalIdPartition genera el ID de una partición lógica con el primer correlativo libre desde firstIndex
+func generateLogicalIdPartition(mount *Mount, firstIndex int) (string, int, error) {
+	for index := firstIndex; ; index++ {
+		idPartition, err := GenerateIdPartition(mount, index)
+		if err != nil {
+			return "", 0, err
+		}
+		if _, used := globals.MountedPartitions[idPartition]; !used {
+			return idPartition, index, nil
+		}
+	}
+}
+
 // GenerateIdPartition genera un ID único para la partición montada
 func GenerateIdPartition(mount *Mount, indexPartition int) (string, error) {
 	lastTwoDigits := globals.Carnet[len(globals.Carnet)-2:]
@@ -149,6 +251,12 @@ func GenerateIdPartition(mount *Mount, indexPartition int) (string, error) {
 		return "", err
 	}
 
-	idPartition := fmt.Sprintf("%s%d%s", lastTwoDigits, indexPartition+1, letter)
+	// El correlativo ocupa un solo carácter del ID: 1-9 y luego A-Z para las entradas GPT
+	if indexPartition+1 >= 36 {
+		return "", fmt.Errorf("solo se pueden montar las primeras 35 entradas de la tabla de particiones")
+	}
+	correlative := strings.ToUpper(strconv.FormatInt(int64(indexPartition+1), 36))
+
+	idPartition := fmt.Sprintf("%s%s%s", lastTwoDigits, correlative, letter)
 	return idPartition, nil
 }
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	structures "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// simulateRestart olvida el estado en memoria de los discos, como al reiniciar el servidor
+func simulateRestart(disk string) {
+	for id, path := range globals.MountedPartitions {
+		if path == disk {
+			delete(globals.MountedPartitions, id)
+			delete(globals.PartitionOptions, id)
+			structures.UnmountLogical(disk, id)
+		}
+	}
+	utils.RemoveLetter(disk)
+}
+
+func TestMountTableSurvivesRestart(t *testing.T) {
+	t.Setenv(globals.DataDirEnv, t.TempDir())
+	disk := filepath.Join(t.TempDir(), "a.mia")
+	if _, err := ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-size=256", "-unit=K", "-name=P1"},
+		{"-size=256", "-unit=K", "-name=P2"},
+		{"-size=512", "-unit=K", "-name=E1", "-type=E"},
+		{"-size=256", "-unit=K", "-name=L1", "-type=L"},
+	} {
+		if _, err := ParserFdisk(append(args, "-path="+disk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p1 := mountPartition(t, disk, "P1", "-options=ro,noatime")
+	l1 := mountPartition(t, disk, "L1")
+
+	// P2 queda con su ID escrito en el MBR, pero fuera de la tabla guardada
+	p2 := mountPartition(t, disk, "P2")
+	delete(globals.MountedPartitions, p2)
+	if err := globals.SaveMountTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	simulateRestart(disk)
+	if err := globals.RestoreMountTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	if globals.MountedPartitions[p1] != disk || globals.MountedPartitions[l1] != disk {
+		t.Fatalf("tabla de montaje restaurada = %v, se esperaban %s y %s", globals.MountedPartitions, p1, l1)
+	}
+	if options := globals.PartitionOptions[p1]; !options.ReadOnly || !options.NoAtime {
+		t.Fatalf("opciones de %s restauradas = %s", p1, options)
+	}
+	if name := structures.LogicalMountName(disk, l1); name != "L1" {
+		t.Fatalf("la lógica %s se restauró con el nombre %q", l1, name)
+	}
+	if _, ok := globals.MountedPartitions[p2]; ok {
+		t.Fatalf("%s no estaba en la tabla guardada y no debería restaurarse", p2)
+	}
+	if id := strings.Trim(string(readPartition(t, disk, "P2").Part_id[:]), "\x00 "); id != "" {
+		t.Fatalf("el ID obsoleto de P2 sigue en el MBR: %q", id)
+	}
+
+	// La letra del disco se conserva: montar de nuevo P2 usa la misma
+	again := mountPartition(t, disk, "P2")
+	if again[len(again)-1] != p1[len(p1)-1] {
+		t.Fatalf("P2 se montó como %s, con otra letra que %s", again, p1)
+	}
+}
@@ -1,12 +1,11 @@
 package commands
 
 import (
+	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 )
 
 type rmDisk struct {
@@ -19,48 +18,20 @@ func ParserRmdisk(tokens []string) (string, error) {
 
 	cmd := &rmDisk{} // Crea una nueva instancia de RMDISK
 
-	// Unir tokens en una sola cadena y luego dividir por espacios, respetando las comillas
-	args := strings.Join(tokens, " ")
-	// Expresión regular para encontrar el parámetro del comando rmdisk
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+`)
-	// Encuentra todas las coincidencias de la expresión regular en la cadena de argumentos
-	matches := re.FindAllString(args, -1)
-
-	// Itera sobre cada coincidencia encontrada
-	for _, match := range matches {
-		// Divide cada parte en clave y valor usando "=" como delimitador
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-
-		// Remueve comillas del valor si están presentes
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
-
-		// Switch para manejar el parámetro -path
-		switch key {
-		case "-path":
-			// Verifica que el path no esté vacío
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
-			}
-			cmd.path = value
-		default:
-			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	// Separar los parámetros ya tokenizados; solo se acepta -path
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
 	}
 
 	// Verifica que el parámetro -path haya sido proporcionado
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	if err := params.Require("path"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
 
 	// Ejecutar el comando para eliminar el disco y capturar la salida en el buffer
-	err := commandRmdisk(cmd, &outputBuffer)
+	err = commandRmdisk(cmd, &outputBuffer)
 	if err != nil {
 		return "", fmt.Errorf("error al eliminar el disco: %v", err)
 	}
@@ -85,6 +56,26 @@ func commandRmdisk(rmdisk *rmDisk, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al eliminar el archivo: %v", err)
 	}
 
+	// Las particiones del disco eliminado dejan de estar montadas
+	for id, path := range globals.MountedPartitions {
+		if path == rmdisk.path {
+			delete(globals.MountedPartitions, id)
+			delete(globals.PartitionOptions, id)
+			delete(globals.PartitionPassphrases, id)
+			delete(globals.ReadOnlyFailures, id)
+			globals.LogoutPartition(id)
+			fmt.Fprintf(outputBuffer, "Partición con ID '%s' desmontada.\n", id)
+		}
+	}
+	utils.RemoveLetter(rmdisk.path)
+	utils.ForgetCiphers(rmdisk.path)
+	if err := utils.RemoveSnapshots(rmdisk.path); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+	}
+	if err := globals.SaveMountTable(); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+	}
+
 	fmt.Fprintf(outputBuffer, "Disco en %s eliminado exitosamente.\n", rmdisk.path)
 	fmt.Fprintln(outputBuffer, "========================================================================")
 	return nil
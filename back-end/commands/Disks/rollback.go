@@ -0,0 +1,60 @@
+package commands
+
+import (
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+)
+
+// Rollback estructura que representa el comando rollback
+type Rollback struct {
+	path string // Ruta del disco
+	name string // Nombre del snapshot a restaurar
+}
+
+// ParserRollback parsea el comando rollback y devuelve los mensajes de la restauración
+func ParserRollback(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &Rollback{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "name"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path", "name"); err != nil {
+		return "", err
+	}
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
+
+	err = commandRollback(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandRollback devuelve el disco al estado en que estaba al crear el snapshot
+func commandRollback(rollback *Rollback, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================= ROLLBACK =========================")
+
+	restored, err := utils.RollbackSnapshot(rollback.path, rollback.name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(outputBuffer, "Disco %s restaurado al snapshot '%s' (%d bytes)\n", rollback.path, rollback.name, restored)
+
+	// La tabla de particiones pudo cambiar: las particiones que ya no tienen su ID se desmontan
+	dropped, err := globals.ReconcileMounts(rollback.path)
+	if err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+	}
+	for _, id := range dropped {
+		fmt.Fprintf(outputBuffer, "Partición con ID '%s' desmontada: no estaba montada en el snapshot.\n", id)
+	}
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
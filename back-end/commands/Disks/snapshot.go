@@ -0,0 +1,60 @@
+package commands
+
+import (
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// Snapshot estructura que representa el comando snapshot
+type Snapshot struct {
+	path string // Ruta del disco
+	name string // Nombre del snapshot
+}
+
+// snapshotNamePattern limita los nombres de snapshot a caracteres válidos en un nombre de archivo
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParserSnapshot parsea el comando snapshot y devuelve los mensajes de la creación del snapshot
+func ParserSnapshot(tokens []string) (string, error) {
+	var outputBuffer bytes.Buffer
+	cmd := &Snapshot{}
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "name"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path", "name"); err != nil {
+		return "", err
+	}
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
+	if !snapshotNamePattern.MatchString(cmd.name) {
+		return "", fmt.Errorf("nombre de snapshot inválido: %s", cmd.name)
+	}
+
+	err = commandSnapshot(cmd, &outputBuffer)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandSnapshot crea un snapshot copy-on-write del disco
+func commandSnapshot(snapshot *Snapshot, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================= SNAPSHOT =========================")
+
+	info, err := utils.CreateSnapshot(snapshot.path, snapshot.name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "Snapshot '%s' creado para el disco %s\n", info.Name, snapshot.path)
+	fmt.Fprintf(outputBuffer, "Tamaño del disco: %d bytes\n", info.DiskSize)
+	fmt.Fprintln(outputBuffer, "Los cambios posteriores guardan su contenido original en el snapshot.")
+	fmt.Fprintln(outputBuffer, "===========================================================")
+	return nil
+}
@@ -3,8 +3,8 @@ package commands
 import (
 	structures "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -21,19 +21,17 @@ func ParserUnmount(tokens []string) (string, error) {
 	cmd := &Unmount{}
 
 	// Parsear el argumento -id
-	for _, token := range tokens {
-		if strings.HasPrefix(token, "-id=") {
-			cmd.id = strings.TrimPrefix(token, "-id=")
-		}
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"id"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Validar que el ID no esté vacío
-	if cmd.id == "" {
-		return "", errors.New("faltan parámetros requeridos: -id")
+	if err := params.Require("id"); err != nil {
+		return "", err
 	}
+	cmd.id = params.Get("id")
 
 	// Ejecutar el comando unmount y capturar los mensajes importantes en el buffer
-	err := commandUnmount(cmd, &outputBuffer)
+	err = commandUnmount(cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -77,6 +75,12 @@ func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 				return fmt.Errorf("error desmontando la partición: %v", err)
 			}
 
+			// Registrar el desmontaje en el superbloque y olvidar la llave de cifrado
+			if !globals.PartitionOptions[unmount.id].ReadOnly {
+				updateMountTimes(file, partition.FilesystemPartition(file), false)
+			}
+			utils.UnregisterCipher(file.Name(), int64(partition.Part_start+structures.CryptHeaderSize))
+
 			// Actualizar el MBR en el archivo después del desmontaje
 			err = mbr.Encode(file)
 			if err != nil {
@@ -88,6 +92,19 @@ func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 		}
 	}
 
+	// Si no está en la tabla, buscarla entre las particiones lógicas
+	if !found {
+		if ebr, err := mbr.FindEBRByID(file, unmount.id); err == nil {
+			partition := ebr.ToPartition()
+			if !globals.PartitionOptions[unmount.id].ReadOnly {
+				updateMountTimes(file, partition.FilesystemPartition(file), false)
+			}
+			utils.UnregisterCipher(file.Name(), int64(partition.Part_start+structures.CryptHeaderSize))
+			structures.UnmountLogical(file.Name(), unmount.id)
+			found = true
+		}
+	}
+
 	// Si no se encontró la partición con el ID, devolver error
 	if !found {
 		return fmt.Errorf("error: no se encontró la partición con ID '%s' en el disco", unmount.id)
@@ -95,6 +112,13 @@ func commandUnmount(unmount *Unmount, outputBuffer *bytes.Buffer) error {
 
 	// Remover el ID de la partición de la lista de particiones montadas
 	delete(globals.MountedPartitions, unmount.id)
+	delete(globals.PartitionOptions, unmount.id)
+	delete(globals.PartitionPassphrases, unmount.id)
+	delete(globals.ReadOnlyFailures, unmount.id)
+	globals.LogoutPartition(unmount.id)
+	if err := globals.SaveMountTable(); err != nil {
+		fmt.Fprintf(outputBuffer, "Advertencia: %v\n", err)
+	}
 
 	// Imprimir el estado después del desmontaje
 	fmt.Fprintf(outputBuffer, "Partición con ID '%s' desmontada exitosamente.\n", unmount.id)
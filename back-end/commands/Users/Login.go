@@ -3,11 +3,10 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -18,73 +17,27 @@ type LOGIN struct {
 }
 
 // ParserLogin analiza los tokens y crea una instancia del comando LOGIN, devolviendo los mensajes importantes en un buffer
-func ParserLogin(tokens []string) (map[string]interface{}, error) {
+func ParserLogin(tokens []string, session *globals.Session) (map[string]interface{}, error) {
 	var outputBuffer bytes.Buffer
 	cmd := &LOGIN{}
-	args := strings.Join(tokens, " ")
-
-	// Expresión regular para los parámetros
-	re := regexp.MustCompile(`-user=[^\s]+|-pass=[^\s]+|-id=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			switch strings.ToLower(kv[0]) {
-			case "-user":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'user' es incorrecto. Asegúrese de escribir '-user=nombre_usuario'.",
-				}, fmt.Errorf("formato de parámetro 'user' incorrecto")
-			case "-pass":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'pass' es incorrecto. Asegúrese de escribir '-pass=contraseña'.",
-				}, fmt.Errorf("formato de parámetro 'pass' incorrecto")
-			case "-id":
-				return map[string]interface{}{
-					"status":  "error",
-					"message": "El formato del parámetro 'id' es incorrecto. Asegúrese de escribir '-id=id_partición'.",
-				}, fmt.Errorf("formato de parámetro 'id' incorrecto")
-			default:
-				return map[string]interface{}{
-					"status":  "error",
-					"message": fmt.Sprintf("Parámetro desconocido: %s. Use solo '-user', '-pass' y '-id'.", kv[0]),
-				}, fmt.Errorf("parámetro desconocido: %s", kv[0])
-			}
-		}
 
-		key, value := strings.ToLower(kv[0]), kv[1]
-		switch key {
-		case "-user":
-			cmd.User = value
-		case "-pass":
-			cmd.Pass = value
-		case "-id":
-			cmd.ID = value
-		}
-	}
-
-	// Validar que se hayan proporcionado todos los parámetros
-	missingParams := []string{}
-	if cmd.User == "" {
-		missingParams = append(missingParams, "El parámetro 'user' es requerido.")
-	}
-	if cmd.Pass == "" {
-		missingParams = append(missingParams, "El parámetro 'pass' es requerido.")
-	}
-	if cmd.ID == "" {
-		missingParams = append(missingParams, "El parámetro 'id' es requerido.")
+	// Buscar los parámetros -user, -pass e -id; los tres son obligatorios
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user", "pass", "id"}})
+	if err == nil {
+		err = params.Require("user", "pass", "id")
 	}
-	if len(missingParams) > 0 {
+	if err != nil {
 		return map[string]interface{}{
 			"status":  "error",
-			"message": strings.Join(missingParams, " "),
-		}, fmt.Errorf("faltan parámetros requeridos: %s", strings.Join(missingParams, ", "))
+			"message": err.Error(),
+		}, err
 	}
+	cmd.User = params.Get("user")
+	cmd.Pass = params.Get("pass")
+	cmd.ID = params.Get("id")
 
 	// Ejecutar el comando login
-	err := commandLogin(cmd, &outputBuffer)
+	err = commandLogin(cmd, session, &outputBuffer)
 	if err != nil {
 		return map[string]interface{}{
 			"status":  "error",
@@ -99,12 +52,12 @@ func ParserLogin(tokens []string) (map[string]interface{}, error) {
 }
 
 // Lógica para ejecutar el login con respuesta estructurada
-func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
+func commandLogin(login *LOGIN, session *globals.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "===== INICIO DE LOGIN =====")
 	fmt.Fprintf(outputBuffer, "Intentando iniciar sesión con ID: %s, Usuario: %s\n", login.ID, login.User)
 
 	// Verificar si ya hay un usuario logueado
-	if globals.UsuarioActual != nil && globals.UsuarioActual.Status {
+	if session.IsLoggedIn() {
 		return fmt.Errorf("ya hay un usuario logueado, debe cerrar sesión primero")
 	}
 
@@ -114,7 +67,7 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Verificar si la partición está montada
-	_, path, err := globals.GetMountedPartition(login.ID)
+	partition, path, err := globals.GetMountedPartition(login.ID)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición: %v", err)
 	}
@@ -127,60 +80,108 @@ func commandLogin(login *LOGIN, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "Superblock cargado correctamente")
 
 	// Leer el archivo users.txt (inodo 1)
-	file, err := os.Open(path)
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("no se puede abrir el archivo de partición: %v", err)
 	}
 	defer file.Close()
 
 	var usersInode structs.Inode
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode)))
-	err = usersInode.Decode(file, inodeOffset)
+	inodeOffset := sb.CalculateInodeOffset(1)
+	err = usersInode.Decode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error leyendo inodo de users.txt: %v", err)
 	}
-	usersInode.UpdateAtime()
+	if globals.AtimeEnabled(login.ID) {
+		usersInode.UpdateAtime()
+	}
 
-	var contenido string
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			continue
-		}
+	contenido, err := globals.ReadFileBlocks(file, sb, &usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo users.txt: %v", err)
+	}
 
-		blockOffset := int64(sb.S_block_start + blockIndex*int32(binary.Size(structs.FileBlock{})))
-		var fileBlock structs.FileBlock
-		err = fileBlock.Decode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error leyendo bloque de users.txt: %v", err)
-		}
-		contenido += string(fileBlock.B_content[:])
+	// Las cuentas con demasiados intentos fallidos quedan bloqueadas hasta que root ejecute unlock
+	intentos := leerIntentos(login.ID)
+	if cuentaBloqueada(intentos, login.User) {
+		registrarAutenticacion(login.ID, login.User, "login rechazado, cuenta bloqueada")
+		return fmt.Errorf("la cuenta '%s' está bloqueada por %d intentos fallidos; root debe ejecutar unlock -user=%s", login.User, intentos[login.User], login.User)
 	}
 
 	// Validar usuario y contraseña
 	encontrado := false
+	existe := false
+	deshabilitado := false
 	for _, linea := range strings.Split(strings.TrimSpace(contenido), "\n") {
 		if linea == "" {
 			continue
 		}
 
-		datos := strings.Split(linea, ",")
-		if len(datos) == 5 && datos[1] == "U" {
-			usuario := structs.NewUser(datos[0], datos[2], datos[3], datos[4])
-			if usuario.Name == login.User && usuario.Password == login.Pass {
+		if usuario := structs.ParseUser(linea); usuario != nil {
+			if usuario.Name == login.User && usuario.Id != "0" {
+				existe = true
+				deshabilitado = usuario.Disabled()
+			}
+			// Los usuarios eliminados (id 0) no pueden iniciar sesión aunque la contraseña coincida
+			if usuario.Name == login.User && usuario.Id != "0" && usuario.CheckPassword(login.Pass) {
+				// Las contraseñas antiguas en texto plano se reemplazan por su hash
+				if usuario.HasPlainPassword() && globals.CheckWritable(login.ID) == nil {
+					err = migrarContrasena(file, sb, &usersInode, usuario, login.Pass)
+					if err != nil {
+						return fmt.Errorf("error actualizando la contraseña de '%s': %v", usuario.Name, err)
+					}
+					err = usersInode.Encode(file, inodeOffset, sb)
+					if err != nil {
+						return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
+					}
+					err = sb.Encode(file, int64(partition.Part_start))
+					if err != nil {
+						return fmt.Errorf("error guardando el Superblock: %v", err)
+					}
+					fmt.Fprintln(outputBuffer, "La contraseña guardada en texto plano se reemplazó por su hash.")
+				}
+
 				encontrado = true
-				globals.UsuarioActual = usuario
-				globals.UsuarioActual.Status = true
+				session.Usuario = usuario
+				session.Usuario.Status = true
 				fmt.Fprintf(outputBuffer, "Bienvenido %s, inicio de sesión exitoso.\n", usuario.Name)
-				globals.UsuarioActual.Id = login.ID
+				session.Usuario.Id = login.ID
 				break
 			}
 		}
 	}
 
+	if !encontrado && deshabilitado {
+		registrarAutenticacion(login.ID, login.User, "login rechazado, cuenta deshabilitada")
+		return fmt.Errorf("la cuenta '%s' está deshabilitada; root debe ejecutar usermod -user=%s -enable", login.User, login.User)
+	}
 	if !encontrado {
-		return fmt.Errorf("usuario o contraseña incorrectos")
+		registrarAutenticacion(login.ID, login.User, "login fallido")
+		aviso := ""
+		if existe {
+			aviso = registrarFallo(login.ID, login.User, intentos)
+		}
+		return fmt.Errorf("usuario o contraseña incorrectos%s", aviso)
 	}
 
+	// Un inicio de sesión correcto reinicia los intentos fallidos
+	if intentos[login.User] > 0 {
+		delete(intentos, login.User)
+		if err := guardarIntentos(login.ID, intentos); err != nil {
+			fmt.Printf("No se pudieron reiniciar los intentos fallidos de '%s': %v\n", login.User, err) // Depuración
+		}
+	}
+	registrarAutenticacion(login.ID, login.User, "login correcto")
+
 	fmt.Fprintln(outputBuffer, "======================================================")
 	return nil
 }
+
+// migrarContrasena reemplaza en users.txt la contraseña en texto plano del usuario por su hash
+func migrarContrasena(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, usuario *structs.User, pass string) error {
+	err := usuario.SetPassword(pass)
+	if err != nil {
+		return err
+	}
+	return guardarContrasena(file, sb, usersInode, usuario)
+}
@@ -1,8 +1,8 @@
 package commands
 
 import (
-	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
 	"fmt"
 )
@@ -11,16 +11,16 @@ import (
 type LOGOUT struct{}
 
 // ParserLogout inicializa el comando LOGOUT (sin parámetros) y captura los mensajes importantes
-func ParserLogout(tokens []string) (string, error) {
+func ParserLogout(tokens []string, session *globals.Session) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes para el usuario
 
 	// El comando Logout no debe recibir parámetros
-	if len(tokens) > 1 {
-		return "", fmt.Errorf("el comando Logout no acepta parámetros")
+	if _, err := utils.ParseParams(tokens, utils.ParamSpec{}); err != nil {
+		return "", err
 	}
 
 	// Ejecutar el comando logout y capturar los mensajes
-	err := commandLogout(&outputBuffer)
+	err := commandLogout(session, &outputBuffer)
 	if err != nil {
 		fmt.Println("Error:", err) // Mensaje de depuración en consola
 		return "", err
@@ -31,20 +31,20 @@ func ParserLogout(tokens []string) (string, error) {
 }
 
 // commandLogout ejecuta el comando LOGOUT, y captura los mensajes importantes en un buffer
-func commandLogout(outputBuffer *bytes.Buffer) error {
+func commandLogout(session *globals.Session, outputBuffer *bytes.Buffer) error {
 	// Verificar si hay una sesión activa
-	if globals.UsuarioActual == nil || !globals.UsuarioActual.Status {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
 
 	// Mensaje importante para el usuario
-	fmt.Fprintf(outputBuffer, "Cerrando sesión de usuario: %s\n", globals.UsuarioActual.Name)
+	fmt.Fprintf(outputBuffer, "Cerrando sesión de usuario: %s\n", session.Usuario.Name)
 
 	// Cerrar la sesión
-	fmt.Printf("Cerrando sesión de usuario: %s\n", globals.UsuarioActual.Name) // Mensaje de depuración
+	fmt.Printf("Cerrando sesión de usuario: %s\n", session.Usuario.Name) // Mensaje de depuración
 
 	// Reiniciar la estructura del usuario actual
-	globals.UsuarioActual = &structs.User{}
+	session.Logout()
 
 	// Mensaje de éxito importante para el usuario
 	fmt.Fprintln(outputBuffer, "Sesión cerrada correctamente.")
@@ -3,10 +3,9 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
-	"encoding/binary"
+	utils "backend/utils"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -17,32 +16,26 @@ type CHGRP struct {
 }
 
 // ParserChgrp : Parseo de argumentos para el comando chgrp
-func ParserChgrp(tokens []string) (string, error) {
+func ParserChgrp(tokens []string, session *globals.Session) (string, error) {
 	// Inicializar el comando CHGRP
 	var outputBuffer strings.Builder
 	cmd := &CHGRP{}
 
-	// Expresión regular para encontrar los parámetros -user y -grp
-	reUser := regexp.MustCompile(`-user=[^\s]+`)
-	reGrp := regexp.MustCompile(`-grp=[^\s]+`)
-
-	// Buscar los parámetros
-	matchesUser := reUser.FindString(strings.Join(tokens, " "))
-	matchesGrp := reGrp.FindString(strings.Join(tokens, " "))
-
-	if matchesUser == "" {
-		return "", fmt.Errorf("falta el parámetro -usr")
+	// Buscar los parámetros -user y -grp
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user", "grp"}})
+	if err != nil {
+		return "", err
 	}
-	if matchesGrp == "" {
-		return "", fmt.Errorf("falta el parámetro -grp")
+	if err := params.Require("user", "grp"); err != nil {
+		return "", err
 	}
 
 	// Extraer los valores de los parámetros
-	cmd.User = strings.SplitN(matchesUser, "=", 2)[1]
-	cmd.Grp = strings.SplitN(matchesGrp, "=", 2)[1]
+	cmd.User = params.Get("user")
+	cmd.Grp = params.Get("grp")
 
 	// Ejecutar la lógica del comando chgrp
-	err := commandChgrp(cmd, &outputBuffer)
+	err = commandChgrp(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -51,18 +44,18 @@ func ParserChgrp(tokens []string) (string, error) {
 }
 
 // commandChgrp : Ejecuta el comando CHGRP
-func commandChgrp(chgrp *CHGRP, outputBuffer *strings.Builder) error {
+func commandChgrp(chgrp *CHGRP, session *globals.Session, outputBuffer *strings.Builder) error {
 	fmt.Fprintln(outputBuffer, "======================= CHGRP =======================")
 	// Verificar si hay una sesión activa y si el usuario es root
-	if !globals.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if session.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
 	// Verificar que la partición esté montada
-	partition, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+	partition, path, err := globals.GetMountedPartition(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
@@ -75,15 +68,15 @@ func commandChgrp(chgrp *CHGRP, outputBuffer *strings.Builder) error {
 	defer file.Close()
 
 	// Cargar el Superblock usando el descriptor de archivo
-	_, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	_, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
 	// Leer el inodo de users.txt
 	var usersInode structs.Inode
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode))) //ubuacion de los bloques de users.txt
-	err = usersInode.Decode(file, inodeOffset)
+	inodeOffset := sb.CalculateInodeOffset(1) //ubuacion de los bloques de users.txt
+	err = usersInode.Decode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
 	}
@@ -127,6 +120,7 @@ func ChangeUserGroup(file *os.File, sb *structs.Superblock, usersInode *structs.
 	// Procesar el contenido del archivo y convertir a objetos de User y Group
 	var usuarios []structs.User
 	var grupos []structs.Group
+	var membresias []structs.Membership
 
 	// Separar usuarios y grupos
 	for _, linea := range lineas {
@@ -141,10 +135,13 @@ func ChangeUserGroup(file *os.File, sb *structs.Superblock, usersInode *structs.
 			// Crear un objeto de tipo Group
 			group := structs.NewGroup(partes[0], partes[2])
 			grupos = append(grupos, *group)
-		} else if tipo == "U" && len(partes) >= 5 {
+		} else if user := structs.ParseUser(linea); tipo == "U" && user != nil {
 			// Crear un objeto de tipo User
-			user := structs.NewUser(partes[0], partes[2], partes[3], partes[4])
 			usuarios = append(usuarios, *user)
+		} else if tipo == "M" && len(partes) == 4 {
+			// Crear un objeto de tipo Membership
+			membresia := structs.NewMembership(partes[0], partes[2], partes[3])
+			membresias = append(membresias, *membresia)
 		}
 	}
 
@@ -165,10 +162,9 @@ func ChangeUserGroup(file *os.File, sb *structs.Superblock, usersInode *structs.
 	// Modificar el grupo del usuario si existe
 	for i, usuario := range usuarios {
 		if usuario.Name == userName && usuario.Id != "0" { // Verificar que el usuario no esté eliminado
-			// Cambiar el grupo del usuario y actualizar su ID al ID del nuevo grupo
+			// Cambiar el grupo del usuario; su UID no cambia
 			fmt.Printf("Cambiando el grupo del usuario '%s' al grupo '%s' (ID grupo: %s)\n", usuario.Name, newGroup, nuevoIDGrupo)
 			usuarios[i].Group = newGroup
-			usuarios[i].Id = nuevoIDGrupo // Cambiar el ID del usuario al ID del grupo destino
 			fmt.Printf("Nuevo estado del usuario: %s\n", usuarios[i].ToString())
 			usuarioModificado = true
 		}
@@ -188,44 +184,29 @@ func ChangeUserGroup(file *os.File, sb *structs.Superblock, usersInode *structs.
 				nuevoContenido = append(nuevoContenido, usuario.ToString())
 			}
 		}
-	}
 
-	// Limpiar los bloques asignados antes de escribir el nuevo contenido
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break // No hay más bloques asignados
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock structs.FileBlock
-
-		// Limpiar el contenido del bloque
-		fileBlock.ClearContent()
-
-		// Escribir el bloque vacío de nuevo
-		err = fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
+		// Conservar las membresías suplementarias del grupo, salvo la del usuario que ahora lo tiene como principal
+		for _, membresia := range membresias {
+			if membresia.Group == group.Group && !(membresia.User == userName && group.Group == newGroup) {
+				nuevoContenido = append(nuevoContenido, membresia.ToString())
+			}
 		}
 	}
 
-	// Reescribir el contenido agrupado en los bloques de `users.txt`
-	// Aquí aseguramos que el contenido se divida correctamente entre los bloques
+	// Reescribir el contenido agrupado en los bloques de `users.txt`; un nombre de grupo más largo
+	// puede hacer crecer el archivo, y si no cabe queda como estaba
 	err = WriteContentToBlocks(file, sb, usersInode, nuevoContenido)
 	if err != nil {
 		return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
 	}
 
-	// Actualizar el tamaño del archivo (i_size)
-	usersInode.I_size = int32(len(strings.Join(nuevoContenido, "\n")))
-
 	// Actualizar tiempos de modificación y cambio
 	usersInode.UpdateMtime()
 	usersInode.UpdateCtime()
 
 	// Guardar el inodo actualizado en el archivo
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(*usersInode)))
-	err = usersInode.Encode(file, inodeOffset)
+	inodeOffset := sb.CalculateInodeOffset(1)
+	err = usersInode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error actualizando inodo de users.txt: %w", err)
 	}
@@ -233,35 +214,9 @@ func ChangeUserGroup(file *os.File, sb *structs.Superblock, usersInode *structs.
 	return nil
 }
 
-// WriteContentToBlocks escribe el contenido de users.txt dividiéndolo en bloques correctamente
+// WriteContentToBlocks reemplaza el contenido de users.txt dividiéndolo en bloques
 func WriteContentToBlocks(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, contenido []string) error {
-	// Convertir el contenido en una cadena
 	contenidoFinal := strings.Join(contenido, "\n") + "\n"
-	data := []byte(contenidoFinal)
-
-	// Tamaño máximo del bloque
-	blockSize := int(sb.S_block_size)
-
-	// Escribir el contenido por bloques
-	for i, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break // No hay más bloques asignados
-		}
-
-		// Dividir los datos en bloques de tamaño máximo
-		start := i * blockSize
-		end := start + blockSize
-		if end > len(data) {
-			end = len(data)
-		}
-
-		// Crear un bloque con el contenido correspondiente
-		var fileBlock structs.FileBlock
-		copy(fileBlock.B_content[:], data[start:end])
-
-		// Mostrar el bloque escrito para depuración
-		fmt.Printf("Escribiendo bloque %d: %s\n", blockIndex, string(fileBlock.B_content[:]))
-	}
-
-	return nil
+	fmt.Printf("Escribiendo users.txt:\n%s", contenidoFinal)
+	return globals.RewriteUsersBlocks(file, sb, usersInode, contenidoFinal)
 }
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GROUPMOD : Estructura para el comando GROUPMOD
+type GROUPMOD struct {
+	Name   string
+	Rename string // Nuevo nombre del grupo
+}
+
+// ParserGroupmod : Parseo de argumentos para el comando groupmod
+func ParserGroupmod(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &GROUPMOD{}
+
+	// Buscar los parámetros -name y -rename
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"name", "rename"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("name", "rename"); err != nil {
+		return "", err
+	}
+	cmd.Name = params.Get("name")
+	cmd.Rename = params.Get("rename")
+	if err := validateParamLength(cmd.Rename, 10, "Grupo"); err != nil {
+		return "", err
+	}
+
+	err = commandGroupmod(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandGroupmod : Ejecuta el comando GROUPMOD sobre el users.txt de la partición de la sesión
+func commandGroupmod(groupmod *GROUPMOD, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "====================== GROUPMOD =====================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+	if session.Usuario.Name != "root" {
+		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
+	}
+	if groupmod.Name == "root" {
+		return fmt.Errorf("no se puede renombrar el grupo root")
+	}
+
+	// Verificar que la partición esté montada
+	partition, path, err := globals.GetMountedPartition(session.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	if err != nil {
+		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
+	}
+	defer file.Close()
+
+	_, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	// Leer el inodo de users.txt
+	var usersInode structs.Inode
+	inodeOffset := sb.CalculateInodeOffset(1)
+	err = usersInode.Decode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
+	}
+
+	usuarios, err := RenameGroup(file, sb, &usersInode, groupmod.Name, groupmod.Rename)
+	if err != nil {
+		return err
+	}
+
+	// Actualizar el inodo de users.txt
+	err = usersInode.Encode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
+	}
+
+	// Guardar el Superblock usando el Part_start como el offset
+	err = sb.Encode(file, int64(partition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error guardando el Superblock: %v", err)
+	}
+
+	// Los usuarios de la sesión actual también ven el nuevo nombre
+	for usuario := session.Usuario; usuario != nil; usuario = usuario.Anterior {
+		if usuario.Group == groupmod.Name {
+			usuario.Group = groupmod.Rename
+		}
+	}
+
+	fmt.Fprintf(outputBuffer, "Grupo '%s' renombrado a '%s' (conserva su id; %d referencias de usuarios actualizadas).\n", groupmod.Name, groupmod.Rename, usuarios)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// RenameGroup : Cambia el nombre de un grupo en su línea, en la de cada usuario que lo tiene como principal
+// y en sus membresías. El gid no cambia, así los inodos del grupo siguen siéndolo.
+// Devuelve cuántas líneas de usuarios y membresías se actualizaron.
+func RenameGroup(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, groupName, newName string) (int, error) {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	posicion := -1
+	for i, linea := range lineas {
+		partes := strings.Split(strings.TrimSpace(linea), ",")
+		if len(partes) != 3 || partes[1] != "G" {
+			continue
+		}
+		if partes[2] == newName {
+			return 0, fmt.Errorf("ya existe un grupo con el nombre '%s'", newName)
+		}
+		if partes[2] == groupName && partes[0] != "0" {
+			posicion = i
+		}
+	}
+	if posicion == -1 {
+		return 0, fmt.Errorf("el grupo '%s' no existe o está eliminado", groupName)
+	}
+
+	actualizadas := 0
+	for i, linea := range lineas {
+		linea = strings.TrimSpace(linea)
+		partes := strings.Split(linea, ",")
+		switch {
+		case i == posicion:
+			lineas[i] = structs.NewGroup(partes[0], newName).ToString()
+		case len(partes) == 4 && partes[1] == "M" && partes[2] == groupName:
+			lineas[i] = structs.NewMembership(partes[0], newName, partes[3]).ToString()
+			actualizadas++
+		default:
+			// Los usuarios eliminados también se actualizan para que usermod -enable encuentre su grupo
+			if usuario := crearUsuarioDesdeLinea(linea); usuario != nil && usuario.Group == groupName {
+				usuario.Group = newName
+				lineas[i] = usuario.ToString()
+				actualizadas++
+			}
+		}
+	}
+	return actualizadas, escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+}
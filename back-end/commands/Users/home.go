@@ -0,0 +1,248 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// HomeDir es la carpeta de la partición donde se crean los directorios personales
+const HomeDir = "home"
+
+// SkelPath es la carpeta cuyo contenido se copia a cada directorio personal nuevo (si existe)
+var SkelPath = []string{"etc", "skel"}
+
+// Permisos del directorio personal: solo el propietario puede leer, escribir y entrar
+var homePerm = [3]byte{'7', '0', '0'}
+
+// asignarPropietario cambia el propietario, el grupo, los permisos y los atributos (I_flags) de un inodo
+func asignarPropietario(file *os.File, sb *structs.Superblock, inodeIndex, uid, gid int32, perm [3]byte, flags uint32) error {
+	inode := &structs.Inode{}
+	offset := sb.CalculateInodeOffset(inodeIndex)
+	err := inode.Decode(file, offset, sb)
+	if err != nil {
+		return fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
+	}
+
+	inode.I_uid = uid
+	inode.I_gid = gid
+	inode.I_perm = perm
+	inode.I_flags = flags
+	inode.UpdateCtime()
+
+	err = inode.Encode(file, offset, sb)
+	if err != nil {
+		return fmt.Errorf("error al serializar inodo %d: %v", inodeIndex, err)
+	}
+	return nil
+}
+
+// crearDirectorioPersonal crea /home/<usuario> con el usuario como propietario y copia /etc/skel si existe.
+// Devuelve la cantidad de entradas copiadas del esqueleto.
+func crearDirectorioPersonal(file *os.File, sb *structs.Superblock, usuario *structs.User, gid string) (int, error) {
+	uid, err := strconv.Atoi(usuario.Id)
+	if err != nil {
+		return 0, fmt.Errorf("id de usuario inválido '%s'", usuario.Id)
+	}
+	gidNum, err := strconv.Atoi(gid)
+	if err != nil {
+		return 0, fmt.Errorf("id de grupo inválido '%s'", gid)
+	}
+
+	// Crear /home si la partición todavía no lo tiene
+	homeInode, err := globals.FindPath(file, sb, []string{HomeDir})
+	if err != nil {
+		return 0, err
+	}
+	if homeInode == -1 {
+		err = sb.CreateFolder(file, nil, HomeDir)
+		if err != nil {
+			return 0, fmt.Errorf("error creando /%s: %v", HomeDir, err)
+		}
+	}
+
+	destino := []string{HomeDir, usuario.Name}
+	err = sb.CreateFolder(file, []string{HomeDir}, usuario.Name)
+	if err != nil {
+		return 0, fmt.Errorf("error creando /%s: %v", strings.Join(destino, "/"), err)
+	}
+	userInode, err := globals.FindPath(file, sb, destino)
+	if err != nil {
+		return 0, err
+	}
+	if userInode == -1 {
+		return 0, fmt.Errorf("no se pudo crear /%s", strings.Join(destino, "/"))
+	}
+
+	err = asignarPropietario(file, sb, userInode, int32(uid), int32(gidNum), homePerm, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	// Copiar el esqueleto, si la partición lo tiene
+	skelInode, err := globals.FindPath(file, sb, SkelPath)
+	if err != nil || skelInode == -1 {
+		return 0, nil
+	}
+	return copiarEsqueleto(file, sb, skelInode, userInode, destino, int32(uid), int32(gidNum))
+}
+
+// copiarEsqueleto copia recursivamente el contenido de la carpeta origen dentro de la carpeta destino,
+// conservando los permisos y atributos de cada entrada y asignándolas al usuario.
+// Los archivos comprimidos se copian tal como están guardados, sin descomprimirlos.
+func copiarEsqueleto(file *os.File, sb *structs.Superblock, origen, destinoInode int32, destino []string, uid, gid int32) (int, error) {
+	entradas, err := leerEntradas(file, sb, origen)
+	if err != nil {
+		return 0, err
+	}
+
+	copiadas := 0
+	for _, entrada := range entradas {
+		inode := &structs.Inode{}
+		err := inode.Decode(file, sb.CalculateInodeOffset(entrada.B_inodo), sb)
+		if err != nil {
+			return copiadas, fmt.Errorf("error al deserializar inodo %d: %v", entrada.B_inodo, err)
+		}
+		nombre := strings.Trim(string(entrada.B_name[:]), "\x00 ")
+
+		if inode.I_type[0] == '0' {
+			err = sb.CreateFolder(file, destino, nombre)
+		} else {
+			// I_size es el tamaño sin comprimir, así que se conserva junto con los bloques
+			var contenido string
+			contenido, err = globals.ReadFileBlocks(file, sb, inode)
+			if err == nil {
+				err = sb.CreateFile(file, destino, nombre, int(inode.I_size), []string{contenido})
+			}
+		}
+		if err != nil {
+			return copiadas, fmt.Errorf("error copiando '%s' del esqueleto: %v", nombre, err)
+		}
+
+		nuevo, err := sb.FindFolderEntry(file, destinoInode, nombre)
+		if err != nil {
+			return copiadas, err
+		}
+		if nuevo == -1 {
+			return copiadas, fmt.Errorf("no se pudo copiar '%s' del esqueleto", nombre)
+		}
+		err = asignarPropietario(file, sb, nuevo, uid, gid, inode.I_perm, inode.I_flags)
+		if err != nil {
+			return copiadas, err
+		}
+		copiadas++
+
+		if inode.I_type[0] == '0' {
+			n, err := copiarEsqueleto(file, sb, entrada.B_inodo, nuevo, append(append([]string{}, destino...), nombre), uid, gid)
+			copiadas += n
+			if err != nil {
+				return copiadas, err
+			}
+		}
+	}
+	return copiadas, nil
+}
+
+// leerEntradas devuelve las entradas de una carpeta, sin . y ..
+func leerEntradas(file *os.File, sb *structs.Superblock, inodeIndex int32) ([]structs.FolderContent, error) {
+	inode := &structs.Inode{}
+	err := inode.Decode(file, sb.CalculateInodeOffset(inodeIndex), sb)
+	if err != nil {
+		return nil, fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
+	}
+
+	var entradas []structs.FolderContent
+	for _, blockIndex := range inode.I_block {
+		if blockIndex == -1 {
+			break
+		}
+
+		block := &structs.FolderBlock{}
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
+		if err != nil {
+			return nil, fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
+		}
+
+		for _, content := range block.B_content {
+			nombre := strings.Trim(string(content.B_name[:]), "\x00 ")
+			if content.B_inodo != -1 && nombre != "." && nombre != ".." {
+				entradas = append(entradas, content)
+			}
+		}
+	}
+	return entradas, nil
+}
+
+// eliminarDirectorioPersonal borra /home/<usuario> y todo su contenido; indica si la carpeta existía.
+// Busca la carpeta solo dentro de /home para no borrar otra carpeta con el nombre del usuario.
+func eliminarDirectorioPersonal(file *os.File, sb *structs.Superblock, userName string) (bool, error) {
+	homeInode, err := globals.FindPath(file, sb, []string{HomeDir})
+	if err != nil || homeInode == -1 {
+		return false, err
+	}
+	userInode, err := sb.FindFolderEntry(file, homeInode, userName)
+	if err != nil {
+		return false, err
+	}
+	if userInode == -1 {
+		return false, nil
+	}
+
+	err = sb.DeleteFolderEntry(file, homeInode, userName)
+	if err != nil {
+		return false, fmt.Errorf("error eliminando /%s/%s: %v", HomeDir, userName, err)
+	}
+	return true, nil
+}
+
+// renombrarDirectorioPersonal cambia el nombre de /home/<anterior> a /home/<nuevo>; indica si la carpeta existía
+func renombrarDirectorioPersonal(file *os.File, sb *structs.Superblock, anterior, nuevo string) (bool, error) {
+	homeInode, err := globals.FindPath(file, sb, []string{HomeDir})
+	if err != nil || homeInode == -1 {
+		return false, err
+	}
+	existente, err := sb.FindFolderEntry(file, homeInode, nuevo)
+	if err != nil {
+		return false, err
+	}
+	if existente != -1 {
+		return false, fmt.Errorf("ya existe /%s/%s", HomeDir, nuevo)
+	}
+
+	inode := &structs.Inode{}
+	err = inode.Decode(file, sb.CalculateInodeOffset(homeInode), sb)
+	if err != nil {
+		return false, fmt.Errorf("error al deserializar inodo %d: %v", homeInode, err)
+	}
+
+	for _, blockIndex := range inode.I_block {
+		if blockIndex == -1 {
+			break
+		}
+
+		offset := int64(sb.S_block_start + (blockIndex * sb.S_block_size))
+		block := &structs.FolderBlock{}
+		err := block.Decode(file, offset, sb)
+		if err != nil {
+			return false, fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
+		}
+
+		for i := range block.B_content {
+			content := &block.B_content[i]
+			if content.B_inodo == -1 || strings.Trim(string(content.B_name[:]), "\x00 ") != anterior {
+				continue
+			}
+			content.B_name = [12]byte{}
+			copy(content.B_name[:], nuevo)
+			err = block.Encode(file, offset, sb)
+			if err != nil {
+				return false, fmt.Errorf("error al serializar bloque %d: %v", blockIndex, err)
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
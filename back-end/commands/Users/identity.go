@@ -0,0 +1,277 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// IDENTITY : Estructura para los comandos whoami, id y groups
+type IDENTITY struct {
+	User string // Usuario consultado; vacío es el usuario de la sesión
+}
+
+// ParserWhoami : Parseo de argumentos para el comando whoami (no recibe parámetros)
+func ParserWhoami(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	if _, err := utils.ParseParams(tokens, utils.ParamSpec{}); err != nil {
+		return "", err
+	}
+
+	err := commandWhoami(session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// ParserId : Parseo de argumentos para el comando id
+func ParserId(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	identity, err := parseIdentity(tokens)
+	if err != nil {
+		return "", err
+	}
+
+	err = commandId(identity, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// ParserGroups : Parseo de argumentos para el comando groups
+func ParserGroups(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	identity, err := parseIdentity(tokens)
+	if err != nil {
+		return "", err
+	}
+
+	err = commandGroups(identity, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// ParserLsusr : Parseo de argumentos para el comando lsusr (no recibe parámetros)
+func ParserLsusr(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	if _, err := utils.ParseParams(tokens, utils.ParamSpec{}); err != nil {
+		return "", err
+	}
+
+	err := commandLsusr(session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// ParserLsgrp : Parseo de argumentos para el comando lsgrp (no recibe parámetros)
+func ParserLsgrp(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	if _, err := utils.ParseParams(tokens, utils.ParamSpec{}); err != nil {
+		return "", err
+	}
+
+	err := commandLsgrp(session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// parseIdentity busca el parámetro opcional -user
+func parseIdentity(tokens []string) (*IDENTITY, error) {
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user"}})
+	if err != nil {
+		return nil, err
+	}
+	return &IDENTITY{User: params.Get("user")}, nil
+}
+
+// commandWhoami : Muestra el usuario con el que se ejecutan los comandos y quién inició la sesión
+func commandWhoami(session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= WHOAMI ======================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+
+	fmt.Fprintln(outputBuffer, session.Usuario.Name)
+	inicial := session.Usuario
+	for inicial.Anterior != nil {
+		inicial = inicial.Anterior
+	}
+	if inicial != session.Usuario {
+		fmt.Fprintf(outputBuffer, "Sesión iniciada por '%s' en la partición %s\n", inicial.Name, session.Usuario.Id)
+	} else {
+		fmt.Fprintf(outputBuffer, "Partición: %s\n", session.Usuario.Id)
+	}
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// commandId : Muestra uid, gid y grupos de un usuario de la partición de la sesión
+func commandId(identity *IDENTITY, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================= ID ========================")
+	users, userName, err := usuariosDeSesion(identity, session)
+	if err != nil {
+		return err
+	}
+
+	usuario := users.ActiveUser(userName)
+	if usuario == nil {
+		return fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+	grupos, err := users.GroupsOf(userName)
+	if err != nil {
+		return err
+	}
+
+	conId := make([]string, len(grupos))
+	for i, grupo := range grupos {
+		conId[i] = fmt.Sprintf("%s(%s)", gidDe(users, grupo), grupo)
+	}
+	fmt.Fprintf(outputBuffer, "uid=%s(%s) gid=%s grupos=%s partición=%s\n",
+		usuario.Id, usuario.Name, conId[0], strings.Join(conId, ","), session.Usuario.Id)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// commandGroups : Muestra el grupo principal y los suplementarios de un usuario
+func commandGroups(identity *IDENTITY, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= GROUPS ======================")
+	users, userName, err := usuariosDeSesion(identity, session)
+	if err != nil {
+		return err
+	}
+
+	grupos, err := users.GroupsOf(userName)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(outputBuffer, "%s : %s\n", userName, strings.Join(grupos, " "))
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// commandLsusr : Lista los usuarios de users.txt, activos y eliminados
+func commandLsusr(session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= LSUSR =======================")
+	users, _, err := usuariosDeSesion(&IDENTITY{}, session)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "%-5s %-12s %-12s %-13s %s\n", "UID", "Usuario", "Grupo", "Estado", "Suplementarios")
+	activos := 0
+	for _, usuario := range users.Users {
+		estado, suplementarios := "eliminado", "-"
+		if usuario.Status {
+			activos++
+			estado = "activo"
+			if usuario.Disabled() {
+				estado = "deshabilitado"
+			}
+			grupos, _ := users.GroupsOf(usuario.Name)
+			if len(grupos) > 1 {
+				suplementarios = strings.Join(grupos[1:], ",")
+			}
+		}
+		fmt.Fprintf(outputBuffer, "%-5s %-12s %-12s %-13s %s\n", usuario.Id, usuario.Name, usuario.Group, estado, suplementarios)
+	}
+	fmt.Fprintf(outputBuffer, "Total: %d usuarios (%d activos, %d eliminados)\n", len(users.Users), activos, len(users.Users)-activos)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// commandLsgrp : Lista los grupos de users.txt con sus miembros, activos y eliminados
+func commandLsgrp(session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= LSGRP =======================")
+	users, _, err := usuariosDeSesion(&IDENTITY{}, session)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(outputBuffer, "%-5s %-12s %-10s %s\n", "GID", "Grupo", "Estado", "Miembros")
+	activos := 0
+	for _, grupo := range users.Groups {
+		estado, miembros := "eliminado", "-"
+		if grupo.GID != "0" {
+			activos++
+			estado = "activo"
+			if nombres := miembrosDe(users, grupo.Group); len(nombres) > 0 {
+				miembros = strings.Join(nombres, ",")
+			}
+		}
+		fmt.Fprintf(outputBuffer, "%-5s %-12s %-10s %s\n", grupo.GID, grupo.Group, estado, miembros)
+	}
+	fmt.Fprintf(outputBuffer, "Total: %d grupos (%d activos, %d eliminados)\n", len(users.Groups), activos, len(users.Groups)-activos)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// usuariosDeSesion lee users.txt de la partición de la sesión y resuelve el usuario consultado
+func usuariosDeSesion(identity *IDENTITY, session *globals.Session) (*globals.UsersFile, string, error) {
+	if !session.IsLoggedIn() {
+		return nil, "", fmt.Errorf("no hay ninguna sesión activa")
+	}
+
+	users, err := globals.ReadUsers(session.Usuario.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	userName := identity.User
+	if userName == "" {
+		userName = session.Usuario.Name
+	}
+	return users, userName, nil
+}
+
+// gidDe devuelve el GID de un grupo activo, o "?" si el grupo fue eliminado
+func gidDe(users *globals.UsersFile, groupName string) string {
+	if grupo := users.ActiveGroup(groupName); grupo != nil {
+		return grupo.GID
+	}
+	return "?"
+}
+
+// miembrosDe devuelve los usuarios activos del grupo, primero los que lo tienen como principal
+func miembrosDe(users *globals.UsersFile, groupName string) []string {
+	var miembros []string
+	incluido := make(map[string]bool)
+	agregar := func(usuario *structs.User) {
+		if usuario != nil && !incluido[usuario.Name] {
+			incluido[usuario.Name] = true
+			miembros = append(miembros, usuario.Name)
+		}
+	}
+
+	for _, usuario := range users.Users {
+		if usuario.Status && usuario.Group == groupName {
+			agregar(usuario)
+		}
+	}
+	for _, miembro := range users.Memberships {
+		if miembro.Group == groupName {
+			agregar(users.ActiveUser(miembro.User))
+		}
+	}
+	return miembros
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	globals "backend/globals"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// filaDe devuelve los campos de la fila de una tabla de lsusr o lsgrp cuyo nombre coincide
+func filaDe(t *testing.T, out, name string) []string {
+	t.Helper()
+	for _, linea := range strings.Split(out, "\n") {
+		if campos := strings.Fields(linea); len(campos) > 1 && campos[1] == name {
+			return campos
+		}
+	}
+	t.Fatalf("no se encontró la fila de %s:\n%s", name, out)
+	return nil
+}
+
+func TestIdentityCommands(t *testing.T) {
+	root, id := newTestPartition(t)
+	steps := []func() (string, error){
+		func() (string, error) { return ParserMkgrp([]string{"-name=devs"}, root) },
+		func() (string, error) { return ParserMkgrp([]string{"-name=viejo"}, root) },
+		func() (string, error) { return ParserMkusr([]string{"-user=ana", "-pass=abc", "-grp=devs"}, root) },
+		func() (string, error) { return ParserMkusr([]string{"-user=bob", "-pass=abc", "-grp=devs"}, root) },
+		func() (string, error) { return ParserMkusr([]string{"-user=eva", "-pass=abc", "-grp=root"}, root) },
+		func() (string, error) { return ParserUsermod([]string{"-user=ana", "-addgrp=root"}, root) },
+		func() (string, error) { return ParserUsermod([]string{"-user=bob", "-disable"}, root) },
+		func() (string, error) { return ParserRmusr([]string{"-user=eva"}, root) },
+		func() (string, error) { return ParserRmgrp([]string{"-name=viejo"}, root) },
+	}
+	for _, step := range steps {
+		if _, err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ana := login(t, "ana", "abc", id)
+
+	// Sin sesión ningún comando de identidad responde
+	for name, parser := range map[string]func([]string, *globals.Session) (string, error){
+		"whoami": ParserWhoami, "id": ParserId, "groups": ParserGroups, "lsusr": ParserLsusr, "lsgrp": ParserLsgrp,
+	} {
+		if _, err := parser(nil, &globals.Session{}); err == nil {
+			t.Errorf("%s sin sesión debería fallar", name)
+		}
+	}
+
+	out, err := ParserWhoami(nil, ana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "\nana\n") || !strings.Contains(out, "Partición: "+id) {
+		t.Fatalf("whoami:\n%s", out)
+	}
+
+	// id sin -user muestra el usuario de la sesión con su grupo principal primero
+	lsusr, err := ParserLsusr(nil, ana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ParserId(nil, ana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := filaDe(t, lsusr, "ana")[0]
+	if want := "uid=" + uid + "(ana) gid=2(devs) grupos=2(devs),1(root) partición=" + id; !strings.Contains(out, want) {
+		t.Fatalf("id = %s\nse esperaba %q", out, want)
+	}
+	if _, err := ParserId([]string{"-user=eva"}, ana); err == nil {
+		t.Fatal("id de un usuario eliminado debería fallar")
+	}
+	if got := gruposDe(t, ana, "ana"); got != "devs root" {
+		t.Fatalf("groups ana = %q", got)
+	}
+	if got := gruposDe(t, ana, "root"); got != "root" {
+		t.Fatalf("groups root = %q", got)
+	}
+
+	// lsusr muestra activos, deshabilitados y eliminados con sus grupos suplementarios
+	for name, want := range map[string]string{
+		"ana": "activo root", "bob": "deshabilitado -", "eva": "eliminado -", "root": "activo -",
+	} {
+		if got := strings.Join(filaDe(t, lsusr, name)[3:], " "); got != want {
+			t.Errorf("lsusr %s = %q, se esperaba %q", name, got, want)
+		}
+	}
+	if !strings.Contains(lsusr, "Total: 4 usuarios (3 activos, 1 eliminados)") {
+		t.Errorf("total de lsusr:\n%s", lsusr)
+	}
+
+	// lsgrp lista a los miembros principales y luego a los suplementarios
+	out, err = ParserLsgrp(nil, ana)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"root": "activo root,ana", "devs": "activo ana,bob", "viejo": "eliminado -",
+	} {
+		fila := filaDe(t, out, name)
+		miembros := strings.Split(fila[3], ",")
+		if name == "devs" {
+			sort.Strings(miembros) // ambos son miembros principales; el orden es el de users.txt
+		}
+		if got := fila[2] + " " + strings.Join(miembros, ","); got != want {
+			t.Errorf("lsgrp %s = %q, se esperaba %q", name, got, want)
+		}
+	}
+	if !strings.Contains(out, "Total: 3 grupos (2 activos, 1 eliminados)") {
+		t.Errorf("total de lsgrp:\n%s", out)
+	}
+}
@@ -3,11 +3,10 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -18,29 +17,24 @@ type MKGRP struct {
 }
 
 // ParserMkgrp : Parseo de argumentos para el comando mkgrp y captura de los mensajes importantes
-func ParserMkgrp(tokens []string) (string, error) {
+func ParserMkgrp(tokens []string, session *globals.Session) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes para el usuario
 
 	// Inicializar el comando MKGRP
 	cmd := &MKGRP{}
 
-	// Expresión regular para encontrar el parámetro -name
-	re := regexp.MustCompile(`-name=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -name")
+	// Buscar el parámetro -name
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"name"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Extraer el valor del parámetro -name
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -name")
+	if err := params.Require("name"); err != nil {
+		return "", err
 	}
-	cmd.Name = param[1]
+	cmd.Name = params.Get("name")
 
 	// Ejecutar la lógica del comando mkgrp
-	err := commandMkgrp(cmd, &outputBuffer)
+	err = commandMkgrp(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -49,18 +43,18 @@ func ParserMkgrp(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
+func commandMkgrp(mkgrp *MKGRP, session *globals.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= MKGRP =======================")
 	// Verificar si hay una sesión activa y si el usuario es root
-	if !globals.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if session.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
 	// Verificar que la partición esté montada
-	_, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+	_, path, err := globals.GetMountedPartition(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
@@ -73,13 +67,13 @@ func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
 	defer file.Close()
 
 	// Cargar el Superblock y la partición
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id) //Id de la particion del usuario actual
+	mbr, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id) //Id de la particion del usuario actual
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
 	// Obtener la partición asociada al id
-	partition, err := mbr.GetPartitionByID(globals.UsuarioActual.Id)
+	partition, err := mbr.FindPartitionByID(file, session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
@@ -87,9 +81,9 @@ func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
 	// Leer el inodo de users.txt
 	var usersInode structs.Inode // Inodo de users.txt
 	// Calcular el offset del inodo de users.txt, esta en el inodo 1
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode))) // Ubicación de los bloques de users.txt
+	inodeOffset := sb.CalculateInodeOffset(1) // Ubicación de los bloques de users.txt
 	// Decodificar el inodo de users.txt
-	err = usersInode.Decode(file, inodeOffset)
+	err = usersInode.Decode(file, inodeOffset, sb)
 	usersInode.UpdateAtime() // Actualizar la última fecha de acceso
 	if err != nil {
 		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
@@ -117,7 +111,7 @@ func commandMkgrp(mkgrp *MKGRP, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Actualizar el inodo de users.txt
-	err = usersInode.Encode(file, inodeOffset)
+	err = usersInode.Encode(file, inodeOffset, sb)
 	usersInode.UpdateAtime()
 	if err != nil {
 		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
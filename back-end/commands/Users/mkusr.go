@@ -3,11 +3,11 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -27,37 +27,25 @@ func validateParamLength(param string, maxLength int, paramName string) error {
 }
 
 // ParserMkusr : Parseo de argumentos para el comando mkusr y captura de los mensajes importantes
-func ParserMkusr(tokens []string) (string, error) {
+func ParserMkusr(tokens []string, session *globals.Session) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes para el usuario
 
 	// Inicializar el comando MKUSR
 	cmd := &MKUSR{}
 
-	// Expresión regular para encontrar los parámetros -user, -pass, -grp
-	reUser := regexp.MustCompile(`-user=[^\s]+`)
-	rePass := regexp.MustCompile(`-pass=[^\s]+`)
-	reGrp := regexp.MustCompile(`-grp=[^\s]+`)
-
-	// Buscar los parámetros
-	matchesUser := reUser.FindString(strings.Join(tokens, " "))
-	matchesPass := rePass.FindString(strings.Join(tokens, " "))
-	matchesGrp := reGrp.FindString(strings.Join(tokens, " "))
-
-	// Verificar que se proporcionen todos los parámetros
-	if matchesUser == "" {
-		return "", fmt.Errorf("falta el parámetro -user")
-	}
-	if matchesPass == "" {
-		return "", fmt.Errorf("falta el parámetro -pass")
+	// Buscar los parámetros -user, -pass y -grp; los tres son obligatorios
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user", "pass", "grp"}})
+	if err != nil {
+		return "", err
 	}
-	if matchesGrp == "" {
-		return "", fmt.Errorf("falta el parámetro -grp")
+	if err := params.Require("user", "pass", "grp"); err != nil {
+		return "", err
 	}
 
 	// Extraer los valores de los parámetros
-	cmd.User = strings.SplitN(matchesUser, "=", 2)[1]
-	cmd.Pass = strings.SplitN(matchesPass, "=", 2)[1]
-	cmd.Grp = strings.SplitN(matchesGrp, "=", 2)[1]
+	cmd.User = params.Get("user")
+	cmd.Pass = params.Get("pass")
+	cmd.Grp = params.Get("grp")
 
 	// Validar longitudes de los parámetros
 	if err := validateParamLength(cmd.User, 10, "Usuario"); err != nil {
@@ -71,7 +59,7 @@ func ParserMkusr(tokens []string) (string, error) {
 	}
 
 	// Ejecutar la lógica del comando mkusr
-	err := commandMkusr(cmd, &outputBuffer)
+	err = commandMkusr(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -81,18 +69,18 @@ func ParserMkusr(tokens []string) (string, error) {
 }
 
 // commandMkusr : Ejecuta el comando MKUSR con captura de mensajes importantes en el buffer
-func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
+func commandMkusr(mkusr *MKUSR, session *globals.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= MKUSR =======================")
 	// Verificar si hay una sesión activa y si el usuario es root
-	if !globals.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if session.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
 	// Verificar que la partición esté montada
-	_, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+	_, path, err := globals.GetMountedPartition(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
@@ -105,27 +93,27 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 	defer file.Close()
 
 	// Cargar el Superblock y la partición utilizando la función GetMountedPartitionRep
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
 	// Obtener la partición montada
-	partition, err := mbr.GetPartitionByID(globals.UsuarioActual.Id)
+	partition, err := mbr.FindPartitionByID(file, session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
 
 	// Leer el inodo de users.txt
 	var usersInode structs.Inode
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode))) //ubicación de los bloques de users.txt
-	err = usersInode.Decode(file, inodeOffset)                              // Usar el descriptor de archivo
+	inodeOffset := sb.CalculateInodeOffset(1)      //ubicación de los bloques de users.txt
+	err = usersInode.Decode(file, inodeOffset, sb) // Usar el descriptor de archivo
 	if err != nil {
 		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
 	}
 
 	// Verificar si el grupo existe en el archivo
-	_, err = globals.FindInUsersFile(file, sb, &usersInode, mkusr.Grp, "G")
+	lineaGrupo, err := globals.FindInUsersFile(file, sb, &usersInode, mkusr.Grp, "G")
 	if err != nil {
 		return fmt.Errorf("el grupo '%s' no existe", mkusr.Grp)
 	}
@@ -136,8 +124,16 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("el usuario '%s' ya existe", mkusr.User)
 	}
 
-	// Crear un nuevo objeto de tipo User
-	usuario := structs.NewUser(fmt.Sprintf("%d", sb.S_inodes_count+1), mkusr.Grp, mkusr.User, mkusr.Pass)
+	// Crear un nuevo objeto de tipo User con el siguiente ID libre de users.txt como UID
+	uid, err := calculateNextID(file, sb, &usersInode)
+	if err != nil {
+		return err
+	}
+	usuario := structs.NewUser(strconv.Itoa(uid), mkusr.Grp, mkusr.User, "")
+	err = usuario.SetPassword(mkusr.Pass)
+	if err != nil {
+		return err
+	}
 	fmt.Println(usuario.ToString())
 
 	// Insertar la nueva entrada en el archivo users.txt
@@ -146,8 +142,29 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error insertando el usuario '%s': %v", mkusr.User, err)
 	}
 
+	// Crear el directorio personal del usuario, salvo que ya exista de un usuario anterior
+	var avisoHome bytes.Buffer
+	home := fmt.Sprintf("/%s/%s", HomeDir, mkusr.User)
+	existente, _ := globals.FindPath(file, sb, []string{HomeDir, mkusr.User})
+	if existente != -1 {
+		fmt.Fprintf(&avisoHome, "La carpeta %s ya existe; no se modificó ni se copió el esqueleto.\n", home)
+	} else {
+		// El usuario ya quedó en users.txt: un fallo aquí se informa sin deshacer el alta.
+		// La carpeta es del UID del usuario y del GID de su grupo principal.
+		gid := strings.Split(lineaGrupo, ",")[0]
+		copiadas, err := crearDirectorioPersonal(file, sb, usuario, gid)
+		if err != nil {
+			fmt.Fprintf(&avisoHome, "No se pudo crear el directorio personal %s: %v\n", home, err)
+		} else {
+			fmt.Fprintf(&avisoHome, "Directorio personal %s creado (permisos 700).\n", home)
+		}
+		if copiadas > 0 {
+			fmt.Fprintf(&avisoHome, "Se copiaron %d entradas de /%s.\n", copiadas, strings.Join(SkelPath, "/"))
+		}
+	}
+
 	// Actualizar el inodo de users.txt
-	err = usersInode.Encode(file, inodeOffset)
+	err = usersInode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
 	}
@@ -160,6 +177,7 @@ func commandMkusr(mkusr *MKUSR, outputBuffer *bytes.Buffer) error {
 
 	// Mostrar mensaje de éxito
 	fmt.Fprintf(outputBuffer, "Usuario '%s' agregado exitosamente al grupo '%s'\n", mkusr.User, mkusr.Grp)
+	outputBuffer.Write(avisoHome.Bytes())
 	fmt.Println("\nSuperblock")
 	sb.Print()
 	fmt.Println("\nInodos")
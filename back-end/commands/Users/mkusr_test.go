@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMkusrGrowsUsersFilePastDirectBlocks(t *testing.T) {
+	session, id := newTestPartition(t)
+
+	// Cada usuario ocupa unos 80 bytes: 14 no caben en los 15 bloques directos
+	const total = 14
+	for i := 1; i <= total; i++ {
+		user := fmt.Sprintf("usuario%02d", i)
+		if _, err := ParserMkusr([]string{"-user=" + user, "-pass=abc", "-grp=root"}, session); err != nil {
+			t.Fatalf("mkusr %s: %v", user, err)
+		}
+	}
+	inode, contenido := usersInode(t, id)
+	if !inode.HasIndirectBlocks() {
+		t.Fatalf("users.txt ocupa %d bytes sin bloques indirectos: I_block = %v", inode.I_size, inode.I_block)
+	}
+	if got := strings.Count(contenido, ",U,"); got != total+1 {
+		t.Fatalf("users.txt tiene %d usuarios, se esperaban %d:\n%s", got, total+1, contenido)
+	}
+
+	// El último usuario está en un bloque indirecto y aun así puede iniciar sesión
+	last := login(t, fmt.Sprintf("usuario%02d", total), "abc", id)
+	if last.Usuario.Name != fmt.Sprintf("usuario%02d", total) {
+		t.Fatalf("sesión iniciada como %q", last.Usuario.Name)
+	}
+
+	// Eliminar un usuario reescribe el archivo sin perder a los demás
+	if _, err := ParserRmusr([]string{"-user=usuario01"}, session); err != nil {
+		t.Fatal(err)
+	}
+	login(t, fmt.Sprintf("usuario%02d", total), "abc", id)
+	if _, err := ParserMkusr([]string{"-user=usuario99", "-pass=abc", "-grp=root"}, session); err != nil {
+		t.Fatal(err)
+	}
+	login(t, "usuario99", "abc", id)
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PASSWD : Estructura para el comando PASSWD
+type PASSWD struct {
+	User string // Usuario al que root le cambia la contraseña (vacío para el usuario actual)
+	Old  string
+	New  string
+}
+
+// ParserPasswd : Parseo de argumentos para el comando passwd
+func ParserPasswd(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &PASSWD{}
+
+	// Buscar los parámetros -user, -old y -new
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user", "old", "new"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("new"); err != nil {
+		return "", err
+	}
+	cmd.User = params.Get("user")
+	cmd.Old = params.Get("old")
+	cmd.New = params.Get("new")
+	if err := validateParamLength(cmd.New, 10, "Contraseña"); err != nil {
+		return "", err
+	}
+
+	// Ejecutar la lógica del comando passwd
+	err = commandPasswd(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandPasswd : Cambia la contraseña del usuario actual, o la de otro usuario si lo ejecuta root
+func commandPasswd(passwd *PASSWD, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= PASSWD ======================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+
+	// Sin -user (o con el propio nombre) se cambia la contraseña propia y hay que confirmar la actual
+	propia := passwd.User == "" || passwd.User == session.Usuario.Name
+	userName := session.Usuario.Name
+	if !propia {
+		if session.Usuario.Name != "root" {
+			return fmt.Errorf("solo el usuario root puede cambiar la contraseña de otro usuario")
+		}
+		userName = passwd.User
+	} else if passwd.Old == "" {
+		return fmt.Errorf("falta el parámetro -old con la contraseña actual")
+	}
+
+	// Verificar que la partición esté montada
+	partition, path, err := globals.GetMountedPartition(session.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	if err != nil {
+		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
+	}
+	defer file.Close()
+
+	_, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	// Leer el inodo de users.txt
+	var usersInode structs.Inode
+	inodeOffset := sb.CalculateInodeOffset(1)
+	err = usersInode.Decode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
+	}
+
+	// Buscar al usuario en users.txt
+	linea, err := globals.FindInUsersFile(file, sb, &usersInode, userName, "U")
+	if err != nil {
+		return fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+	usuario := crearUsuarioDesdeLinea(linea)
+	if usuario == nil || usuario.Id == "0" {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+	if propia && !usuario.CheckPassword(passwd.Old) {
+		return fmt.Errorf("la contraseña actual es incorrecta")
+	}
+
+	err = usuario.SetPassword(passwd.New)
+	if err != nil {
+		return err
+	}
+	err = guardarContrasena(file, sb, &usersInode, usuario)
+	if err != nil {
+		return fmt.Errorf("error actualizando la contraseña de '%s': %v", userName, err)
+	}
+
+	// Actualizar el inodo de users.txt
+	err = usersInode.Encode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
+	}
+
+	// Guardar el Superblock usando el Part_start como el offset
+	err = sb.Encode(file, int64(partition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error guardando el Superblock: %v", err)
+	}
+
+	if propia {
+		session.Usuario.Password = usuario.Password
+	}
+
+	fmt.Fprintf(outputBuffer, "Contraseña del usuario '%s' actualizada correctamente.\n", userName)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// guardarContrasena reescribe en users.txt la línea del usuario con su nuevo hash de contraseña
+func guardarContrasena(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, usuario *structs.User) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+
+	lineas := strings.Split(contenido, "\n")
+	modificado := false
+	for i, linea := range lineas {
+		existente := crearUsuarioDesdeLinea(strings.TrimSpace(linea))
+		if existente != nil && existente.Id != "0" && existente.Name == usuario.Name {
+			existente.Password = usuario.Password
+			lineas[i] = existente.ToString()
+			modificado = true
+			break
+		}
+	}
+	if !modificado {
+		return fmt.Errorf("usuario '%s' no encontrado en users.txt", usuario.Name)
+	}
+
+	return escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+}
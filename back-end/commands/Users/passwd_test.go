@@ -0,0 +1,51 @@
+package commands
+
+import (
+	globals "backend/globals"
+	"testing"
+)
+
+func TestPasswd(t *testing.T) {
+	root, id := newTestPartition(t)
+	if _, err := ParserMkusr([]string{"-user=ana", "-pass=abc", "-grp=root"}, root); err != nil {
+		t.Fatal(err)
+	}
+	ana := login(t, "ana", "abc", id)
+
+	// La contraseña propia solo cambia confirmando la actual
+	if _, err := ParserPasswd([]string{"-new=nueva"}, ana); err == nil {
+		t.Fatal("passwd sin -old debería fallar")
+	}
+	if _, err := ParserPasswd([]string{"-old=mala", "-new=nueva"}, ana); err == nil {
+		t.Fatal("passwd con una contraseña actual incorrecta debería fallar")
+	}
+	if _, err := ParserPasswd([]string{"-old=abc", "-new=nueva"}, ana); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserLogin([]string{"-user=ana", "-pass=abc", "-id=" + id}, &globals.Session{}); err == nil {
+		t.Fatal("la contraseña anterior sigue siendo válida")
+	}
+	login(t, "ana", "nueva", id)
+
+	// Solo root cambia la contraseña de otro usuario, y sin -old
+	if _, err := ParserPasswd([]string{"-user=root", "-new=xyz"}, ana); err == nil {
+		t.Fatal("un usuario normal no debería cambiar la contraseña de root")
+	}
+	if _, err := ParserPasswd([]string{"-user=ana", "-new=deroot"}, root); err != nil {
+		t.Fatal(err)
+	}
+	login(t, "ana", "deroot", id)
+
+	// Las contraseñas tienen como máximo 10 caracteres
+	if _, err := ParserPasswd([]string{"-user=ana", "-new=01234567890"}, root); err == nil {
+		t.Fatal("passwd debería rechazar una contraseña de más de 10 caracteres")
+	}
+	if _, err := ParserPasswd([]string{"-user=ana", "-new=0123456789"}, root); err != nil {
+		t.Fatal(err)
+	}
+	login(t, "ana", "0123456789", id)
+
+	if _, err := ParserPasswd([]string{"-user=nadie", "-new=abc"}, root); err == nil {
+		t.Fatal("passwd de un usuario inexistente debería fallar")
+	}
+}
@@ -3,11 +3,10 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -17,29 +16,24 @@ type RMGRP struct {
 }
 
 // ParserRmgrp : Parseo de argumentos para el comando rmgrp y captura de mensajes importantes
-func ParserRmgrp(tokens []string) (string, error) {
+func ParserRmgrp(tokens []string, session *globals.Session) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes para el usuario
 
 	// Inicializar el comando RMGRP
 	cmd := &RMGRP{}
 
-	// Expresión regular para encontrar el parámetro -name
-	re := regexp.MustCompile(`-name=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -name")
+	// Buscar el parámetro -name
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"name"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Extraer el valor del parámetro -name
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -name")
+	if err := params.Require("name"); err != nil {
+		return "", err
 	}
-	cmd.Name = param[1]
+	cmd.Name = params.Get("name")
 
 	// Ejecutar la lógica del comando rmgrp
-	err := commandRmgrp(cmd, &outputBuffer)
+	err = commandRmgrp(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -49,18 +43,18 @@ func ParserRmgrp(tokens []string) (string, error) {
 }
 
 // commandRmgrp : Ejecuta el comando RMGRP con captura de mensajes importantes en el buffer
-func commandRmgrp(rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
+func commandRmgrp(rmgrp *RMGRP, session *globals.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= RMGRP =======================")
 	// Verificar si hay una sesión activa y si el usuario es root
-	if !globals.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if session.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
 	// Verificar que la partición esté montada
-	_, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+	_, path, err := globals.GetMountedPartition(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
@@ -73,21 +67,21 @@ func commandRmgrp(rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
 	defer file.Close()
 
 	// Cargar el Superblock y la partición
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
 	// Obtener la partición montada
-	partition, err := mbr.GetPartitionByID(globals.UsuarioActual.Id)
+	partition, err := mbr.FindPartitionByID(file, session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
 
 	// Leer el inodo de users.txt
 	var usersInode structs.Inode
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode))) //posición del inodo de users.txt
-	err = usersInode.Decode(file, inodeOffset)
+	inodeOffset := sb.CalculateInodeOffset(1) //posición del inodo de users.txt
+	err = usersInode.Decode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
 	}
@@ -105,7 +99,7 @@ func commandRmgrp(rmgrp *RMGRP, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Actualizar el inodo de users.txt en el archivo
-	err = usersInode.Encode(file, inodeOffset)
+	err = usersInode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
 	}
@@ -170,6 +164,7 @@ func UpdateEntityStateOrRemoveUsers(file *os.File, sb *structs.Superblock, users
 
 			// Si es un grupo, busca y elimina a los usuarios asociados
 			if entityType == "G" {
+				eliminados := make(map[string]bool)
 				// Recorrer de nuevo todas las líneas para eliminar usuarios de ese grupo
 				for j, lineaUsuario := range lineas {
 					lineaUsuario = strings.TrimSpace(lineaUsuario)
@@ -181,8 +176,14 @@ func UpdateEntityStateOrRemoveUsers(file *os.File, sb *structs.Superblock, users
 						// Marcar el usuario como eliminado
 						partesUsuario[0] = "0"
 						lineas[j] = strings.Join(partesUsuario, ",")
+						eliminados[partesUsuario[3]] = true
 					}
 				}
+
+				// Quitar las membresías del grupo y las de los usuarios eliminados
+				lineas = quitarMembresias(lineas, func(grupo, usuario string) bool {
+					return grupo == groupName || eliminados[usuario]
+				})
 			}
 			break // Solo necesitamos modificar una entrada del grupo/usuario
 		}
@@ -192,27 +193,8 @@ func UpdateEntityStateOrRemoveUsers(file *os.File, sb *structs.Superblock, users
 	if modificado {
 		contenidoActualizado := strings.Join(lineas, "\n")
 
-		// Limpiar los bloques asignados al archivo antes de escribir
-		for _, blockIndex := range usersInode.I_block {
-			if blockIndex == -1 {
-				break // No hay más bloques asignados
-			}
-
-			blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-			var fileBlock structs.FileBlock
-
-			// Limpiar el contenido del bloque
-			fileBlock.ClearContent()
-
-			// Escribir el bloque vacío de nuevo
-			err = fileBlock.Encode(file, blockOffset)
-			if err != nil {
-				return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-			}
-		}
-
-		// Reescribir todo el contenido en los bloques después de limpiar
-		err = globals.WriteUsersBlocks(file, sb, usersInode, contenidoActualizado)
+		// Reescribir todo el contenido en los bloques del archivo
+		err = globals.RewriteUsersBlocks(file, sb, usersInode, contenidoActualizado)
 		if err != nil {
 			return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
 		}
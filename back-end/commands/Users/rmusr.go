@@ -3,43 +3,42 @@ package commands
 import (
 	structs "backend/Structs"
 	globals "backend/globals"
+	utils "backend/utils"
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
 // RMUSR : Estructura para el comando RMUSR
 type RMUSR struct {
-	User string
+	User  string
+	Purge bool // Elimina también el directorio personal /home/<usuario>
 }
 
 // ParserRmusr : Parseo de argumentos para el comando rmusr y captura de mensajes importantes
-func ParserRmusr(tokens []string) (string, error) {
+func ParserRmusr(tokens []string, session *globals.Session) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes para el usuario
 
 	// Inicializar el comando RMUSR
 	cmd := &RMUSR{}
 
-	// Expresión regular para encontrar el parámetro -user
-	re := regexp.MustCompile(`-user=[^\s]+`)
-	matches := re.FindString(strings.Join(tokens, " "))
-
-	if matches == "" {
-		return "", fmt.Errorf("falta el parámetro -usr")
+	// Buscar el parámetro -user y la bandera -purge, que no recibe valor
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"user"},
+		Flags:  []string{"purge"},
+	})
+	if err != nil {
+		return "", err
 	}
-
-	// Extraer el valor del parámetro -user
-	param := strings.SplitN(matches, "=", 2)
-	if len(param) != 2 {
-		return "", fmt.Errorf("formato incorrecto para -user")
+	if err := params.Require("user"); err != nil {
+		return "", err
 	}
-	cmd.User = param[1]
+	cmd.User = params.Get("user")
+	cmd.Purge = params.Has("purge")
 
 	// Ejecutar la lógica del comando rmusr
-	err := commandRmusr(cmd, &outputBuffer)
+	err = commandRmusr(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -49,18 +48,18 @@ func ParserRmusr(tokens []string) (string, error) {
 }
 
 // commandRmusr : Ejecuta el comando RMUSR y captura los mensajes importantes en un buffer
-func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
+func commandRmusr(rmusr *RMUSR, session *globals.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprintln(outputBuffer, "======================= RMUSR =======================")
 	// Verificar si hay una sesión activa y si el usuario es root
-	if !globals.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay ninguna sesión activa")
 	}
-	if globals.UsuarioActual.Name != "root" {
+	if session.Usuario.Name != "root" {
 		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
 	}
 
 	// Verificar que la partición está montada
-	_, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+	_, path, err := globals.GetMountedPartition(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
 	}
@@ -73,21 +72,21 @@ func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 	defer file.Close()
 
 	// Cargar el Superblock y la partición usando el descriptor de archivo
-	mbr, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+	mbr, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
 	}
 
 	// Obtener la partición montada
-	partition, err := mbr.GetPartitionByID(globals.UsuarioActual.Id)
+	partition, err := mbr.FindPartitionByID(file, session.Usuario.Id)
 	if err != nil {
 		return fmt.Errorf("no se pudo obtener la partición: %v", err)
 	}
 
 	// Leer el inodo de users.txt
 	var usersInode structs.Inode
-	inodeOffset := int64(sb.S_inode_start + int32(binary.Size(usersInode))) // Posición de los bloques de users.txt
-	err = usersInode.Decode(file, inodeOffset)
+	inodeOffset := sb.CalculateInodeOffset(1) // Posición de los bloques de users.txt
+	err = usersInode.Decode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
 	}
@@ -98,6 +97,15 @@ func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("el usuario '%s' no existe", rmusr.User)
 	}
 
+	// Con -purge se borra primero el directorio personal
+	eliminado := false
+	if rmusr.Purge {
+		eliminado, err = eliminarDirectorioPersonal(file, sb, rmusr.User)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Marcar el usuario como eliminado
 	err = UpdateUserState(file, sb, &usersInode, rmusr.User)
 	if err != nil {
@@ -105,7 +113,7 @@ func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Actualizar el inodo de users.txt
-	err = usersInode.Encode(file, inodeOffset)
+	err = usersInode.Encode(file, inodeOffset, sb)
 	if err != nil {
 		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
 	}
@@ -123,6 +131,11 @@ func commandRmusr(rmusr *RMUSR, outputBuffer *bytes.Buffer) error {
 	sb.Print()
 	fmt.Println("------")
 	fmt.Fprintf(outputBuffer, "Usuario '%s' eliminado exitosamente.\n", rmusr.User)
+	if eliminado {
+		fmt.Fprintf(outputBuffer, "Directorio personal /%s/%s eliminado.\n", HomeDir, rmusr.User)
+	} else if rmusr.Purge {
+		fmt.Fprintf(outputBuffer, "El usuario no tenía directorio personal en /%s.\n", HomeDir)
+	}
 	fmt.Println("\nBloques:")
 	sb.PrintBlocks(file.Name())
 	fmt.Println("\nInodos:")
@@ -170,6 +183,11 @@ func UpdateUserState(file *os.File, sb *structs.Superblock, usersInode *structs.
 		return fmt.Errorf("usuario '%s' no encontrado en users.txt", userName)
 	}
 
+	// Quitar al usuario de sus grupos suplementarios
+	lineas = quitarMembresias(lineas, func(_, usuario string) bool {
+		return usuario == userName
+	})
+
 	// Limpiar y actualizar las líneas antes de escribir
 	contenidoActualizado := limpiarYActualizarContenido(lineas)
 
@@ -179,11 +197,7 @@ func UpdateUserState(file *os.File, sb *structs.Superblock, usersInode *structs.
 
 // crearUsuarioDesdeLinea : Crea un objeto User a partir de una línea del archivo
 func crearUsuarioDesdeLinea(linea string) *structs.User {
-	partes := strings.Split(linea, ",")
-	if len(partes) >= 5 && partes[1] == "U" {
-		return structs.NewUser(partes[0], partes[2], partes[3], partes[4])
-	}
-	return nil
+	return structs.ParseUser(linea)
 }
 
 // limpiarYActualizarContenido : Elimina líneas vacías y devuelve el contenido actualizado como string
@@ -197,29 +211,9 @@ func limpiarYActualizarContenido(lineas []string) string {
 	return strings.Join(contenidoActualizado, "\n") + "\n"
 }
 
-// escribirCambiosEnArchivo : Limpia los bloques y escribe el contenido actualizado en el archivo
+// escribirCambiosEnArchivo : Reemplaza el contenido de users.txt; si no cabe el archivo queda como estaba
 func escribirCambiosEnArchivo(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, contenido string) error {
-	// Limpiar los bloques asignados al archivo antes de escribir
-	for _, blockIndex := range usersInode.I_block {
-		if blockIndex == -1 {
-			break // No hay más bloques asignados
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock structs.FileBlock
-
-		// Limpiar el contenido del bloque
-		fileBlock.ClearContent()
-
-		// Escribir el bloque vacío de nuevo
-		err := fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-		}
-	}
-
-	// Reescribir todo el contenido en los bloques después de limpiar
-	err := globals.WriteUsersBlocks(file, sb, usersInode, contenido)
+	err := globals.RewriteUsersBlocks(file, sb, usersInode, contenido)
 	if err != nil {
 		return fmt.Errorf("error guardando los cambios en users.txt: %v", err)
 	}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+)
+
+// SU : Estructura para el comando SU
+type SU struct {
+	User string
+	Pass string
+}
+
+// ParserSu : Parseo de argumentos para el comando su
+func ParserSu(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &SU{}
+
+	// Buscar los parámetros -user y -pass
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user", "pass"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("user"); err != nil {
+		return "", err
+	}
+	cmd.User = params.Get("user")
+	cmd.Pass = params.Get("pass")
+
+	err = commandSu(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandSu : Cambia al usuario indicado guardando el usuario actual para volver con exit-su
+func commandSu(su *SU, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "========================= SU ========================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+	if su.User == session.Usuario.Name {
+		return fmt.Errorf("ya está trabajando como '%s'", su.User)
+	}
+
+	// El usuario se busca en la misma partición de la sesión actual
+	id := session.Usuario.Id
+	usuario, err := globals.FindActiveUser(id, su.User)
+	if err != nil {
+		return err
+	}
+	if usuario.Disabled() {
+		return fmt.Errorf("la cuenta '%s' está deshabilitada", su.User)
+	}
+
+	// root puede cambiar a cualquier usuario sin su contraseña
+	if session.Usuario.Name != "root" {
+		if su.Pass == "" {
+			return fmt.Errorf("falta el parámetro -pass")
+		}
+		if !usuario.CheckPassword(su.Pass) {
+			registrarAutenticacion(id, session.Usuario.Name, fmt.Sprintf("su a '%s' fallido", su.User))
+			return fmt.Errorf("usuario o contraseña incorrectos")
+		}
+	}
+	registrarAutenticacion(id, session.Usuario.Name, fmt.Sprintf("su a '%s' correcto", su.User))
+
+	usuario.Id = id
+	usuario.Status = true
+	usuario.Anterior = session.Usuario
+	session.Usuario = usuario
+
+	fmt.Fprintf(outputBuffer, "Ahora trabaja como '%s'. Use exit-su para volver a '%s'.\n", usuario.Name, usuario.Anterior.Name)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// ParserExitSu : Parseo del comando exit-su, que no recibe parámetros
+func ParserExitSu(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	if _, err := utils.ParseParams(tokens, utils.ParamSpec{}); err != nil {
+		return "", err
+	}
+
+	err := commandExitSu(session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandExitSu : Vuelve al usuario que estaba activo antes del último su
+func commandExitSu(session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "====================== EXIT-SU ======================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+	anterior := session.Usuario.Anterior
+	if anterior == nil {
+		return fmt.Errorf("no hay ningún su activo")
+	}
+
+	fmt.Fprintf(outputBuffer, "Saliendo de '%s', de vuelta como '%s'.\n", session.Usuario.Name, anterior.Name)
+	session.Usuario = anterior
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
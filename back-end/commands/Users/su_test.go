@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuAndExitSu(t *testing.T) {
+	root, id := newTestPartition(t)
+	for _, user := range []string{"ana", "bob"} {
+		if _, err := ParserMkusr([]string{"-user=" + user, "-pass=" + user, "-grp=root"}, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session := login(t, "ana", "ana", id)
+
+	if _, err := ParserExitSu(nil, session); err == nil {
+		t.Fatal("exit-su sin un su activo debería fallar")
+	}
+	if _, err := ParserSu([]string{"-user=bob"}, session); err == nil {
+		t.Fatal("su sin -pass debería fallar para un usuario normal")
+	}
+	if _, err := ParserSu([]string{"-user=bob", "-pass=mala"}, session); err == nil {
+		t.Fatal("su con una contraseña incorrecta debería fallar")
+	}
+	if _, err := ParserSu([]string{"-user=nadie", "-pass=x"}, session); err == nil {
+		t.Fatal("su a un usuario inexistente debería fallar")
+	}
+	if session.Usuario.Name != "ana" {
+		t.Fatalf("un su fallido cambió la sesión a %q", session.Usuario.Name)
+	}
+
+	// ana -> bob -> root: cada su guarda al usuario anterior
+	if _, err := ParserSu([]string{"-user=bob", "-pass=bob"}, session); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserSu([]string{"-user=root", "-pass=123"}, session); err != nil {
+		t.Fatal(err)
+	}
+	// root cambia a cualquier usuario sin contraseña
+	if _, err := ParserSu([]string{"-user=ana"}, session); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ParserWhoami(nil, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Sesión iniciada por 'ana'") {
+		t.Fatalf("whoami después de su:\n%s", out)
+	}
+
+	// exit-su desapila en orden inverso hasta el usuario que inició la sesión
+	for _, want := range []string{"root", "bob", "ana"} {
+		if _, err := ParserExitSu(nil, session); err != nil {
+			t.Fatal(err)
+		}
+		if session.Usuario.Name != want {
+			t.Fatalf("exit-su volvió a %q, se esperaba %q", session.Usuario.Name, want)
+		}
+	}
+	if _, err := ParserExitSu(nil, session); err == nil {
+		t.Fatal("exit-su debería fallar al llegar al usuario de login")
+	}
+	if session.Usuario.Id != id {
+		t.Fatalf("la sesión quedó en la partición %q", session.Usuario.Id)
+	}
+
+	// Una cuenta deshabilitada no es destino de su
+	if _, err := ParserUsermod([]string{"-user=bob", "-disable"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserSu([]string{"-user=bob"}, root); err == nil {
+		t.Fatal("su a una cuenta deshabilitada debería fallar")
+	}
+	if root.Usuario.Name != "root" || root.Usuario.Anterior != nil {
+		t.Fatalf("la sesión de root cambió: %+v", root.Usuario)
+	}
+}
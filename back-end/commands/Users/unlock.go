@@ -0,0 +1,179 @@
+package commands
+
+import (
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxLoginFailuresEnv es la variable de entorno con la cantidad de intentos fallidos que bloquean una cuenta
+const MaxLoginFailuresEnv = "MIA_MAX_LOGIN_FAILURES"
+
+// DefaultMaxLoginFailures es el umbral de bloqueo cuando la variable de entorno no está definida
+const DefaultMaxLoginFailures = 3
+
+// FaillockPath es el archivo de la partición con los intentos fallidos de cada usuario (usuario,intentos)
+var FaillockPath = []string{"etc", "faillock"}
+
+// UNLOCK : Estructura para el comando UNLOCK
+type UNLOCK struct {
+	User string
+}
+
+// ParserUnlock : Parseo de argumentos para el comando unlock
+func ParserUnlock(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &UNLOCK{}
+
+	// Buscar el parámetro -user
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"user"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("user"); err != nil {
+		return "", err
+	}
+	cmd.User = params.Get("user")
+
+	err = commandUnlock(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandUnlock : Reinicia los intentos fallidos de un usuario para desbloquear su cuenta
+func commandUnlock(unlock *UNLOCK, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= UNLOCK ======================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+	if session.Usuario.Name != "root" {
+		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
+	}
+
+	id := session.Usuario.Id
+	if _, err := globals.FindActiveUser(id, unlock.User); err != nil {
+		return err
+	}
+
+	intentos := leerIntentos(id)
+	previos := intentos[unlock.User]
+	if previos == 0 {
+		fmt.Fprintf(outputBuffer, "El usuario '%s' no tiene intentos fallidos registrados.\n", unlock.User)
+	} else {
+		delete(intentos, unlock.User)
+		err := guardarIntentos(id, intentos)
+		if err != nil {
+			return fmt.Errorf("error desbloqueando a '%s': %v", unlock.User, err)
+		}
+		registrarAutenticacion(id, unlock.User, "unlock por root")
+		fmt.Fprintf(outputBuffer, "Usuario '%s' desbloqueado (%d intentos fallidos reiniciados).\n", unlock.User, previos)
+	}
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// maxIntentos devuelve el umbral de bloqueo configurado en MIA_MAX_LOGIN_FAILURES
+func maxIntentos() int {
+	if valor := os.Getenv(MaxLoginFailuresEnv); valor != "" {
+		if n, err := strconv.Atoi(valor); err == nil && n > 0 {
+			return n
+		}
+		fmt.Printf("Valor inválido en %s: '%s', se usa %d\n", MaxLoginFailuresEnv, valor, DefaultMaxLoginFailures)
+	}
+	return DefaultMaxLoginFailures
+}
+
+// cuentaBloqueada indica si el usuario alcanzó el umbral de intentos fallidos; root nunca se bloquea
+func cuentaBloqueada(intentos map[string]int, userName string) bool {
+	return userName != "root" && intentos[userName] >= maxIntentos()
+}
+
+// leerIntentos lee /etc/faillock de la partición; si no existe no hay intentos registrados.
+// En una partición de solo lectura devuelve los intentos guardados en memoria, si los hay.
+func leerIntentos(id string) map[string]int {
+	intentos := make(map[string]int)
+	if enMemoria, ok := globals.ReadOnlyFailures[id]; ok && globals.CheckWritable(id) != nil {
+		for usuario, n := range enMemoria {
+			intentos[usuario] = n
+		}
+		return intentos
+	}
+
+	contenido, _, err := globals.ReadPartitionFile(id, FaillockPath)
+	if err != nil {
+		fmt.Printf("No se pudo leer /%s: %v\n", strings.Join(FaillockPath, "/"), err) // Depuración
+		return intentos
+	}
+
+	for _, linea := range strings.Split(contenido, "\n") {
+		campos := strings.Split(strings.TrimSpace(linea), ",")
+		if len(campos) != 2 {
+			continue
+		}
+		if n, err := strconv.Atoi(campos[1]); err == nil && n > 0 {
+			intentos[campos[0]] = n
+		}
+	}
+	return intentos
+}
+
+// guardarIntentos reescribe /etc/faillock con los intentos fallidos de cada usuario. En una partición
+// de solo lectura los guarda en memoria hasta desmontarla, para que el bloqueo siga funcionando.
+func guardarIntentos(id string, intentos map[string]int) error {
+	if globals.CheckWritable(id) != nil {
+		enMemoria := make(map[string]int, len(intentos))
+		for usuario, n := range intentos {
+			enMemoria[usuario] = n
+		}
+		globals.ReadOnlyFailures[id] = enMemoria
+		return nil
+	}
+
+	usuarios := make([]string, 0, len(intentos))
+	for usuario := range intentos {
+		usuarios = append(usuarios, usuario)
+	}
+	sort.Strings(usuarios)
+
+	var contenido strings.Builder
+	for _, usuario := range usuarios {
+		fmt.Fprintf(&contenido, "%s,%d\n", usuario, intentos[usuario])
+	}
+	return globals.WritePartitionFile(id, FaillockPath, contenido.String())
+}
+
+// registrarFallo suma un intento fallido al usuario y devuelve un aviso si la cuenta quedó bloqueada
+func registrarFallo(id, userName string, intentos map[string]int) string {
+	if userName == "root" {
+		return ""
+	}
+
+	intentos[userName]++
+	err := guardarIntentos(id, intentos)
+	if err != nil {
+		fmt.Printf("No se pudo registrar el intento fallido de '%s': %v\n", userName, err) // Depuración
+		return ""
+	}
+	if cuentaBloqueada(intentos, userName) {
+		registrarAutenticacion(id, userName, fmt.Sprintf("cuenta bloqueada tras %d intentos fallidos", intentos[userName]))
+		return fmt.Sprintf("; la cuenta '%s' quedó bloqueada tras %d intentos fallidos", userName, intentos[userName])
+	}
+	return ""
+}
+
+// registrarAutenticacion agrega el evento a /var/log/auth.log; un fallo al escribir no interrumpe el comando
+func registrarAutenticacion(id, userName, evento string) {
+	err := globals.AppendAuthLog(id, userName, evento)
+	if err != nil {
+		fmt.Printf("No se pudo escribir en /%s: %v\n", strings.Join(globals.AuthLogPath, "/"), err) // Depuración
+	}
+}
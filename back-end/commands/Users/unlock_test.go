@@ -0,0 +1,138 @@
+package commands
+
+import (
+	Disks "backend/commands/Disks"
+	globals "backend/globals"
+	"strings"
+	"testing"
+)
+
+func TestMaxIntentosFromEnv(t *testing.T) {
+	tests := []struct {
+		valor string
+		want  int
+	}{
+		{"", DefaultMaxLoginFailures},
+		{"5", 5},
+		{"0", DefaultMaxLoginFailures},
+		{"-2", DefaultMaxLoginFailures},
+		{"tres", DefaultMaxLoginFailures},
+	}
+	for _, tt := range tests {
+		t.Setenv(MaxLoginFailuresEnv, tt.valor)
+		if got := maxIntentos(); got != tt.want {
+			t.Errorf("maxIntentos() con %s=%q = %d, se esperaba %d", MaxLoginFailuresEnv, tt.valor, got, tt.want)
+		}
+	}
+}
+
+func TestCuentaBloqueada(t *testing.T) {
+	t.Setenv(MaxLoginFailuresEnv, "2")
+	intentos := map[string]int{"ana": 1, "luis": 2, "root": 10}
+	if cuentaBloqueada(intentos, "ana") {
+		t.Error("ana tiene 1 intento y no debería estar bloqueada")
+	}
+	if !cuentaBloqueada(intentos, "luis") {
+		t.Error("luis alcanzó el umbral y debería estar bloqueado")
+	}
+	if cuentaBloqueada(intentos, "root") {
+		t.Error("root nunca se bloquea")
+	}
+	if cuentaBloqueada(intentos, "nadie") {
+		t.Error("un usuario sin intentos no está bloqueado")
+	}
+}
+
+// fallarLogin intenta iniciar sesión con una contraseña incorrecta y devuelve el error
+func fallarLogin(t *testing.T, user, id string) error {
+	t.Helper()
+	_, err := ParserLogin([]string{"-user=" + user, "-pass=incorrecta", "-id=" + id}, &globals.Session{})
+	if err == nil {
+		t.Fatalf("login de %s con una contraseña incorrecta no falló", user)
+	}
+	return err
+}
+
+func TestRegistrarFalloLocksAccount(t *testing.T) {
+	t.Setenv(MaxLoginFailuresEnv, "2")
+	session, id := newTestPartition(t)
+	if _, err := ParserMkusr([]string{"-user=ana", "-pass=abc", "-grp=root"}, session); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fallarLogin(t, "ana", id); strings.Contains(err.Error(), "bloqueada") {
+		t.Fatalf("el primer intento fallido ya bloqueó la cuenta: %v", err)
+	}
+	if err := fallarLogin(t, "ana", id); !strings.Contains(err.Error(), "quedó bloqueada") {
+		t.Fatalf("el segundo intento fallido debería bloquear la cuenta: %v", err)
+	}
+	if got := leerIntentos(id)["ana"]; got != 2 {
+		t.Fatalf("/etc/faillock registra %d intentos de ana, se esperaban 2", got)
+	}
+	if _, err := ParserLogin([]string{"-user=ana", "-pass=abc", "-id=" + id}, &globals.Session{}); err == nil {
+		t.Fatal("una cuenta bloqueada no debería poder iniciar sesión")
+	}
+
+	// root no se bloquea y un usuario inexistente no suma intentos
+	for i := 0; i < 3; i++ {
+		fallarLogin(t, "root", id)
+		fallarLogin(t, "nadie", id)
+	}
+	login(t, "root", "123", id)
+	if intentos := leerIntentos(id); intentos["root"] != 0 || intentos["nadie"] != 0 {
+		t.Fatalf("intentos registrados = %v", intentos)
+	}
+
+	if _, err := ParserUnlock([]string{"-user=ana"}, session); err != nil {
+		t.Fatal(err)
+	}
+	login(t, "ana", "abc", id)
+	if got := leerIntentos(id)["ana"]; got != 0 {
+		t.Fatalf("unlock dejó %d intentos de ana", got)
+	}
+}
+
+func TestFailedLoginsOnReadOnlyMount(t *testing.T) {
+	t.Setenv(MaxLoginFailuresEnv, "2")
+	session, id := newTestPartition(t)
+	if _, err := ParserMkusr([]string{"-user=ana", "-pass=abc", "-grp=root"}, session); err != nil {
+		t.Fatal(err)
+	}
+	fallarLogin(t, "ana", id)
+
+	// Volver a montar la partición como solo lectura
+	disk := globals.MountedPartitions[id]
+	if _, err := Disks.ParserUnmount([]string{"-id=" + id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Disks.ParserMount([]string{"-path=" + disk, "-name=P1", "-options=ro"}); err != nil {
+		t.Fatal(err)
+	}
+	if globals.CheckWritable(id) == nil {
+		t.Fatalf("la partición %s no quedó montada como solo lectura", id)
+	}
+
+	// El intento guardado en disco cuenta y el siguiente bloquea la cuenta aunque no se pueda escribir
+	if err := fallarLogin(t, "ana", id); !strings.Contains(err.Error(), "quedó bloqueada") {
+		t.Fatalf("el intento fallido en solo lectura debería bloquear la cuenta: %v", err)
+	}
+	if _, err := ParserLogin([]string{"-user=ana", "-pass=abc", "-id=" + id}, &globals.Session{}); err == nil || !strings.Contains(err.Error(), "bloqueada") {
+		t.Fatalf("login de una cuenta bloqueada en solo lectura = %v", err)
+	}
+	contenido, _, err := globals.ReadPartitionFile(id, FaillockPath)
+	if err != nil || contenido != "ana,1\n" {
+		t.Fatalf("/etc/faillock cambió en una partición de solo lectura: %q, %v", contenido, err)
+	}
+
+	// Al desmontar se olvidan los intentos en memoria
+	if _, err := Disks.ParserUnmount([]string{"-id=" + id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := globals.ReadOnlyFailures[id]; ok {
+		t.Fatal("unmount no olvidó los intentos fallidos en memoria")
+	}
+	if _, err := Disks.ParserMount([]string{"-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	login(t, "ana", "abc", id)
+}
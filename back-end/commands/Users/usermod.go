@@ -0,0 +1,363 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	globals "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// USERMOD : Estructura para el comando USERMOD
+type USERMOD struct {
+	User    string
+	AddGrp  string // Grupo suplementario que se agrega
+	DelGrp  string // Grupo suplementario que se quita
+	Rename  string // Nuevo nombre del usuario
+	Disable bool   // Deshabilita la cuenta sin perder su id
+	Enable  bool   // Habilita una cuenta deshabilitada o eliminada
+}
+
+// ParserUsermod : Parseo de argumentos para el comando usermod
+func ParserUsermod(tokens []string, session *globals.Session) (string, error) {
+	var outputBuffer bytes.Buffer
+
+	cmd := &USERMOD{}
+
+	// Buscar los parámetros -user, -addgrp, -delgrp y -rename, y las banderas -disable y -enable
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"user", "addgrp", "delgrp", "rename"},
+		Flags:  []string{"disable", "enable"},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("user"); err != nil {
+		return "", err
+	}
+	cmd.User = params.Get("user")
+	cmd.AddGrp = params.Get("addgrp")
+	cmd.DelGrp = params.Get("delgrp")
+	cmd.Rename = params.Get("rename")
+	cmd.Disable = params.Has("disable")
+	cmd.Enable = params.Has("enable")
+
+	// Los parámetros que no son -user son el cambio a aplicar
+	if len(params.Names()) != 2 {
+		return "", fmt.Errorf("indique un solo cambio: -addgrp, -delgrp, -rename, -disable o -enable")
+	}
+	if err := validateParamLength(cmd.Rename, 10, "Usuario"); err != nil {
+		return "", err
+	}
+
+	err = commandUsermod(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandUsermod : Ejecuta el comando USERMOD sobre el users.txt de la partición de la sesión
+func commandUsermod(usermod *USERMOD, session *globals.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "====================== USERMOD ======================")
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay ninguna sesión activa")
+	}
+	if session.Usuario.Name != "root" {
+		return fmt.Errorf("solo el usuario root puede ejecutar este comando")
+	}
+	if usermod.User == "root" && (usermod.Rename != "" || usermod.Disable) {
+		return fmt.Errorf("no se puede renombrar ni deshabilitar la cuenta root")
+	}
+
+	// Verificar que la partición esté montada
+	id := session.Usuario.Id
+	partition, path, err := globals.GetMountedPartition(id)
+	if err != nil {
+		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0755)
+	if err != nil {
+		return fmt.Errorf("no se puede abrir el archivo de la partición: %v", err)
+	}
+	defer file.Close()
+
+	_, sb, _, err := globals.GetMountedPartitionRep(id)
+	if err != nil {
+		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	// Leer el inodo de users.txt
+	var usersInode structs.Inode
+	inodeOffset := sb.CalculateInodeOffset(1)
+	err = usersInode.Decode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
+	}
+
+	var mensaje string
+	switch {
+	case usermod.AddGrp != "":
+		err = AddMembership(file, sb, &usersInode, usermod.User, usermod.AddGrp)
+		mensaje = fmt.Sprintf("Usuario '%s' agregado al grupo suplementario '%s'.", usermod.User, usermod.AddGrp)
+	case usermod.DelGrp != "":
+		err = RemoveMembership(file, sb, &usersInode, usermod.User, usermod.DelGrp)
+		mensaje = fmt.Sprintf("Usuario '%s' quitado del grupo suplementario '%s'.", usermod.User, usermod.DelGrp)
+	case usermod.Rename != "":
+		err = RenameUser(file, sb, &usersInode, usermod.User, usermod.Rename)
+		mensaje = fmt.Sprintf("Usuario '%s' renombrado a '%s' (conserva su id y sus archivos).", usermod.User, usermod.Rename)
+		if err == nil {
+			movido, errHome := renombrarDirectorioPersonal(file, sb, usermod.User, usermod.Rename)
+			if errHome != nil {
+				mensaje += fmt.Sprintf("\nAdvertencia: no se pudo renombrar /%s/%s: %v", HomeDir, usermod.User, errHome)
+			} else if movido {
+				mensaje += fmt.Sprintf("\nDirectorio personal movido a /%s/%s.", HomeDir, usermod.Rename)
+			}
+		}
+	case usermod.Disable:
+		err = DisableUser(file, sb, &usersInode, usermod.User)
+		mensaje = fmt.Sprintf("Cuenta '%s' deshabilitada; no podrá iniciar sesión hasta usermod -user=%s -enable.", usermod.User, usermod.User)
+	case usermod.Enable:
+		var uid string
+		uid, err = EnableUser(file, sb, &usersInode, usermod.User)
+		mensaje = fmt.Sprintf("Cuenta '%s' habilitada.", usermod.User)
+		if uid != "" {
+			mensaje = fmt.Sprintf("Cuenta eliminada '%s' restaurada con uid %s.", usermod.User, uid)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Actualizar el inodo de users.txt
+	err = usersInode.Encode(file, inodeOffset, sb)
+	if err != nil {
+		return fmt.Errorf("error actualizando inodo de users.txt: %v", err)
+	}
+
+	// Guardar el Superblock usando el Part_start como el offset
+	err = sb.Encode(file, int64(partition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error guardando el Superblock: %v", err)
+	}
+
+	if usermod.Rename != "" {
+		// Los intentos fallidos y la sesión actual siguen al usuario con su nuevo nombre
+		intentos := leerIntentos(id)
+		if n, ok := intentos[usermod.User]; ok {
+			delete(intentos, usermod.User)
+			intentos[usermod.Rename] = n
+			if err := guardarIntentos(id, intentos); err != nil {
+				fmt.Printf("No se pudieron mover los intentos fallidos de '%s': %v\n", usermod.User, err) // Depuración
+			}
+		}
+		for usuario := session.Usuario; usuario != nil; usuario = usuario.Anterior {
+			if usuario.Name == usermod.User {
+				usuario.Name = usermod.Rename
+			}
+		}
+	}
+
+	fmt.Fprintln(outputBuffer, mensaje)
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// AddMembership : Agrega al usuario a un grupo suplementario, justo después de la línea del grupo
+func AddMembership(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, userName, groupName string) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	usuario := buscarUsuarioActivo(lineas, userName)
+	if usuario == nil {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+	if usuario.Group == groupName {
+		return fmt.Errorf("'%s' ya es el grupo principal de '%s'", groupName, userName)
+	}
+
+	posicion := -1
+	var gid string
+	for i, linea := range lineas {
+		partes := strings.Split(strings.TrimSpace(linea), ",")
+		if len(partes) == 3 && partes[1] == "G" && partes[0] != "0" && partes[2] == groupName {
+			posicion, gid = i, partes[0]
+		}
+		if len(partes) == 4 && partes[1] == "M" && partes[2] == groupName && partes[3] == userName {
+			return fmt.Errorf("el usuario '%s' ya pertenece al grupo '%s'", userName, groupName)
+		}
+	}
+	if posicion == -1 {
+		return fmt.Errorf("el grupo '%s' no existe o está eliminado", groupName)
+	}
+
+	membresia := structs.NewMembership(gid, groupName, userName)
+	lineas = append(lineas[:posicion+1], append([]string{membresia.ToString()}, lineas[posicion+1:]...)...)
+	return escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+}
+
+// RemoveMembership : Quita al usuario de un grupo suplementario
+func RemoveMembership(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, userName, groupName string) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	usuario := buscarUsuarioActivo(lineas, userName)
+	if usuario == nil {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+	if usuario.Group == groupName {
+		return fmt.Errorf("'%s' es el grupo principal de '%s'; use chgrp para cambiarlo", groupName, userName)
+	}
+
+	restantes := quitarMembresias(lineas, func(grupo, usuario string) bool {
+		return grupo == groupName && usuario == userName
+	})
+	if len(restantes) == len(lineas) {
+		return fmt.Errorf("el usuario '%s' no pertenece al grupo '%s'", userName, groupName)
+	}
+	return escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(restantes))
+}
+
+// buscarUsuarioActivo : Devuelve el usuario no eliminado con ese nombre, o nil
+func buscarUsuarioActivo(lineas []string, userName string) *structs.User {
+	for _, linea := range lineas {
+		usuario := crearUsuarioDesdeLinea(strings.TrimSpace(linea))
+		if usuario != nil && usuario.Id != "0" && usuario.Name == userName {
+			return usuario
+		}
+	}
+	return nil
+}
+
+// quitarMembresias : Devuelve las líneas sin las membresías que cumplan la condición
+func quitarMembresias(lineas []string, quitar func(grupo, usuario string) bool) []string {
+	var restantes []string
+	for _, linea := range lineas {
+		partes := strings.Split(strings.TrimSpace(linea), ",")
+		if len(partes) == 4 && partes[1] == "M" && quitar(partes[2], partes[3]) {
+			continue
+		}
+		restantes = append(restantes, linea)
+	}
+	return restantes
+}
+
+// RenameUser : Cambia el nombre de un usuario y de sus membresías; el id no cambia, así los inodos
+// que le pertenecen siguen siendo suyos
+func RenameUser(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, userName, newName string) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	if buscarUsuarioActivo(lineas, userName) == nil {
+		return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+	}
+	for _, linea := range lineas {
+		if usuario := crearUsuarioDesdeLinea(strings.TrimSpace(linea)); usuario != nil && usuario.Name == newName {
+			return fmt.Errorf("ya existe un usuario con el nombre '%s'", newName)
+		}
+	}
+
+	for i, linea := range lineas {
+		linea = strings.TrimSpace(linea)
+		partes := strings.Split(linea, ",")
+		if usuario := crearUsuarioDesdeLinea(linea); usuario != nil && usuario.Id != "0" && usuario.Name == userName {
+			usuario.Name = newName
+			lineas[i] = usuario.ToString()
+		} else if len(partes) == 4 && partes[1] == "M" && partes[3] == userName {
+			lineas[i] = structs.NewMembership(partes[0], partes[2], newName).ToString()
+		}
+	}
+	return escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+}
+
+// DisableUser : Deshabilita la cuenta conservando su línea, su id y sus grupos
+func DisableUser(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, userName string) error {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	for i, linea := range lineas {
+		usuario := crearUsuarioDesdeLinea(strings.TrimSpace(linea))
+		if usuario == nil || usuario.Id == "0" || usuario.Name != userName {
+			continue
+		}
+		if usuario.Disabled() {
+			return fmt.Errorf("la cuenta '%s' ya está deshabilitada", userName)
+		}
+		usuario.Disable()
+		lineas[i] = usuario.ToString()
+		return escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+	}
+	return fmt.Errorf("el usuario '%s' no existe o está eliminado", userName)
+}
+
+// EnableUser : Habilita una cuenta deshabilitada o restaura una eliminada con rmusr.
+// Al restaurar, el usuario recibe el id de su grupo, igual que en mkusr, para que recupere sus archivos;
+// devuelve el id asignado, o "" si la cuenta solo estaba deshabilitada.
+func EnableUser(file *os.File, sb *structs.Superblock, usersInode *structs.Inode, userName string) (string, error) {
+	contenido, err := globals.ReadFileBlocks(file, sb, usersInode)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo el contenido de users.txt: %v", err)
+	}
+	lineas := strings.Split(strings.TrimSpace(contenido), "\n")
+
+	eliminado := -1
+	gruposActivos := make(map[string]string)
+	for i, linea := range lineas {
+		linea = strings.TrimSpace(linea)
+		partes := strings.Split(linea, ",")
+		if len(partes) == 3 && partes[1] == "G" && partes[0] != "0" {
+			gruposActivos[partes[2]] = partes[0]
+		}
+
+		usuario := crearUsuarioDesdeLinea(linea)
+		if usuario == nil || usuario.Name != userName {
+			continue
+		}
+		if usuario.Id == "0" {
+			eliminado = i
+			continue
+		}
+
+		// La cuenta existe: solo se quita la marca de deshabilitada
+		if !usuario.Disabled() {
+			return "", fmt.Errorf("la cuenta '%s' no está deshabilitada", userName)
+		}
+		usuario.Enable()
+		lineas[i] = usuario.ToString()
+		return "", escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+	}
+	if eliminado == -1 {
+		return "", fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+
+	usuario := crearUsuarioDesdeLinea(strings.TrimSpace(lineas[eliminado]))
+	if _, ok := gruposActivos[usuario.Group]; !ok {
+		return "", fmt.Errorf("el grupo '%s' de '%s' está eliminado; créelo antes de habilitar la cuenta", usuario.Group, userName)
+	}
+
+	// rmusr borró el UID anterior: la cuenta restaurada recibe el siguiente ID libre
+	uid, err := calculateNextID(file, sb, usersInode)
+	if err != nil {
+		return "", err
+	}
+	usuario.Id = strconv.Itoa(uid)
+	lineas[eliminado] = usuario.ToString()
+	return usuario.Id, escribirCambiosEnArchivo(file, sb, usersInode, limpiarYActualizarContenido(lineas))
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	globals "backend/globals"
+	"strings"
+	"testing"
+)
+
+// gruposDe devuelve los grupos que muestra groups para un usuario
+func gruposDe(t *testing.T, session *globals.Session, user string) string {
+	t.Helper()
+	out, err := ParserGroups([]string{"-user=" + user}, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, linea := range strings.Split(out, "\n") {
+		if grupos, ok := strings.CutPrefix(linea, user+" : "); ok {
+			return grupos
+		}
+	}
+	t.Fatalf("groups no muestra a %s:\n%s", user, out)
+	return ""
+}
+
+func TestGroupMemberships(t *testing.T) {
+	root, id := newTestPartition(t)
+	for _, grupo := range []string{"devs", "ops", "qa"} {
+		if _, err := ParserMkgrp([]string{"-name=" + grupo}, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ParserMkusr([]string{"-user=ana", "-pass=abc", "-grp=devs"}, root); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, grupo := range []string{"ops", "qa"} {
+		if _, err := ParserUsermod([]string{"-user=ana", "-addgrp=" + grupo}, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := gruposDe(t, root, "ana"); got != "devs ops qa" {
+		t.Fatalf("groups ana = %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"-user=ana", "-addgrp=ops"},   // ya es miembro
+		{"-user=ana", "-addgrp=devs"},  // es su grupo principal
+		{"-user=ana", "-addgrp=nadie"}, // el grupo no existe
+		{"-user=nadie", "-addgrp=ops"}, // el usuario no existe
+		{"-user=ana", "-delgrp=devs"},  // el principal se cambia con chgrp
+		{"-user=ana", "-addgrp=ops", "-delgrp=qa"},
+	} {
+		if _, err := ParserUsermod(args, root); err == nil {
+			t.Errorf("usermod %v debería fallar", args)
+		}
+	}
+	ana := login(t, "ana", "abc", id)
+	if _, err := ParserUsermod([]string{"-user=ana", "-delgrp=qa"}, ana); err == nil {
+		t.Fatal("usermod debería ser solo de root")
+	}
+
+	if _, err := ParserUsermod([]string{"-user=ana", "-delgrp=qa"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if got := gruposDe(t, root, "ana"); got != "devs ops" {
+		t.Fatalf("groups ana después de -delgrp = %q", got)
+	}
+
+	// chgrp a un grupo suplementario lo vuelve principal y quita la membresía repetida
+	if _, err := ParserChgrp([]string{"-user=ana", "-grp=ops"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if got := gruposDe(t, root, "ana"); got != "ops" {
+		t.Fatalf("groups ana después de chgrp = %q", got)
+	}
+	if _, err := ParserChgrp([]string{"-user=ana", "-grp=nadie"}, root); err == nil {
+		t.Fatal("chgrp a un grupo inexistente debería fallar")
+	}
+
+	// rmgrp borra las membresías del grupo sin tocar a los usuarios que solo eran miembros
+	if _, err := ParserUsermod([]string{"-user=ana", "-addgrp=qa"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserRmgrp([]string{"-name=qa"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if got := gruposDe(t, root, "ana"); got != "ops" {
+		t.Fatalf("groups ana después de rmgrp qa = %q", got)
+	}
+	_, contenido := usersInode(t, id)
+	if strings.Contains(contenido, ",M,qa,") {
+		t.Fatalf("users.txt conserva membresías de qa:\n%s", contenido)
+	}
+	login(t, "ana", "abc", id)
+
+	// Borrar el grupo principal elimina al usuario y también sus membresías en otros grupos
+	if _, err := ParserUsermod([]string{"-user=ana", "-addgrp=devs"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParserRmgrp([]string{"-name=ops"}, root); err != nil {
+		t.Fatal(err)
+	}
+	if _, contenido := usersInode(t, id); strings.Contains(contenido, ",M,") {
+		t.Fatalf("users.txt conserva membresías de un usuario eliminado:\n%s", contenido)
+	}
+	if _, err := ParserLogin([]string{"-user=ana", "-pass=abc", "-id=" + id}, &globals.Session{}); err == nil {
+		t.Fatal("ana sigue activa después de borrar su grupo principal")
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	Disks "backend/commands/Disks"
+	globals "backend/globals"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestPartition crea, monta y formatea una partición en un disco temporal e inicia sesión como root.
+// Devuelve la sesión y el ID de la partición.
+func newTestPartition(t *testing.T) (*globals.Session, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(globals.DataDirEnv, filepath.Join(dir, "data"))
+	disk := filepath.Join(dir, "a.mia")
+
+	if _, err := Disks.ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Disks.ParserFdisk([]string{"-size=1", "-unit=M", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Disks.ParserMount([]string{"-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	for mountID, path := range globals.MountedPartitions {
+		if path == disk {
+			id = mountID
+		}
+	}
+	if id == "" {
+		t.Fatal("la partición no quedó montada")
+	}
+	t.Cleanup(func() { Disks.ParserUnmount([]string{"-id=" + id}) })
+
+	if _, err := Disks.ParserMkfs([]string{"-id=" + id, "-type=full"}); err != nil {
+		t.Fatal(err)
+	}
+	return login(t, "root", "123", id), id
+}
+
+// login inicia una sesión nueva en la partición y falla la prueba si no lo logra
+func login(t *testing.T, user, pass, id string) *globals.Session {
+	t.Helper()
+	session := &globals.Session{}
+	if _, err := ParserLogin([]string{"-user=" + user, "-pass=" + pass, "-id=" + id}, session); err != nil {
+		t.Fatalf("login de %s: %v", user, err)
+	}
+	return session
+}
+
+// usersInode devuelve el inodo de users.txt y su contenido
+func usersInode(t *testing.T, id string) (*structs.Inode, string) {
+	t.Helper()
+	sb, _, diskPath, err := globals.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	inode := &structs.Inode{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(1), sb); err != nil {
+		t.Fatal(err)
+	}
+	contenido, err := globals.ReadFileBlocks(file, sb, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inode, contenido
+}
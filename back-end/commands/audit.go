@@ -0,0 +1,117 @@
+package commands
+
+import (
+	global "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Formatos aceptados por -since, además de duraciones relativas como 2h o 30m
+var auditSinceLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// AUDIT estructura que representa el comando AUDIT con sus parámetros
+type AUDIT struct {
+	id    string    // Partición cuyos registros se consultan
+	user  string    // Filtra por usuario (el efectivo o el que inició la sesión)
+	since time.Time // Solo registros desde esta fecha
+}
+
+// ParserAudit parsea el comando audit y muestra los registros de auditoría de una partición
+func ParserAudit(tokens []string, session *global.Session) (string, error) {
+	cmd := &AUDIT{}
+	var outputBuffer bytes.Buffer
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"id", "user", "since"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("id"); err != nil {
+		return "", err
+	}
+	cmd.id = params.Get("id")
+	cmd.user = params.Get("user")
+	if params.Has("since") {
+		cmd.since, err = parseAuditSince(params.Get("since"), time.Now())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = commandAudit(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandAudit muestra los comandos que modificaron la partición o su disco
+func commandAudit(audit *AUDIT, session *global.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================= AUDIT =======================")
+	if !session.IsLoggedIn() {
+		return errors.New("no hay un usuario logueado")
+	}
+	if session.Usuario.Name != "root" {
+		return errors.New("solo el usuario root puede consultar la auditoría")
+	}
+
+	// Los registros se filtran por disco y nombre de la partición: el ID se reutiliza al desmontar
+	target, err := global.ResolveAuditPartition(audit.id)
+	if err != nil {
+		return err
+	}
+	records, err := global.ReadAudit(func(record global.AuditRecord) bool {
+		if !target.Matches(record) {
+			return false
+		}
+		if audit.user != "" && record.User != audit.user && record.LoginUser != audit.user {
+			return false
+		}
+		return audit.since.IsZero() || !record.Time.Before(audit.since)
+	})
+	if err != nil {
+		return err
+	}
+
+	if target.Name != "" {
+		fmt.Fprintf(outputBuffer, "Partición: %s (%s en %s)\n", audit.id, target.Name, target.Disk)
+	} else {
+		fmt.Fprintf(outputBuffer, "Partición: %s\n", audit.id)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(outputBuffer, "No hay registros de auditoría con esos filtros.")
+	} else {
+		fmt.Fprintf(outputBuffer, "%-20s %-16s %-7s %-8s %s\n", "Fecha", "Usuario", "Estado", "Duración", "Comando")
+		for _, record := range records {
+			usuario := record.User
+			if record.LoginUser != "" {
+				usuario = fmt.Sprintf("%s(%s)", record.User, record.LoginUser)
+			}
+			fmt.Fprintf(outputBuffer, "%-20s %-16s %-7s %-8s %s\n",
+				record.Time.Format("2006-01-02 15:04:05"), usuario, record.Status,
+				fmt.Sprintf("%dms", record.DurationMs), record.Command)
+			if record.Error != "" {
+				fmt.Fprintf(outputBuffer, "%-20s error: %s\n", "", record.Error)
+			}
+		}
+	}
+	fmt.Fprintf(outputBuffer, "Total: %d registros\n", len(records))
+	fmt.Fprintln(outputBuffer, "=====================================================")
+	return nil
+}
+
+// parseAuditSince interpreta -since como fecha (2006-01-02, 2006-01-02T15:04...) o como duración hacia atrás (2h, 30m)
+func parseAuditSince(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range auditSinceLayouts {
+		if since, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return since, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("valor inválido para -since: %s (use 2006-01-02, 2006-01-02T15:04 o una duración como 2h)", value)
+}
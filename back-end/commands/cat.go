@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -18,35 +17,26 @@ type CAT struct {
 }
 
 // ParserCat parsea el comando cat y devuelve una instancia de CAT
-func ParserCat(tokens []string) (string, error) {
+func ParserCat(tokens []string, session *global.Session) (string, error) {
 	cmd := &CAT{}                 // Crea una nueva instancia de CAT
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	// Expresión regular para capturar los archivos pasados como parámetros -file1, -file2, etc.
-	re := regexp.MustCompile(`-file\d+=("[^"]+"|[^\s]+)`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
+	// Los archivos se pasan como -file1, -file2, etc. y se leen en el orden de la línea
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"file#"}})
+	if err != nil {
+		return "", err
+	}
+	for _, key := range params.Names() {
+		cmd.files = append(cmd.files, params.Get(key))
+	}
 
 	// Verificar si no se encontraron archivos
-	if len(matches) == 0 {
+	if len(cmd.files) == 0 {
 		return "", errors.New("no se especificaron archivos para leer")
 	}
 
-	// Iterar sobre cada coincidencia y extraer los archivos
-	for _, match := range matches {
-		// Separar el parámetro en clave y valor (ej. "-file1=/home/user/a.txt")
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) == 2 {
-			filePath := kv[1]
-			// Si el valor está entre comillas, eliminarlas
-			if strings.HasPrefix(filePath, "\"") && strings.HasSuffix(filePath, "\"") {
-				filePath = strings.Trim(filePath, "\"")
-			}
-			cmd.files = append(cmd.files, filePath)
-		}
-	}
-
 	// Ejecutar el comando CAT
-	err := commandCat(cmd, &outputBuffer)
+	err = commandCat(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -54,15 +44,15 @@ func ParserCat(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandCat(cat *CAT, outputBuffer *bytes.Buffer) error {
+func commandCat(cat *CAT, session *global.Session, outputBuffer *bytes.Buffer) error {
 	//fmt.Fprint(outputBuffer, "======================= CAT =======================\n")
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
 	_, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
@@ -84,7 +74,7 @@ func commandCat(cat *CAT, outputBuffer *bytes.Buffer) error {
 		fmt.Fprintln(outputBuffer, "===================================================================")
 
 		// Leer el contenido del archivo
-		content, err := readFileContent(filePath)
+		content, err := readFileContent(idPartition, filePath)
 		if err != nil {
 			fmt.Fprintf(outputBuffer, "Error al leer el archivo %s: %v\n", filePath, err)
 			continue
@@ -99,10 +89,9 @@ func commandCat(cat *CAT, outputBuffer *bytes.Buffer) error {
 	return nil
 }
 
-// readFileContent busca el archivo en el sistema de archivos y lee su contenido
-func readFileContent(filePath string) (string, error) {
+// readFileContent busca el archivo en el sistema de archivos de la partición y lee su contenido
+func readFileContent(idPartition string, filePath string) (string, error) {
 	// Obtener el Superblock y la partición montada asociada
-	idPartition := global.UsuarioActual.Id
 	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return "", fmt.Errorf("error al obtener la partición montada: %v", err)
@@ -139,7 +128,7 @@ func directoryExists(sb *structs.Superblock, file *os.File, inodeIndex int32, di
 
 	// Deserializar el inodo correspondiente
 	inode := &structs.Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return false, -1, fmt.Errorf("error al deserializar inodo %d: %v", inodeIndex, err)
 	}
@@ -157,7 +146,7 @@ func directoryExists(sb *structs.Superblock, file *os.File, inodeIndex int32, di
 
 		// Deserializar el bloque de directorio
 		block := &structs.FolderBlock{}
-		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 		if err != nil {
 			return false, -1, fmt.Errorf("error al deserializar bloque %d: %v", blockIndex, err)
 		}
@@ -236,7 +225,7 @@ func findFolderInode(file *os.File, sb *structs.Superblock, parentsDir []string)
 // readFileFromInode lee el contenido de un archivo desde su inodo
 func readFileFromInode(file *os.File, sb *structs.Superblock, inodeIndex int32) (string, error) {
 	inode := &structs.Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return "", fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
 	}
@@ -245,13 +234,13 @@ func readFileFromInode(file *os.File, sb *structs.Superblock, inodeIndex int32)
 		return "", fmt.Errorf("el inodo %d no corresponde a un archivo", inodeIndex)
 	}
 
-	// Concatenar los bloques de contenido del archivo
+	// Concatenar los bloques de contenido del archivo, incluidos los de los apuntadores indirectos
+	blocks, err := inode.DataBlocks(file, sb)
+	if err != nil {
+		return "", fmt.Errorf("error al leer los bloques del inodo %d: %v", inodeIndex, err)
+	}
 	var contentBuilder strings.Builder
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break
-		}
-
+	for _, blockIndex := range blocks {
 		fileBlock := &structs.FileBlock{}
 		err := fileBlock.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
 		if err != nil {
@@ -261,5 +250,11 @@ func readFileFromInode(file *os.File, sb *structs.Superblock, inodeIndex int32)
 		contentBuilder.WriteString(string(fileBlock.B_content[:]))
 	}
 
-	return contentBuilder.String(), nil
+	// Los archivos comprimidos se descomprimen de forma transparente
+	content, err := inode.DecodeContent(contentBuilder.String())
+	if err != nil {
+		return "", fmt.Errorf("error al leer el archivo comprimido del inodo %d: %v", inodeIndex, err)
+	}
+
+	return content, nil
 }
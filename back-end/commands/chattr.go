@@ -0,0 +1,163 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	global "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CHATTR estructura que representa el comando chattr con sus parámetros
+type CHATTR struct {
+	path     string // Ruta del archivo
+	compress bool   // true con +c, false con -c
+	set      bool   // Indica si se especificó algún atributo
+}
+
+// ParserChattr parsea el comando chattr y devuelve los mensajes del cambio de atributos
+func ParserChattr(tokens []string, session *global.Session) (string, error) {
+	cmd := &CHATTR{}              // Crea una nueva instancia de CHATTR
+	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
+
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"path"},
+		Flags:  []string{"+c", "c"},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("path"); err != nil {
+		return "", err
+	}
+	if params.Has("+c") == params.Has("c") {
+		return "", errors.New("indique solo uno de los parámetros: +c o -c")
+	}
+	cmd.path = params.Get("path")
+	cmd.compress = params.Has("+c")
+	cmd.set = true
+
+	err = commandChattr(cmd, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+func commandChattr(chattr *CHATTR, session *global.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprint(outputBuffer, "======================= CHATTR =======================\n")
+
+	// Verificar si hay un usuario logueado
+	if !session.IsLoggedIn() {
+		return fmt.Errorf("no hay un usuario logueado")
+	}
+
+	// Obtener la partición montada asociada al usuario logueado
+	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(session.Usuario.Id)
+	if err != nil {
+		return fmt.Errorf("error al obtener la partición montada: %w", err)
+	}
+
+	file, err := os.OpenFile(partitionPath, os.O_RDWR, 0666)
+	if err != nil {
+		return fmt.Errorf("error al abrir el archivo de partición: %w", err)
+	}
+	defer file.Close()
+
+	// Buscar el inodo del archivo
+	parentDirs, fileName := utils.GetParentDirectories(chattr.path)
+	inodeIndex, err := findFileInode(file, partitionSuperblock, parentDirs, fileName)
+	if err != nil {
+		return fmt.Errorf("error al encontrar el archivo: %v", err)
+	}
+
+	before, after, err := setFileCompression(file, partitionSuperblock, inodeIndex, chattr.compress)
+	if err != nil {
+		return err
+	}
+
+	// Guardar los contadores de bloques del superbloque
+	err = partitionSuperblock.Encode(file, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %v", err)
+	}
+
+	if chattr.compress {
+		fmt.Fprintf(outputBuffer, "Compresión activada para '%s'\n", chattr.path)
+	} else {
+		fmt.Fprintf(outputBuffer, "Compresión desactivada para '%s'\n", chattr.path)
+	}
+	fmt.Fprintf(outputBuffer, "Bloques usados: %d -> %d\n", before, after)
+	fmt.Fprint(outputBuffer, "=================================================\n")
+
+	return nil
+}
+
+// setFileCompression cambia el atributo de compresión de un archivo y reescribe su contenido.
+// Devuelve la cantidad de bloques que usaba el archivo antes y después del cambio.
+func setFileCompression(file *os.File, sb *structs.Superblock, inodeIndex int32, compress bool) (int, int, error) {
+	if sb.S_revision < structs.RevisionInodeFlags {
+		return 0, 0, fmt.Errorf("el sistema de archivos usa %s y no guarda atributos de archivo; ejecute migrate para actualizarlo", structs.RevisionName(sb.S_revision))
+	}
+
+	// users.txt se reescribe directamente por los comandos de usuarios y grupos
+	if inodeIndex == 1 {
+		return 0, 0, errors.New("el archivo users.txt no admite compresión")
+	}
+
+	inodeOffset := sb.CalculateInodeOffset(inodeIndex)
+	inode := &structs.Inode{}
+	err := inode.Decode(file, inodeOffset, sb)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+	if inode.I_type[0] != '1' {
+		return 0, 0, fmt.Errorf("el inodo %d no corresponde a un archivo", inodeIndex)
+	}
+
+	before := usedBlocks(inode)
+	if inode.IsCompressed() == compress {
+		return before, before, nil
+	}
+
+	// Leer el contenido actual (descomprimido si hace falta) antes de cambiar el atributo
+	content, err := readFileFromInode(file, sb, inodeIndex)
+	if err != nil {
+		return 0, 0, err
+	}
+	content = strings.TrimRight(content, "\x00")
+
+	inode.SetCompressed(compress)
+	inode.UpdateCtime()
+	err = inode.Encode(file, inodeOffset, sb)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error al actualizar el inodo %d: %v", inodeIndex, err)
+	}
+
+	// Reescribir el contenido con el nuevo atributo
+	err = editFileContent(file, sb, inodeIndex, []byte(content))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error al reescribir el contenido del archivo: %v", err)
+	}
+
+	err = inode.Decode(file, inodeOffset, sb)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
+	}
+	return before, usedBlocks(inode), nil
+}
+
+// usedBlocks cuenta los bloques asignados a un inodo
+func usedBlocks(inode *structs.Inode) int {
+	count := 0
+	for _, blockIndex := range inode.I_block {
+		if blockIndex != -1 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	structs "backend/Structs"
+	Disks "backend/commands/Disks"
+	Users "backend/commands/Users"
+	global "backend/globals"
+	utils "backend/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestPartition crea, monta y formatea una partición en un disco temporal e inicia sesión como root.
+// Devuelve la sesión y el ID de la partición.
+func newTestPartition(t *testing.T) (*global.Session, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(global.DataDirEnv, filepath.Join(dir, "data"))
+	disk := filepath.Join(dir, "a.mia")
+
+	if _, err := Disks.ParserMkdisk([]string{"-size=2", "-unit=M", "-path=" + disk}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Disks.ParserFdisk([]string{"-size=1", "-unit=M", "-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Disks.ParserMount([]string{"-path=" + disk, "-name=P1"}); err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	for mountID, path := range global.MountedPartitions {
+		if path == disk {
+			id = mountID
+		}
+	}
+	if id == "" {
+		t.Fatal("la partición no quedó montada")
+	}
+	t.Cleanup(func() { Disks.ParserUnmount([]string{"-id=" + id}) })
+
+	if _, err := Disks.ParserMkfs([]string{"-id=" + id, "-type=full"}); err != nil {
+		t.Fatal(err)
+	}
+	session := &global.Session{}
+	if _, err := Users.ParserLogin([]string{"-user=root", "-pass=123", "-id=" + id}, session); err != nil {
+		t.Fatal(err)
+	}
+	return session, id
+}
+
+// fileInode devuelve el inodo de un archivo de la partición montada
+func fileInode(t *testing.T, id, path string) *structs.Inode {
+	t.Helper()
+	sb, _, diskPath, err := global.GetMountedPartitionSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	parentDirs, fileName := utils.GetParentDirectories(path)
+	index, err := findFileInode(file, sb, parentDirs, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inode := &structs.Inode{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(index), sb); err != nil {
+		t.Fatal(err)
+	}
+	return inode
+}
+
+func TestChattrCompressesAndRestoresFile(t *testing.T) {
+	session, id := newTestPartition(t)
+	content := strings.Repeat("linea repetida del archivo\n", 20)
+	if _, err := ParserMkfile([]string{"-path=/notas.txt", "-cont=" + content}, session); err != nil {
+		t.Fatal(err)
+	}
+	before := usedBlocks(fileInode(t, id, "/notas.txt"))
+
+	out, err := ParserChattr([]string{"-path=/notas.txt", "+c"}, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inode := fileInode(t, id, "/notas.txt")
+	if !inode.IsCompressed() || usedBlocks(inode) >= before {
+		t.Fatalf("chattr +c: comprimido %v, %d bloques (antes %d)\n%s", inode.IsCompressed(), usedBlocks(inode), before, out)
+	}
+	if got, err := ParserCat([]string{"-file1=/notas.txt"}, session); err != nil || !strings.Contains(got, content) {
+		t.Fatalf("cat de un archivo comprimido = %q, %v", got, err)
+	}
+
+	if _, err := ParserChattr([]string{"-path=/notas.txt", "-c"}, session); err != nil {
+		t.Fatal(err)
+	}
+	inode = fileInode(t, id, "/notas.txt")
+	if inode.IsCompressed() || usedBlocks(inode) != before {
+		t.Fatalf("chattr -c: comprimido %v, %d bloques, se esperaban %d", inode.IsCompressed(), usedBlocks(inode), before)
+	}
+	if got, err := ParserCat([]string{"-file1=/notas.txt"}, session); err != nil || !strings.Contains(got, content) {
+		t.Fatalf("cat después de descomprimir = %q, %v", got, err)
+	}
+}
+
+func TestChattrRejectsUsersFile(t *testing.T) {
+	session, _ := newTestPartition(t)
+	if _, err := ParserChattr([]string{"-path=/users.txt", "+c"}, session); err == nil {
+		t.Fatal("chattr debería rechazar comprimir users.txt")
+	}
+	if _, err := ParserChattr([]string{"-path=/users.txt", "+c", "-c"}, session); err == nil {
+		t.Fatal("chattr debería rechazar +c y -c juntos")
+	}
+}
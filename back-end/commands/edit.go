@@ -5,11 +5,8 @@ import (
 	global "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 )
 
 // EDIT estructura que representa el comando EDIT con sus parámetros
@@ -19,41 +16,23 @@ type EDIT struct {
 }
 
 // ParserEdit parsea el comando edit y devuelve una instancia de EDIT
-func ParserEdit(tokens []string) (string, error) {
+func ParserEdit(tokens []string, session *global.Session) (string, error) {
 	cmd := &EDIT{}                // Crea una nueva instancia de EDIT
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	// Expresión regular para capturar los parámetros -path y -contenido
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-contenido="[^"]+"|-contenido=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	// Verificar que se han proporcionado ambos parámetros
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -contenido")
-	}
-
-	// Iterar sobre cada coincidencia y extraer los valores de -path y -contenido
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"") // Eliminar comillas si existen
-
-		// Asignar los valores de los parámetros
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-contenido":
-			cmd.contenido = value
-		}
+	// Capturar los parámetros -path y -contenido
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "contenido"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Verificar que ambos parámetros tengan valores
-	if cmd.path == "" || cmd.contenido == "" {
-		return "", errors.New("los parámetros -path y -contenido son obligatorios")
+	if err := params.Require("path", "contenido"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
+	cmd.contenido = params.Get("contenido")
 
 	// Ejecutar el comando EDIT
-	err := commandEdit(cmd, &outputBuffer)
+	err = commandEdit(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -61,19 +40,19 @@ func ParserEdit(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandEdit(editCmd *EDIT, outputBuffer *bytes.Buffer) error {
+func commandEdit(editCmd *EDIT, session *global.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= EDIT =======================\n")
 
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
-	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
+	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
 	}
@@ -106,6 +85,12 @@ func commandEdit(editCmd *EDIT, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al editar el contenido del archivo: %v", err)
 	}
 
+	// Guardar los contadores de bloques del superbloque
+	err = partitionSuperblock.Encode(file, int64(mountedPartition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error al serializar el superbloque: %v", err)
+	}
+
 	fmt.Fprintf(outputBuffer, "Contenido del archivo '%s' editado exitosamente\n", fileName)
 	fmt.Fprint(outputBuffer, "=================================================\n")
 
@@ -114,7 +99,7 @@ func commandEdit(editCmd *EDIT, outputBuffer *bytes.Buffer) error {
 
 func editFileContent(file *os.File, sb *structs.Superblock, inodeIndex int32, newContent []byte) error {
 	inode := &structs.Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
 	}
@@ -135,8 +120,14 @@ func editFileContent(file *os.File, sb *structs.Superblock, inodeIndex int32, ne
 		}
 	}
 
+	// Si el archivo tiene el atributo de compresión, se comprime antes de dividirlo
+	storedContent, err := inode.EncodeContent(string(newContent))
+	if err != nil {
+		return fmt.Errorf("error al preparar el contenido del inodo %d: %v", inodeIndex, err)
+	}
+
 	// Dividir el nuevo contenido en bloques de 64 bytes
-	blocks, err := structs.SplitContent(string(newContent))
+	blocks, err := structs.SplitContent(storedContent)
 	if err != nil {
 		return fmt.Errorf("error al dividir el contenido en bloques: %v", err)
 	}
@@ -191,7 +182,7 @@ func editFileContent(file *os.File, sb *structs.Superblock, inodeIndex int32, ne
 			}
 
 			// Actualizar el puntero en el bloque de apuntadores
-			err = pointerBlock.SetPointer(freeIndex, int64(newBlockIndex))
+			err = pointerBlock.SetPointer(freeIndex, newBlockIndex)
 			if err != nil {
 				return fmt.Errorf("error actualizando el bloque de apuntadores: %v", err)
 			}
@@ -210,9 +201,21 @@ func editFileContent(file *os.File, sb *structs.Superblock, inodeIndex int32, ne
 		}
 	}
 
+	// Liberar los bloques directos que ya no se usan con el nuevo contenido
+	for i := blockCount; i < len(inode.I_block); i++ {
+		if inode.I_block[i] == -1 {
+			continue
+		}
+		err := sb.FreeBlock(file, inode.I_block[i])
+		if err != nil {
+			return fmt.Errorf("error al liberar el bloque %d: %v", inode.I_block[i], err)
+		}
+		inode.I_block[i] = -1
+	}
+
 	// Actualizar el tamaño del archivo en el inodo
 	inode.I_size = int32(len(newContent))
-	err = inode.Encode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err = inode.Encode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al actualizar el inodo %d: %v", inodeIndex, err)
 	}
@@ -5,7 +5,6 @@ import (
 	global "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -19,41 +18,23 @@ type FIND struct {
 }
 
 // ParserFind parsea el comando find y devuelve una instancia de FIND
-func ParserFind(tokens []string) (string, error) {
+func ParserFind(tokens []string, session *global.Session) (string, error) {
 	cmd := &FIND{}
 	var outputBuffer bytes.Buffer
 
-	// Expresión regular para capturar los parámetros -path y -name
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	// Verificar que se han proporcionado ambos parámetros
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -name")
-	}
-
-	// Iterar sobre cada coincidencia y extraer los valores de -path y -name
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"") // Eliminar comillas si existen
-
-		// Asignar los valores de los parámetros
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-name":
-			cmd.name = value
-		}
+	// Capturar los parámetros -path y -name
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "name"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Verificar que ambos parámetros tengan valores
-	if cmd.path == "" || cmd.name == "" {
-		return "", errors.New("los parámetros -path y -name son obligatorios")
+	if err := params.Require("path", "name"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
 
 	// Ejecutar el comando FIND
-	err := commandFind(cmd, &outputBuffer)
+	err = commandFind(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -61,16 +42,16 @@ func ParserFind(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandFind(findCmd *FIND, outputBuffer *bytes.Buffer) error {
+func commandFind(findCmd *FIND, session *global.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= FIND =======================\n")
 
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
 	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
@@ -117,7 +98,7 @@ func commandFind(findCmd *FIND, outputBuffer *bytes.Buffer) error {
 func searchRecursive(file *os.File, sb *structs.Superblock, inodeIndex int32, pattern *regexp.Regexp, currentPath string, outputBuffer *bytes.Buffer) error {
 	// Deserializar el inodo del directorio actual
 	inode := &structs.Inode{}
-	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)))
+	err := inode.Decode(file, int64(sb.S_inode_start+(inodeIndex*sb.S_inode_size)), sb)
 	if err != nil {
 		return fmt.Errorf("error al deserializar el inodo %d: %v", inodeIndex, err)
 	}
@@ -135,7 +116,7 @@ func searchRecursive(file *os.File, sb *structs.Superblock, inodeIndex int32, pa
 
 		// Deserializar el bloque de directorio
 		block := &structs.FolderBlock{}
-		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)))
+		err := block.Decode(file, int64(sb.S_block_start+(blockIndex*sb.S_block_size)), sb)
 		if err != nil {
 			return fmt.Errorf("error al deserializar el bloque %d: %v", blockIndex, err)
 		}
@@ -5,11 +5,8 @@ import (
 	global "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 )
 
 // MKDIR estructura que representa el comando mkdir con sus parámetros
@@ -18,60 +15,28 @@ type MKDIR struct {
 	p    bool   // Opción -p (crea directorios padres si no existen)
 }
 
-func ParserMkdir(tokens []string) (string, error) {
+func ParserMkdir(tokens []string, session *global.Session) (string, error) {
 	cmd := &MKDIR{}               // Crea una nueva instancia de MKDIR
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	// Unir tokens en una sola cadena y luego dividir por espacios, respetando las comillas
-	args := strings.Join(tokens, " ")
-	// Expresión regular para encontrar los parámetros del comando mkdir
-	re := regexp.MustCompile(`-path=[^\s]+|-p`)
-	// Encuentra todas las coincidencias de la expresión regular en la cadena de argumentos
-	matches := re.FindAllString(args, -1)
-
-	// Verificar que todos los tokens fueron reconocidos por la expresión regular
-	if len(matches) != len(tokens) {
-		// Identificar el parámetro inválido
-		for _, token := range tokens {
-			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
-			}
-		}
-	}
-
-	// Itera sobre cada coincidencia encontrada
-	for _, match := range matches {
-		// Divide cada parte en clave y valor usando "=" como delimitador
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-
-		// Switch para manejar diferentes parámetros
-		switch key {
-		case "-path":
-			if len(kv) != 2 {
-				return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-			}
-			value := kv[1]
-			// Remove quotes from value if present
-			if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-				value = strings.Trim(value, "\"")
-			}
-			cmd.path = value
-		case "-p":
-			cmd.p = true
-		default:
-			// Si el parámetro no es reconocido, devuelve un error
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	// Separar los parámetros del comando mkdir: -path y la bandera -p
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"path"},
+		Flags:  []string{"p"},
+	})
+	if err != nil {
+		return "", err
 	}
 
 	// Verifica que el parámetro -path haya sido proporcionado
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	if err := params.Require("path"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
+	cmd.p = params.Has("p")
 
 	// Ejecutar el comando mkdir con captura de mensajes en el buffer
-	err := commandMkdir(cmd, &outputBuffer)
+	err = commandMkdir(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -80,14 +45,14 @@ func ParserMkdir(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkdir(mkdir *MKDIR, outputBuffer *bytes.Buffer) error {
+func commandMkdir(mkdir *MKDIR, session *global.Session, outputBuffer *bytes.Buffer) error {
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
 	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
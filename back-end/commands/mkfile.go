@@ -9,87 +9,49 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
 // MKFILE estructura que representa el comando mkfile con sus parámetros
 type MKFILE struct {
-	path string // Ruta del archivo
-	r    bool   // Opción recursiva
-	size int    // Tamaño del archivo
-	cont string // Contenido del archivo
+	path     string // Ruta del archivo
+	r        bool   // Opción recursiva
+	size     int    // Tamaño del archivo
+	cont     string // Contenido del archivo
+	compress bool   // Guardar el contenido comprimido
 }
 
 // ParserMkfile parsea el comando mkfile y devuelve una instancia de MKFILE
-func ParserMkfile(tokens []string) (string, error) {
+func ParserMkfile(tokens []string, session *global.Session) (string, error) {
 	cmd := &MKFILE{}              // Crea una nueva instancia de MKFILE
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-r|-size=\d+|-cont="[^"]+"|-cont=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	if len(matches) != len(tokens) {
-		for _, token := range tokens {
-			if !re.MatchString(token) {
-				return "", fmt.Errorf("parámetro inválido: %s", token)
-			}
-		}
-	}
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		var value string
-		if len(kv) == 2 {
-			value = kv[1]
-		}
-
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
-
-		// Switch para manejar diferentes parámetros
-		switch key {
-		case "-path":
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
-			}
-			cmd.path = value
-		case "-r":
-			cmd.r = true // Habilitar la opción recursiva
-		case "-size":
-			size, err := strconv.Atoi(value)
-			if err != nil || size < 0 {
-				return "", errors.New("el tamaño debe ser un número entero no negativo")
-			}
-			cmd.size = size
-		case "-cont":
-			if value == "" {
-				return "", errors.New("el contenido no puede estar vacío")
-			}
-			cmd.cont = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
-	}
-
-	if cmd.path == "" {
-		return "", errors.New("faltan parámetros requeridos: -path")
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{
+		Values: []string{"path", "size", "cont"},
+		Flags:  []string{"r", "compress"},
+	})
+	if err != nil {
+		return "", err
 	}
-
-	if cmd.size == 0 {
-		cmd.size = 0
+	if err := params.Require("path"); err != nil {
+		return "", err
 	}
 
-	if cmd.cont == "" {
-		cmd.cont = ""
+	cmd.path = params.Get("path")
+	cmd.cont = params.Get("cont")
+	cmd.r = params.Has("r")               // Habilitar la opción recursiva
+	cmd.compress = params.Has("compress") // Guardar el contenido comprimido
+	if params.Has("size") {
+		size, err := strconv.Atoi(params.Get("size"))
+		if err != nil || size < 0 {
+			return "", errors.New("el tamaño debe ser un número entero no negativo")
+		}
+		cmd.size = size
 	}
 
 	// Crear el archivo con los parámetros proporcionados
-	err := commandMkfile(cmd, &outputBuffer)
+	err = commandMkfile(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -98,14 +60,14 @@ func ParserMkfile(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandMkfile(mkfile *MKFILE, outputBuffer *bytes.Buffer) error {
+func commandMkfile(mkfile *MKFILE, session *global.Session, outputBuffer *bytes.Buffer) error {
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
 	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
@@ -155,6 +117,26 @@ func commandMkfile(mkfile *MKFILE, outputBuffer *bytes.Buffer) error {
 		return fmt.Errorf("error al crear el archivo: %w", err)
 	}
 
+	// Activar la compresión una vez creado el archivo, igual que chattr +c
+	if mkfile.compress {
+		fileDirs, fileName := utils.GetParentDirectories(mkfile.path)
+		inodeIndex, err := findFileInode(file, partitionSuperblock, fileDirs, fileName)
+		if err != nil {
+			return fmt.Errorf("error al encontrar el archivo creado: %w", err)
+		}
+
+		before, after, err := setFileCompression(file, partitionSuperblock, inodeIndex, true)
+		if err != nil {
+			return fmt.Errorf("error al comprimir el archivo: %w", err)
+		}
+
+		err = partitionSuperblock.Encode(file, int64(mountedPartition.Part_start))
+		if err != nil {
+			return fmt.Errorf("error al serializar el superbloque: %w", err)
+		}
+		fmt.Fprintf(outputBuffer, "Archivo comprimido: %d -> %d bloques\n", before, after)
+	}
+
 	fmt.Fprintf(outputBuffer, "Archivo %s creado exitosamente\n", mkfile.path)
 	fmt.Fprintln(outputBuffer, "=====================================================")
 
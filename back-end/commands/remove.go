@@ -5,11 +5,8 @@ import (
 	global "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 )
 
 // REMOVE estructura que representa el comando REMOVE con sus parámetros
@@ -17,47 +14,38 @@ type REMOVE struct {
 	path string // Ruta del archivo o carpeta a eliminar
 }
 
-func ParserRemove(tokens []string) (string, error) {
+func ParserRemove(tokens []string, session *global.Session) (string, error) {
 	cmd := &REMOVE{}              // Crea una nueva instancia de REMOVE
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	// Expresión regular para capturar el parámetro -path="ruta"
-	re := regexp.MustCompile(`-path=("[^"]+"|[^\s]+)`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	// Verificar si no se encontró la ruta
-	if len(matches) == 0 {
-		return "", errors.New("no se especificó una ruta para eliminar")
+	// Capturar la ruta a eliminar
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Extraer el valor de la ruta
-	kv := strings.SplitN(matches[0], "=", 2)
-	if len(kv) == 2 {
-		cmd.path = kv[1]
-		// Si la ruta está entre comillas, eliminarlas
-		if strings.HasPrefix(cmd.path, "\"") && strings.HasSuffix(cmd.path, "\"") {
-			cmd.path = strings.Trim(cmd.path, "\"")
-		}
+	if err := params.Require("path"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
 
 	// Ejecutar el comando REMOVE
-	err := commandRemove(cmd, &outputBuffer)
+	err = commandRemove(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
 
 	return outputBuffer.String(), nil
 }
-func commandRemove(removeCmd *REMOVE, outputBuffer *bytes.Buffer) error {
+func commandRemove(removeCmd *REMOVE, session *global.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "====================== REMOVE ======================\n")
 
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener la partición montada asociada al usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 	partitionSuperblock, mountedPartition, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
 	if err != nil {
 		return fmt.Errorf("error al obtener la partición montada: %w", err)
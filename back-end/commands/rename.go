@@ -5,10 +5,8 @@ import (
 	global "backend/globals"
 	utils "backend/utils"
 	"bytes"
-	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -18,41 +16,23 @@ type RENAME struct {
 }
 
 // ParserRename parsea el comando rename y devuelve una instancia de RENAME
-func ParserRename(tokens []string) (string, error) {
+func ParserRename(tokens []string, session *global.Session) (string, error) {
 	cmd := &RENAME{}              // Crea una nueva instancia de RENAME
 	var outputBuffer bytes.Buffer // Buffer para capturar mensajes importantes
 
-	// Expresión regular para capturar los parámetros -path y -name
-	re := regexp.MustCompile(`-path="[^"]+"|-path=[^\s]+|-name="[^"]+"|-name=[^\s]+`)
-	matches := re.FindAllString(strings.Join(tokens, " "), -1)
-
-	// Verificar que se han proporcionado ambos parámetros
-	if len(matches) != len(tokens) || len(matches) < 2 {
-		return "", errors.New("faltan parámetros requeridos: -path o -name")
-	}
-
-	// Iterar sobre cada coincidencia y extraer los valores de -path y -name
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		key := strings.ToLower(kv[0])
-		value := strings.Trim(kv[1], "\"") // Eliminar comillas si existen
-
-		// Asignar los valores de los parámetros
-		switch key {
-		case "-path":
-			cmd.path = value
-		case "-name":
-			cmd.name = value
-		}
+	// Capturar los parámetros -path y -name
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"path", "name"}})
+	if err != nil {
+		return "", err
 	}
-
-	// Verificar que ambos parámetros tengan valores
-	if cmd.path == "" || cmd.name == "" {
-		return "", errors.New("los parámetros -path y -name son obligatorios")
+	if err := params.Require("path", "name"); err != nil {
+		return "", err
 	}
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
 
 	// Ejecutar el comando RENAME
-	err := commandRename(cmd, &outputBuffer)
+	err = commandRename(cmd, session, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -60,16 +40,16 @@ func ParserRename(tokens []string) (string, error) {
 	return outputBuffer.String(), nil
 }
 
-func commandRename(renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
+func commandRename(renameCmd *RENAME, session *global.Session, outputBuffer *bytes.Buffer) error {
 	fmt.Fprint(outputBuffer, "======================= RENAME =======================\n")
 
 	// Verificar si hay un usuario logueado
-	if !global.IsLoggedIn() {
+	if !session.IsLoggedIn() {
 		return fmt.Errorf("no hay un usuario logueado")
 	}
 
 	// Obtener el ID de la partición desde el usuario logueado
-	idPartition := global.UsuarioActual.Id
+	idPartition := session.Usuario.Id
 
 	// Obtener la partición montada asociada al usuario logueado
 	partitionSuperblock, _, partitionPath, err := global.GetMountedPartitionSuperblock(idPartition)
@@ -95,7 +75,7 @@ func commandRename(renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
 
 	// Cargar el FolderBlock del directorio padre
 	folderBlock := &structs.FolderBlock{}
-	err = folderBlock.Decode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)))
+	err = folderBlock.Decode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)), partitionSuperblock)
 	if err != nil {
 		return fmt.Errorf("error al deserializar el bloque de carpeta: %v", err)
 	}
@@ -114,7 +94,7 @@ func commandRename(renameCmd *RENAME, outputBuffer *bytes.Buffer) error {
 	}
 
 	// Guardar el bloque modificado de nuevo en el archivo
-	err = folderBlock.Encode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)))
+	err = folderBlock.Encode(file, int64(partitionSuperblock.S_block_start+(inodeIndex*partitionSuperblock.S_block_size)), partitionSuperblock)
 	if err != nil {
 		return fmt.Errorf("error al guardar el bloque de carpeta modificado: %v", err)
 	}
@@ -3,12 +3,11 @@ package commands
 import (
 	global "backend/globals"
 	reports "backend/reps"
+	utils "backend/utils"
 	"bytes"
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 )
 
 // REP estructura que representa el comando rep con sus parámetros
@@ -23,50 +22,25 @@ func ParserRep(tokens []string) (string, error) {
 	var outputBuffer bytes.Buffer // Buffer para capturar los mensajes importantes
 
 	cmd := &REP{} // Crea una nueva instancia de REP
-	args := strings.Join(tokens, " ")
-	re := regexp.MustCompile(`-id=[^\s]+|-path="[^"]+"|-path=[^\s]+|-name=[^\s]+|-path_file_ls="[^"]+"|-path_file_ls=[^\s]+`)
-	matches := re.FindAllString(args, -1)
-
-	for _, match := range matches {
-		kv := strings.SplitN(match, "=", 2)
-		if len(kv) != 2 {
-			return "", fmt.Errorf("formato de parámetro inválido: %s", match)
-		}
-		key, value := strings.ToLower(kv[0]), kv[1]
-		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-			value = strings.Trim(value, "\"")
-		}
-
-		switch key {
-		case "-id":
-			if value == "" {
-				return "", errors.New("el id no puede estar vacío")
-			}
-			cmd.id = value
-		case "-path":
-			if value == "" {
-				return "", errors.New("el path no puede estar vacío")
-			}
-			cmd.path = value
-		case "-name":
-			validNames := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "sb", "file", "ls", "journal"}
-			if !contains(validNames, value) {
-				return "", errors.New("nombre inválido, debe ser uno de los siguientes: mbr, disk, inode, block, bm_inode, bm_block, sb, file, ls, journal")
-			}
-			cmd.name = value
-		case "-path_file_ls":
-			cmd.path_file_ls = value
-		default:
-			return "", fmt.Errorf("parámetro desconocido: %s", key)
-		}
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"id", "path", "name", "path_file_ls"}})
+	if err != nil {
+		return "", err
+	}
+	if err := params.Require("id", "path", "name"); err != nil {
+		return "", err
 	}
 
-	if cmd.id == "" || cmd.path == "" || cmd.name == "" {
-		return "", errors.New("faltan parámetros requeridos: -id, -path, -name")
+	cmd.id = params.Get("id")
+	cmd.path = params.Get("path")
+	cmd.name = params.Get("name")
+	cmd.path_file_ls = params.Get("path_file_ls")
+	validNames := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "sb", "file", "ls", "journal"}
+	if !contains(validNames, cmd.name) {
+		return "", errors.New("nombre inválido, debe ser uno de los siguientes: mbr, disk, inode, block, bm_inode, bm_block, sb, file, ls, journal")
 	}
 
 	// Ejecutar el comando y capturar mensajes
-	err := commandRep(cmd, &outputBuffer)
+	err = commandRep(cmd, &outputBuffer)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	global "backend/globals"
+	utils "backend/utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SudoersPath es el archivo de la partición con los grupos que pueden usar sudo (uno por línea)
+const SudoersPath = "/etc/sudoers"
+
+// DefaultSudoersGroup es el grupo con permiso de sudo cuando la partición no tiene /etc/sudoers
+const DefaultSudoersGroup = "sudo"
+
+// Comandos que cambian la sesión y no tienen sentido dentro de sudo
+var sudoForbidden = map[string]bool{"sudo": true, "su": true, "exit-su": true, "login": true, "logout": true}
+
+// SUDO estructura que representa el comando SUDO con sus parámetros
+type SUDO struct {
+	pass string // Contraseña del usuario actual para volver a autenticarse
+	cmd  string // Línea que se ejecuta como root
+}
+
+// ParserSudo parsea el comando sudo; run ejecuta la línea indicada en -cmd
+func ParserSudo(tokens []string, session *global.Session, run func(string, *global.Session) (string, error)) (string, error) {
+	cmd := &SUDO{}
+	var outputBuffer bytes.Buffer
+
+	// -cmd llega como un solo token; sus comillas internas se vuelven a separar al ejecutarlo
+	params, err := utils.ParseParams(tokens, utils.ParamSpec{Values: []string{"pass", "cmd"}})
+	if err != nil {
+		return "", err
+	}
+	if !params.Has("pass") {
+		return "", errors.New("falta el parámetro -pass con la contraseña del usuario actual")
+	}
+	if strings.TrimSpace(params.Get("cmd")) == "" {
+		return "", errors.New("falta el parámetro -cmd con el comando a ejecutar")
+	}
+	cmd.pass = params.Get("pass")
+	cmd.cmd = params.Get("cmd")
+
+	err = commandSudo(cmd, run, session, &outputBuffer)
+	if err != nil {
+		return "", err
+	}
+
+	return outputBuffer.String(), nil
+}
+
+// commandSudo ejecuta una línea como root si el usuario actual pertenece a un grupo de sudoers
+func commandSudo(sudo *SUDO, run func(string, *global.Session) (string, error), session *global.Session, outputBuffer *bytes.Buffer) error {
+	fmt.Fprintln(outputBuffer, "======================== SUDO =======================")
+	if !session.IsLoggedIn() {
+		return errors.New("no hay un usuario logueado")
+	}
+
+	inner := strings.ToLower(strings.Fields(sudo.cmd)[0])
+	if sudoForbidden[inner] {
+		return fmt.Errorf("el comando '%s' no se puede ejecutar con sudo", inner)
+	}
+
+	// Volver a autenticar al usuario con la contraseña guardada en users.txt
+	id := session.Usuario.Id
+	usuario, err := global.FindActiveUser(id, session.Usuario.Name)
+	if err != nil {
+		return err
+	}
+	if !usuario.CheckPassword(sudo.pass) {
+		logSudo(id, usuario.Name, "sudo fallido: contraseña incorrecta")
+		return errors.New("contraseña incorrecta")
+	}
+
+	if usuario.Name != "root" {
+		grupos, err := global.UserGroups(id, usuario.Name)
+		if err != nil {
+			return err
+		}
+		sudoers := sudoersGroups(id)
+		if !sudoersContains(sudoers, grupos...) {
+			logSudo(id, usuario.Name, "sudo rechazado: no está en los sudoers")
+			return fmt.Errorf("el usuario '%s' no está en los sudoers (grupos permitidos: %s)", usuario.Name, strings.Join(sudoers, ", "))
+		}
+	}
+
+	logSudo(id, usuario.Name, fmt.Sprintf("sudo %s", inner))
+
+	root, err := global.FindActiveUser(id, "root")
+	if err != nil {
+		return err
+	}
+	root.Id = id
+	root.Status = true
+
+	// Ejecutar la línea en una sesión de root aparte; la sesión del usuario no cambia.
+	// Anterior apunta al usuario real para que la auditoría sepa quién usó sudo.
+	root.Anterior = session.Usuario
+	result, err := run(sudo.cmd, &global.Session{Usuario: root})
+
+	fmt.Fprintf(outputBuffer, "Ejecutando como root: %s\n", sudo.cmd)
+	outputBuffer.WriteString(result)
+	if err != nil {
+		return fmt.Errorf("%s: %w", sudo.cmd, err)
+	}
+	fmt.Fprintln(outputBuffer, "\n=====================================================")
+	return nil
+}
+
+// logSudo registra el intento de sudo en /var/log/auth.log sin interrumpir el comando si no se puede escribir
+func logSudo(id, userName, evento string) {
+	if err := global.AppendAuthLog(id, userName, evento); err != nil {
+		fmt.Printf("No se pudo escribir en el registro de autenticación: %v\n", err) // Depuración
+	}
+}
+
+// sudoersGroups lee los grupos con permiso de sudo de /etc/sudoers, o usa el grupo por defecto
+func sudoersGroups(idPartition string) []string {
+	content, err := readFileContent(idPartition, SudoersPath)
+	if err != nil {
+		return []string{DefaultSudoersGroup}
+	}
+
+	var grupos []string
+	for _, linea := range strings.Split(content, "\n") {
+		linea = strings.TrimSpace(strings.Trim(linea, "\x00"))
+		if linea != "" && !strings.HasPrefix(linea, "#") {
+			grupos = append(grupos, linea)
+		}
+	}
+	if len(grupos) == 0 {
+		return []string{DefaultSudoersGroup}
+	}
+	return grupos
+}
+
+// sudoersContains indica si alguno de los grupos del usuario está en los sudoers
+func sudoersContains(sudoers []string, grupos ...string) bool {
+	for _, sudoer := range sudoers {
+		for _, grupo := range grupos {
+			if sudoer == grupo {
+				return true
+			}
+		}
+	}
+	return false
+}
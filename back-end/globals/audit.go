@@ -0,0 +1,201 @@
+package globals
+
+import (
+	utils "backend/utils"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditFile es el registro de auditoría dentro del directorio de datos. Solo se agregan líneas (JSON por línea).
+const auditFile = "audit.log"
+
+// AuditRecord es una entrada del registro de auditoría de un comando que modificó un disco
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`                 // Usuario con el que se ejecutó el comando
+	LoginUser  string    `json:"login_user,omitempty"` // Usuario que inició la sesión, si se usó su o sudo
+	Partition  string    `json:"partition,omitempty"`  // ID de la partición afectada (se reutiliza al desmontar)
+	Name       string    `json:"name,omitempty"`       // Nombre de la partición afectada dentro de su disco
+	Disk       string    `json:"disk,omitempty"`       // Path absoluto del disco afectado
+	Command    string    `json:"command"`              // Línea completa, sin contraseñas
+	Status     string    `json:"status"`               // "ok" o "error"
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Parámetros cuyo valor no se guarda en la auditoría
+var auditSecretParams = map[string]bool{"pass": true, "old": true, "new": true, "passphrase": true}
+
+// RedactCommand reemplaza las contraseñas de una línea de comando por asteriscos.
+// La línea se separa con el mismo tokenizador que el Analyzer, así que las comillas simples,
+// las comillas escapadas y los nombres en mayúsculas se tratan igual que al ejecutar el comando.
+// La línea de -cmd (sudo) se redacta también. Los comentarios al final no se guardan.
+func RedactCommand(line string) string {
+	tokens, err := utils.Tokenize(line)
+	if err != nil {
+		// Sin poder separar los parámetros no se sabe dónde termina cada valor: solo se guarda el comando
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return ""
+		}
+		return fields[0] + " ****"
+	}
+
+	redacted := make([]string, len(tokens))
+	for i, token := range tokens {
+		name, value, hasValue := strings.Cut(token, "=")
+		key := strings.ToLower(strings.TrimPrefix(name, "-"))
+		switch {
+		case i == 0 || !hasValue || !strings.HasPrefix(name, "-"):
+		case auditSecretParams[key]:
+			token = name + "=****"
+		case key == "cmd":
+			token = name + "=" + RedactCommand(value)
+		}
+		redacted[i] = utils.QuoteToken(token)
+	}
+	return strings.Join(redacted, " ")
+}
+
+// RecordAudit agrega al registro de auditoría el resultado de un comando ejecutado en la sesión.
+// name es el nombre de la partición en el disco: junto con el disco la identifica aunque se desmonte.
+func RecordAudit(session *Session, partition, name, disk, line string, start time.Time, cmdErr error) error {
+	if disk != "" {
+		disk = utils.DiskKey(disk)
+	}
+	record := AuditRecord{
+		Time:       start,
+		User:       "-",
+		Partition:  partition,
+		Name:       name,
+		Disk:       disk,
+		Command:    RedactCommand(line),
+		Status:     "ok",
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if session.IsLoggedIn() {
+		record.User = session.Usuario.Name
+		// El usuario de la sesión es el último de la cadena de su/sudo
+		inicial := session.Usuario
+		for inicial.Anterior != nil {
+			inicial = inicial.Anterior
+		}
+		if inicial != session.Usuario {
+			record.LoginUser = inicial.Name
+		}
+	}
+	if cmdErr != nil {
+		record.Status = "error"
+		record.Error = cmdErr.Error()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializando el registro de auditoría: %w", err)
+	}
+	if err := os.MkdirAll(DataDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creando el directorio de datos: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(DataDir(), auditFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo el registro de auditoría: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error escribiendo el registro de auditoría: %w", err)
+	}
+	return nil
+}
+
+// ReadAudit devuelve, en orden, los registros que cumplen la condición
+func ReadAudit(match func(AuditRecord) bool) ([]AuditRecord, error) {
+	file, err := os.Open(filepath.Join(DataDir(), auditFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo el registro de auditoría: %w", err)
+	}
+	defer file.Close()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			fmt.Printf("Registro de auditoría inválido, se omite: %v\n", err) // Depuración
+			continue
+		}
+		if match(record) {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo el registro de auditoría: %w", err)
+	}
+	return records, nil
+}
+
+// AuditPartition identifica una partición en el registro de auditoría por su disco y su nombre
+type AuditPartition struct {
+	ID   string // ID con el que se consultó
+	Name string // Nombre de la partición; vacío si solo se conoce el ID (registros antiguos)
+	Disk string // Path absoluto del disco
+}
+
+// ResolveAuditPartition obtiene el disco y el nombre de la partición con el ID indicado. Si ya no está
+// montada, los toma del último registro con ese ID, porque el ID puede volver a asignarse a otra partición.
+func ResolveAuditPartition(id string) (AuditPartition, error) {
+	target := AuditPartition{ID: id}
+	if path, mounted := MountedPartitions[id]; mounted {
+		target.Disk = utils.DiskKey(path)
+		target.Name = PartitionName(id)
+		return target, nil
+	}
+
+	records, err := ReadAudit(func(record AuditRecord) bool { return record.Partition == id })
+	if err != nil {
+		return target, err
+	}
+	if len(records) == 0 {
+		return target, fmt.Errorf("la partición '%s' no está montada y no tiene registros de auditoría", id)
+	}
+	last := records[len(records)-1]
+	target.Name = last.Name
+	if last.Disk != "" {
+		target.Disk = utils.DiskKey(last.Disk)
+	}
+	return target, nil
+}
+
+// Matches indica si un registro modificó la partición o, sin indicar partición, su disco
+func (target AuditPartition) Matches(record AuditRecord) bool {
+	sameDisk := record.Disk != "" && utils.DiskKey(record.Disk) == target.Disk
+	switch {
+	case record.Name != "":
+		return sameDisk && record.Name == target.Name
+	case record.Partition != "":
+		// Registro anterior a los nombres: solo se puede comparar el ID
+		return record.Partition == target.ID
+	default:
+		return sameDisk
+	}
+}
+
+// PartitionName devuelve el nombre de la partición montada con el ID indicado, o "" si no se puede leer
+func PartitionName(id string) string {
+	partition, _, err := GetMountedRawPartition(id)
+	if err != nil || partition == nil {
+		return ""
+	}
+	return strings.Trim(string(partition.Part_name[:]), "\x00 ")
+}
@@ -0,0 +1,117 @@
+package globals
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commandsOf devuelve los comandos de los registros que coinciden con la partición
+func commandsOf(t *testing.T, target AuditPartition) []string {
+	t.Helper()
+	records, err := ReadAudit(target.Matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, record := range records {
+		commands = append(commands, record.Command)
+	}
+	return commands
+}
+
+func TestAuditPartitionSurvivesIDReuse(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DataDirEnv, dir)
+	diskA, diskB := filepath.Join(dir, "a.mia"), filepath.Join(dir, "b.mia")
+
+	record := func(partition, name, disk, line string) {
+		t.Helper()
+		if err := RecordAudit(nil, partition, name, disk, line, time.Now(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record("", "P1", diskA, "mount -name=P1")
+	record("061A", "P1", diskA, "mkdir -path=/a")
+	record("", "", diskA, "compactdisk")
+	record("061A", "P1", diskA, "unmount -id=061A")
+	// El ID queda libre y se asigna a otra partición de otro disco
+	record("", "P2", diskB, "mount -name=P2")
+	record("061A", "P2", diskB, "mkdir -path=/b")
+	record("", "P2", diskA, "fdisk -name=P2")
+
+	// Desmontada, -id=061A corresponde a la última partición que tuvo ese ID
+	target, err := ResolveAuditPartition("061A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Name != "P2" || target.Disk != diskB {
+		t.Fatalf("061A se resolvió a %+v, se esperaba P2 en %s", target, diskB)
+	}
+	if got := commandsOf(t, target); len(got) != 2 || got[0] != "mount -name=P2" || got[1] != "mkdir -path=/b" {
+		t.Fatalf("registros de P2 = %q", got)
+	}
+
+	// P1 conserva su historia, incluidos los comandos de su disco sin partición
+	got := commandsOf(t, AuditPartition{ID: "061A", Name: "P1", Disk: diskA})
+	want := []string{"mount -name=P1", "mkdir -path=/a", "compactdisk", "unmount -id=061A"}
+	if len(got) != len(want) {
+		t.Fatalf("registros de P1 = %q, se esperaba %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("registros de P1 = %q, se esperaba %q", got, want)
+		}
+	}
+
+	if _, err := ResolveAuditPartition("069Z"); err == nil {
+		t.Fatal("un ID sin montar y sin registros debería dar error")
+	}
+}
+
+func TestAuditMatchesRecordsWithoutName(t *testing.T) {
+	target := AuditPartition{ID: "061A", Name: "P1", Disk: "/discos/a.mia"}
+	cases := []struct {
+		record AuditRecord
+		want   bool
+	}{
+		{AuditRecord{Partition: "061A", Disk: "/discos/b.mia"}, true},
+		{AuditRecord{Partition: "062A", Disk: "/discos/a.mia"}, false},
+		{AuditRecord{Disk: "/discos/a.mia"}, true},
+		{AuditRecord{Disk: "/discos/b.mia"}, false},
+		{AuditRecord{Partition: "061A", Name: "P2", Disk: "/discos/a.mia"}, false},
+	}
+	for _, c := range cases {
+		if got := target.Matches(c.record); got != c.want {
+			t.Errorf("Matches(%+v) = %v, se esperaba %v", c.record, got, c.want)
+		}
+	}
+}
+
+func TestRedactCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"sin comillas", "mkusr -user=ana -pass=abc -grp=devs", "mkusr -user=ana -pass=**** -grp=devs"},
+		{"comillas dobles", `login -user=ana -pass="mi clave" -id=061A`, "login -user=ana -pass=**** -id=061A"},
+		{"comillas simples", `login -user=ana -pass='a "b" c' -id=061A`, "login -user=ana -pass=**** -id=061A"},
+		{"comillas escapadas", `passwd -old="x\" -new=y" -new=z`, "passwd -old=**** -new=****"},
+		{"mayúsculas", "LOGIN -User=ana -PaSS=secreto -ID=061A", "LOGIN -User=ana -PaSS=**** -ID=061A"},
+		{"passphrase", `mount -path="/mis discos/a.mia" -name=P1 -PASSPHRASE='x y'`, `mount -path="/mis discos/a.mia" -name=P1 -PASSPHRASE=****`},
+		{"sudo", `sudo -pass=abc -cmd="mkusr -user=b -pass='x y' -grp=g"`, `sudo -pass=**** -cmd="mkusr -user=b -pass=**** -grp=g"`},
+		{"sudo con comillas dentro", `sudo -Pass=abc -CMD='passwd -old="a b" -new=c'`, `sudo -Pass=**** -CMD="passwd -old=**** -new=****"`},
+		{"comentario", "mkusr -user=ana -pass=abc # la clave es abc", "mkusr -user=ana -pass=****"},
+		{"sin contraseñas", "mkdir -path=/home/ana -p", "mkdir -path=/home/ana -p"},
+		{"otro parámetro que contiene pass", "mkfile -path=/passwords.txt -cont=-pass=abc", "mkfile -path=/passwords.txt -cont=-pass=abc"},
+		{"comillas sin cerrar", `login -user=ana -pass="abc`, "login ****"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactCommand(tt.line); got != tt.want {
+				t.Errorf("RedactCommand(%q) = %q, se esperaba %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,20 +2,23 @@ package globals
 
 import (
 	structs "backend/Structs"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 )
 
-// ReadFileBlocks lee todos los bloques asignados a un archivo y devuelve su contenido completo
+// ReadFileBlocks lee todos los bloques asignados a un archivo y devuelve su contenido completo.
+// No cambia la fecha de último acceso: quien lee en nombre del usuario la actualiza según AtimeEnabled.
 func ReadFileBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inode) (string, error) {
 	var contenido string
 
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break // No hay más bloques asignados
-		}
-
+	// Los bloques de contenido en orden, incluidos los que cuelgan de los apuntadores indirectos
+	blocks, err := inode.DataBlocks(file, sb)
+	if err != nil {
+		return "", err
+	}
+	for _, blockIndex := range blocks {
 		blockOffset := int64(sb.S_block_start + blockIndex*int32(sb.S_block_size))
 		var fileBlock structs.FileBlock
 
@@ -29,63 +32,41 @@ func ReadFileBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inode)
 		contenido += string(fileBlock.B_content[:])
 	}
 
-	// Actualizar el tiempo de último acceso
-	inode.UpdateAtime()
-
 	return strings.TrimRight(contenido, "\x00"), nil
 }
 
-func WriteUsersBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inode, nuevoContenido string) error {
-	// Leer el contenido actual de los bloques asignados al inodo
-	contenidoExistente, err := ReadFileBlocks(file, sb, inode)
-	if err != nil {
-		return fmt.Errorf("error leyendo contenido existente de users.txt: %w", err)
+// ErrUsersFileFull indica que el contenido no cabe en users.txt: no quedan bloques libres en la
+// partición o, antes de RevisionInodeFlags, no cabe en los 15 apuntadores directos de su inodo.
+var ErrUsersFileFull = errors.New("users.txt está lleno")
+
+// CheckUsersFileSize verifica que el contenido quepa en users.txt antes de modificarlo
+func CheckUsersFileSize(file *os.File, sb *structs.Superblock, inode *structs.Inode, contenido string) error {
+	count := (len(contenido) + structs.BlockSize - 1) / structs.BlockSize
+	if err := sb.CheckFileBlocks(file, inode, count); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsersFileFull, err)
 	}
+	return nil
+}
 
-	// Combinar el contenido existente con el nuevo contenido
-	contenidoTotal := contenidoExistente + nuevoContenido
+// RewriteUsersBlocks reemplaza el contenido de users.txt. Pasados los 15 bloques directos el archivo
+// sigue creciendo con los apuntadores indirectos. Si el contenido no cabe devuelve ErrUsersFileFull
+// sin modificar el archivo.
+func RewriteUsersBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inode, contenido string) error {
+	if err := CheckUsersFileSize(file, sb, inode, contenido); err != nil {
+		return err
+	}
 
-	// Dividir el contenido total en bloques de tamaño BlockSize
-	blocks, err := structs.SplitContent(contenidoTotal)
+	// Dividir el contenido en bloques de tamaño BlockSize
+	blocks, err := structs.SplitContent(contenido)
 	if err != nil {
 		return fmt.Errorf("error al dividir el contenido en bloques: %w", err)
 	}
-
-	// Variable para mantener el índice del bloque
-	index := 0
-
-	// Iterar sobre los bloques generados y escribirlos en los bloques del inodo
-	for _, block := range blocks {
-		// Verificar si el índice excede la capacidad del array I_block
-		if index >= len(inode.I_block) {
-			return fmt.Errorf("se alcanzó el límite máximo de bloques del inodo")
-		}
-
-		// Si el bloque actual en el inodo está vacío, asignar uno nuevo
-		if inode.I_block[index] == -1 {
-			newBlockIndex, err := sb.AssignNewBlock(file, inode, index)
-			if err != nil {
-				return fmt.Errorf("error asignando nuevo bloque: %w", err)
-			}
-			inode.I_block[index] = newBlockIndex
-		}
-
-		// Calcular el offset del bloque en el archivo
-		blockOffset := int64(sb.S_block_start + inode.I_block[index]*int32(sb.S_block_size))
-
-		// Escribir el contenido del bloque en la partición
-		err = block.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo el bloque %d: %w", inode.I_block[index], err)
-		}
-
-		// Mover al siguiente bloque
-		index++
+	if err := sb.WriteFileBlocks(file, inode, blocks); err != nil {
+		return fmt.Errorf("error escribiendo los bloques de users.txt: %w", err)
 	}
 
 	// Actualizar el tamaño del archivo en el inodo (i_size)
-	nuevoTamano := len(contenidoTotal)
-	inode.I_size = int32(nuevoTamano)
+	inode.I_size = int32(len(contenido))
 
 	// Actualizar los tiempos de modificación y cambio
 	inode.UpdateMtime()
@@ -94,6 +75,19 @@ func WriteUsersBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inod
 	return nil
 }
 
+// WriteUsersBlocks agrega nuevoContenido al final de users.txt. Si el resultado no cabe en el
+// archivo devuelve ErrUsersFileFull sin asignar ningún bloque.
+func WriteUsersBlocks(file *os.File, sb *structs.Superblock, inode *structs.Inode, nuevoContenido string) error {
+	// Leer el contenido actual de los bloques asignados al inodo
+	contenidoExistente, err := ReadFileBlocks(file, sb, inode)
+	if err != nil {
+		return fmt.Errorf("error leyendo contenido existente de users.txt: %w", err)
+	}
+
+	// Combinar el contenido existente con el nuevo contenido
+	return RewriteUsersBlocks(file, sb, inode, contenidoExistente+nuevoContenido)
+}
+
 // InsertIntoUsersFile inserta una nueva entrada en el archivo users.txt
 func InsertIntoUsersFile(file *os.File, sb *structs.Superblock, inode *structs.Inode, entry string) error {
 	// Leer el contenido actual de los bloques asignados al inodo
@@ -127,10 +121,9 @@ func InsertIntoUsersFile(file *os.File, sb *structs.Superblock, inode *structs.I
 		if len(partes) > 2 && partes[1] == "G" && partes[2] == userGrupo {
 			groupID = partes[0] // Obtener el ID del grupo
 
-			// Insertar el usuario justo después del grupo si no se ha insertado ya
+			// Insertar el usuario justo después del grupo si no se ha insertado ya, con su propio UID
 			if groupID != "" && !usuarioInsertado {
-				usuarioConGrupo := fmt.Sprintf("%s,U,%s,%s,%s", groupID, partesEntry[2], partesEntry[3], partesEntry[4])
-				nuevoContenido = append(nuevoContenido, usuarioConGrupo)
+				nuevoContenido = append(nuevoContenido, strings.TrimSpace(entry))
 				usuarioInsertado = true
 			}
 		}
@@ -146,38 +139,12 @@ func InsertIntoUsersFile(file *os.File, sb *structs.Superblock, inode *structs.I
 	fmt.Println("=== Escribiendo nuevo contenido en users.txt ===")
 	fmt.Println(contenidoNuevo)
 
-	// Limpiar los bloques asignados al archivo
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			break // No hay más bloques asignados
-		}
-
-		blockOffset := int64(sb.S_block_start + blockIndex*sb.S_block_size)
-		var fileBlock structs.FileBlock
-
-		// Limpiar el contenido del bloque
-		fileBlock.ClearContent()
-
-		// Escribir el bloque vacío de nuevo
-		err = fileBlock.Encode(file, blockOffset)
-		if err != nil {
-			return fmt.Errorf("error escribiendo bloque limpio %d: %w", blockIndex, err)
-		}
-	}
-
 	// Reescribir todo el contenido línea por línea
-	err = WriteUsersBlocks(file, sb, inode, contenidoNuevo)
+	err = RewriteUsersBlocks(file, sb, inode, contenidoNuevo)
 	if err != nil {
 		return fmt.Errorf("error escribiendo el nuevo contenido en users.txt: %w", err)
 	}
 
-	// Actualizar el tamaño del archivo (i_size)
-	inode.I_size = int32(len(contenidoNuevo))
-
-	// Actualizar tiempos de modificación y cambio
-	inode.UpdateMtime()
-	inode.UpdateCtime()
-
 	return nil
 }
 
@@ -224,8 +191,11 @@ func CreateGroup(file *os.File, sb *structs.Superblock, inode *structs.Inode, gr
 
 // CreateUser añade un nuevo usuario en el archivo users.txt
 func CreateUser(file *os.File, sb *structs.Superblock, inode *structs.Inode, userName, userPassword, groupName string) error {
-	userEntry := fmt.Sprintf("%d,U,%s,%s,%s", sb.S_inodes_count+1, userName, groupName, userPassword)
-	return AddEntryToUsersFile(file, sb, inode, userEntry, userName, "U")
+	usuario := structs.NewUser(fmt.Sprintf("%d", sb.S_inodes_count+1), groupName, userName, "")
+	if err := usuario.SetPassword(userPassword); err != nil {
+		return err
+	}
+	return AddEntryToUsersFile(file, sb, inode, usuario.ToString(), userName, "U")
 }
 
 // FindInUsersFile busca una entrada en el archivo users.txt según nombre y tipo
@@ -262,10 +232,10 @@ func findLineInUsersFile(contenido string, name, entityType string) (string, int
 			if grupo.Tipo == entityType && grupo.Group == name {
 				return grupo.ToString(), i, nil // Devolver la línea y el índice
 			}
-		} else if entityType == "U" && len(campos) == 5 {
+		} else if entityType == "U" {
 			// Es un usuario
-			usuario := structs.NewUser(campos[0], campos[2], campos[3], campos[4]) // Crear instancia de User
-			if usuario.Tipo == entityType && usuario.Name == name {
+			usuario := structs.ParseUser(linea) // Crear instancia de User
+			if usuario != nil && usuario.Name == name {
 				return usuario.ToString(), i, nil // Devolver la línea y el índice
 			}
 		}
@@ -310,3 +280,125 @@ func ListUsersAndGroups(file *os.File, sb *structs.Superblock, inode *structs.In
 
 	return data, nil
 }
+
+// readUsersFile lee el contenido de users.txt de la partición montada
+func readUsersFile(id string) (string, error) {
+	path := MountedPartitions[id]
+	if path == "" {
+		return "", fmt.Errorf("la partición '%s' no está montada", id)
+	}
+
+	_, sb, _, err := GetMountedPartitionRep(id)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("no se puede abrir el archivo de partición: %v", err)
+	}
+	defer file.Close()
+
+	var usersInode structs.Inode
+	err = usersInode.Decode(file, sb.CalculateInodeOffset(1), sb)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo el inodo de users.txt: %v", err)
+	}
+
+	contenido, err := ReadFileBlocks(file, sb, &usersInode)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo users.txt: %v", err)
+	}
+	return contenido, nil
+}
+
+// UsersFile son las entradas de users.txt separadas por tipo, en el orden del archivo.
+// Las entradas eliminadas (id "0") se conservan para poder listarlas.
+type UsersFile struct {
+	Groups      []*structs.Group
+	Users       []*structs.User
+	Memberships []*structs.Membership
+}
+
+// ReadUsers lee y separa las entradas del users.txt de la partición montada
+func ReadUsers(id string) (*UsersFile, error) {
+	contenido, err := readUsersFile(id)
+	if err != nil {
+		return nil, err
+	}
+
+	users := &UsersFile{}
+	for _, linea := range strings.Split(contenido, "\n") {
+		campos := strings.Split(strings.TrimSpace(linea), ",")
+		switch {
+		case len(campos) == 3 && campos[1] == "G":
+			users.Groups = append(users.Groups, structs.NewGroup(campos[0], campos[2]))
+		case len(campos) == 4 && campos[1] == "M":
+			users.Memberships = append(users.Memberships, structs.NewMembership(campos[0], campos[2], campos[3]))
+		default:
+			if usuario := structs.ParseUser(linea); usuario != nil {
+				usuario.Status = usuario.Id != "0"
+				users.Users = append(users.Users, usuario)
+			}
+		}
+	}
+	return users, nil
+}
+
+// ActiveUser devuelve el usuario activo con ese nombre, o nil si no existe
+func (users *UsersFile) ActiveUser(userName string) *structs.User {
+	for _, usuario := range users.Users {
+		if usuario.Status && usuario.Name == userName {
+			return usuario
+		}
+	}
+	return nil
+}
+
+// ActiveGroup devuelve el grupo activo con ese nombre, o nil si no existe
+func (users *UsersFile) ActiveGroup(groupName string) *structs.Group {
+	for _, grupo := range users.Groups {
+		if grupo.GID != "0" && grupo.Group == groupName {
+			return grupo
+		}
+	}
+	return nil
+}
+
+// GroupsOf devuelve el grupo principal del usuario seguido de sus grupos suplementarios activos
+func (users *UsersFile) GroupsOf(userName string) ([]string, error) {
+	usuario := users.ActiveUser(userName)
+	if usuario == nil {
+		return nil, fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+
+	grupos := []string{usuario.Group}
+	for _, miembro := range users.Memberships {
+		if miembro.User == userName && miembro.Group != usuario.Group && users.ActiveGroup(miembro.Group) != nil {
+			grupos = append(grupos, miembro.Group)
+		}
+	}
+	return grupos, nil
+}
+
+// FindActiveUser busca en el users.txt de la partición montada un usuario que no esté eliminado
+func FindActiveUser(id, userName string) (*structs.User, error) {
+	users, err := ReadUsers(id)
+	if err != nil {
+		return nil, err
+	}
+	usuario := users.ActiveUser(userName)
+	if usuario == nil {
+		return nil, fmt.Errorf("el usuario '%s' no existe", userName)
+	}
+	return usuario, nil
+}
+
+// UserGroups devuelve el grupo principal del usuario seguido de sus grupos suplementarios activos
+func UserGroups(id, userName string) ([]string, error) {
+	users, err := ReadUsers(id)
+	if err != nil {
+		return nil, err
+	}
+	return users.GroupsOf(userName)
+}
@@ -0,0 +1,148 @@
+package globals
+
+import (
+	structs "backend/Structs"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestFilesystem formatea un sistema de archivos EXT2 pequeño y devuelve el inodo de users.txt
+func newTestFilesystem(t *testing.T) (*os.File, *structs.Superblock, *structs.Inode) {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "disco.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	const n = 64
+	inodeSize := int32(binary.Size(structs.Inode{}))
+	blockSize := int32(binary.Size(structs.FileBlock{}))
+	bmInodeStart := int32(binary.Size(structs.Superblock{}))
+	csumStart := bmInodeStart + 4*n
+	inodeStart := csumStart + 3*n*4
+	blockStart := inodeStart + inodeSize*n
+	if err := file.Truncate(int64(blockStart + 3*n*blockSize)); err != nil {
+		t.Fatal(err)
+	}
+
+	sb := &structs.Superblock{
+		S_filesystem_type:   2,
+		S_free_inodes_count: n,
+		S_free_blocks_count: 3 * n,
+		S_magic:             structs.FilesystemMagic,
+		S_inode_size:        inodeSize,
+		S_block_size:        blockSize,
+		S_first_ino:         inodeStart,
+		S_first_blo:         blockStart,
+		S_bm_inode_start:    bmInodeStart,
+		S_bm_block_start:    bmInodeStart + n,
+		S_inode_start:       inodeStart,
+		S_block_start:       blockStart,
+		S_rev_magic:         structs.SuperblockRevisionMagic,
+		S_revision:          structs.FormatRevision,
+		S_csum_start:        csumStart,
+	}
+	if err := sb.Encode(file, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateBitMaps(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.CreateUsersFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	inode := &structs.Inode{}
+	if err := inode.Decode(file, sb.CalculateInodeOffset(1), sb); err != nil {
+		t.Fatal(err)
+	}
+	return file, sb, inode
+}
+
+func TestUsersFileGrowsThroughIndirectBlocks(t *testing.T) {
+	file, sb, inode := newTestFilesystem(t)
+
+	// Cada grupo ocupa 16 bytes: 80 grupos no caben en los 15 bloques directos
+	for i := 2; i <= 80; i++ {
+		err := AddEntryToUsersFile(file, sb, inode, fmt.Sprintf("%d,G,grupo%06d", i, i), fmt.Sprintf("grupo%06d", i), "G")
+		if err != nil {
+			t.Fatalf("AddEntryToUsersFile() del grupo %d: %v", i, err)
+		}
+	}
+	if !inode.HasIndirectBlocks() || inode.I_block[12] == -1 {
+		t.Fatalf("users.txt no usa bloques indirectos: I_flags = %#x, I_block = %v", inode.I_flags, inode.I_block)
+	}
+	contenido, err := ReadFileBlocks(file, sb, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(contenido, "1,G,root\n") || !strings.HasSuffix(contenido, "80,G,grupo000080\n") {
+		t.Fatalf("ReadFileBlocks() no devolvió todos los grupos:\n%s", contenido)
+	}
+
+	// Al achicarse conserva sus bloques en lugar de liberarlos
+	freeBlocks := sb.S_free_blocks_count
+	if err := RewriteUsersBlocks(file, sb, inode, "1,G,root\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadFileBlocks(file, sb, inode); err != nil || got != "1,G,root\n" {
+		t.Fatalf("ReadFileBlocks() después de achicar = %q, %v", got, err)
+	}
+	if sb.S_free_blocks_count != freeBlocks {
+		t.Errorf("achicar users.txt cambió los bloques libres de %d a %d", freeBlocks, sb.S_free_blocks_count)
+	}
+}
+
+func TestWriteUsersBlocksRejectsFullFile(t *testing.T) {
+	file, sb, inode := newTestFilesystem(t)
+	for i := 2; i <= 20; i++ {
+		err := AddEntryToUsersFile(file, sb, inode, fmt.Sprintf("%d,G,grupo%06d", i, i), fmt.Sprintf("grupo%06d", i), "G")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Ocupar el resto de los bloques de la partición
+	for sb.S_free_blocks_count > 0 {
+		if _, err := sb.FindNextFreeBlock(file); err != nil {
+			t.Fatal(err)
+		}
+		sb.UpdateSuperblockAfterBlockAllocation()
+	}
+
+	before, err := ReadFileBlocks(file, sb, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := inode.I_block
+
+	// Un usuario nuevo no cabe: users.txt debe quedar igual
+	err = InsertIntoUsersFile(file, sb, inode, "0,U,root,usuario,$2a$10$abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabc")
+	if !errors.Is(err, ErrUsersFileFull) {
+		t.Fatalf("InsertIntoUsersFile() sin bloques libres = %v, se esperaba ErrUsersFileFull", err)
+	}
+	after, err := ReadFileBlocks(file, sb, inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before || inode.I_block != blocks {
+		t.Errorf("un intento rechazado cambió users.txt:\n%q\n%q", before, after)
+	}
+}
+
+func TestUsersFileNeedsMigrateForIndirectBlocks(t *testing.T) {
+	file, sb, inode := newTestFilesystem(t)
+	sb.S_revision = structs.RevisionChecksums
+
+	// 16 bloques ya no caben en los apuntadores directos
+	contenido := strings.Repeat("1,G,root\n", 16*64/9+1)
+	if err := CheckUsersFileSize(file, sb, inode, contenido); !errors.Is(err, ErrUsersFileFull) || !strings.Contains(err.Error(), "migrate") {
+		t.Fatalf("CheckUsersFileSize() en %s = %v, se esperaba ErrUsersFileFull sugiriendo migrate", structs.RevisionName(sb.S_revision), err)
+	}
+}
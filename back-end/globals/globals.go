@@ -9,9 +9,9 @@ import (
 // Mi carnet
 const Carnet string = "06" // 202100106
 var (
-	// UsuarioActual guarda la información del usuario logueado actualmente
-	UsuarioActual     *structures.User  = nil
 	MountedPartitions map[string]string = make(map[string]string)
+	// PartitionPassphrases guarda la contraseña indicada al montar cada partición; nunca se escribe en disco
+	PartitionPassphrases map[string]string = make(map[string]string)
 )
 
 // GetMountedPartitionSuperblock obtiene el SuperBlock de la partición montada con el id especificado
@@ -36,7 +36,7 @@ func GetMountedPartitionSuperblock(id string) (*structures.Superblock, *structur
 	}
 
 	// Buscar la partición con el id especificado
-	partition, err := mbr.GetPartitionByID(id)
+	partition, err := mbr.FindPartitionByID(file, id)
 	if partition == nil {
 		return nil, nil, "", err
 	}
@@ -53,8 +53,17 @@ func GetMountedPartitionSuperblock(id string) (*structures.Superblock, *structur
 	return &sb, partition, path, nil
 }
 
-// GetMountedPartition obtiene la partición montada con el id especificado
+// GetMountedPartition obtiene la zona del sistema de archivos de la partición montada con el id especificado
 func GetMountedPartition(id string) (*structures.Partition, string, error) {
+	return getMountedPartition(id, false)
+}
+
+// GetMountedRawPartition obtiene la partición montada con el id especificado, incluida su cabecera de cifrado
+func GetMountedRawPartition(id string) (*structures.Partition, string, error) {
+	return getMountedPartition(id, true)
+}
+
+func getMountedPartition(id string, raw bool) (*structures.Partition, string, error) {
 	// Obtener el path de la partición montada
 	path := MountedPartitions[id]
 	if path == "" {
@@ -78,10 +87,13 @@ func GetMountedPartition(id string) (*structures.Partition, string, error) {
 	}
 
 	// Buscar la partición con el id especificado
-	partition, err := mbr.GetPartitionByID(id)
+	partition, err := mbr.FindRawPartitionByID(file, id)
 	if partition == nil {
 		return nil, "", err
 	}
+	if !raw {
+		partition = partition.FilesystemPartition(file)
+	}
 
 	return partition, path, nil
 }
@@ -111,7 +123,7 @@ func GetMountedPartitionRep(id string) (*structures.MBR, *structures.Superblock,
 	}
 
 	// Buscar la partición con el id especificado
-	partition, err := mbr.GetPartitionByID(id)
+	partition, err := mbr.FindPartitionByID(file, id)
 	if err != nil {
 		return nil, nil, "", err
 	}
@@ -127,16 +139,3 @@ func GetMountedPartitionRep(id string) (*structures.MBR, *structures.Superblock,
 
 	return &mbr, &sb, path, nil
 }
-
-// IsLoggedIn verifica si hay un usuario logueado actualmente
-func IsLoggedIn() bool {
-	return UsuarioActual != nil && UsuarioActual.Status
-}
-
-// Logout cierra la sesión del usuario actual
-func Logout() {
-	if UsuarioActual != nil {
-		UsuarioActual.Status = false // Cambiar el estado del usuario a no logueado
-		UsuarioActual = nil          // Limpiar la información del usuario
-	}
-}
@@ -0,0 +1,88 @@
+package globals
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MountOptions son las opciones con las que se montó una partición
+type MountOptions struct {
+	ReadOnly bool `json:"ro,omitempty"`      // No se permite modificar el sistema de archivos
+	NoAtime  bool `json:"noatime,omitempty"` // No se actualiza la fecha de último acceso de los inodos
+	Sync     bool `json:"sync,omitempty"`    // Los cambios se sincronizan con el disco después de cada comando
+}
+
+// PartitionOptions guarda las opciones de montaje de cada partición montada
+var PartitionOptions = make(map[string]MountOptions)
+
+// ParseMountOptions interpreta una lista de opciones separadas por comas (ro, rw, noatime, atime, sync)
+func ParseMountOptions(value string) (MountOptions, error) {
+	var options MountOptions
+	for _, option := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(option)) {
+		case "ro":
+			options.ReadOnly = true
+		case "rw":
+			options.ReadOnly = false
+		case "noatime":
+			options.NoAtime = true
+		case "atime":
+			options.NoAtime = false
+		case "sync":
+			options.Sync = true
+		case "":
+		default:
+			return options, fmt.Errorf("opción de montaje desconocida: %s", option)
+		}
+	}
+	return options, nil
+}
+
+// String devuelve las opciones en el formato de -options
+func (options MountOptions) String() string {
+	list := []string{"rw"}
+	if options.ReadOnly {
+		list[0] = "ro"
+	}
+	if options.NoAtime {
+		list = append(list, "noatime")
+	}
+	if options.Sync {
+		list = append(list, "sync")
+	}
+	return strings.Join(list, ",")
+}
+
+// ReadOnlyFailures guarda los intentos fallidos de login de cada partición montada como solo lectura,
+// donde /etc/faillock no se puede actualizar. Se pierden al desmontar la partición.
+var ReadOnlyFailures = make(map[string]map[string]int)
+
+// CheckWritable devuelve un error si la partición está montada como solo lectura
+func CheckWritable(id string) error {
+	if PartitionOptions[id].ReadOnly {
+		return fmt.Errorf("la partición con ID '%s' está montada como solo lectura", id)
+	}
+	return nil
+}
+
+// AtimeEnabled indica si se debe actualizar la fecha de último acceso en la partición
+func AtimeEnabled(id string) bool {
+	options := PartitionOptions[id]
+	return !options.ReadOnly && !options.NoAtime
+}
+
+// SyncPartition fuerza la escritura en disco si la partición se montó con sync
+func SyncPartition(id string) error {
+	path, mounted := MountedPartitions[id]
+	if !mounted || !PartitionOptions[id].Sync {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("error abriendo el disco para sincronizar: %v", err)
+	}
+	defer file.Close()
+	return file.Sync()
+}
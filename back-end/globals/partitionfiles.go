@@ -0,0 +1,182 @@
+package globals
+
+import (
+	structs "backend/Structs"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AuthLogPath es el archivo de la partición donde se registran los inicios de sesión y las autenticaciones
+var AuthLogPath = []string{"var", "log", "auth.log"}
+
+// MaxPartitionFileSize es el contenido máximo de un archivo escrito con WritePartitionFile (15 bloques directos)
+const MaxPartitionFileSize = 15 * 64
+
+// ReadPartitionFile lee un archivo de la partición montada; el segundo valor indica si el archivo existe
+func ReadPartitionFile(id string, ruta []string) (string, bool, error) {
+	path := MountedPartitions[id]
+	if path == "" {
+		return "", false, fmt.Errorf("la partición '%s' no está montada", id)
+	}
+
+	_, sb, _, err := GetMountedPartitionRep(id)
+	if err != nil {
+		return "", false, fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, fmt.Errorf("no se puede abrir el archivo de partición: %v", err)
+	}
+	defer file.Close()
+
+	inodeIndex, err := FindPath(file, sb, ruta)
+	if err != nil || inodeIndex == -1 {
+		return "", false, err
+	}
+
+	var inode structs.Inode
+	err = inode.Decode(file, sb.CalculateInodeOffset(inodeIndex), sb)
+	if err != nil {
+		return "", false, fmt.Errorf("error leyendo el inodo de /%s: %v", strings.Join(ruta, "/"), err)
+	}
+	if inode.I_type[0] != '1' {
+		return "", false, fmt.Errorf("/%s no es un archivo", strings.Join(ruta, "/"))
+	}
+
+	contenido, err := ReadFileBlocks(file, sb, &inode)
+	if err != nil {
+		return "", false, err
+	}
+	contenido, err = inode.DecodeContent(contenido)
+	if err != nil {
+		return "", false, err
+	}
+	return contenido, true, nil
+}
+
+// WritePartitionFile reemplaza el contenido de un archivo de la partición montada,
+// creando el archivo y sus carpetas si todavía no existen
+func WritePartitionFile(id string, ruta []string, contenido string) error {
+	if err := CheckWritable(id); err != nil {
+		return err
+	}
+	if len(contenido) > MaxPartitionFileSize {
+		return fmt.Errorf("el contenido de /%s excede los %d bytes", strings.Join(ruta, "/"), MaxPartitionFileSize)
+	}
+
+	partition, path, err := GetMountedPartition(id)
+	if err != nil {
+		return fmt.Errorf("no se puede encontrar la partición montada: %v", err)
+	}
+	_, sb, _, err := GetMountedPartitionRep(id)
+	if err != nil {
+		return fmt.Errorf("no se pudo cargar el Superblock: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("no se puede abrir el archivo de partición: %v", err)
+	}
+	defer file.Close()
+
+	// Crear las carpetas que falten
+	carpetas, nombre := ruta[:len(ruta)-1], ruta[len(ruta)-1]
+	for i := range carpetas {
+		existente, err := FindPath(file, sb, carpetas[:i+1])
+		if err != nil {
+			return err
+		}
+		if existente == -1 {
+			err = sb.CreateFolder(file, append([]string{}, carpetas[:i]...), carpetas[i])
+			if err != nil {
+				return fmt.Errorf("error creando /%s: %v", strings.Join(carpetas[:i+1], "/"), err)
+			}
+		}
+	}
+
+	inodeIndex, err := FindPath(file, sb, ruta)
+	if err != nil {
+		return err
+	}
+	if inodeIndex == -1 {
+		// Archivo nuevo: se crea directamente con su contenido
+		err = sb.CreateFile(file, append([]string{}, carpetas...), nombre, len(contenido), []string{contenido})
+		if err != nil {
+			return fmt.Errorf("error creando /%s: %v", strings.Join(ruta, "/"), err)
+		}
+	} else {
+		err = rewriteFile(file, sb, inodeIndex, contenido)
+		if err != nil {
+			return fmt.Errorf("error escribiendo /%s: %v", strings.Join(ruta, "/"), err)
+		}
+	}
+
+	// Guardar el Superblock usando el Part_start como el offset
+	err = sb.Encode(file, int64(partition.Part_start))
+	if err != nil {
+		return fmt.Errorf("error guardando el Superblock: %v", err)
+	}
+	return nil
+}
+
+// AppendAuthLog agrega una línea con fecha, partición, usuario y resultado a /var/log/auth.log.
+// En particiones de solo lectura no se registra nada. Si el archivo se llena se descartan las líneas más antiguas.
+func AppendAuthLog(id, usuario, evento string) error {
+	if CheckWritable(id) != nil {
+		return nil
+	}
+
+	contenido, _, err := ReadPartitionFile(id, AuthLogPath)
+	if err != nil {
+		return err
+	}
+
+	linea := fmt.Sprintf("%s [%s] %s: %s\n", time.Now().Format("2006-01-02 15:04:05"), id, usuario, evento)
+	contenido += linea
+	for len(contenido) > MaxPartitionFileSize {
+		corte := strings.Index(contenido, "\n")
+		if corte == -1 || corte == len(contenido)-1 {
+			contenido = linea[:MaxPartitionFileSize]
+			break
+		}
+		contenido = contenido[corte+1:]
+	}
+
+	return WritePartitionFile(id, AuthLogPath, contenido)
+}
+
+// FindPath devuelve el inodo de la ruta indicada desde la raíz, o -1 si no existe
+func FindPath(file *os.File, sb *structs.Superblock, ruta []string) (int32, error) {
+	inodeIndex := int32(0)
+	for _, nombre := range ruta {
+		siguiente, err := sb.FindFolderEntry(file, inodeIndex, nombre)
+		if err != nil || siguiente == -1 {
+			return -1, err
+		}
+		inodeIndex = siguiente
+	}
+	return inodeIndex, nil
+}
+
+// rewriteFile reemplaza el contenido del archivo reutilizando sus bloques
+func rewriteFile(file *os.File, sb *structs.Superblock, inodeIndex int32, contenido string) error {
+	var inode structs.Inode
+	offset := sb.CalculateInodeOffset(inodeIndex)
+	err := inode.Decode(file, offset, sb)
+	if err != nil {
+		return fmt.Errorf("error leyendo el inodo %d: %v", inodeIndex, err)
+	}
+
+	guardado, err := inode.EncodeContent(contenido)
+	if err != nil {
+		return err
+	}
+	err = RewriteUsersBlocks(file, sb, &inode, guardado)
+	if err != nil {
+		return err
+	}
+	return inode.Encode(file, offset, sb)
+}
@@ -0,0 +1,124 @@
+package globals
+
+import (
+	structures "backend/Structs"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Session guarda el usuario logueado de un cliente HTTP. Cada cliente se identifica con su token,
+// así varios clientes pueden compartir el servidor sin usar la sesión de los demás.
+type Session struct {
+	Token    string
+	Usuario  *structures.User // nil mientras el cliente no haya iniciado sesión
+	LastUsed time.Time
+}
+
+// SessionTTL es el tiempo sin uso tras el cual una sesión expira
+const SessionTTL = 8 * time.Hour
+
+// ErrInvalidSession indica que el token no corresponde a ninguna sesión activa
+var ErrInvalidSession = errors.New("sesión inválida o expirada, solicite un token nuevo")
+
+var (
+	sessions = make(map[string]*Session)
+	// commandMu serializa la ejecución de comandos: la tabla de montaje y las llaves de cifrado son estado global
+	commandMu sync.Mutex
+)
+
+// IsLoggedIn verifica si la sesión tiene un usuario logueado
+func (s *Session) IsLoggedIn() bool {
+	return s != nil && s.Usuario != nil && s.Usuario.Status
+}
+
+// Logout cierra la sesión del usuario
+func (s *Session) Logout() {
+	if s.Usuario != nil {
+		s.Usuario.Status = false // Cambiar el estado del usuario a no logueado
+		s.Usuario = nil          // Limpiar la información del usuario
+	}
+}
+
+// PartitionID devuelve el ID de la partición donde inició sesión el usuario, o "" si no hay sesión
+func (s *Session) PartitionID() string {
+	if !s.IsLoggedIn() {
+		return ""
+	}
+	return s.Usuario.Id
+}
+
+// NewSession crea una sesión sin usuario y devuelve su token
+func NewSession() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error generando el token de sesión: %v", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	commandMu.Lock()
+	defer commandMu.Unlock()
+	sessions[token] = &Session{Token: token, LastUsed: time.Now()}
+	return token, nil
+}
+
+// HasSession indica si el token corresponde a una sesión activa
+func HasSession(token string) bool {
+	commandMu.Lock()
+	defer commandMu.Unlock()
+	return lookupSession(token) != nil
+}
+
+// EndSession elimina la sesión del token
+func EndSession(token string) {
+	commandMu.Lock()
+	defer commandMu.Unlock()
+	delete(sessions, token)
+}
+
+// RunInSession ejecuta fn con la sesión del token; los comandos que recibe fn leen y cambian
+// (login, logout, su) el usuario de esa sesión y de ninguna otra
+func RunInSession(token string, fn func(session *Session)) error {
+	commandMu.Lock()
+	defer commandMu.Unlock()
+
+	session := lookupSession(token)
+	if session == nil {
+		return ErrInvalidSession
+	}
+
+	fn(session)
+	if !session.IsLoggedIn() {
+		session.Usuario = nil
+	}
+	return nil
+}
+
+// lookupSession devuelve la sesión del token, descartando las que expiraron
+func lookupSession(token string) *Session {
+	now := time.Now()
+	for t, session := range sessions {
+		if now.Sub(session.LastUsed) > SessionTTL {
+			delete(sessions, t)
+		}
+	}
+
+	session := sessions[token]
+	if session != nil {
+		session.LastUsed = now
+	}
+	return session
+}
+
+// LogoutPartition cierra la sesión de los usuarios logueados en la partición. No toma commandMu:
+// se llama desde los comandos, que ya corren dentro de RunInSession.
+func LogoutPartition(id string) {
+	for _, session := range sessions {
+		if session.Usuario != nil && session.Usuario.Id == id {
+			session.Usuario = nil
+		}
+	}
+}
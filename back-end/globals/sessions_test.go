@@ -0,0 +1,68 @@
+package globals
+
+import (
+	structures "backend/Structs"
+	"testing"
+)
+
+// loginAs abre una sesión con el usuario logueado en la partición id y devuelve su token
+func loginAs(t *testing.T, name, id string) string {
+	t.Helper()
+	token, err := NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { EndSession(token) })
+	if err := RunInSession(token, func(session *Session) {
+		session.Usuario = &structures.User{Name: name, Id: id, Status: true}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// userOf devuelve el usuario logueado en la sesión del token, o "" si no hay
+func userOf(t *testing.T, token string) string {
+	t.Helper()
+	var name string
+	if err := RunInSession(token, func(session *Session) {
+		if session.IsLoggedIn() {
+			name = session.Usuario.Name
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLogoutPartition(t *testing.T) {
+	root := loginAs(t, "root", "061A")
+	ana := loginAs(t, "ana", "061A")
+	luis := loginAs(t, "luis", "062A")
+
+	// Los comandos llaman a LogoutPartition desde dentro de RunInSession, con commandMu tomado
+	if err := RunInSession(root, func(session *Session) { LogoutPartition("061A") }); err != nil {
+		t.Fatal(err)
+	}
+
+	for token, want := range map[string]string{root: "", ana: "", luis: "luis"} {
+		if got := userOf(t, token); got != want {
+			t.Errorf("después de LogoutPartition(061A) la sesión ve a %q, se esperaba %q", got, want)
+		}
+	}
+	// Las sesiones siguen existiendo: el cliente puede volver a iniciar sesión con su token
+	if !HasSession(root) || !HasSession(ana) {
+		t.Error("LogoutPartition eliminó las sesiones en lugar de cerrarlas")
+	}
+}
+
+func TestRunInSessionRejectsUnknownToken(t *testing.T) {
+	if err := RunInSession("no-existe", func(session *Session) {}); err != ErrInvalidSession {
+		t.Fatalf("RunInSession() con un token desconocido = %v, se esperaba ErrInvalidSession", err)
+	}
+	token := loginAs(t, "ana", "061A")
+	EndSession(token)
+	if HasSession(token) {
+		t.Fatal("EndSession no eliminó la sesión")
+	}
+}
@@ -0,0 +1,248 @@
+package globals
+
+import (
+	structures "backend/Structs"
+	utils "backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DataDirEnv es la variable de entorno que indica dónde guardar el estado del servidor
+const DataDirEnv = "MIA_DATA_DIR"
+
+// mountStateFile es el archivo donde se guarda la tabla de montaje dentro del directorio de datos
+const mountStateFile = "mounts.json"
+
+// MountState es el contenido del archivo de estado de la tabla de montaje
+type MountState struct {
+	Mounts  map[string]string       `json:"mounts"`            // ID de la partición -> path del disco
+	Letters map[string]string       `json:"letters"`           // Path del disco -> letra asignada
+	Options map[string]MountOptions `json:"options,omitempty"` // ID de la partición -> opciones de montaje
+	Logical map[string]string       `json:"logical,omitempty"` // ID de la partición lógica -> su nombre
+}
+
+// DataDir devuelve el directorio de datos del servidor
+func DataDir() string {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
+	return "data"
+}
+
+// SaveMountTable guarda la tabla de montaje para restaurarla al reiniciar el servidor
+func SaveMountTable() error {
+	state := MountState{Mounts: MountedPartitions, Letters: utils.Letters(), Options: PartitionOptions, Logical: make(map[string]string)}
+	for id, path := range MountedPartitions {
+		if name := structures.LogicalMountName(path, id); name != "" {
+			state.Logical[id] = name
+		}
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando la tabla de montaje: %w", err)
+	}
+
+	if err := os.MkdirAll(DataDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creando el directorio de datos: %w", err)
+	}
+
+	// Escribir en un archivo temporal y renombrarlo para no dejar un estado a medias
+	path := filepath.Join(DataDir(), mountStateFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("error escribiendo la tabla de montaje: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("error escribiendo la tabla de montaje: %w", err)
+	}
+	return nil
+}
+
+// RestoreMountTable carga la tabla de montaje guardada, conserva solo las particiones cuyo MBR
+// sigue teniendo el mismo ID y limpia los IDs que quedaron escritos sin estar montados
+func RestoreMountTable() error {
+	data, err := os.ReadFile(filepath.Join(DataDir(), mountStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error leyendo la tabla de montaje: %w", err)
+	}
+
+	var state MountState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error deserializando la tabla de montaje: %w", err)
+	}
+
+	// Las letras se restauran aunque el disco no tenga particiones montadas, para que los IDs no cambien
+	for path, letter := range state.Letters {
+		if _, err := os.Stat(path); err != nil {
+			fmt.Printf("Disco '%s' ya no existe, se descarta su letra %s\n", path, letter)
+			continue
+		}
+		if err := utils.RestoreLetter(path, letter); err != nil {
+			fmt.Printf("No se pudo restaurar la letra del disco '%s': %v\n", path, err)
+		}
+	}
+
+	// Agrupar las particiones montadas por disco
+	disks := make(map[string][]string)
+	for id, path := range state.Mounts {
+		disks[path] = append(disks[path], id)
+	}
+	for path := range state.Letters {
+		if _, exists := disks[path]; !exists {
+			disks[path] = nil
+		}
+	}
+
+	for path, ids := range disks {
+		restored, err := reconcileDisk(path, ids, state.Logical)
+		if err != nil {
+			fmt.Printf("No se restauraron las particiones del disco '%s': %v\n", path, err)
+			continue
+		}
+		for _, id := range restored {
+			MountedPartitions[id] = path
+			if options, exists := state.Options[id]; exists {
+				PartitionOptions[id] = options
+			}
+			fmt.Printf("Partición con ID %s restaurada desde %s\n", id, path)
+		}
+	}
+
+	// Guardar el estado ya depurado
+	return SaveMountTable()
+}
+
+// reconcileDisk valida los IDs montados de un disco contra su MBR y limpia los que ya no están montados.
+// logical indica el nombre de las particiones lógicas montadas, que no guardan su ID en el disco.
+func reconcileDisk(path string, ids []string, logical map[string]string) ([]string, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mbr structures.MBR
+	if err := mbr.Decode(file); err != nil {
+		return nil, fmt.Errorf("MBR inválido: %v", err)
+	}
+
+	mounted := make(map[string]bool)
+	for _, id := range ids {
+		mounted[id] = true
+	}
+
+	var restored []string
+	changed := false
+	for i := range mbr.MbrPartitions {
+		partition := &mbr.MbrPartitions[i]
+		if partition.Part_start == -1 {
+			continue
+		}
+		id := strings.Trim(string(partition.Part_id[:]), "\x00 ")
+		if id == "" || id == "0" {
+			continue
+		}
+
+		if mounted[id] && !isLocked(file, partition) {
+			restored = append(restored, id)
+			delete(mounted, id)
+			continue
+		}
+		utils.UnregisterCipher(path, int64(partition.Part_start+structures.CryptHeaderSize))
+
+		// El ID quedó escrito en el MBR, pero la partición ya no está montada
+		fmt.Printf("Limpiando ID obsoleto %s de la partición '%s'\n", id, strings.Trim(string(partition.Part_name[:]), "\x00 "))
+		partition.MountPartition(0, "")
+		changed = true
+	}
+
+	// Las particiones lógicas se buscan por el nombre con que se montaron
+	for id := range mounted {
+		name, exists := logical[id]
+		if !exists {
+			continue
+		}
+		structures.MountLogical(path, id, name)
+		ebr, err := mbr.FindEBRByID(file, id)
+		if err == nil && !isLocked(file, ebr.ToPartition()) {
+			restored = append(restored, id)
+			delete(mounted, id)
+			continue
+		}
+		structures.UnmountLogical(path, id)
+		if err == nil {
+			utils.UnregisterCipher(path, int64(ebr.DataStart()+structures.CryptHeaderSize))
+		}
+	}
+
+	for id := range mounted {
+		fmt.Printf("La partición con ID %s ya no existe en el disco '%s'\n", id, path)
+	}
+
+	if changed {
+		if err := mbr.Encode(file); err != nil {
+			return nil, fmt.Errorf("error actualizando el MBR: %v", err)
+		}
+	}
+	return restored, nil
+}
+
+// isLocked indica si la partición está cifrada y su llave no está cargada; al reiniciar el servidor
+// las contraseñas se pierden, así que esas particiones se deben volver a montar con -passphrase
+func isLocked(file *os.File, partition *structures.Partition) bool {
+	if !structures.IsEncrypted(file, partition.Part_start) || partition.IsUnlocked(file) {
+		return false
+	}
+	fmt.Printf("La partición '%s' está cifrada; vuelva a montarla con -passphrase\n", strings.Trim(string(partition.Part_name[:]), "\x00 "))
+	return true
+}
+
+// ReconcileMounts vuelve a validar las particiones montadas de un disco cuyo contenido cambió
+// por fuera de mount y unmount. Desmonta las que ya no tienen su ID en el disco y las devuelve.
+func ReconcileMounts(path string) ([]string, error) {
+	var ids []string
+	for id, mountedPath := range MountedPartitions {
+		if mountedPath == path {
+			ids = append(ids, id)
+		}
+	}
+
+	logical := make(map[string]string)
+	for _, id := range ids {
+		if name := structures.LogicalMountName(path, id); name != "" {
+			logical[id] = name
+		}
+	}
+
+	restored, err := reconcileDisk(path, ids, logical)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]bool)
+	for _, id := range restored {
+		kept[id] = true
+	}
+	var dropped []string
+	for _, id := range ids {
+		if kept[id] {
+			continue
+		}
+		delete(MountedPartitions, id)
+		delete(PartitionOptions, id)
+		delete(PartitionPassphrases, id)
+		delete(ReadOnlyFailures, id)
+		LogoutPartition(id)
+		dropped = append(dropped, id)
+	}
+	sort.Strings(dropped)
+
+	return dropped, SaveMountTable()
+}
@@ -6,7 +6,6 @@ import (
 	commands "backend/commands"
 	usercommands "backend/commands/Users"
 	"backend/globals"
-	"encoding/binary"
 	"fmt"
 	"log" // Importa el paquete "log" para registrar mensajes de error
 	"os"
@@ -17,7 +16,35 @@ import (
 )
 
 var diskManager = commands.NewDiskManager() // Crear una nueva instancia de DiskManager
+
+// sessionToken obtiene el token de sesión de la cabecera "Authorization: Bearer <token>"
+func sessionToken(c *fiber.Ctx) string {
+	return strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+}
+
+// inSession exige un token de sesión válido y ejecuta el handler con esa sesión
+func inSession(handler func(c *fiber.Ctx, session *globals.Session) error) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var result error
+		err := globals.RunInSession(sessionToken(c), func(session *globals.Session) {
+			result = handler(c, session)
+		})
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+		return result
+	}
+}
+
 func main() {
+	// Restaurar las particiones que estaban montadas antes de reiniciar el servidor
+	if err := globals.RestoreMountTable(); err != nil {
+		log.Println("Error restaurando la tabla de montaje:", err)
+	}
+
 	// Crear una nueva instancia de Fiber
 	app := fiber.New()
 
@@ -28,7 +55,7 @@ func main() {
 	}))
 
 	// Definir la ruta POST para recibir el comando del usuario
-	app.Post("/analyze", func(c *fiber.Ctx) error {
+	app.Post("/analyze", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		// Estructura para recibir el JSON
 		type Request struct {
 			Command string `json:"command"`
@@ -62,7 +89,7 @@ func main() {
 			}
 
 			// Llamar a la función Analyzer del paquete analyzer para analizar la línea
-			result, err := analyzer.Analyzer(line)
+			result, err := analyzer.Analyzer(line, session)
 			if err != nil {
 				// Si hay un error, almacenar el mensaje de error en lugar del resultado
 				result = fmt.Sprintf("Error: %s", err.Error())
@@ -76,10 +103,10 @@ func main() {
 		return c.JSON(fiber.Map{
 			"results": results,
 		})
-	})
+	}))
 
 	// Endpoint para obtener las particiones de un disco
-	app.Post("/api/disk/partitions", func(c *fiber.Ctx) error {
+	app.Post("/api/disk/partitions", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		type DiskRequest struct {
 			Path string `json:"path"`
 		}
@@ -119,9 +146,9 @@ func main() {
 		return c.JSON(fiber.Map{
 			"partitions": partitions,
 		})
-	})
+	}))
 
-	app.Post("/api/disk/partition/tree", func(c *fiber.Ctx) error {
+	app.Post("/api/disk/partition/tree", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		// Estructura para recibir el JSON
 		type PartitionRequest struct {
 			DiskPath      string `json:"diskPath"`
@@ -156,7 +183,7 @@ func main() {
 		}
 
 		// Crear una nueva instancia de DirectoryTreeService
-		treeService, err := commands.NewDirectoryTreeService()
+		treeService, err := commands.NewDirectoryTreeService(session)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": fmt.Sprintf("Error al inicializar el servicio del árbol de directorios: %v", err),
@@ -176,7 +203,7 @@ func main() {
 		return c.JSON(fiber.Map{
 			"tree": tree,
 		})
-	})
+	}))
 
 	// Definir la ruta POST para el inicio de sesión de los usuarios
 	app.Post("/users/login", func(c *fiber.Ctx) error {
@@ -194,20 +221,80 @@ func main() {
 			})
 		}
 
-		// Crear el comando de login sin verificar nada en este nivel
-		loginCommand := fmt.Sprintf("login -user=%s -pass=%s -id=%s", req.Username, req.Password, req.ID)
+		// Usar la sesión del cliente o crear una nueva si no envió un token válido
+		token := sessionToken(c)
+		created := false
+		if token == "" || !globals.HasSession(token) {
+			var err error
+			token, err = globals.NewSession()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"status":  "error",
+					"message": err.Error(),
+				})
+			}
+			created = true
+		}
 
-		// Pasar los parámetros directamente al comando ParserLogin
-		result, _ := usercommands.ParserLogin(strings.Split(loginCommand, " "))
+		// Pasar los parámetros directamente al comando ParserLogin, con el usuario de la sesión
+		var result map[string]interface{}
+		var loginErr error
+		err := globals.RunInSession(token, func(session *globals.Session) {
+			result, loginErr = usercommands.ParserLogin([]string{"-user=" + req.Username, "-pass=" + req.Password, "-id=" + req.ID}, session)
+		})
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+
+		// El token identifica la sesión en las siguientes solicitudes
+		if loginErr != nil && created {
+			globals.EndSession(token)
+		} else {
+			result["token"] = token
+		}
 
 		// Retornar directamente el resultado del comando, que ya maneja los errores
 		return c.JSON(result)
 	})
 
+	// Crear una sesión sin usuario para ejecutar comandos antes de iniciar sesión
+	app.Post("/session", func(c *fiber.Ctx) error {
+		token, err := globals.NewSession()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"token":  token,
+		})
+	})
+
+	// Eliminar la sesión del cliente
+	app.Delete("/session", func(c *fiber.Ctx) error {
+		token := sessionToken(c)
+		if token == "" || !globals.HasSession(token) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "error",
+				"message": globals.ErrInvalidSession.Error(),
+			})
+		}
+		globals.EndSession(token)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Sesión eliminada.",
+		})
+	})
+
 	// Definir la ruta GET para retornar la lista de usuarios y grupos
-	app.Get("/list-users-groups", func(c *fiber.Ctx) error {
+	app.Get("/list-users-groups", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		// Verificar si hay una sesión activa
-		if !globals.IsLoggedIn() {
+		if !session.IsLoggedIn() {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "No hay ninguna sesión activa",
@@ -215,7 +302,7 @@ func main() {
 		}
 
 		// Verificar que la partición esté montada
-		_, path, err := globals.GetMountedPartition(globals.UsuarioActual.Id)
+		_, path, err := globals.GetMountedPartition(session.Usuario.Id)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status":  "error",
@@ -234,7 +321,7 @@ func main() {
 		defer file.Close()
 
 		// Cargar el Superblock y la partición
-		_, sb, _, err := globals.GetMountedPartitionRep(globals.UsuarioActual.Id)
+		_, sb, _, err := globals.GetMountedPartitionRep(session.Usuario.Id)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status":  "error",
@@ -244,8 +331,8 @@ func main() {
 
 		// Leer el inodo de users.txt (asumimos que es el segundo inodo)
 		var inode structs.Inode
-		inodeOffset := int64(sb.S_inode_start + int32(binary.Size(inode))) // Calcular el offset del inodo de users.txt
-		err = inode.Decode(file, inodeOffset)                              // Decodificar el inodo
+		inodeOffset := sb.CalculateInodeOffset(1) // Calcular el offset del inodo de users.txt
+		err = inode.Decode(file, inodeOffset, sb) // Decodificar el inodo
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status":  "error",
@@ -264,12 +351,12 @@ func main() {
 
 		// Devolver la lista de usuarios y grupos en formato JSON
 		return c.JSON(data)
-	})
+	}))
 
 	// Definir la ruta GET para verificar si hay un usuario logueado
-	app.Get("/users/logged-in", func(c *fiber.Ctx) error {
+	app.Get("/users/logged-in", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		// Verificar si hay una sesión activa
-		if globals.UsuarioActual == nil || !globals.UsuarioActual.Status {
+		if !session.IsLoggedIn() {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "No hay ningún usuario logueado.",
@@ -280,13 +367,13 @@ func main() {
 		return c.JSON(fiber.Map{
 			"status":  "success",
 			"message": "Usuario logueado.",
-			"user":    globals.UsuarioActual.Name,
-			"id":      globals.UsuarioActual.Id,
+			"user":    session.Usuario.Name,
+			"id":      session.Usuario.Id,
 		})
-	})
+	}))
 
 	//Verificar si al menos una partición está montada
-	app.Get("/check-partition", func(c *fiber.Ctx) error {
+	app.Get("/check-partition", inSession(func(c *fiber.Ctx, session *globals.Session) error {
 		// Verificar si hay al menos una partición montada
 		if len(globals.MountedPartitions) == 0 {
 			return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -300,7 +387,7 @@ func main() {
 			"status":  "success",
 			"message": fmt.Sprintf("Hay %d particiones montadas. Puede proceder con el login.", len(globals.MountedPartitions)),
 		})
-	})
+	}))
 
 	app.Get("docker/test", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
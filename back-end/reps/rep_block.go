@@ -76,7 +76,7 @@ func generateBlockGraph(dotContent string, superblock *structs.Superblock, file
 
 	for i := int32(0); i < superblock.S_inodes_count; i++ {
 		inode := &structs.Inode{}
-		err := inode.Decode(file, int64(superblock.S_inode_start+(i*superblock.S_inode_size)))
+		err := inode.Decode(file, int64(superblock.S_inode_start+(i*superblock.S_inode_size)), superblock)
 		if err != nil {
 			return "", "", fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
 		}
@@ -106,7 +106,7 @@ func generateBlockLabel(dotContent, connections string, blockIndex int32, inode
 
 	if inode.I_type[0] == '0' { // Bloque de carpeta
 		folderBlock := &structs.FolderBlock{}
-		err := folderBlock.Decode(file, blockOffset)
+		err := folderBlock.Decode(file, blockOffset, superblock)
 		if err != nil {
 			return "", "", fmt.Errorf("error al decodificar bloque de carpeta %d: %w", blockIndex, err)
 		}
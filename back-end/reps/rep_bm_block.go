@@ -1,7 +1,6 @@
 package reps
 
 import (
-	"encoding/binary"
 	"fmt"
 	"os"
 	"strings"
@@ -34,15 +33,9 @@ func ReportBMBlock(superblock *structures.Superblock, diskPath string, outputPat
 	var bitmapContent strings.Builder
 
 	for byteIndex := int32(0); byteIndex < byteCount; byteIndex++ {
-		// Mover el puntero al byte correspondiente en el bitmap de bloques
-		_, err := file.Seek(int64(superblock.S_bm_block_start+byteIndex), 0)
-		if err != nil {
-			return fmt.Errorf("error al posicionar el archivo: %v", err)
-		}
-
 		// Leer un byte del bitmap
 		var byteVal byte
-		err = binary.Read(file, binary.LittleEndian, &byteVal)
+		err := utils.ReadFromFile(file, int64(superblock.S_bm_block_start+byteIndex), &byteVal)
 		if err != nil {
 			return fmt.Errorf("error al leer el byte del bitmap: %v", err)
 		}
@@ -43,8 +43,13 @@ func ReportDisk(mbr *structs.MBR, path string, diskPath string) error {
 	totalSize := mbr.MbrSize
 	usedSize := int32(0)
 
-	// Agregar MBR al reporte
-	dotContent += "{MBR}"
+	// Agregar MBR al reporte (en GPT, el MBR protector seguido de la tabla primaria)
+	if mbr.IsGPT() {
+		dotContent += "{MBR protector|GPT}"
+		usedSize += int32(mbr.HeaderSize()) + mbr.MbrSize - mbr.UsableEnd()
+	} else {
+		dotContent += "{MBR}"
+	}
 
 	// Recorrer las particiones del MBR y generar el contenido DOT
 	for _, part := range mbr.MbrPartitions {
@@ -107,6 +112,11 @@ func ReportDisk(mbr *structs.MBR, path string, diskPath string) error {
 		dotContent += fmt.Sprintf("|Libre %.2f%%", freePercentage)
 	}
 
+	// La copia de respaldo de la tabla GPT ocupa el final del disco
+	if mbr.IsGPT() {
+		dotContent += "|{GPT respaldo}"
+	}
+
 	// Cerrar el nodo de disco y completar el DOT
 	dotContent += `"];
 
@@ -101,13 +101,13 @@ func readFileContent(superblock *structs.Superblock, diskFile *os.File, inodeInd
 		return "", fmt.Errorf("error al leer el inodo del archivo: %v", err)
 	}
 
-	// Concatenar el contenido de los bloques
+	// Concatenar el contenido de los bloques, incluidos los de los apuntadores indirectos
+	blocks, err := inode.DataBlocks(diskFile, superblock)
+	if err != nil {
+		return "", fmt.Errorf("error al leer los bloques del archivo: %v", err)
+	}
 	var content string
-	for _, blockIndex := range inode.I_block {
-		if blockIndex == -1 {
-			continue
-		}
-
+	for _, blockIndex := range blocks {
 		// Leer el bloque de archivo
 		block, err := readFileBlock(superblock, diskFile, blockIndex)
 		if err != nil {
@@ -117,14 +117,15 @@ func readFileContent(superblock *structs.Superblock, diskFile *os.File, inodeInd
 		content += string(block.B_content[:])
 	}
 
-	return content, nil
+	// Los archivos comprimidos se muestran descomprimidos
+	return inode.DecodeContent(content)
 }
 
 // readInode lee el inodo en la posición dada
 func readInode(superblock *structs.Superblock, diskFile *os.File, inodeIndex int32) (*structs.Inode, error) {
 	inode := &structs.Inode{}
 	offset := int64(superblock.S_inode_start + inodeIndex*superblock.S_inode_size)
-	err := inode.Decode(diskFile, offset)
+	err := inode.Decode(diskFile, offset, superblock)
 	if err != nil {
 		return nil, fmt.Errorf("error al decodificar el inodo: %v", err)
 	}
@@ -152,7 +153,7 @@ func findInodeInDirectory(inode *structs.Inode, diskFile *os.File, name string,
 		// Leer el bloque de carpeta
 		block := &structs.FolderBlock{}
 		offset := int64(superblock.S_block_start + blockIndex*superblock.S_block_size)
-		err := block.Decode(diskFile, offset)
+		err := block.Decode(diskFile, offset, superblock)
 		if err != nil {
 			continue
 		}
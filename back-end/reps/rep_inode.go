@@ -80,7 +80,7 @@ func initDotGraph() string {
 func generateInodeGraph(dotContent string, superblock *structs.Superblock, file *os.File) (string, error) {
 	for i := int32(0); i < superblock.S_inodes_count; i++ {
 		inode := &structs.Inode{}
-		err := inode.Decode(file, int64(superblock.S_inode_start+(i*superblock.S_inode_size)))
+		err := inode.Decode(file, int64(superblock.S_inode_start+(i*superblock.S_inode_size)), superblock)
 		if err != nil {
 			return "", fmt.Errorf("error al deserializar el inodo %d: %v", i, err)
 		}
@@ -104,9 +104,9 @@ func generateInodeGraph(dotContent string, superblock *structs.Superblock, file
 // generateInodeTable genera la tabla con los atributos y bloques del inodo en formato DOT
 func generateInodeTable(inodeIndex int32, inode *structs.Inode) string {
 	// Convertir tiempos a string
-	atime := time.Unix(int64(inode.I_atime), 0).Format(time.RFC3339)
-	ctime := time.Unix(int64(inode.I_ctime), 0).Format(time.RFC3339)
-	mtime := time.Unix(int64(inode.I_mtime), 0).Format(time.RFC3339)
+	atime := inode.Atime().Format(time.RFC3339Nano)
+	ctime := inode.Ctime().Format(time.RFC3339Nano)
+	mtime := inode.Mtime().Format(time.RFC3339Nano)
 
 	// Generar la tabla del inodo
 	table := fmt.Sprintf(`inode%d [label=<
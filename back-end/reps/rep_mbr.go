@@ -37,7 +37,16 @@ func ReportMBR(mbr *structures.MBR, path string, file *os.File) error {
                 <tr><td bgcolor="#F5B7B1">mbr_tamano</td><td bgcolor="#F5B7B1">%d</td></tr>
                 <tr><td bgcolor="#F5B7B1">mrb_fecha_creacion</td><td bgcolor="#F5B7B1">%s</td></tr>
                 <tr><td bgcolor="#F5B7B1">mbr_disk_signature</td><td bgcolor="#F5B7B1">%d</td></tr>
-            `, mbr.MbrSize, time.Unix(int64(mbr.MbrCreacionDate), 0), mbr.MbrDiskSignature)
+            `, mbr.MbrSize, mbr.CreationTime().Format(time.RFC3339Nano), mbr.MbrDiskSignature)
+
+	// Datos propios de la tabla GPT
+	if mbr.IsGPT() {
+		dotContent += fmt.Sprintf(`
+                <tr><td bgcolor="#F5B7B1">esquema</td><td bgcolor="#F5B7B1">GPT</td></tr>
+                <tr><td bgcolor="#F5B7B1">gpt_disk_guid</td><td bgcolor="#F5B7B1">%s</td></tr>
+                <tr><td bgcolor="#F5B7B1">gpt_entradas</td><td bgcolor="#F5B7B1">%d</td></tr>
+            `, structures.FormatGUID(mbr.MbrDiskGUID), len(mbr.MbrPartitions))
+	}
 
 	// Calcular el tamaño total del disco y mantener un seguimiento del espacio no asignado
 	totalSize := mbr.MbrSize
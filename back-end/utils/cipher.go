@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"crypto/aes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/xts"
+)
+
+// CipherSectorSize es la unidad de cifrado: cada sector se cifra con AES-XTS usando como tweak su
+// número dentro de la zona. Reescribir un sector no reutiliza un flujo de llave como en CTR, y un
+// bit alterado en el disco arruina su bloque de 16 bytes completo en lugar de invertir un bit del texto.
+const CipherSectorSize = 512
+
+// cipherRegion es una zona cifrada de un disco. El tweak de cada sector se calcula con la posición
+// relativa al inicio de la zona, así los datos cifrados se pueden mover o copiar a otra posición
+// sin volver a cifrarlos.
+type cipherRegion struct {
+	start int64 // Primer byte cifrado
+	end   int64 // Fin de la zona (exclusivo); los bytes después del último múltiplo de 16 quedan en claro
+	key   []byte
+	xts   *xts.Cipher
+}
+
+// Zonas cifradas registradas por disco; solo existen mientras la partición está montada
+var diskCiphers = make(map[string][]*cipherRegion)
+
+// RegisterCipher registra la llave AES-XTS (64 bytes para AES-256) de la zona [start, end) del disco;
+// las lecturas y escrituras hechas con ReadAt, WriteAt, ReadFromFile y WriteToFile la descifran y
+// cifran de forma transparente
+func RegisterCipher(diskPath string, start int64, end int64, key []byte) error {
+	c, err := xts.NewCipher(aes.NewCipher, key)
+	if err != nil {
+		return fmt.Errorf("llave de cifrado inválida: %w", err)
+	}
+
+	UnregisterCipher(diskPath, start)
+	k := DiskKey(diskPath)
+	end = start + (end-start)/aes.BlockSize*aes.BlockSize
+	diskCiphers[k] = append(diskCiphers[k], &cipherRegion{start: start, end: end, key: key, xts: c})
+	return nil
+}
+
+// UnregisterCipher olvida la llave de la zona que empieza en start
+func UnregisterCipher(diskPath string, start int64) {
+	k := DiskKey(diskPath)
+	var regions []*cipherRegion
+	for _, region := range diskCiphers[k] {
+		if region.start != start {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		delete(diskCiphers, k)
+		return
+	}
+	diskCiphers[k] = regions
+}
+
+// ForgetCiphers olvida todas las llaves de un disco
+func ForgetCiphers(diskPath string) {
+	delete(diskCiphers, DiskKey(diskPath))
+}
+
+// CipherKey devuelve la llave registrada para la zona que empieza en start
+func CipherKey(diskPath string, start int64) ([]byte, bool) {
+	for _, region := range diskCiphers[DiskKey(diskPath)] {
+		if region.start == start {
+			return region.key, true
+		}
+	}
+	return nil, false
+}
+
+// MoveCipher actualiza la posición de una zona cifrada cuyos datos se movieron de oldStart a newStart
+func MoveCipher(diskPath string, oldStart int64, newStart int64) {
+	for _, region := range diskCiphers[DiskKey(diskPath)] {
+		if region.start == oldStart {
+			region.end += newStart - oldStart
+			region.start = newStart
+			return
+		}
+	}
+}
+
+// ReadAt lee len(data) bytes del disco en la posición indicada, descifrando las zonas cifradas.
+// Los sectores cifrados se leen completos aunque solo se pida una parte.
+func ReadAt(file *os.File, data []byte, offset int64) error {
+	regions := diskCiphers[DiskKey(file.Name())]
+	if !overlapsAny(regions, offset, offset+int64(len(data))) {
+		return readRaw(file, data, offset)
+	}
+	lo, hi := sectorBounds(regions, offset, offset+int64(len(data)))
+
+	buffer := make([]byte, hi-lo)
+	if err := readRaw(file, buffer, lo); err != nil {
+		return err
+	}
+	for _, region := range regions {
+		region.crypt(buffer, lo, false)
+	}
+	copy(data, buffer[offset-lo:])
+	return nil
+}
+
+// readRaw lee bytes tal como están en el disco
+func readRaw(file *os.File, data []byte, offset int64) error {
+	if _, err := file.ReadAt(data, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read data from file: %w", io.ErrUnexpectedEOF)
+		}
+		return fmt.Errorf("failed to read data from file: %w", err)
+	}
+	return nil
+}
+
+// encryptForWrite devuelve los bytes que se deben escribir para guardar data en offset y la posición
+// donde empiezan. Si la escritura cae en una zona cifrada se amplía a sectores completos: los bytes
+// que no cambian se descifran del disco y el sector se vuelve a cifrar entero.
+func encryptForWrite(file *os.File, data []byte, offset int64) ([]byte, int64, error) {
+	regions := diskCiphers[DiskKey(file.Name())]
+	if !overlapsAny(regions, offset, offset+int64(len(data))) {
+		return data, offset, nil
+	}
+	lo, hi := sectorBounds(regions, offset, offset+int64(len(data)))
+
+	buffer := make([]byte, hi-lo)
+	if err := readRaw(file, buffer, lo); err != nil {
+		return nil, 0, err
+	}
+	for _, region := range regions {
+		region.crypt(buffer, lo, false)
+	}
+	copy(buffer[offset-lo:], data)
+	for _, region := range regions {
+		region.crypt(buffer, lo, true)
+	}
+	return buffer, lo, nil
+}
+
+// sectorBounds amplía [start, end) hasta los límites de los sectores cifrados que toca
+func sectorBounds(regions []*cipherRegion, start int64, end int64) (int64, int64) {
+	lo, hi := start, end
+	for _, region := range regions {
+		from, to := max(start, region.start), min(end, region.end)
+		if from >= to {
+			continue
+		}
+		lo = min(lo, region.sectorStart(from))
+		hi = max(hi, min(region.sectorStart(to-1)+CipherSectorSize, region.end))
+	}
+	return lo, hi
+}
+
+// overlapsAny indica si [start, end) toca alguna zona cifrada
+func overlapsAny(regions []*cipherRegion, start int64, end int64) bool {
+	for _, region := range regions {
+		if max(start, region.start) < min(end, region.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectorStart devuelve el inicio del sector de la zona que contiene la posición absoluta position
+func (region *cipherRegion) sectorStart(position int64) int64 {
+	return region.start + (position-region.start)/CipherSectorSize*CipherSectorSize
+}
+
+// crypt cifra o descifra en su lugar los sectores de la zona contenidos en buffer, que empieza en
+// la posición absoluta lo; sectorBounds garantiza que los sectores que toca están completos
+func (region *cipherRegion) crypt(buffer []byte, lo int64, encrypt bool) {
+	from, to := max(lo, region.start), min(lo+int64(len(buffer)), region.end)
+	if from >= to {
+		return
+	}
+	for sector := region.sectorStart(from); sector < to; sector += CipherSectorSize {
+		sectorEnd := min(sector+CipherSectorSize, region.end)
+		chunk := buffer[sector-lo : sectorEnd-lo]
+		tweak := uint64((sector - region.start) / CipherSectorSize)
+		if encrypt {
+			region.xts.Encrypt(chunk, chunk, tweak)
+		} else {
+			region.xts.Decrypt(chunk, chunk, tweak)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newCipherDisk crea un disco de prueba con la zona [start, end) cifrada
+func newCipherDisk(t *testing.T, size int64, start int64, end int64) *os.File {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "disco.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ForgetCiphers(file.Name())
+		file.Close()
+	})
+	if err := file.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterCipher(file.Name(), start, end, bytes.Repeat([]byte{7}, 64)); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// rawAt lee los bytes tal como quedaron en el disco
+func rawAt(t *testing.T, file *os.File, offset int64, size int) []byte {
+	t.Helper()
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, offset); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	const start, end = 100, 100 + 4*CipherSectorSize + 40
+	tests := []struct {
+		name   string
+		offset int64
+		size   int
+	}{
+		{"dentro de un sector", start + 10, 30},
+		{"entre dos sectores", start + CipherSectorSize - 5, 20},
+		{"varios sectores", start + 3, 3 * CipherSectorSize},
+		{"cruza el inicio de la zona", start - 20, 50},
+		{"sector final corto", end - 50, 40},
+		{"cola sin cifrar", end - 8, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newCipherDisk(t, 4096, start, end)
+			data := bytes.Repeat([]byte("datos "), tt.size/6+1)[:tt.size]
+			if err := WriteAt(file, data, tt.offset); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, tt.size)
+			if err := ReadAt(file, got, tt.offset); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("ReadAt() = %q, se esperaba %q", got, data)
+			}
+		})
+	}
+}
+
+func TestCipherPreservesNeighbours(t *testing.T) {
+	const start = 0
+	file := newCipherDisk(t, 4096, start, 2*CipherSectorSize)
+	full := bytes.Repeat([]byte{'a'}, 2*CipherSectorSize)
+	if err := WriteAt(file, full, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAt(file, []byte("xyz"), 600); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]byte, len(full))
+	if err := ReadAt(file, got, start); err != nil {
+		t.Fatal(err)
+	}
+	copy(full[600:], "xyz")
+	if !bytes.Equal(got, full) {
+		t.Errorf("una escritura parcial alteró los bytes vecinos del sector")
+	}
+}
+
+func TestCipherDoesNotReuseKeystream(t *testing.T) {
+	file := newCipherDisk(t, 4096, 0, 2048)
+	first := bytes.Repeat([]byte{'A'}, CipherSectorSize)
+	second := bytes.Repeat([]byte{'B'}, CipherSectorSize)
+
+	if err := WriteAt(file, first, 0); err != nil {
+		t.Fatal(err)
+	}
+	before := rawAt(t, file, 0, CipherSectorSize)
+	if err := WriteAt(file, second, 0); err != nil {
+		t.Fatal(err)
+	}
+	after := rawAt(t, file, 0, CipherSectorSize)
+
+	// Con un flujo de llave reutilizado, cifrado1 ^ cifrado2 sería igual a texto1 ^ texto2
+	for i := range before {
+		if before[i]^after[i] != first[i]^second[i] {
+			return
+		}
+	}
+	t.Errorf("reescribir un sector expone el XOR de los dos textos")
+}
+
+func TestCipherZeroFillHidesKey(t *testing.T) {
+	file := newCipherDisk(t, 4096, 0, 2048)
+	if err := WriteAt(file, make([]byte, 2048), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cada sector tiene su tweak: dos sectores en cero no producen el mismo texto cifrado
+	raw := rawAt(t, file, 0, 2048)
+	if bytes.Equal(raw[:CipherSectorSize], raw[CipherSectorSize:2*CipherSectorSize]) {
+		t.Errorf("dos sectores en cero se cifraron igual")
+	}
+	if bytes.Equal(raw[:aes.BlockSize], raw[aes.BlockSize:2*aes.BlockSize]) {
+		t.Errorf("dos bloques en cero del mismo sector se cifraron igual")
+	}
+}
+
+func TestCipherBitFlipGarblesBlock(t *testing.T) {
+	file := newCipherDisk(t, 4096, 0, 2048)
+	plain := bytes.Repeat([]byte{'z'}, CipherSectorSize)
+	if err := WriteAt(file, plain, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invertir un bit del texto cifrado no debe invertir solo ese bit del texto
+	raw := rawAt(t, file, 0, 1)
+	raw[0] ^= 1
+	if _, err := file.WriteAt(raw, 0); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, aes.BlockSize)
+	if err := ReadAt(file, got, 0); err != nil {
+		t.Fatal(err)
+	}
+	changed := 0
+	for i := range got {
+		if got[i] != plain[i] {
+			changed++
+		}
+	}
+	if changed < aes.BlockSize/2 {
+		t.Errorf("un bit alterado cambió solo %d bytes del bloque", changed)
+	}
+}
@@ -0,0 +1,418 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Los snapshots de un disco se guardan en la carpeta <disco>.snap, un archivo .cow por snapshot.
+// Crear un snapshot no copia el disco: antes de sobrescribir un rango de bytes por primera vez
+// se guarda su contenido original en la capa del snapshot, y rollback lo vuelve a escribir.
+const (
+	snapshotDirSuffix = ".snap"
+	snapshotExt       = ".cow"
+)
+
+// snapshotMagic identifica los archivos de capa copy-on-write
+var snapshotMagic = [8]byte{'M', 'I', 'A', 'S', 'N', 'A', 'P', '1'}
+
+// snapshotHeader es la cabecera de un archivo .cow
+type snapshotHeader struct {
+	Magic    [8]byte
+	Created  int64 // Fecha de creación en nanosegundos Unix
+	DiskSize int64 // Tamaño del disco al crear el snapshot
+}
+
+// snapshotRecord precede a cada rango de bytes originales guardado en la capa
+type snapshotRecord struct {
+	Offset int64
+	Length int64
+}
+
+// byteRange es un rango [start, end) de bytes del disco
+type byteRange struct {
+	start, end int64
+}
+
+// overlay es la capa copy-on-write de un snapshot
+type overlay struct {
+	name     string
+	path     string      // Archivo .cow
+	created  int64       // Fecha de creación en nanosegundos Unix
+	diskSize int64       // Tamaño del disco al crear el snapshot
+	ranges   []byteRange // Rangos ya guardados, ordenados y sin solaparse
+}
+
+// SnapshotInfo describe un snapshot para listarlo
+type SnapshotInfo struct {
+	Name      string
+	Created   time.Time
+	DiskSize  int64
+	Preserved int64 // Bytes originales guardados en la capa
+	Ranges    int   // Cantidad de rangos guardados
+}
+
+// Capas cargadas por disco, ordenadas de la más antigua a la más reciente
+var diskOverlays = make(map[string][]*overlay)
+
+// WriteAt escribe data en el disco en la posición indicada, cifrando las zonas cifradas y
+// pasando por las capas de sus snapshots
+func WriteAt(file *os.File, data []byte, offset int64) error {
+	data, offset, err := encryptForWrite(file, data, offset)
+	if err != nil {
+		return err
+	}
+	return WriteRawAt(file, data, offset)
+}
+
+// WriteRawAt escribe bytes que ya están en su forma final (por ejemplo, datos cifrados que se
+// copian de otra posición). Pasa por los snapshots, pero no por el cifrado.
+func WriteRawAt(file *os.File, data []byte, offset int64) error {
+	overlays, err := loadOverlays(file.Name())
+	if err != nil {
+		return err
+	}
+	for _, ov := range overlays {
+		if err := ov.preserve(file, offset, offset+int64(len(data))); err != nil {
+			return fmt.Errorf("error guardando el snapshot '%s': %w", ov.name, err)
+		}
+	}
+
+	if _, err := file.WriteAt(data, offset); err != nil {
+		return fmt.Errorf("failed to write data to file: %w", err)
+	}
+	return nil
+}
+
+// CreateSnapshot crea un snapshot vacío del disco; los cambios posteriores se guardan en su capa
+func CreateSnapshot(diskPath string, name string) (SnapshotInfo, error) {
+	info, err := os.Stat(diskPath)
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("el disco %s no existe", diskPath)
+	}
+
+	overlays, err := loadOverlays(diskPath)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	for _, ov := range overlays {
+		if ov.name == name {
+			return SnapshotInfo{}, fmt.Errorf("ya existe un snapshot llamado '%s'", name)
+		}
+	}
+
+	dir := snapshotDir(diskPath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("error creando la carpeta de snapshots: %w", err)
+	}
+
+	ov := &overlay{
+		name:     name,
+		path:     filepath.Join(dir, name+snapshotExt),
+		created:  time.Now().UnixNano(),
+		diskSize: info.Size(),
+	}
+	if err := ov.reset(); err != nil {
+		return SnapshotInfo{}, err
+	}
+
+	key := DiskKey(diskPath)
+	diskOverlays[key] = append(diskOverlays[key], ov)
+	return ov.info(), nil
+}
+
+// ListSnapshots devuelve los snapshots del disco, del más antiguo al más reciente
+func ListSnapshots(diskPath string) ([]SnapshotInfo, error) {
+	overlays, err := loadOverlays(diskPath)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]SnapshotInfo, 0, len(overlays))
+	for _, ov := range overlays {
+		infos = append(infos, ov.info())
+	}
+	return infos, nil
+}
+
+// RollbackSnapshot devuelve el disco al estado del snapshot. Los snapshots más recientes se
+// eliminan y el snapshot restaurado queda vacío, listo para volver a usarse.
+// Devuelve la cantidad de bytes restaurados.
+func RollbackSnapshot(diskPath string, name string) (int64, error) {
+	overlays, err := loadOverlays(diskPath)
+	if err != nil {
+		return 0, err
+	}
+
+	index := -1
+	for i, ov := range overlays {
+		if ov.name == name {
+			index = i
+		}
+	}
+	if index == -1 {
+		return 0, fmt.Errorf("no existe un snapshot llamado '%s'", name)
+	}
+	target := overlays[index]
+
+	// Los snapshots posteriores describen estados que dejan de existir
+	for _, ov := range overlays[index+1:] {
+		if err := os.Remove(ov.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("error eliminando el snapshot '%s': %w", ov.name, err)
+		}
+	}
+	older := overlays[:index]
+	diskOverlays[DiskKey(diskPath)] = overlays[:index+1]
+
+	file, err := os.OpenFile(diskPath, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("error abriendo el disco: %w", err)
+	}
+	defer file.Close()
+
+	cow, err := os.Open(target.path)
+	if err != nil {
+		return 0, fmt.Errorf("error abriendo el snapshot '%s': %w", name, err)
+	}
+	defer cow.Close()
+
+	if _, err := cow.Seek(int64(binary.Size(snapshotHeader{})), io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var restored int64
+	for {
+		var record snapshotRecord
+		err := binary.Read(cow, binary.LittleEndian, &record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("snapshot '%s' dañado: %w", name, err)
+		}
+
+		data := make([]byte, record.Length)
+		if _, err := io.ReadFull(cow, data); err != nil {
+			return 0, fmt.Errorf("snapshot '%s' dañado: %w", name, err)
+		}
+
+		// Los snapshots anteriores también deben conservar lo que se va a sobrescribir
+		for _, ov := range older {
+			if err := ov.preserve(file, record.Offset, record.Offset+record.Length); err != nil {
+				return 0, fmt.Errorf("error guardando el snapshot '%s': %w", ov.name, err)
+			}
+		}
+		if _, err := file.WriteAt(data, record.Offset); err != nil {
+			return 0, fmt.Errorf("error restaurando el byte %d: %w", record.Offset, err)
+		}
+		restored += record.Length
+	}
+
+	if info, err := file.Stat(); err == nil && info.Size() != target.diskSize {
+		if err := file.Truncate(target.diskSize); err != nil {
+			return 0, fmt.Errorf("error restaurando el tamaño del disco: %w", err)
+		}
+	}
+
+	// El disco vuelve a coincidir con el snapshot, su capa empieza de nuevo
+	if err := target.reset(); err != nil {
+		return 0, err
+	}
+	return restored, nil
+}
+
+// RemoveSnapshots elimina todos los snapshots de un disco
+func RemoveSnapshots(diskPath string) error {
+	delete(diskOverlays, DiskKey(diskPath))
+	if err := os.RemoveAll(snapshotDir(diskPath)); err != nil {
+		return fmt.Errorf("error eliminando los snapshots del disco: %w", err)
+	}
+	return nil
+}
+
+// snapshotDir devuelve la carpeta donde se guardan los snapshots del disco
+func snapshotDir(diskPath string) string {
+	return diskPath + snapshotDirSuffix
+}
+
+// DiskKey normaliza el path del disco para usarlo como llave del estado que se guarda por disco
+func DiskKey(diskPath string) string {
+	if abs, err := filepath.Abs(diskPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(diskPath)
+}
+
+// loadOverlays lee las capas del disco la primera vez que se usan
+func loadOverlays(diskPath string) ([]*overlay, error) {
+	key := DiskKey(diskPath)
+	if overlays, loaded := diskOverlays[key]; loaded {
+		return overlays, nil
+	}
+
+	entries, err := os.ReadDir(snapshotDir(diskPath))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error leyendo los snapshots del disco: %w", err)
+	}
+
+	overlays := []*overlay{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotExt) {
+			continue
+		}
+		ov, err := readOverlay(filepath.Join(snapshotDir(diskPath), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, ov)
+	}
+	sort.Slice(overlays, func(i, j int) bool { return overlays[i].created < overlays[j].created })
+
+	diskOverlays[key] = overlays
+	return overlays, nil
+}
+
+// readOverlay lee la cabecera de un archivo .cow y los rangos que ya tiene guardados
+func readOverlay(path string) (*overlay, error) {
+	cow, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo el snapshot: %w", err)
+	}
+	defer cow.Close()
+
+	var header snapshotHeader
+	if err := binary.Read(cow, binary.LittleEndian, &header); err != nil || header.Magic != snapshotMagic {
+		return nil, fmt.Errorf("el archivo %s no es un snapshot válido", path)
+	}
+
+	ov := &overlay{
+		name:     strings.TrimSuffix(filepath.Base(path), snapshotExt),
+		path:     path,
+		created:  header.Created,
+		diskSize: header.DiskSize,
+	}
+	for {
+		var record snapshotRecord
+		err := binary.Read(cow, binary.LittleEndian, &record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("snapshot '%s' dañado: %w", ov.name, err)
+		}
+		if _, err := cow.Seek(record.Length, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("snapshot '%s' dañado: %w", ov.name, err)
+		}
+		ov.addRange(byteRange{record.Offset, record.Offset + record.Length})
+	}
+	return ov, nil
+}
+
+// reset deja la capa solo con su cabecera
+func (ov *overlay) reset() error {
+	var buffer bytes.Buffer
+	header := snapshotHeader{Magic: snapshotMagic, Created: ov.created, DiskSize: ov.diskSize}
+	if err := binary.Write(&buffer, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if err := os.WriteFile(ov.path, buffer.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error escribiendo el snapshot '%s': %w", ov.name, err)
+	}
+	ov.ranges = nil
+	return nil
+}
+
+// preserve guarda en la capa el contenido original de los bytes de [start, end) que aún no tiene
+func (ov *overlay) preserve(file *os.File, start int64, end int64) error {
+	// Los bytes que no existían al crear el snapshot se descartan con el rollback
+	end = min(end, ov.diskSize)
+	if start >= end {
+		return nil
+	}
+
+	missing := ov.missing(byteRange{start, end})
+	if len(missing) == 0 {
+		return nil
+	}
+
+	cow, err := os.OpenFile(ov.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer cow.Close()
+
+	for _, r := range missing {
+		data := make([]byte, r.end-r.start)
+		if _, err := file.ReadAt(data, r.start); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		record := snapshotRecord{Offset: r.start, Length: r.end - r.start}
+		if err := binary.Write(&buffer, binary.LittleEndian, &record); err != nil {
+			return err
+		}
+		buffer.Write(data)
+		if _, err := cow.Write(buffer.Bytes()); err != nil {
+			return err
+		}
+		ov.addRange(r)
+	}
+	return nil
+}
+
+// missing devuelve las partes de r que la capa todavía no ha guardado
+func (ov *overlay) missing(r byteRange) []byteRange {
+	var gaps []byteRange
+	cursor := r.start
+	for _, saved := range ov.ranges {
+		if saved.end <= cursor {
+			continue
+		}
+		if saved.start >= r.end {
+			break
+		}
+		if saved.start > cursor {
+			gaps = append(gaps, byteRange{cursor, saved.start})
+		}
+		cursor = max(cursor, saved.end)
+	}
+	if cursor < r.end {
+		gaps = append(gaps, byteRange{cursor, r.end})
+	}
+	return gaps
+}
+
+// addRange agrega un rango guardado, uniendo los rangos contiguos
+func (ov *overlay) addRange(r byteRange) {
+	ranges := make([]byteRange, 0, len(ov.ranges)+1)
+	for _, saved := range ov.ranges {
+		switch {
+		case saved.end < r.start:
+			ranges = append(ranges, saved)
+		case saved.start > r.end:
+			ranges = append(ranges, r)
+			r = saved
+		default:
+			r = byteRange{min(r.start, saved.start), max(r.end, saved.end)}
+		}
+	}
+	ov.ranges = append(ranges, r)
+}
+
+// info devuelve el resumen del snapshot
+func (ov *overlay) info() SnapshotInfo {
+	info := SnapshotInfo{Name: ov.name, Created: time.Unix(0, ov.created), DiskSize: ov.diskSize, Ranges: len(ov.ranges)}
+	for _, r := range ov.ranges {
+		info.Preserved += r.end - r.start
+	}
+	return info
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newSnapshotDisk crea un disco de prueba con un patrón conocido
+func newSnapshotDisk(t *testing.T, size int) (*os.File, []byte) {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "disco.mia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		RemoveSnapshots(file.Name())
+		file.Close()
+	})
+
+	original := make([]byte, size)
+	for i := range original {
+		original[i] = byte(i % 251)
+	}
+	if _, err := file.WriteAt(original, 0); err != nil {
+		t.Fatal(err)
+	}
+	return file, original
+}
+
+// diskContent lee el disco completo
+func diskContent(t *testing.T, file *os.File) []byte {
+	t.Helper()
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSnapshotRollbackRestoresOriginalBytes(t *testing.T) {
+	file, original := newSnapshotDisk(t, 4096)
+	if _, err := CreateSnapshot(file.Name(), "base"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Escrituras que se solapan, una que pasa por WriteRawAt y otra que agranda el disco
+	if err := WriteAt(file, bytes.Repeat([]byte{1}, 100), 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAt(file, bytes.Repeat([]byte{2}, 100), 250); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRawAt(file, bytes.Repeat([]byte{3}, 10), 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAt(file, bytes.Repeat([]byte{4}, 100), 4050); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := ListSnapshots(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// [200, 350) se guarda una sola vez; de la última escritura solo existían 46 bytes
+	if len(infos) != 1 || infos[0].Ranges != 3 || infos[0].Preserved != 150+10+46 {
+		t.Fatalf("ListSnapshots() = %+v", infos)
+	}
+
+	restored, err := RollbackSnapshot(file.Name(), "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != 150+10+46 {
+		t.Errorf("RollbackSnapshot() restauró %d bytes, se esperaban %d", restored, 150+10+46)
+	}
+	if got := diskContent(t, file); !bytes.Equal(got, original) {
+		t.Fatalf("el rollback no devolvió el contenido original (%d bytes, se esperaban %d)", len(got), len(original))
+	}
+
+	// La capa queda vacía y vuelve a guardar los cambios siguientes
+	if err := WriteAt(file, []byte{9}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RollbackSnapshot(file.Name(), "base"); err != nil {
+		t.Fatal(err)
+	}
+	if got := diskContent(t, file); !bytes.Equal(got, original) {
+		t.Fatal("el segundo rollback no devolvió el contenido original")
+	}
+}
+
+func TestNestedSnapshots(t *testing.T) {
+	file, original := newSnapshotDisk(t, 1024)
+	if _, err := CreateSnapshot(file.Name(), "primero"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAt(file, bytes.Repeat([]byte{1}, 64), 100); err != nil {
+		t.Fatal(err)
+	}
+	middle := diskContent(t, file)
+
+	if _, err := CreateSnapshot(file.Name(), "segundo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateSnapshot(file.Name(), "segundo"); err == nil {
+		t.Fatal("CreateSnapshot() debería rechazar un nombre repetido")
+	}
+	if err := WriteAt(file, bytes.Repeat([]byte{2}, 64), 132); err != nil {
+		t.Fatal(err)
+	}
+
+	// Volver al segundo conserva los cambios hechos antes de crearlo
+	if _, err := RollbackSnapshot(file.Name(), "segundo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := diskContent(t, file); !bytes.Equal(got, middle) {
+		t.Fatal("el rollback al segundo snapshot no devolvió el estado intermedio")
+	}
+
+	if err := WriteAt(file, bytes.Repeat([]byte{3}, 64), 500); err != nil {
+		t.Fatal(err)
+	}
+
+	// Volver al primero elimina el segundo, aunque no esté cargado en memoria
+	delete(diskOverlays, DiskKey(file.Name()))
+	if _, err := RollbackSnapshot(file.Name(), "primero"); err != nil {
+		t.Fatal(err)
+	}
+	if got := diskContent(t, file); !bytes.Equal(got, original) {
+		t.Fatal("el rollback al primer snapshot no devolvió el contenido original")
+	}
+	infos, err := ListSnapshots(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name != "primero" {
+		t.Fatalf("ListSnapshots() después del rollback = %+v", infos)
+	}
+	if _, err := RollbackSnapshot(file.Name(), "segundo"); err == nil {
+		t.Fatal("RollbackSnapshot() debería rechazar un snapshot eliminado")
+	}
+}
+
+func TestRemoveSnapshots(t *testing.T) {
+	file, _ := newSnapshotDisk(t, 512)
+	if _, err := CreateSnapshot(file.Name(), "base"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSnapshots(file.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(snapshotDir(file.Name())); !os.IsNotExist(err) {
+		t.Fatalf("la carpeta de snapshots sigue existiendo: %v", err)
+	}
+
+	// Sin snapshots las escrituras van directo al disco
+	if err := WriteAt(file, []byte{1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if infos, err := ListSnapshots(file.Name()); err != nil || len(infos) != 0 {
+		t.Fatalf("ListSnapshots() = %+v, %v", infos, err)
+	}
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Tokenize separa una línea de comando en tokens como lo haría un shell:
+//   - los espacios fuera de comillas separan tokens
+//   - las comillas dobles agrupan y se quitan; dentro, \" y \\ son literales
+//   - las comillas simples agrupan sin interpretar nada
+//   - fuera de comillas, \ hace literal el siguiente carácter
+//   - un # al inicio de un token comienza un comentario hasta el final de la línea
+//
+// Así -path="/home/mis discos/a.mia" llega al comando como el token -path=/home/mis discos/a.mia
+func Tokenize(line string) ([]string, error) {
+	var tokens []string
+	var actual strings.Builder
+	enToken := false // Distingue un token vacío ("") de la ausencia de token
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			if enToken {
+				tokens = append(tokens, actual.String())
+				actual.Reset()
+				enToken = false
+			}
+		case r == '#' && !enToken:
+			return tokens, nil
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				actual.WriteRune(runes[i])
+			} else {
+				actual.WriteRune(r)
+			}
+			enToken = true
+		case r == '"':
+			cierre := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && (runes[j+1] == '"' || runes[j+1] == '\\') {
+					actual.WriteRune(runes[j+1])
+					j++
+					continue
+				}
+				if runes[j] == '"' {
+					cierre = j
+					break
+				}
+				actual.WriteRune(runes[j])
+			}
+			if cierre == -1 {
+				return nil, fmt.Errorf("comillas dobles sin cerrar en: %s", line)
+			}
+			i = cierre
+			enToken = true
+		case r == '\'':
+			cierre := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == '\'' {
+					cierre = j
+					break
+				}
+			}
+			if cierre == -1 {
+				return nil, fmt.Errorf("comillas simples sin cerrar en: %s", line)
+			}
+			actual.WriteString(string(runes[i+1 : cierre]))
+			i = cierre
+			enToken = true
+		default:
+			actual.WriteRune(r)
+			enToken = true
+		}
+	}
+	if enToken {
+		tokens = append(tokens, actual.String())
+	}
+	return tokens, nil
+}
+
+// QuoteToken escribe un token de forma que Tokenize lo vuelva a leer igual.
+// En un parámetro -nombre=valor solo se ponen comillas al valor, como en -path="/home/mis discos/a.mia".
+func QuoteToken(token string) string {
+	prefix, value := "", token
+	if name, rest, ok := strings.Cut(token, "="); ok && strings.HasPrefix(name, "-") && !needsQuotes(name) {
+		prefix, value = name+"=", rest
+	}
+	if !needsQuotes(value) && (value != "" || prefix != "") {
+		return prefix + value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return prefix + `"` + escaped + `"`
+}
+
+// needsQuotes indica si el texto tiene caracteres que Tokenize interpreta (espacios, comillas, \ o #)
+func needsQuotes(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"'\#`, r)
+	}) != -1
+}
+
+// ParamSpec describe los parámetros que acepta un comando, sin el guion inicial.
+// Un nombre terminado en # acepta un número al final (file# acepta -file1, -file2...).
+type ParamSpec struct {
+	Values []string // Parámetros con valor: -nombre=valor
+	Flags  []string // Banderas sin valor: -nombre (o +nombre si se declara con el +)
+}
+
+// Params son los parámetros de un comando ya validados, con el nombre en minúsculas y sin guion.
+// Las banderas se guardan con valor vacío.
+type Params struct {
+	values map[string]string
+	order  []string // Nombres en el orden de la línea de comando
+}
+
+// Get devuelve el valor del parámetro, o "" si no se indicó
+func (p *Params) Get(name string) string {
+	return p.values[name]
+}
+
+// Has indica si el parámetro o la bandera se indicó
+func (p *Params) Has(name string) bool {
+	_, ok := p.values[name]
+	return ok
+}
+
+// Names devuelve los parámetros indicados en el orden de la línea de comando
+func (p *Params) Names() []string {
+	return p.order
+}
+
+// Require devuelve un error con todos los parámetros obligatorios que faltan
+func (p *Params) Require(names ...string) error {
+	var faltantes []string
+	for _, name := range names {
+		if !p.Has(name) {
+			faltantes = append(faltantes, "-"+name)
+		}
+	}
+	if len(faltantes) > 0 {
+		return fmt.Errorf("faltan parámetros requeridos: %s", strings.Join(faltantes, ", "))
+	}
+	return nil
+}
+
+// ParseParams convierte los tokens de un comando en parámetros según la especificación.
+// Los nombres no distinguen mayúsculas. Un parámetro desconocido o repetido, un valor faltante
+// o un argumento suelto producen el mismo error en todos los comandos.
+func ParseParams(tokens []string, spec ParamSpec) (*Params, error) {
+	params := &Params{values: make(map[string]string)}
+	for _, token := range tokens {
+		if len(token) < 2 || (token[0] != '-' && token[0] != '+') {
+			return nil, fmt.Errorf("argumento inesperado: %s", token)
+		}
+
+		name, value, hasValue := strings.Cut(token, "=")
+		key := strings.ToLower(name[1:])
+		if name[0] == '+' {
+			key = "+" + key
+		}
+
+		switch {
+		case spec.accepts(spec.Values, key):
+			if !hasValue || value == "" {
+				return nil, fmt.Errorf("el parámetro %s requiere un valor (%s=valor)", name, name)
+			}
+		case spec.accepts(spec.Flags, key):
+			if hasValue {
+				return nil, fmt.Errorf("el parámetro %s no recibe un valor", name)
+			}
+		default:
+			return nil, fmt.Errorf("parámetro desconocido: %s", name)
+		}
+
+		if params.Has(key) {
+			return nil, fmt.Errorf("parámetro duplicado: %s", name)
+		}
+		params.values[key] = value
+		params.order = append(params.order, key)
+	}
+	return params, nil
+}
+
+// accepts indica si el nombre está en la lista, incluyendo los nombres numerados (file#)
+func (spec ParamSpec) accepts(names []string, key string) bool {
+	for _, name := range names {
+		if prefix, numbered := strings.CutSuffix(name, "#"); numbered {
+			sufijo, ok := strings.CutPrefix(key, prefix)
+			if ok && sufijo != "" && strings.Trim(sufijo, "0123456789") == "" {
+				return true
+			}
+		} else if name == key {
+			return true
+		}
+	}
+	return false
+}
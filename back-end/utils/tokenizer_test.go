@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"espacios", "  mkdisk   -size=5\t-unit=M ", []string{"mkdisk", "-size=5", "-unit=M"}},
+		{"comillas dobles", `mkdir -path="/home/mis discos"`, []string{"mkdir", "-path=/home/mis discos"}},
+		{"comillas dobles escapadas", `mkfile -cont="dijo \"hola\" \\ fin"`, []string{"mkfile", `-cont=dijo "hola" \ fin`}},
+		{"barra sin escape dentro de dobles", `mkfile -cont="a\nb"`, []string{"mkfile", `-cont=a\nb`}},
+		{"comillas simples literales", `login -pass='a "b" \c'`, []string{"login", `-pass=a "b" \c`}},
+		{"escape fuera de comillas", `mkdir -path=/mis\ discos`, []string{"mkdir", "-path=/mis discos"}},
+		{"comillas en medio del token", `mkdir -path=/a"b c"d`, []string{"mkdir", "-path=/ab cd"}},
+		{"valor vacío", `mkfile -cont=""`, []string{"mkfile", "-cont="}},
+		{"token vacío", `echo "" x`, []string{"echo", "", "x"}},
+		{"comentario", "mkdisk -size=5 # -unit=K", []string{"mkdisk", "-size=5"}},
+		{"numeral dentro de un token", "mkfile -cont=a#b", []string{"mkfile", "-cont=a#b"}},
+		{"numeral entre comillas", `mkfile -cont="# no es comentario"`, []string{"mkfile", "-cont=# no es comentario"}},
+		{"solo comentario", "# nada", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.line)
+			if err != nil {
+				t.Fatalf("Tokenize(%q) error = %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, se esperaba %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeUnclosedQuotes(t *testing.T) {
+	for _, line := range []string{`mkdir -path="/a b`, `login -pass='abc`, `mkfile -cont="fin\"`} {
+		if _, err := Tokenize(line); err == nil {
+			t.Errorf("Tokenize(%q) debería fallar por comillas sin cerrar", line)
+		}
+	}
+}
+
+func TestQuoteTokenRoundTrip(t *testing.T) {
+	tokens := []string{
+		"mkdisk", "-size=5", "-path=/home/mis discos/a.mia", `-cont=dijo "hola"`, `-cont=a\b`,
+		"-pass=it's", "-cont=#inicio", "-cont=", "", "-cont=tab\tfin", `-a b=c`, "+c",
+	}
+	for _, token := range tokens {
+		quoted := QuoteToken(token)
+		got, err := Tokenize(quoted)
+		if err != nil {
+			t.Fatalf("Tokenize(QuoteToken(%q) = %q) error = %v", token, quoted, err)
+		}
+		if len(got) != 1 || got[0] != token {
+			t.Errorf("Tokenize(QuoteToken(%q) = %q) = %q", token, quoted, got)
+		}
+	}
+
+	if got := QuoteToken("-path=/home/mis discos/a.mia"); got != `-path="/home/mis discos/a.mia"` {
+		t.Errorf("QuoteToken debería poner comillas solo al valor: %s", got)
+	}
+	if got := QuoteToken("-size=5"); got != "-size=5" {
+		t.Errorf("QuoteToken no debería cambiar un token simple: %s", got)
+	}
+}
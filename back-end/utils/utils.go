@@ -1,26 +1,40 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// MaxSizeBytes es el mayor tamaño representable: los offsets del disco se guardan en int32
+const MaxSizeBytes = math.MaxInt32
+
 // ConvertToBytes convierte un tamaño y una unidad a bytes
 func ConvertToBytes(size int, unit string) (int, error) {
+	var total int
 	switch unit {
 	case "B":
-		return size, nil // Devuelve el tamaño en bytes
+		total = size // Devuelve el tamaño en bytes
 	case "K":
-		return size * 1024, nil // Convierte kilobytes a bytes
+		total = size * 1024 // Convierte kilobytes a bytes
 	case "M":
-		return size * 1024 * 1024, nil // Convierte megabytes a bytes
+		total = size * 1024 * 1024 // Convierte megabytes a bytes
+	case "G":
+		total = size * 1024 * 1024 * 1024 // Convierte gigabytes a bytes
 	default:
 		return 0, errors.New("invalid unit") // Devuelve un error si la unidad es inválida
 	}
+
+	// -add de fdisk usa tamaños negativos, así que se compara el valor absoluto
+	if max(size, -size) > MaxSizeBytes || max(total, -total) > MaxSizeBytes {
+		return 0, fmt.Errorf("el tamaño %d %s excede el máximo de %d bytes", size, unit, MaxSizeBytes)
+	}
+	return total, nil
 }
 
 // Lista con todo el abecedario
@@ -49,6 +63,27 @@ func GetLetter(path string) (string, error) {
 	return pathToLetter[path], nil
 }
 
+// Letters devuelve una copia de las letras asignadas a cada path
+func Letters() map[string]string {
+	letters := make(map[string]string, len(pathToLetter))
+	for path, letter := range pathToLetter {
+		letters[path] = letter
+	}
+	return letters
+}
+
+// RestoreLetter vuelve a asignar una letra guardada a un path, sin reutilizarla para otros discos
+func RestoreLetter(path string, letter string) error {
+	for i, candidate := range alphabet {
+		if candidate == letter {
+			pathToLetter[path] = letter
+			nextLetterIndex = max(nextLetterIndex, i+1)
+			return nil
+		}
+	}
+	return fmt.Errorf("letra inválida: %s", letter)
+}
+
 // RemoveLetter elimina la letra asignada a un path
 func RemoveLetter(path string) {
 	delete(pathToLetter, path)
@@ -56,12 +91,17 @@ func RemoveLetter(path string) {
 
 // readFromFile lee datos desde un archivo binario en la posición especificada
 func ReadFromFile(file *os.File, offset int64, data interface{}) error {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
-		return fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+	size := binary.Size(data)
+	if size < 0 {
+		return fmt.Errorf("failed to read data from file: tipo de tamaño variable %T", data)
+	}
+
+	buffer := make([]byte, size)
+	if err := ReadAt(file, buffer, offset); err != nil {
+		return err
 	}
 
-	err = binary.Read(file, binary.LittleEndian, data)
+	err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, data)
 	if err != nil {
 		return fmt.Errorf("failed to read data from file: %w", err)
 	}
@@ -71,17 +111,13 @@ func ReadFromFile(file *os.File, offset int64, data interface{}) error {
 
 // writeToFile escribe datos a un archivo binario en la posición especificada
 func WriteToFile(file *os.File, offset int64, data interface{}) error {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
-		return fmt.Errorf("failed to seek to offset %d: %w", offset, err)
-	}
-
-	err = binary.Write(file, binary.LittleEndian, data)
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.LittleEndian, data)
 	if err != nil {
 		return fmt.Errorf("failed to write data to file: %w", err)
 	}
 
-	return nil
+	return WriteAt(file, buffer.Bytes(), offset)
 }
 
 // createParentDirs crea las carpetas padre si no existen
@@ -118,7 +154,8 @@ func RemoveElement[T any](slice []T, index int) []T {
 	if index < 0 || index >= len(slice) {
 		return slice // Índice fuera de rango, devolver el slice original
 	}
-	return append(slice[:index], slice[index+1:]...)
+	// Se construye un slice nuevo para no modificar el arreglo del llamador
+	return append(append([]T{}, slice[:index]...), slice[index+1:]...)
 }
 
 // splitStringIntoChunks divide una cadena en partes de tamaño chunkSize y las almacena en una lista
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertToBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		unit    string
+		want    int
+		wantErr bool
+	}{
+		{"bytes", 512, "B", 512, false},
+		{"kilobytes", 3, "K", 3 * 1024, false},
+		{"megabytes", 5, "M", 5 * 1024 * 1024, false},
+		{"negativo para -add", -2, "K", -2 * 1024, false},
+		{"mayor disco posible", 2047, "M", 2047 * 1024 * 1024, false},
+		{"excede int32", 2048, "M", 0, true},
+		{"gigabyte negativo para -add", -1, "G", -1 << 30, false},
+		{"unidad inválida", 1, "T", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertToBytes(tt.size, tt.unit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertToBytes(%d, %q) error = %v, wantErr %v", tt.size, tt.unit, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertToBytes(%d, %q) = %d, se esperaba %d", tt.size, tt.unit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertToBytesGigabytes(t *testing.T) {
+	if got, err := ConvertToBytes(1, "G"); err != nil || got != 1<<30 {
+		t.Errorf("ConvertToBytes(1, \"G\") = %d, %v; se esperaba %d", got, err, 1<<30)
+	}
+	// 2 G ya no cabe en los offsets de 32 bits
+	if _, err := ConvertToBytes(2, "G"); err == nil || !strings.Contains(err.Error(), "excede el máximo") {
+		t.Errorf("ConvertToBytes(2, \"G\") error = %v, se esperaba el error de desbordamiento", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Manejo del token de sesión que identifica a este cliente ante el backend
+
+const TOKEN_KEY = "sessionToken";
+
+export const getSessionToken = () => localStorage.getItem(TOKEN_KEY);
+
+export const setSessionToken = (token: string) => localStorage.setItem(TOKEN_KEY, token);
+
+export const clearSessionToken = () => localStorage.removeItem(TOKEN_KEY);
+
+// Obtiene el token guardado o pide uno nuevo al backend
+const ensureSession = async (): Promise<string> => {
+  const stored = getSessionToken();
+  if (stored) {
+    return stored;
+  }
+
+  const apiUrl = import.meta.env.VITE_API_URL;
+  const response = await fetch(`${apiUrl}/session`, { method: "POST" });
+  const data = await response.json();
+  if (!data.token) {
+    throw new Error(data.message || "No se pudo crear la sesión");
+  }
+  setSessionToken(data.token);
+  return data.token;
+};
+
+// fetch que envía el token de sesión; si el backend ya no reconoce el token, pide uno nuevo y reintenta
+export const authFetch = async (url: string, init: RequestInit = {}): Promise<Response> => {
+  const send = async () => {
+    const token = await ensureSession();
+    return fetch(url, {
+      ...init,
+      headers: { ...init.headers, Authorization: `Bearer ${token}` },
+    });
+  };
+
+  const response = await send();
+  if (response.status !== 401) {
+    return response;
+  }
+  clearSessionToken();
+  return send();
+};
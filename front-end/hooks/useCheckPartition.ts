@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react';
 import { usePart } from './usePart'; // Importamos el hook de Zustand
+import { authFetch } from "./session";
 
 export const useCheckPartition = () => {
   const [partitionStatus, setPartitionStatus] = useState<string | null>(null);
@@ -11,7 +12,7 @@ export const useCheckPartition = () => {
   useEffect(() => {
     const checkPartition = async () => {
       try {
-        const response = await fetch('http://localhost:3000/check-partition');
+        const response = await authFetch('http://localhost:3000/check-partition');
         const data = await response.json();
 
         if (data.status === 'success') {
@@ -1,4 +1,5 @@
 import { useState, useCallback } from "react";
+import { authFetch } from "./session";
 
 function useCommandExecution() {
   const [inputText, setInputText] = useState("");
@@ -27,7 +28,7 @@ function useCommandExecution() {
     setLoading(true);
     try {
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/analyze`, {
+      const response = await authFetch(`${apiUrl}/analyze`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
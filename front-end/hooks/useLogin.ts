@@ -1,4 +1,5 @@
 import { useState } from "react";
+import { getSessionToken, setSessionToken } from "./session";
 
 export const useLogin = () => {
   const [loading, setLoading] = useState(false);
@@ -18,6 +19,8 @@ export const useLogin = () => {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          // Si ya hay una sesión, el login se hace sobre ella; si no, el backend crea una nueva
+          Authorization: `Bearer ${getSessionToken() ?? ""}`,
         },
         body: JSON.stringify({ username, password, id: userId }),
       });
@@ -30,6 +33,7 @@ export const useLogin = () => {
         setMessageType("error");
         return "error"; // Retornamos "error" si falla
       } else if (data.status === "success") {
+        setSessionToken(data.token); // Guardar el token de la sesión iniciada
         // Si el login es exitoso, redirigir y mostrar un mensaje de éxito
         setBackendMessage(data.message);
         setMessageType("success");
@@ -1,6 +1,7 @@
 import { useState } from "react";
 import { useAuth } from "../hooks/useAuth"; // Importamos el hook de Zustand para manejar el estado de autenticación
 import { useDisksStore } from "../hooks/useDiskStore"; // Importamos el hook para manejar el estado de los discos
+import { authFetch } from "./session";
 
 export const useLogout = () => {
   const [loading, setLoading] = useState(false); // Estado para controlar si se está realizando el logout
@@ -17,7 +18,7 @@ export const useLogout = () => {
     try {
       // Realizar la solicitud al backend para enviar el comando logout
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/analyze`, {
+      const response = await authFetch(`${apiUrl}/analyze`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
@@ -1,4 +1,5 @@
 import { useState, useEffect } from "react";
+import { authFetch } from "./session";
 
 interface Partition {
   name: string;
@@ -27,7 +28,7 @@ export const usePartitions = (diskPath: string) => {
 
     try {
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/api/disk/partitions`, {
+      const response = await authFetch(`${apiUrl}/api/disk/partitions`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
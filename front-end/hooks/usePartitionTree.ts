@@ -1,4 +1,5 @@
 import { useState, useEffect } from "react";
+import { authFetch } from "./session";
 
 // Definimos la interfaz para representar cada archivo o carpeta
 interface FileOrFolder {
@@ -39,7 +40,7 @@ export const usePartitionTree = (diskPath: string, partitionName: string) => {
     try {
       // Solicitud al backend para obtener el árbol de archivos/carpetas
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/api/disk/partition/tree`, {
+      const response = await authFetch(`${apiUrl}/api/disk/partition/tree`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
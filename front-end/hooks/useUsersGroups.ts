@@ -1,4 +1,5 @@
 import { useState, useEffect } from "react";
+import { authFetch } from "./session";
 
 // Hook personalizado para obtener la lista de usuarios y grupos
 function useUsersGroups() {
@@ -12,7 +13,7 @@ function useUsersGroups() {
     setLoading(true);
     try {
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/list-users-groups`);
+      const response = await authFetch(`${apiUrl}/list-users-groups`);
       if (!response.ok) {
         throw new Error("Error al obtener la lista de usuarios y grupos");
       }
@@ -4,6 +4,7 @@ import Folder from "./Folder";
 import File from "./File";
 import { usePartitionTree } from "../hooks/usePartitionTree";
 import goBackIcon from "../src/assets/goBack.svg"; // Importa el icono de retroceso
+import { authFetch } from "../hooks/session";
 
 const FileSystemTree: React.FC = () => {
   const location = useLocation();
@@ -48,7 +49,7 @@ const FileSystemTree: React.FC = () => {
     try {
       const command = `cat -file1="${currentPath}/${fileName}"`;
       const apiUrl = import.meta.env.VITE_API_URL;
-      const response = await fetch(`${apiUrl}/analyze`, {
+      const response = await authFetch(`${apiUrl}/analyze`, {
         method: "POST",
         headers: {
           "Content-Type": "application/json",